@@ -2,14 +2,20 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"text/tabwriter"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/vault"
 )
 
 const (
@@ -29,18 +35,35 @@ func main() {
 	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
 
 	searchCmd := flag.NewFlagSet("search", flag.ExitOnError)
-	searchType := searchCmd.String("type", "text", "Search type: text, tag, wikilink, fuzzy, phrase, prefix, title")
+	searchType := searchCmd.String("type", "text", "Search type: text, tag, wikilink, fuzzy, phrase, prefix, title, query (Obsidian syntax)")
 	searchTags := searchCmd.String("tags", "", "Comma-separated tags for tag search")
 	searchWikis := searchCmd.String("wikilinks", "", "Comma-separated wikilinks for wikilink search")
 	searchLimit := searchCmd.Int("limit", 50, "Max results")
+	searchOffset := searchCmd.Int("offset", 0, "Results to skip")
+	searchAfter := searchCmd.String("after", "", "Continue after a result: the JSON cursor printed with the previous page")
+	searchSort := searchCmd.String("sort", "", "Comma-separated sort keys: score, title, path, created, modified; prefix with - to reverse")
+	searchFields := searchCmd.String("fields", "", "Comma-separated fields to return: title, path, tags, wikilinks, created, modified, date")
+	searchAllVaults := searchCmd.Bool("all-vaults", false, "Search every active vault and merge the results")
 
 	viewCmd := flag.NewFlagSet("view", flag.ExitOnError)
 
+	hygieneCmd := flag.NewFlagSet("hygiene", flag.ExitOnError)
+	hygieneFolder := hygieneCmd.String("folder", "", "Only report on notes in this folder")
+	hygieneSection := hygieneCmd.String("section", "", "Only show one section: unresolved, anchors, orphans, dead_ends")
+	hygieneOffset := hygieneCmd.Int("offset", 0, "Items to skip in each section")
+	hygieneLimit := hygieneCmd.Int("limit", 100, "Max items per section")
+
+	// migrate works on the database files directly, not through the server
+	migrateCmd := flag.NewFlagSet("migrate", flag.ExitOnError)
+	migrateConfig := migrateCmd.String("config", "", "Path to configuration file")
+	migrateVault := migrateCmd.String("vault", "", "Vault ID (default: all vaults)")
+	migrateDryRun := migrateCmd.Bool("dry-run", false, "Show the migrations that would be applied without applying them")
+
 	// Global flags (handled manually or passed to subcommands if we used a library,
 	// but with standard flag, we'll parse them from env or a helper)
 	// For simplicity, we'll use env vars for global config or flags on the subcommands if needed.
 	// Let's add common flags to all subcommands for server/vault
-	cmds := []*flag.FlagSet{statusCmd, listCmd, searchCmd, viewCmd}
+	cmds := []*flag.FlagSet{statusCmd, listCmd, searchCmd, viewCmd, hygieneCmd}
 	configs := make([]*Config, len(cmds))
 
 	for i, cmd := range cmds {
@@ -52,7 +75,7 @@ func main() {
 	}
 
 	if len(os.Args) < 2 {
-		fmt.Println("Expected 'status', 'list', 'search', or 'view' subcommands")
+		fmt.Println("Expected 'status', 'list', 'search', 'view', 'hygiene', or 'migrate' subcommands")
 		os.Exit(1)
 	}
 
@@ -65,7 +88,13 @@ func main() {
 		handleList(configs[1])
 	case "search":
 		searchCmd.Parse(os.Args[2:])
-		handleSearch(configs[2], searchCmd.Args(), *searchType, *searchTags, *searchWikis, *searchLimit)
+		handleSearch(configs[2], searchCmd.Args(), *searchType, *searchTags, *searchWikis, searchPage{
+			limit:  *searchLimit,
+			offset: *searchOffset,
+			after:  *searchAfter,
+			sort:   *searchSort,
+			fields: *searchFields,
+		}, *searchAllVaults)
 	case "view":
 		viewCmd.Parse(os.Args[2:])
 		if viewCmd.NArg() < 1 {
@@ -73,8 +102,14 @@ func main() {
 			os.Exit(1)
 		}
 		handleView(configs[3], viewCmd.Arg(0))
+	case "hygiene":
+		hygieneCmd.Parse(os.Args[2:])
+		handleHygiene(configs[4], *hygieneFolder, *hygieneSection, *hygieneOffset, *hygieneLimit)
+	case "migrate":
+		migrateCmd.Parse(os.Args[2:])
+		handleMigrate(*migrateConfig, *migrateVault, *migrateDryRun)
 	default:
-		fmt.Println("Expected 'status', 'list', 'search', or 'view' subcommands")
+		fmt.Println("Expected 'status', 'list', 'search', 'view', 'hygiene', or 'migrate' subcommands")
 		os.Exit(1)
 	}
 }
@@ -180,14 +215,20 @@ func printTree(nodes []interface{}, prefix string) {
 	}
 }
 
-func handleSearch(cfg *Config, args []string, searchType, tags, wikilinks string, limit int) {
+// searchPage holds the search flags that page, sort and trim results
+type searchPage struct {
+	limit, offset       int
+	after, sort, fields string
+}
+
+func handleSearch(cfg *Config, args []string, searchType, tags, wikilinks string, page searchPage, allVaults bool) {
 	query := strings.Join(args, " ")
 
 	// Construct request body
 	reqBody := map[string]interface{}{
 		"query":      query,
 		"type":       searchType,
-		"limit":      limit,
+		"limit":      page.limit,
 		"title_only": false,
 	}
 
@@ -197,6 +238,22 @@ func handleSearch(cfg *Config, args []string, searchType, tags, wikilinks string
 	if wikilinks != "" {
 		reqBody["wikilinks"] = strings.Split(wikilinks, ",")
 	}
+	if page.offset > 0 {
+		reqBody["offset"] = page.offset
+	}
+	if page.after != "" {
+		var after []string
+		if err := json.Unmarshal([]byte(page.after), &after); err != nil {
+			fatal("Invalid -after cursor, expected a JSON list: %v", err)
+		}
+		reqBody["search_after"] = after
+	}
+	if page.sort != "" {
+		reqBody["sort"] = strings.Split(page.sort, ",")
+	}
+	if page.fields != "" {
+		reqBody["fields"] = strings.Split(page.fields, ",")
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
@@ -204,6 +261,9 @@ func handleSearch(cfg *Config, args []string, searchType, tags, wikilinks string
 	}
 
 	url := fmt.Sprintf("%s/api/v1/search/%s", cfg.ServerURL, cfg.VaultID)
+	if allVaults {
+		url = fmt.Sprintf("%s/api/v1/search", cfg.ServerURL)
+	}
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		fatal("Failed to perform search: %v", err)
@@ -215,22 +275,37 @@ func handleSearch(cfg *Config, args []string, searchType, tags, wikilinks string
 		fatal("Server returned error: %s - %s", resp.Status, string(body))
 	}
 
-	var result struct {
-		Total   int `json:"total"`
-		Results []struct {
-			ID        string                 `json:"id"`
-			Score     float64                `json:"score"`
-			Fields    map[string]interface{} `json:"fields"`
-			Fragments map[string][]string    `json:"fragments"`
-		} `json:"results"`
-		Took string `json:"took"`
+	var response struct {
+		Data struct {
+			Total   int `json:"total"`
+			Results []struct {
+				ID              string                 `json:"id"`
+				Score           float64                `json:"score"`
+				Fields          map[string]interface{} `json:"fields"`
+				Fragments       map[string][]string    `json:"fragments"`
+				Sort            []string               `json:"sort"`
+				VaultID         string                 `json:"vault_id"`
+				NormalizedScore float64                `json:"normalized_score"`
+			} `json:"results"`
+			Vaults []struct {
+				VaultID string `json:"vault_id"`
+				Error   string `json:"error"`
+			} `json:"vaults"`
+			Took string `json:"took"`
+		} `json:"data"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		fatal("Failed to decode response: %v", err)
 	}
+	result := response.Data
 
 	fmt.Printf("Found %d results in %s:\n\n", result.Total, result.Took)
+	for _, v := range result.Vaults {
+		if v.Error != "" {
+			fmt.Printf("Vault %s not searched: %s\n\n", v.VaultID, v.Error)
+		}
+	}
 
 	for _, res := range result.Results {
 		title := res.ID // Default to ID
@@ -240,7 +315,11 @@ func handleSearch(cfg *Config, args []string, searchType, tags, wikilinks string
 			title = path
 		}
 
-		fmt.Printf("[%s] %s (Score: %.2f)\n", res.ID, title, res.Score)
+		if res.VaultID != "" {
+			fmt.Printf("%s: [%s] %s (Score: %.2f)\n", res.VaultID, res.ID, title, res.NormalizedScore)
+		} else {
+			fmt.Printf("[%s] %s (Score: %.2f)\n", res.ID, title, res.Score)
+		}
 
 		// Print snippets/fragments if available
 		if len(res.Fragments) > 0 {
@@ -252,6 +331,12 @@ func handleSearch(cfg *Config, args []string, searchType, tags, wikilinks string
 			fmt.Println()
 		}
 	}
+
+	// A full page may have more after it; searches across vaults have no cursor
+	if n := len(result.Results); !allVaults && n > 0 && n == page.limit && len(result.Results[n-1].Sort) > 0 {
+		cursor, _ := json.Marshal(result.Results[n-1].Sort)
+		fmt.Printf("\nNext page: -after '%s'\n", cursor)
+	}
 }
 
 func handleView(cfg *Config, fileID string) {
@@ -281,6 +366,138 @@ func handleView(cfg *Config, fileID string) {
 	fmt.Println(result.Content)
 }
 
+func handleHygiene(cfg *Config, folder, section string, offset, limit int) {
+	params := url.Values{}
+	params.Set("offset", fmt.Sprint(offset))
+	params.Set("limit", fmt.Sprint(limit))
+	if folder != "" {
+		params.Set("folder", folder)
+	}
+	if section != "" {
+		params.Set("section", section)
+	}
+
+	reqURL := fmt.Sprintf("%s/api/v1/hygiene/%s?%s", cfg.ServerURL, cfg.VaultID, params.Encode())
+	resp, err := http.Get(reqURL)
+	if err != nil {
+		fatal("Failed to connect to server: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fatal("Server returned error: %s - %s", resp.Status, string(body))
+	}
+
+	type linkSection struct {
+		Total int `json:"total"`
+		Items []struct {
+			SourcePath string `json:"source_path"`
+			Line       int    `json:"line"`
+			Target     string `json:"target"`
+			IsEmbed    bool   `json:"is_embed"`
+		} `json:"items"`
+	}
+	type noteSection struct {
+		Total int `json:"total"`
+		Items []struct {
+			Path string `json:"path"`
+		} `json:"items"`
+	}
+	var result struct {
+		Data struct {
+			UnresolvedLinks *linkSection `json:"unresolved_links"`
+			BrokenAnchors   *linkSection `json:"broken_anchors"`
+			Orphans         *noteSection `json:"orphans"`
+			DeadEnds        *noteSection `json:"dead_ends"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		fatal("Failed to decode response: %v", err)
+	}
+
+	printLinks := func(title string, section *linkSection) {
+		if section == nil {
+			return
+		}
+		fmt.Printf("%s (%d):\n", title, section.Total)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		for _, l := range section.Items {
+			link := "[[" + l.Target + "]]"
+			if l.IsEmbed {
+				link = "!" + link
+			}
+			fmt.Fprintf(w, "  %s:%d\t%s\n", l.SourcePath, l.Line, link)
+		}
+		w.Flush()
+		fmt.Println()
+	}
+	printNotes := func(title string, section *noteSection) {
+		if section == nil {
+			return
+		}
+		fmt.Printf("%s (%d):\n", title, section.Total)
+		for _, n := range section.Items {
+			fmt.Printf("  %s\n", n.Path)
+		}
+		fmt.Println()
+	}
+
+	printLinks("Unresolved links", result.Data.UnresolvedLinks)
+	printLinks("Broken heading links", result.Data.BrokenAnchors)
+	printNotes("Orphans", result.Data.Orphans)
+	printNotes("Dead ends", result.Data.DeadEnds)
+}
+
+func handleMigrate(configPath, vaultID string, dryRun bool) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		fatal("Failed to load configuration: %v", err)
+	}
+
+	var vaults []config.VaultConfig
+	for _, v := range cfg.Vaults {
+		if vaultID == "" || v.ID == vaultID {
+			vaults = append(vaults, v)
+		}
+	}
+	if len(vaults) == 0 {
+		fatal("Vault not found: %s", vaultID)
+	}
+
+	failed := false
+	for i := range vaults {
+		path := vault.DBFilePath(&vaults[i])
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			fmt.Printf("%s: no database yet, skipped\n", vaults[i].ID)
+			continue
+		}
+
+		result, err := db.MigrateFile(context.Background(), path, dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: migration failed: %v\n", vaults[i].ID, err)
+			failed = true
+			continue
+		}
+
+		verb := "applied"
+		if dryRun {
+			verb = "would apply"
+		}
+		fmt.Printf("%s: schema version %d, latest %d\n", vaults[i].ID, result.From, db.LatestSchemaVersion())
+		for _, m := range result.Applied {
+			fmt.Printf("  %s %d %s\n", verb, m.Version, m.Name)
+		}
+		if len(result.Applied) == 0 {
+			fmt.Println("  up to date")
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
 func fatal(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, format+"\n", args...)
 	os.Exit(1)
@@ -70,6 +70,7 @@ func main() {
 			logger.WithError(err).WithField("vault_id", vaultCfg.ID).Error("Failed to create vault")
 			continue
 		}
+		v.SetDriftCheckInterval(cfg.Indexing.UpdateInterval)
 
 		// Start vault
 		logger.WithField("vault", vaultCfg.Name).Info("Starting vault")
@@ -47,13 +47,40 @@ type LogFileConfig struct {
 
 // VaultConfig represents a single vault configuration
 type VaultConfig struct {
-	ID        string        `yaml:"id"`
-	Name      string        `yaml:"name"`
-	Storage   StorageConfig `yaml:"storage"`
-	IndexPath string        `yaml:"index_path"`
-	DBPath    string        `yaml:"db_path"`
-	Enabled   bool          `yaml:"enabled"`
-	Default   bool          `yaml:"default"`
+	ID        string         `yaml:"id"`
+	Name      string         `yaml:"name"`
+	Storage   StorageConfig  `yaml:"storage"`
+	IndexPath string         `yaml:"index_path"`
+	DBPath    string         `yaml:"db_path"`
+	Enabled   bool           `yaml:"enabled"`
+	Default   bool           `yaml:"default"`
+	Ignore    []string       `yaml:"ignore"`     // gitignore-style patterns, applied before the vault's .obsidianignore
+	FileTypes []string       `yaml:"file_types"` // Extensions to track (e.g. md, png, pdf); empty tracks every file
+	Revisions RevisionConfig `yaml:"revisions"`
+	Trash     TrashConfig    `yaml:"trash"`
+	Search    VaultSearch    `yaml:"search"`
+}
+
+// RevisionConfig limits the note revisions kept in the vault database
+type RevisionConfig struct {
+	Keep   int           `yaml:"keep,omitempty"`    // Revisions kept per note (default 50)
+	MaxAge time.Duration `yaml:"max_age,omitempty"` // Older revisions are dropped; 0 keeps them regardless of age
+}
+
+// TrashConfig controls the vault trash that deleted files are moved to
+type TrashConfig struct {
+	PurgeAfterDays int `yaml:"purge_after_days,omitempty"` // Items older than this are purged; 0 keeps them until purged by hand
+}
+
+// Search engines a vault's notes can be indexed with
+const (
+	SearchEngineBleve     = "bleve"
+	SearchEngineSQLiteFTS = "sqlite_fts" // FTS5 tables in the vault database; needs the sqlite_fts5 build tag
+)
+
+// VaultSearch selects how a vault's notes are indexed and searched
+type VaultSearch struct {
+	Engine string `yaml:"engine,omitempty"` // bleve (default) or sqlite_fts
 }
 
 // StorageType represents the type of storage backend
@@ -63,6 +90,7 @@ const (
 	LocalStorage StorageType = iota
 	S3Storage
 	MinIOStorage
+	GitStorage
 )
 
 // String returns the string representation of StorageType
@@ -74,23 +102,36 @@ func (s StorageType) String() string {
 		return "s3"
 	case MinIOStorage:
 		return "minio"
+	case GitStorage:
+		return "git"
 	default:
 		return "unknown"
 	}
 }
 
+// Watch modes for local storage
+const (
+	WatchModeAuto     = "auto"     // Use fsnotify, falling back to polling if it never reports changes
+	WatchModeFsnotify = "fsnotify" // Filesystem notifications only
+	WatchModePoll     = "poll"     // Periodic scans, for NFS/SMB/FUSE mounts without notifications
+)
+
 // LocalStorageConfig holds local filesystem storage configuration
 type LocalStorageConfig struct {
-	Path string `yaml:"path"`
+	Path         string        `yaml:"path"`
+	WatchMode    string        `yaml:"watch_mode,omitempty"`    // auto, fsnotify or poll (default auto)
+	PollInterval time.Duration `yaml:"poll_interval,omitempty"` // How often to scan in poll mode (default 10s)
 }
 
 // S3StorageConfig holds S3 storage configuration
 type S3StorageConfig struct {
-	Bucket    string `yaml:"bucket"`
-	Region    string `yaml:"region"`
-	Endpoint  string `yaml:"endpoint,omitempty"`   // For S3-compatible services
-	AccessKey string `yaml:"access_key,omitempty"` // Optional, can use env vars
-	SecretKey string `yaml:"secret_key,omitempty"` // Optional, can use env vars
+	Bucket       string        `yaml:"bucket"`
+	Region       string        `yaml:"region"`
+	Prefix       string        `yaml:"prefix,omitempty"`        // Optional key prefix the vault lives under
+	Endpoint     string        `yaml:"endpoint,omitempty"`      // For S3-compatible services
+	AccessKey    string        `yaml:"access_key,omitempty"`    // Optional, can use env vars
+	SecretKey    string        `yaml:"secret_key,omitempty"`    // Optional, can use env vars
+	PollInterval time.Duration `yaml:"poll_interval,omitempty"` // How often to list the bucket for changes (default 30s)
 }
 
 // MinIOStorageConfig holds MinIO storage configuration
@@ -102,13 +143,26 @@ type MinIOStorageConfig struct {
 	UseSSL    bool   `yaml:"use_ssl"`
 }
 
+// GitStorageConfig holds git repository storage configuration
+// The repository is cloned (or opened) into Path and served like a local vault
+type GitStorageConfig struct {
+	Path          string        `yaml:"path"`                     // Working directory of the repository
+	URL           string        `yaml:"url,omitempty"`            // Remote to clone from when Path has no repository yet
+	Branch        string        `yaml:"branch,omitempty"`         // Branch to pull (default: the checked-out branch)
+	PullInterval  time.Duration `yaml:"pull_interval,omitempty"`  // How often to pull from the remote (default 1m)
+	AuthorName    string        `yaml:"author_name,omitempty"`    // Author of commits made for API writes
+	AuthorEmail   string        `yaml:"author_email,omitempty"`   // Author email of commits made for API writes
+	CommitMessage string        `yaml:"commit_message,omitempty"` // Go template with .Action, .Path and .OldPath
+}
+
 // StorageConfig holds storage backend configuration
-// Only one of Local, S3, or MinIO should be set based on Type
+// Only one of Local, S3, MinIO or Git should be set based on Type
 type StorageConfig struct {
-	Type  string              `yaml:"type"` // "local", "s3", "minio"
+	Type  string              `yaml:"type"` // "local", "s3", "minio", "git"
 	Local *LocalStorageConfig `yaml:"local,omitempty"`
 	S3    *S3StorageConfig    `yaml:"s3,omitempty"`
 	MinIO *MinIOStorageConfig `yaml:"minio,omitempty"`
+	Git   *GitStorageConfig   `yaml:"git,omitempty"`
 }
 
 // GetType returns the StorageType enum value
@@ -120,6 +174,8 @@ func (s *StorageConfig) GetType() StorageType {
 		return S3Storage
 	case "minio":
 		return MinIOStorage
+	case "git":
+		return GitStorage
 	default:
 		return -1
 	}
@@ -134,6 +190,8 @@ func (s *StorageConfig) GetConfig() interface{} {
 		return s.S3
 	case MinIOStorage:
 		return s.MinIO
+	case GitStorage:
+		return s.Git
 	default:
 		return nil
 	}
@@ -163,11 +221,20 @@ func (s *StorageConfig) GetMinIOConfig() *MinIOStorageConfig {
 	return nil
 }
 
+// GetGitConfig returns GitStorageConfig if type is git
+func (s *StorageConfig) GetGitConfig() *GitStorageConfig {
+	if s.GetType() == GitStorage {
+		return s.Git
+	}
+	return nil
+}
+
 // SearchConfig holds search-related configuration
 type SearchConfig struct {
-	DefaultLimit int `yaml:"default_limit"`
-	MaxLimit     int `yaml:"max_limit"`
-	CacheSizeMB  int `yaml:"cache_size_mb"`
+	DefaultLimit int           `yaml:"default_limit"`
+	MaxLimit     int           `yaml:"max_limit"`
+	CacheSizeMB  int           `yaml:"cache_size_mb"`
+	VaultTimeout time.Duration `yaml:"vault_timeout"` // How long a search across vaults waits for each vault
 }
 
 // IndexingConfig holds indexing-related configuration
@@ -329,6 +396,11 @@ func (c *Config) applyEnvOverrides() {
 			c.Search.MaxLimit = l
 		}
 	}
+	if timeout := os.Getenv("OBSIDIAN_WEB_SEARCH_VAULT_TIMEOUT"); timeout != "" {
+		if d, err := time.ParseDuration(timeout); err == nil {
+			c.Search.VaultTimeout = d
+		}
+	}
 
 	// Indexing overrides
 	if batchSize := os.Getenv("OBSIDIAN_WEB_INDEXING_BATCH_SIZE"); batchSize != "" {
@@ -429,6 +501,7 @@ func DefaultConfig() *Config {
 			DefaultLimit: 20,
 			MaxLimit:     100,
 			CacheSizeMB:  512,
+			VaultTimeout: 5 * time.Second,
 		},
 		Indexing: IndexingConfig{
 			BatchSize:          100,
@@ -492,9 +565,9 @@ func (c *Config) Validate() error {
 		}
 
 		// Storage validation
-		validStorageTypes := map[string]bool{"local": true, "s3": true, "minio": true}
+		validStorageTypes := map[string]bool{"local": true, "s3": true, "minio": true, "git": true}
 		if !validStorageTypes[vault.Storage.Type] {
-			return fmt.Errorf("vaults[%d].storage.type must be one of: local, s3, minio; got %s", i, vault.Storage.Type)
+			return fmt.Errorf("vaults[%d].storage.type must be one of: local, s3, minio, git; got %s", i, vault.Storage.Type)
 		}
 
 		storageType := vault.Storage.GetType()
@@ -507,6 +580,14 @@ func (c *Config) Validate() error {
 			if localCfg.Path == "" {
 				return fmt.Errorf("vaults[%d].storage.local.path cannot be empty for local storage", i)
 			}
+			switch localCfg.WatchMode {
+			case "", WatchModeAuto, WatchModeFsnotify, WatchModePoll:
+			default:
+				return fmt.Errorf("vaults[%d].storage.local.watch_mode must be one of: auto, fsnotify, poll; got %s", i, localCfg.WatchMode)
+			}
+			if localCfg.PollInterval < 0 {
+				return fmt.Errorf("vaults[%d].storage.local.poll_interval cannot be negative", i)
+			}
 		case S3Storage:
 			s3Cfg := vault.Storage.GetS3Config()
 			if s3Cfg == nil {
@@ -529,10 +610,36 @@ func (c *Config) Validate() error {
 			if minioCfg.Endpoint == "" {
 				return fmt.Errorf("vaults[%d].storage.minio.endpoint cannot be empty for MinIO storage", i)
 			}
+		case GitStorage:
+			gitCfg := vault.Storage.GetGitConfig()
+			if gitCfg == nil {
+				return fmt.Errorf("vaults[%d].storage.git configuration is required for git storage", i)
+			}
+			if gitCfg.Path == "" {
+				return fmt.Errorf("vaults[%d].storage.git.path cannot be empty for git storage", i)
+			}
+			if gitCfg.PullInterval < 0 {
+				return fmt.Errorf("vaults[%d].storage.git.pull_interval cannot be negative", i)
+			}
 		default:
 			return fmt.Errorf("vaults[%d].storage.type is invalid", i)
 		}
 
+		if vault.Revisions.Keep < 0 {
+			return fmt.Errorf("vaults[%d].revisions.keep cannot be negative", i)
+		}
+		if vault.Revisions.MaxAge < 0 {
+			return fmt.Errorf("vaults[%d].revisions.max_age cannot be negative", i)
+		}
+		if vault.Trash.PurgeAfterDays < 0 {
+			return fmt.Errorf("vaults[%d].trash.purge_after_days cannot be negative", i)
+		}
+		switch vault.Search.Engine {
+		case "", SearchEngineBleve, SearchEngineSQLiteFTS:
+		default:
+			return fmt.Errorf("vaults[%d].search.engine must be %s or %s, got %q", i, SearchEngineBleve, SearchEngineSQLiteFTS, vault.Search.Engine)
+		}
+
 		if vault.Default {
 			defaultCount++
 		}
@@ -552,6 +659,9 @@ func (c *Config) Validate() error {
 	if c.Search.MaxLimit < c.Search.DefaultLimit {
 		return fmt.Errorf("search.max_limit (%d) must be >= search.default_limit (%d)", c.Search.MaxLimit, c.Search.DefaultLimit)
 	}
+	if c.Search.VaultTimeout < 0 {
+		return fmt.Errorf("search.vault_timeout cannot be negative, got %s", c.Search.VaultTimeout)
+	}
 
 	// Indexing validation
 	if c.Indexing.BatchSize < 1 {
@@ -286,6 +286,75 @@ func TestValidation(t *testing.T) {
 			wantError: true,
 			errorMsg:  "db_path cannot be empty",
 		},
+		{
+			name: "invalid watch mode",
+			config: &Config{
+				Server:  ServerConfig{Host: "localhost", Port: 8080},
+				Logging: LoggingConfig{Level: "info", Format: "text"},
+				Vaults: []VaultConfig{
+					{ID: "test", Name: "Test", Storage: StorageConfig{Type: "local", Local: &LocalStorageConfig{Path: "/tmp", WatchMode: "inotify"}}, IndexPath: "/tmp/idx", DBPath: "/tmp/db", Default: true, Enabled: true},
+				},
+				Search:   SearchConfig{DefaultLimit: 20, MaxLimit: 100},
+				Indexing: IndexingConfig{BatchSize: 100},
+			},
+			wantError: true,
+			errorMsg:  "watch_mode must be one of",
+		},
+		{
+			name: "poll watch mode",
+			config: &Config{
+				Server:  ServerConfig{Host: "localhost", Port: 8080},
+				Logging: LoggingConfig{Level: "info", Format: "text"},
+				Vaults: []VaultConfig{
+					{ID: "test", Name: "Test", Storage: StorageConfig{Type: "local", Local: &LocalStorageConfig{Path: "/tmp", WatchMode: WatchModePoll, PollInterval: time.Minute}}, IndexPath: "/tmp/idx", DBPath: "/tmp/db", Default: true, Enabled: true},
+				},
+				Search:   SearchConfig{DefaultLimit: 20, MaxLimit: 100},
+				Indexing: IndexingConfig{BatchSize: 100},
+			},
+			wantError: false,
+		},
+		{
+			name: "negative revision count",
+			config: &Config{
+				Server:  ServerConfig{Host: "localhost", Port: 8080},
+				Logging: LoggingConfig{Level: "info", Format: "text"},
+				Vaults: []VaultConfig{
+					{ID: "test", Name: "Test", Storage: StorageConfig{Type: "local", Local: &LocalStorageConfig{Path: "/tmp"}}, IndexPath: "/tmp/idx", DBPath: "/tmp/db", Default: true, Enabled: true, Revisions: RevisionConfig{Keep: -1}},
+				},
+				Search:   SearchConfig{DefaultLimit: 20, MaxLimit: 100},
+				Indexing: IndexingConfig{BatchSize: 100},
+			},
+			wantError: true,
+			errorMsg:  "revisions.keep cannot be negative",
+		},
+		{
+			name: "negative trash purge age",
+			config: &Config{
+				Server:  ServerConfig{Host: "localhost", Port: 8080},
+				Logging: LoggingConfig{Level: "info", Format: "text"},
+				Vaults: []VaultConfig{
+					{ID: "test", Name: "Test", Storage: StorageConfig{Type: "local", Local: &LocalStorageConfig{Path: "/tmp"}}, IndexPath: "/tmp/idx", DBPath: "/tmp/db", Default: true, Enabled: true, Trash: TrashConfig{PurgeAfterDays: -1}},
+				},
+				Search:   SearchConfig{DefaultLimit: 20, MaxLimit: 100},
+				Indexing: IndexingConfig{BatchSize: 100},
+			},
+			wantError: true,
+			errorMsg:  "trash.purge_after_days cannot be negative",
+		},
+		{
+			name: "unknown search engine",
+			config: &Config{
+				Server:  ServerConfig{Host: "localhost", Port: 8080},
+				Logging: LoggingConfig{Level: "info", Format: "text"},
+				Vaults: []VaultConfig{
+					{ID: "test", Name: "Test", Storage: StorageConfig{Type: "local", Local: &LocalStorageConfig{Path: "/tmp"}}, IndexPath: "/tmp/idx", DBPath: "/tmp/db", Default: true, Enabled: true, Search: VaultSearch{Engine: "lucene"}},
+				},
+				Search:   SearchConfig{DefaultLimit: 20, MaxLimit: 100},
+				Indexing: IndexingConfig{BatchSize: 100},
+			},
+			wantError: true,
+			errorMsg:  "search.engine must be bleve or sqlite_fts",
+		},
 		{
 			name: "invalid log level",
 			config: &Config{
@@ -383,6 +452,33 @@ func TestValidation(t *testing.T) {
 			wantError: true,
 			errorMsg:  "endpoint cannot be empty",
 		},
+		{
+			name: "git storage missing path",
+			config: &Config{
+				Server:  ServerConfig{Host: "localhost", Port: 8080},
+				Logging: LoggingConfig{Level: "info", Format: "text"},
+				Vaults: []VaultConfig{
+					{ID: "test", Name: "Test", Storage: StorageConfig{Type: "git", Git: &GitStorageConfig{URL: "https://example.com/vault.git"}}, IndexPath: "/tmp/idx", DBPath: "/tmp/db", Default: true, Enabled: true},
+				},
+				Search:   SearchConfig{DefaultLimit: 20, MaxLimit: 100},
+				Indexing: IndexingConfig{BatchSize: 100},
+			},
+			wantError: true,
+			errorMsg:  "git.path cannot be empty",
+		},
+		{
+			name: "valid git storage",
+			config: &Config{
+				Server:  ServerConfig{Host: "localhost", Port: 8080},
+				Logging: LoggingConfig{Level: "info", Format: "text"},
+				Vaults: []VaultConfig{
+					{ID: "test", Name: "Test", Storage: StorageConfig{Type: "git", Git: &GitStorageConfig{Path: "/tmp/vault", URL: "https://example.com/vault.git"}}, IndexPath: "/tmp/idx", DBPath: "/tmp/db", Default: true, Enabled: true},
+				},
+				Search:   SearchConfig{DefaultLimit: 20, MaxLimit: 100},
+				Indexing: IndexingConfig{BatchSize: 100},
+			},
+			wantError: false,
+		},
 		{
 			name: "invalid storage type",
 			config: &Config{
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+)
+
+func TestApplyFileEvent_SkipsUnchangedContent(t *testing.T) {
+	svc, store, vaultDir := newMoveTestVault(t, "note.md")
+	path := filepath.Join(vaultDir, "note.md")
+
+	entry, err := svc.GetFileEntryByPath("note.md")
+	if err != nil || entry == nil {
+		t.Fatalf("GetFileEntryByPath() = %v, %v", entry, err)
+	}
+	if want := ContentHash([]byte("# note.md")); entry.ContentHash != want {
+		t.Fatalf("ContentHash = %q, want %q", entry.ContentHash, want)
+	}
+
+	modify := func(force bool) UpdateResult {
+		t.Helper()
+		result, err := svc.ApplyFileEvent(store, vaultDir, syncpkg.FileChangeEvent{
+			Path:      path,
+			EventType: syncpkg.FileModified,
+			Timestamp: time.Now(),
+			Force:     force,
+		})
+		if err != nil {
+			t.Fatalf("ApplyFileEvent() error = %v", err)
+		}
+		if result.FileID != entry.ID {
+			t.Fatalf("FileID = %q, want %q", result.FileID, entry.ID)
+		}
+		return result
+	}
+
+	if !modify(false).Unchanged {
+		t.Error("saving the same bytes should be reported unchanged")
+	}
+	if modify(true).Unchanged {
+		t.Error("a forced event should never be reported unchanged")
+	}
+
+	if err := os.WriteFile(path, []byte("# edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if modify(false).Unchanged {
+		t.Error("changed bytes should not be reported unchanged")
+	}
+	updated, _ := svc.GetFileEntryByPath("note.md")
+	if want := ContentHash([]byte("# edited")); updated.ContentHash != want {
+		t.Errorf("ContentHash after edit = %q, want %q", updated.ContentHash, want)
+	}
+
+	// A deleted file that comes back with the same bytes has to be restored
+	if err := svc.DeleteFileEntry(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+	if modify(false).Unchanged {
+		t.Error("a deleted entry should be restored, not reported unchanged")
+	}
+}
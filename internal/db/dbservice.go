@@ -2,7 +2,9 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
@@ -10,9 +12,11 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/susamn/obsidian-web/internal/logger"
+	"github.com/susamn/obsidian-web/internal/storage"
 	syncpkg "github.com/susamn/obsidian-web/internal/sync"
 	"github.com/susamn/obsidian-web/internal/utils"
 )
@@ -85,8 +89,9 @@ type FileEntry struct {
 	FileStatusID *int64    `json:"file_status_id,omitempty"` // Foreign key to file_statuses
 	Created      time.Time `json:"created"`
 	Modified     time.Time `json:"modified"`
-	Size         int64     `json:"size"` // 0 for directories
-	Path         string    `json:"-"`    // For internal use only, hidden from API
+	Size         int64     `json:"size"`                   // 0 for directories
+	ContentHash  string    `json:"content_hash,omitempty"` // SHA-256 of a note's bytes; empty for other files
+	Path         string    `json:"-"`                      // For internal use only, hidden from API
 }
 
 // DBService manages a sqlite database for file metadata
@@ -101,6 +106,9 @@ type DBService struct {
 	status   ServiceStatus
 	statusMu sync.RWMutex
 
+	// Set when tables filled from file contents were added to an existing database
+	needsBackfill bool
+
 	wg sync.WaitGroup
 }
 
@@ -123,7 +131,7 @@ func NewDBService(parent context.Context, dbPath *string) (*DBService, error) {
 	}, nil
 }
 
-// Start opens the sqlite database and ensures schema exists.
+// Start opens the sqlite database and migrates it to the latest schema version.
 func (s *DBService) Start() error {
 	s.statusMu.Lock()
 	if s.status != StatusInitializing {
@@ -132,10 +140,32 @@ func (s *DBService) Start() error {
 	}
 	s.statusMu.Unlock()
 
-	db, err := sql.Open("sqlite3", s.dbPath+"?_foreign_keys=1")
+	db, err := openSQLite(s.ctx, s.dbPath)
 	if err != nil {
 		s.setStatus(StatusError)
-		return fmt.Errorf("open sqlite: %w", err)
+		return err
+	}
+
+	s.dbMu.Lock()
+	s.db = db
+	s.dbMu.Unlock()
+
+	// Bring the schema up to date
+	if err := s.migrateSchema(); err != nil {
+		_ = db.Close()
+		s.setStatus(StatusError)
+		return fmt.Errorf("migrate schema: %w", err)
+	}
+
+	s.setStatus(StatusReady)
+	return nil
+}
+
+// openSQLite opens the database at path and sets it up for concurrent workers
+func openSQLite(parent context.Context, path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=1")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
 	}
 
 	// Tune DB connection pool for better concurrency with workers
@@ -144,48 +174,32 @@ func (s *DBService) Start() error {
 	db.SetConnMaxLifetime(time.Minute * 5)
 
 	// Ping with context
-	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
 	defer cancel()
 	if err := db.PingContext(ctx); err != nil {
 		_ = db.Close()
-		s.setStatus(StatusError)
-		return fmt.Errorf("ping sqlite: %w", err)
+		return nil, fmt.Errorf("ping sqlite: %w", err)
 	}
 
 	// Enable WAL mode for better concurrency
 	if _, err := db.ExecContext(ctx, "PRAGMA journal_mode=WAL"); err != nil {
 		_ = db.Close()
-		s.setStatus(StatusError)
-		return fmt.Errorf("enable WAL mode: %w", err)
+		return nil, fmt.Errorf("enable WAL mode: %w", err)
 	}
 
 	// Set synchronous mode to NORMAL for better performance (still safe with WAL)
 	if _, err := db.ExecContext(ctx, "PRAGMA synchronous=NORMAL"); err != nil {
 		_ = db.Close()
-		s.setStatus(StatusError)
-		return fmt.Errorf("set synchronous mode: %w", err)
+		return nil, fmt.Errorf("set synchronous mode: %w", err)
 	}
 
 	// Set busy timeout to handle concurrent writes
 	if _, err := db.ExecContext(ctx, "PRAGMA busy_timeout=5000"); err != nil {
 		_ = db.Close()
-		s.setStatus(StatusError)
-		return fmt.Errorf("set busy timeout: %w", err)
+		return nil, fmt.Errorf("set busy timeout: %w", err)
 	}
 
-	s.dbMu.Lock()
-	s.db = db
-	s.dbMu.Unlock()
-
-	// Ensure schema
-	if err := s.ensureSchema(); err != nil {
-		_ = db.Close()
-		s.setStatus(StatusError)
-		return fmt.Errorf("ensure schema: %w", err)
-	}
-
-	s.setStatus(StatusReady)
-	return nil
+	return db, nil
 }
 
 // Stop closes the DB and cancels the service context.
@@ -228,173 +242,227 @@ func (s *DBService) getDB() *sql.DB {
 	return s.db
 }
 
-func (s *DBService) ensureSchema() error {
-	db := s.getDB()
-	if db == nil {
-		return errors.New("db not initialized")
-	}
-
-	schema := `
-CREATE TABLE IF NOT EXISTS file_types (
-  id INTEGER PRIMARY KEY AUTOINCREMENT,
-  name TEXT NOT NULL UNIQUE,
-  description TEXT,
-  is_binary INTEGER DEFAULT 0,
-  mime_type TEXT
-);
-
-CREATE TABLE IF NOT EXISTS file_statuses (
-  id INTEGER PRIMARY KEY AUTOINCREMENT,
-  name TEXT NOT NULL UNIQUE,
-  description TEXT
-);
-
-CREATE TABLE IF NOT EXISTS file_entries (
-  id TEXT PRIMARY KEY,
-  name TEXT NOT NULL,
-  parent_id TEXT,
-  is_dir INTEGER NOT NULL,
-  file_type_id INTEGER,
-  file_status_id INTEGER,
-  created INTEGER NOT NULL,
-  modified INTEGER NOT NULL,
-  size INTEGER,
-  path TEXT NOT NULL UNIQUE,
-  FOREIGN KEY (parent_id) REFERENCES file_entries(id) ON DELETE CASCADE,
-  FOREIGN KEY (file_type_id) REFERENCES file_types(id),
-  FOREIGN KEY (file_status_id) REFERENCES file_statuses(id)
-);
-
-CREATE INDEX IF NOT EXISTS idx_parent_id ON file_entries(parent_id);
-CREATE INDEX IF NOT EXISTS idx_path ON file_entries(path);
-CREATE INDEX IF NOT EXISTS idx_file_type ON file_entries(file_type_id);
-CREATE INDEX IF NOT EXISTS idx_file_status ON file_entries(file_status_id);
-`
-	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
-	defer cancel()
-
-	if _, err := db.ExecContext(ctx, schema); err != nil {
-		return err
-	}
-
-	// Seed file types if table is empty
-	if err := s.seedFileTypes(); err != nil {
-		return err
-	}
-
-	// Seed file statuses if table is empty
-	return s.seedFileStatuses()
+// NeedsBackfill reports whether Start added tables that are filled from file
+// contents (tags, links, properties) to a database that already tracked files.
+// Those files must be processed again to fill them.
+func (s *DBService) NeedsBackfill() bool {
+	return s.needsBackfill
 }
 
 // Global mutex for parent directory creation to prevent race conditions
 // when multiple workers try to create the same parent path simultaneously
 var parentDirMutex sync.Mutex
 
-// performDatabaseUpdate updates the database for a given file event
+// UpdateResult is the outcome of applying a file event to the database
+type UpdateResult struct {
+	FileID string
+	// Set when a created or modified file still has the content hash stored
+	// for it; nothing was written and there is nothing downstream to update
+	Unchanged bool
+}
+
+// ContentHash returns the hash stored for file contents: hex-encoded SHA-256
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// PerformDatabaseUpdate updates the database for a given file event
 // This is a shared helper that both vault.updateDatabase and worker.updateDatabase use
+// File details (directory flag, size) are read through store; vaultPath is the root event paths are under
 // Returns the file ID and error
 // For create/modify events, returns the ID of the created/updated file
 // For delete events, returns the ID of the deleted file (before deletion)
-func (dbService *DBService) PerformDatabaseUpdate(vaultPath string, event syncpkg.FileChangeEvent) (string, error) {
+// For move events, returns the ID the file keeps at its new path
+func (dbService *DBService) PerformDatabaseUpdate(store storage.VaultStorage, vaultPath string, event syncpkg.FileChangeEvent) (string, error) {
+	result, err := dbService.ApplyFileEvent(store, vaultPath, event)
+	return result.FileID, err
+}
+
+// ApplyFileEvent is PerformDatabaseUpdate that also reports whether a created
+// or modified file's content was unchanged (and the event not forced)
+func (dbService *DBService) ApplyFileEvent(store storage.VaultStorage, vaultPath string, event syncpkg.FileChangeEvent) (UpdateResult, error) {
 	if dbService == nil {
-		return "", fmt.Errorf("db service not available")
+		return UpdateResult{}, fmt.Errorf("db service not available")
 	}
 
 	// Convert absolute path to relative path
 	relPath, err := filepath.Rel(vaultPath, event.Path)
 	if err != nil {
-		return "", fmt.Errorf("failed to get relative path: %w", err)
+		return UpdateResult{}, fmt.Errorf("failed to get relative path: %w", err)
 	}
 
 	switch event.EventType {
 	case syncpkg.FileCreated, syncpkg.FileModified:
-		// Determine if it's a directory and get file info
-		isDir := false
-		var size int64
-		if info, err := os.Stat(event.Path); err == nil {
-			isDir = info.IsDir()
-			if !isDir {
-				size = info.Size()
-			}
-		}
+		return dbService.upsertFileEntry(store, vaultPath, relPath, event)
 
-		// Detect file type
-		fileType := DetectFileType(filepath.Base(event.Path), isDir)
-		fileTypeID, err := dbService.GetFileTypeID(fileType)
+	case syncpkg.FileMoved:
+		oldRelPath, err := filepath.Rel(vaultPath, event.OldPath)
 		if err != nil {
-			logger.WithField("file_type", fileType).WithField("error", err).Warn("Failed to get file type ID")
+			return UpdateResult{}, fmt.Errorf("failed to get old relative path: %w", err)
 		}
+		fileID, err := dbService.moveFileEntry(store, vaultPath, oldRelPath, relPath, event)
+		return UpdateResult{FileID: fileID}, err
 
-		// Get ACTIVE status ID
-		activeStatusID, err := dbService.GetFileStatusID(FileStatusActive)
-		if err != nil {
-			logger.WithField("error", err).Warn("Failed to get active status ID")
+	case syncpkg.FileDeleted:
+		// Mark entry as deleted in database instead of removing it
+		entry, err := dbService.GetFileEntryByPath(relPath)
+		if err == nil && entry != nil {
+			fileID := entry.ID
+			if err := dbService.DeleteFileEntry(entry.ID); err != nil {
+				return UpdateResult{}, fmt.Errorf("failed to mark entry as deleted: %w", err)
+			}
+			return UpdateResult{FileID: fileID}, nil
 		}
+		return UpdateResult{}, nil // File not found in DB, but not an error
+	}
 
-		// Ensure parent directories exist in the database
-		var parentID *string
-		parentPath := filepath.Dir(relPath)
-		if parentPath != "." && parentPath != "" {
-			// Item is nested, ensure parent directories exist
-			parentID = dbService.EnsureParentDirsExist(vaultPath, parentPath)
-		} else {
-			// Item is at root level, set parent to root node ID
-			rootEntry, err := dbService.GetFileEntryByPath("")
-			if err == nil && rootEntry != nil {
-				// Root exists, use its ID as parent
-				parentID = &rootEntry.ID
-			}
+	return UpdateResult{}, nil
+}
+
+// upsertFileEntry creates the entry for a created or modified path, or updates it if the path is already known.
+// An active entry whose content hash matches the file is left alone unless the event is forced.
+func (dbService *DBService) upsertFileEntry(store storage.VaultStorage, vaultPath, relPath string, event syncpkg.FileChangeEvent) (UpdateResult, error) {
+	// Determine if it's a directory and get file info
+	isDir := false
+	var size int64
+	if info, err := store.Stat(dbService.ctx, filepath.ToSlash(relPath)); err == nil {
+		isDir = info.IsDir
+		size = info.Size
+	}
+
+	// Hash notes so saves that did not change the bytes can be skipped; other
+	// files are not parsed or indexed, so reading them on every event buys nothing
+	var contentHash string
+	if !isDir && utils.IsMarkdownFile(relPath) {
+		if data, err := store.Read(dbService.ctx, filepath.ToSlash(relPath)); err == nil {
+			contentHash = ContentHash(data)
 		}
+	}
 
-		// Create or update file entry in database
-		entry := &FileEntry{
-			ID:           utils.GenerateID(),
-			Name:         filepath.Base(event.Path),
-			IsDir:        isDir,
-			FileTypeID:   fileTypeID,
-			FileStatusID: activeStatusID,
-			Created:      event.Timestamp,
-			Modified:     event.Timestamp,
-			Size:         size,
-			Path:         relPath,
-			ParentID:     parentID,
+	// Detect file type
+	fileType := DetectFileType(filepath.Base(event.Path), isDir)
+	fileTypeID, err := dbService.GetFileTypeID(fileType)
+	if err != nil {
+		logger.WithField("file_type", fileType).WithField("error", err).Warn("Failed to get file type ID")
+	}
+
+	// Get ACTIVE status ID
+	activeStatusID, err := dbService.GetFileStatusID(FileStatusActive)
+	if err != nil {
+		logger.WithField("error", err).Warn("Failed to get active status ID")
+	}
+
+	// Ensure parent directories exist in the database
+	parentID := dbService.resolveParentID(vaultPath, relPath)
+
+	// Create or update file entry in database
+	entry := &FileEntry{
+		ID:           utils.GenerateID(),
+		Name:         filepath.Base(event.Path),
+		IsDir:        isDir,
+		FileTypeID:   fileTypeID,
+		FileStatusID: activeStatusID,
+		Created:      event.Timestamp,
+		Modified:     event.Timestamp,
+		Size:         size,
+		ContentHash:  contentHash,
+		Path:         relPath,
+		ParentID:     parentID,
+	}
+
+	// Check if entry already exists
+	existing, err := dbService.GetFileEntryByPath(relPath)
+	if err == nil && existing != nil {
+		if !event.Force && contentHash != "" && existing.ContentHash == contentHash &&
+			existing.FileStatusID != nil && activeStatusID != nil && *existing.FileStatusID == *activeStatusID {
+			return UpdateResult{FileID: existing.ID, Unchanged: true}, nil
 		}
 
-		// Check if entry already exists
-		existing, err := dbService.GetFileEntryByPath(relPath)
-		if err == nil && existing != nil {
-			// Update existing entry
-			entry.ID = existing.ID
-			entry.Created = existing.Created
-			entry.ParentID = existing.ParentID
-			entry.FileTypeID = fileTypeID
-			entry.FileStatusID = activeStatusID
-			if err := dbService.UpdateFileEntry(entry); err != nil {
-				return "", fmt.Errorf("failed to update entry: %w", err)
-			}
-			return entry.ID, nil
-		} else {
-			// Create new entry
-			if err := dbService.CreateFileEntry(entry); err != nil {
-				return "", fmt.Errorf("failed to create entry: %w", err)
-			}
-			return entry.ID, nil
+		// Update existing entry
+		entry.ID = existing.ID
+		entry.Created = existing.Created
+		entry.ParentID = existing.ParentID
+		entry.FileTypeID = fileTypeID
+		entry.FileStatusID = activeStatusID
+		if err := dbService.UpdateFileEntry(entry); err != nil {
+			return UpdateResult{}, fmt.Errorf("failed to update entry: %w", err)
 		}
+		return UpdateResult{FileID: entry.ID}, nil
+	}
 
-	case syncpkg.FileDeleted:
-		// Mark entry as deleted in database instead of removing it
-		entry, err := dbService.GetFileEntryByPath(relPath)
-		if err == nil && entry != nil {
-			fileID := entry.ID
-			if err := dbService.DeleteFileEntry(entry.ID); err != nil {
-				return "", fmt.Errorf("failed to mark entry as deleted: %w", err)
-			}
-			return fileID, nil
+	// Create new entry
+	if err := dbService.CreateFileEntry(entry); err != nil {
+		return UpdateResult{}, fmt.Errorf("failed to create entry: %w", err)
+	}
+	return UpdateResult{FileID: entry.ID}, nil
+}
+
+// moveFileEntry moves the entry at oldRelPath to relPath, keeping its ID
+func (dbService *DBService) moveFileEntry(store storage.VaultStorage, vaultPath, oldRelPath, relPath string, event syncpkg.FileChangeEvent) (string, error) {
+	existing, err := dbService.GetFileEntryByPath(oldRelPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up moved entry: %w", err)
+	}
+	if existing == nil {
+		// Never tracked under its old name, so this is just a new file
+		result, err := dbService.upsertFileEntry(store, vaultPath, relPath, event)
+		return result.FileID, err
+	}
+
+	activeStatusID, err := dbService.GetFileStatusID(FileStatusActive)
+	if err != nil {
+		return "", fmt.Errorf("failed to get active status ID: %w", err)
+	}
+
+	// Moved over a live file (editors save by renaming a temp file over the
+	// note): the target keeps its ID and the source goes away
+	target, err := dbService.GetFileEntryByPath(relPath)
+	if err == nil && target != nil && target.ID != existing.ID &&
+		target.FileStatusID != nil && activeStatusID != nil && *target.FileStatusID == *activeStatusID {
+		if err := dbService.DeleteFileEntry(existing.ID); err != nil {
+			return "", fmt.Errorf("failed to mark replaced entry as deleted: %w", err)
+		}
+		result, err := dbService.upsertFileEntry(store, vaultPath, relPath, event)
+		return result.FileID, err
+	}
+
+	fileTypeID := existing.FileTypeID
+	if !existing.IsDir {
+		// A rename can change the extension
+		if id, err := dbService.GetFileTypeID(DetectFileType(filepath.Base(relPath), false)); err == nil {
+			fileTypeID = id
 		}
-		return "", nil // File not found in DB, but not an error
 	}
 
-	return "", nil
+	entry := *existing
+	entry.Name = filepath.Base(relPath)
+	entry.Path = relPath
+	entry.ParentID = dbService.resolveParentID(vaultPath, relPath)
+	entry.FileTypeID = fileTypeID
+	entry.FileStatusID = activeStatusID
+	entry.Modified = event.Timestamp
+	if err := dbService.MoveFileEntry(&entry, existing.Path); err != nil {
+		return "", fmt.Errorf("failed to move entry: %w", err)
+	}
+	return entry.ID, nil
+}
+
+// resolveParentID returns the ID of the directory containing relPath, creating missing directories
+func (dbService *DBService) resolveParentID(vaultPath, relPath string) *string {
+	parentPath := filepath.Dir(relPath)
+	if parentPath != "." && parentPath != "" {
+		// Item is nested, ensure parent directories exist
+		return dbService.EnsureParentDirsExist(vaultPath, parentPath)
+	}
+
+	// Item is at root level, set parent to root node ID
+	rootEntry, err := dbService.GetFileEntryByPath("")
+	if err == nil && rootEntry != nil {
+		// Root exists, use its ID as parent
+		return &rootEntry.ID
+	}
+	return nil
 }
 
 // ensureParentDirsExist ensures all parent directories exist in the database and returns the ID of the immediate parent
@@ -502,108 +570,6 @@ func (dbService *DBService) EnsureParentDirsExist(vaultPath, parentPath string)
 	return currentParentID
 }
 
-// seedFileTypes populates the file_types table with predefined types
-func (s *DBService) seedFileTypes() error {
-	db := s.getDB()
-	if db == nil {
-		return errors.New("db not initialized")
-	}
-
-	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
-	defer cancel()
-
-	// Check if table already has data
-	var count int
-	row := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM file_types")
-	if err := row.Scan(&count); err != nil {
-		return fmt.Errorf("check file_types count: %w", err)
-	}
-
-	if count > 0 {
-		return nil // Already seeded
-	}
-
-	// Define file types with metadata
-	types := []struct {
-		name        string
-		description string
-		isBinary    bool
-		mimeType    string
-	}{
-		{string(FileTypeDirectory), "Directory", false, ""},
-		{string(FileTypeMarkdown), "Markdown document", false, "text/markdown"},
-		{string(FileTypePNG), "PNG image", true, "image/png"},
-		{string(FileTypeJPEG), "JPEG image", true, "image/jpeg"},
-		{string(FileTypeJPG), "JPG image", true, "image/jpeg"},
-		{string(FileTypeGIF), "GIF image", true, "image/gif"},
-		{string(FileTypeWebP), "WebP image", true, "image/webp"},
-		{string(FileTypeSVG), "SVG image", false, "image/svg+xml"},
-		{string(FileTypePDF), "PDF document", true, "application/pdf"},
-		{string(FileTypeTXT), "Text file", false, "text/plain"},
-		{string(FileTypeJSON), "JSON file", false, "application/json"},
-		{string(FileTypeYAML), "YAML file", false, "application/x-yaml"},
-		{string(FileTypeXML), "XML file", false, "application/xml"},
-		{string(FileTypeCSV), "CSV file", false, "text/csv"},
-		{string(FileTypeUnknown), "Unknown file type", true, "application/octet-stream"},
-	}
-
-	// Insert all types
-	for _, ft := range types {
-		_, err := db.ExecContext(ctx,
-			"INSERT INTO file_types(name, description, is_binary, mime_type) VALUES (?, ?, ?, ?)",
-			ft.name, ft.description, boolToInt(ft.isBinary), ft.mimeType)
-		if err != nil {
-			return fmt.Errorf("insert file type %s: %w", ft.name, err)
-		}
-	}
-
-	return nil
-}
-
-// seedFileStatuses populates the file_statuses table with predefined statuses
-func (s *DBService) seedFileStatuses() error {
-	db := s.getDB()
-	if db == nil {
-		return errors.New("db not initialized")
-	}
-
-	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
-	defer cancel()
-
-	// Check if table already has data
-	var count int
-	row := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM file_statuses")
-	if err := row.Scan(&count); err != nil {
-		return fmt.Errorf("check file_statuses count: %w", err)
-	}
-
-	if count > 0 {
-		return nil // Already seeded
-	}
-
-	// Define file statuses with metadata
-	statuses := []struct {
-		name        string
-		description string
-	}{
-		{string(FileStatusActive), "File is active and available"},
-		{string(FileStatusDeleted), "File has been deleted"},
-		{string(FileStatusDisabled), "File has been disabled"},
-	}
-
-	// Insert all statuses
-	for _, fs := range statuses {
-		_, err := db.ExecContext(ctx,
-			"INSERT INTO file_statuses(name, description) VALUES (?, ?)",
-			fs.name, fs.description)
-		if err != nil {
-			return fmt.Errorf("insert file status %s: %w", fs.name, err)
-		}
-	}
-
-	return nil
-}
-
 // GetFileTypeID returns the ID for a given file type name
 func (s *DBService) GetFileTypeID(fileType FileType) (*int64, error) {
 	db := s.getDB()
@@ -752,10 +718,10 @@ func (s *DBService) CreateFileEntry(entry *FileEntry) error {
 	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
 	defer cancel()
 	_, err := db.ExecContext(ctx,
-		`INSERT INTO file_entries(id, name, parent_id, is_dir, file_type_id, file_status_id, created, modified, size, path)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		entry.ID, entry.Name, entry.ParentID, boolToInt(entry.IsDir), entry.FileTypeID, entry.FileStatusID,
-		entry.Created.Unix(), entry.Modified.Unix(), entry.Size, entry.Path)
+		`INSERT INTO file_entries(id, name, name_folded, parent_id, is_dir, file_type_id, file_status_id, created, modified, size, path, content_hash)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.Name, foldName(entry.Name), entry.ParentID, boolToInt(entry.IsDir), entry.FileTypeID, entry.FileStatusID,
+		entry.Created.Unix(), entry.Modified.Unix(), entry.Size, entry.Path, nullIfEmpty(entry.ContentHash))
 	if err != nil {
 		return fmt.Errorf("insert entry: %w", err)
 	}
@@ -773,7 +739,7 @@ func (s *DBService) GetFileEntryByIDWithStatus(id string, status FileStatus) (*F
 
 	query := `
 		SELECT fe.id, fe.name, fe.parent_id, fe.is_dir, fe.file_type_id, fe.file_status_id,
-		       fe.created, fe.modified, fe.size, fe.path
+		       fe.created, fe.modified, fe.size, fe.path, fe.content_hash
 		FROM file_entries fe
 		INNER JOIN file_statuses fs ON fe.file_status_id = fs.id
 		WHERE fe.id = ? AND fs.name = ?`
@@ -788,9 +754,10 @@ func (s *DBService) GetFileEntryByIDWithStatus(id string, status FileStatus) (*F
 		parentID     sql.NullString
 		fileTypeID   sql.NullInt64
 		fileStatusID sql.NullInt64
+		contentHash  sql.NullString
 	)
 	if err := row.Scan(&entry.ID, &entry.Name, &parentID, &isDirInt, &fileTypeID, &fileStatusID,
-		&creatUnix, &modUnix, &entry.Size, &entry.Path); err != nil {
+		&creatUnix, &modUnix, &entry.Size, &entry.Path, &contentHash); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -807,6 +774,7 @@ func (s *DBService) GetFileEntryByIDWithStatus(id string, status FileStatus) (*F
 		entry.FileStatusID = &fileStatusID.Int64
 	}
 	entry.IsDir = intToBool(isDirInt)
+	entry.ContentHash = contentHash.String
 	if creatUnix.Valid {
 		entry.Created = time.Unix(creatUnix.Int64, 0).UTC()
 	}
@@ -825,7 +793,7 @@ func (s *DBService) GetFileEntryByID(id string) (*FileEntry, error) {
 	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
 	defer cancel()
 	row := db.QueryRowContext(ctx,
-		`SELECT id, name, parent_id, is_dir, file_type_id, file_status_id, created, modified, size, path FROM file_entries WHERE id = ?`, id)
+		`SELECT id, name, parent_id, is_dir, file_type_id, file_status_id, created, modified, size, path, content_hash FROM file_entries WHERE id = ?`, id)
 
 	var (
 		entry        FileEntry
@@ -835,9 +803,10 @@ func (s *DBService) GetFileEntryByID(id string) (*FileEntry, error) {
 		parentID     sql.NullString
 		fileTypeID   sql.NullInt64
 		fileStatusID sql.NullInt64
+		contentHash  sql.NullString
 	)
 	if err := row.Scan(&entry.ID, &entry.Name, &parentID, &isDirInt, &fileTypeID, &fileStatusID,
-		&creatUnix, &modUnix, &entry.Size, &entry.Path); err != nil {
+		&creatUnix, &modUnix, &entry.Size, &entry.Path, &contentHash); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -854,6 +823,7 @@ func (s *DBService) GetFileEntryByID(id string) (*FileEntry, error) {
 		entry.FileStatusID = &fileStatusID.Int64
 	}
 	entry.IsDir = intToBool(isDirInt)
+	entry.ContentHash = contentHash.String
 	if creatUnix.Valid {
 		entry.Created = time.Unix(creatUnix.Int64, 0).UTC()
 	}
@@ -874,7 +844,7 @@ func (s *DBService) GetFileEntryByPathWithStatus(path string, status FileStatus)
 
 	query := `
 		SELECT fe.id, fe.name, fe.parent_id, fe.is_dir, fe.file_type_id, fe.file_status_id,
-		       fe.created, fe.modified, fe.size, fe.path
+		       fe.created, fe.modified, fe.size, fe.path, fe.content_hash
 		FROM file_entries fe
 		INNER JOIN file_statuses fs ON fe.file_status_id = fs.id
 		WHERE fe.path = ? AND fs.name = ?`
@@ -889,9 +859,10 @@ func (s *DBService) GetFileEntryByPathWithStatus(path string, status FileStatus)
 		parentID     sql.NullString
 		fileTypeID   sql.NullInt64
 		fileStatusID sql.NullInt64
+		contentHash  sql.NullString
 	)
 	if err := row.Scan(&entry.ID, &entry.Name, &parentID, &isDirInt, &fileTypeID, &fileStatusID,
-		&creatUnix, &modUnix, &entry.Size, &entry.Path); err != nil {
+		&creatUnix, &modUnix, &entry.Size, &entry.Path, &contentHash); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -908,6 +879,7 @@ func (s *DBService) GetFileEntryByPathWithStatus(path string, status FileStatus)
 		entry.FileStatusID = &fileStatusID.Int64
 	}
 	entry.IsDir = intToBool(isDirInt)
+	entry.ContentHash = contentHash.String
 	if creatUnix.Valid {
 		entry.Created = time.Unix(creatUnix.Int64, 0).UTC()
 	}
@@ -926,7 +898,7 @@ func (s *DBService) GetFileEntryByPath(path string) (*FileEntry, error) {
 	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
 	defer cancel()
 	row := db.QueryRowContext(ctx,
-		`SELECT id, name, parent_id, is_dir, file_type_id, file_status_id, created, modified, size, path FROM file_entries WHERE path = ?`, path)
+		`SELECT id, name, parent_id, is_dir, file_type_id, file_status_id, created, modified, size, path, content_hash FROM file_entries WHERE path = ?`, path)
 
 	var (
 		entry        FileEntry
@@ -936,9 +908,10 @@ func (s *DBService) GetFileEntryByPath(path string) (*FileEntry, error) {
 		parentID     sql.NullString
 		fileTypeID   sql.NullInt64
 		fileStatusID sql.NullInt64
+		contentHash  sql.NullString
 	)
 	if err := row.Scan(&entry.ID, &entry.Name, &parentID, &isDirInt, &fileTypeID, &fileStatusID,
-		&creatUnix, &modUnix, &entry.Size, &entry.Path); err != nil {
+		&creatUnix, &modUnix, &entry.Size, &entry.Path, &contentHash); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -955,6 +928,7 @@ func (s *DBService) GetFileEntryByPath(path string) (*FileEntry, error) {
 		entry.FileStatusID = &fileStatusID.Int64
 	}
 	entry.IsDir = intToBool(isDirInt)
+	entry.ContentHash = contentHash.String
 	if creatUnix.Valid {
 		entry.Created = time.Unix(creatUnix.Int64, 0).UTC()
 	}
@@ -990,7 +964,7 @@ func (s *DBService) GetFileEntriesByParentID(parentID *string) ([]FileEntry, err
 	if parentID == nil {
 		query = `
 			SELECT fe.id, fe.name, fe.parent_id, fe.is_dir, fe.file_type_id, fe.file_status_id,
-			       fe.created, fe.modified, fe.size, fe.path
+			       fe.created, fe.modified, fe.size, fe.path, fe.content_hash
 			FROM file_entries fe
 			INNER JOIN file_statuses fs ON fe.file_status_id = fs.id
 			WHERE fe.parent_id IS NULL AND fs.name = ?
@@ -999,7 +973,7 @@ func (s *DBService) GetFileEntriesByParentID(parentID *string) ([]FileEntry, err
 	} else {
 		query = `
 			SELECT fe.id, fe.name, fe.parent_id, fe.is_dir, fe.file_type_id, fe.file_status_id,
-			       fe.created, fe.modified, fe.size, fe.path
+			       fe.created, fe.modified, fe.size, fe.path, fe.content_hash
 			FROM file_entries fe
 			INNER JOIN file_statuses fs ON fe.file_status_id = fs.id
 			WHERE fe.parent_id = ? AND fs.name = ?
@@ -1022,9 +996,10 @@ func (s *DBService) GetFileEntriesByParentID(parentID *string) ([]FileEntry, err
 		var parentIDStr sql.NullString
 		var fileTypeID sql.NullInt64
 		var fileStatusID sql.NullInt64
+		var contentHash sql.NullString
 
 		if err := rows.Scan(&entry.ID, &entry.Name, &parentIDStr, &isDirInt, &fileTypeID, &fileStatusID,
-			&creatUnix, &modUnix, &entry.Size, &entry.Path); err != nil {
+			&creatUnix, &modUnix, &entry.Size, &entry.Path, &contentHash); err != nil {
 			return nil, err
 		}
 
@@ -1038,6 +1013,7 @@ func (s *DBService) GetFileEntriesByParentID(parentID *string) ([]FileEntry, err
 			entry.FileStatusID = &fileStatusID.Int64
 		}
 		entry.IsDir = intToBool(isDirInt)
+		entry.ContentHash = contentHash.String
 		if creatUnix.Valid {
 			entry.Created = time.Unix(creatUnix.Int64, 0).UTC()
 		}
@@ -1049,6 +1025,69 @@ func (s *DBService) GetFileEntriesByParentID(parentID *string) ([]FileEntry, err
 	return out, nil
 }
 
+// GetActiveFileEntries retrieves every ACTIVE entry in the vault.
+func (s *DBService) GetActiveFileEntries() ([]FileEntry, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT fe.id, fe.name, fe.parent_id, fe.is_dir, fe.file_type_id, fe.file_status_id,
+		       fe.created, fe.modified, fe.size, fe.path, fe.content_hash
+		FROM file_entries fe
+		INNER JOIN file_statuses fs ON fe.file_status_id = fs.id
+		WHERE fs.name = ?`, string(FileStatusActive))
+	if err != nil {
+		return nil, fmt.Errorf("query active entries: %w", err)
+	}
+	defer rows.Close()
+
+	return scanFileEntries(rows)
+}
+
+// scanFileEntries reads rows selecting the file_entries columns in table order
+func scanFileEntries(rows *sql.Rows) ([]FileEntry, error) {
+	var out []FileEntry
+	for rows.Next() {
+		var entry FileEntry
+		var isDirInt int
+		var creatUnix sql.NullInt64
+		var modUnix sql.NullInt64
+		var parentIDStr sql.NullString
+		var fileTypeID sql.NullInt64
+		var fileStatusID sql.NullInt64
+		var contentHash sql.NullString
+
+		if err := rows.Scan(&entry.ID, &entry.Name, &parentIDStr, &isDirInt, &fileTypeID, &fileStatusID,
+			&creatUnix, &modUnix, &entry.Size, &entry.Path, &contentHash); err != nil {
+			return nil, err
+		}
+
+		if parentIDStr.Valid {
+			entry.ParentID = &parentIDStr.String
+		}
+		if fileTypeID.Valid {
+			entry.FileTypeID = &fileTypeID.Int64
+		}
+		if fileStatusID.Valid {
+			entry.FileStatusID = &fileStatusID.Int64
+		}
+		entry.IsDir = intToBool(isDirInt)
+		entry.ContentHash = contentHash.String
+		if creatUnix.Valid {
+			entry.Created = time.Unix(creatUnix.Int64, 0).UTC()
+		}
+		if modUnix.Valid {
+			entry.Modified = time.Unix(modUnix.Int64, 0).UTC()
+		}
+		out = append(out, entry)
+	}
+	return out, rows.Err()
+}
+
 // UpdateFileEntry updates an existing entry by id.
 func (s *DBService) UpdateFileEntry(entry *FileEntry) error {
 	if entry == nil {
@@ -1065,8 +1104,9 @@ func (s *DBService) UpdateFileEntry(entry *FileEntry) error {
 	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
 	defer cancel()
 	res, err := db.ExecContext(ctx,
-		`UPDATE file_entries SET name = ?, parent_id = ?, file_type_id = ?, file_status_id = ?, modified = ?, size = ?, path = ? WHERE id = ?`,
-		entry.Name, entry.ParentID, entry.FileTypeID, entry.FileStatusID, entry.Modified.Unix(), entry.Size, entry.Path, entry.ID)
+		`UPDATE file_entries SET name = ?, name_folded = ?, parent_id = ?, file_type_id = ?, file_status_id = ?, modified = ?, size = ?, path = ?, content_hash = ? WHERE id = ?`,
+		entry.Name, foldName(entry.Name), entry.ParentID, entry.FileTypeID, entry.FileStatusID, entry.Modified.Unix(), entry.Size, entry.Path,
+		nullIfEmpty(entry.ContentHash), entry.ID)
 	if err != nil {
 		return fmt.Errorf("update entry: %w", err)
 	}
@@ -1077,6 +1117,71 @@ func (s *DBService) UpdateFileEntry(entry *FileEntry) error {
 	return nil
 }
 
+// MoveFileEntry updates an entry to its new name, parent and path by id and
+// rewrites the paths of every entry below it, all in one transaction.
+// Deleted entries left at the destination are removed first.
+func (s *DBService) MoveFileEntry(entry *FileEntry, oldPath string) error {
+	if entry == nil {
+		return errors.New("nil entry")
+	}
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	if entry.Modified.IsZero() {
+		entry.Modified = time.Now().UTC()
+	}
+
+	deletedStatusID, err := s.GetFileStatusID(FileStatusDeleted)
+	if err != nil {
+		return fmt.Errorf("failed to get deleted status ID: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin move: %w", err)
+	}
+	defer tx.Rollback()
+
+	// substr counts characters, so prefixes are measured in runes
+	sep := string(filepath.Separator)
+	newPrefix := entry.Path + sep
+	oldPrefix := oldPath + sep
+
+	if deletedStatusID != nil {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM file_entries WHERE id != ? AND file_status_id = ? AND (path = ? OR substr(path, 1, ?) = ?)`,
+			entry.ID, *deletedStatusID, entry.Path, utf8.RuneCountInString(newPrefix), newPrefix); err != nil {
+			return fmt.Errorf("clear move destination: %w", err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE file_entries SET name = ?, name_folded = ?, parent_id = ?, file_type_id = ?, file_status_id = ?, modified = ?, path = ? WHERE id = ?`,
+		entry.Name, foldName(entry.Name), entry.ParentID, entry.FileTypeID, entry.FileStatusID, entry.Modified.Unix(), entry.Path, entry.ID)
+	if err != nil {
+		return fmt.Errorf("move entry: %w", err)
+	}
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		return sql.ErrNoRows
+	}
+
+	if entry.IsDir {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE file_entries SET path = ? || substr(path, ?) WHERE substr(path, 1, ?) = ?`,
+			newPrefix, utf8.RuneCountInString(oldPrefix)+1, utf8.RuneCountInString(oldPrefix), oldPrefix); err != nil {
+			return fmt.Errorf("move descendants: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit move: %w", err)
+	}
+	return nil
+}
+
 // DeleteFileEntry marks an entry as deleted by setting its status to DELETED instead of removing it.
 func (s *DBService) DeleteFileEntry(id string) error {
 	db := s.getDB()
@@ -1151,61 +1256,6 @@ func (s *DBService) DisableAllFiles() error {
 	return nil
 }
 
-// GetFileEntryByName finds a file entry by name (for resolving wikilinks)
-// This searches for an exact match or match with .md extension
-func (s *DBService) GetFileEntryByName(name string) (*FileEntry, error) {
-	db := s.getDB()
-	if db == nil {
-		return nil, errors.New("db not ready")
-	}
-
-	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
-	defer cancel()
-
-	// Try exact match first
-	var entry FileEntry
-	var isDirInt int
-	var creatUnix sql.NullInt64
-	var modUnix sql.NullInt64
-	var parentID sql.NullString
-	var fileTypeID sql.NullInt64
-	var fileStatusID sql.NullInt64
-
-	row := db.QueryRowContext(ctx,
-		`SELECT id, name, parent_id, is_dir, file_type_id, file_status_id, created, modified, size, path
-		 FROM file_entries
-		 WHERE name = ? OR name = ?
-		 LIMIT 1`,
-		name, name+".md")
-
-	if err := row.Scan(&entry.ID, &entry.Name, &parentID, &isDirInt, &fileTypeID, &fileStatusID,
-		&creatUnix, &modUnix, &entry.Size, &entry.Path); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("scan entry: %w", err)
-	}
-
-	if parentID.Valid {
-		entry.ParentID = &parentID.String
-	}
-	if fileTypeID.Valid {
-		entry.FileTypeID = &fileTypeID.Int64
-	}
-	if fileStatusID.Valid {
-		entry.FileStatusID = &fileStatusID.Int64
-	}
-	entry.IsDir = intToBool(isDirInt)
-	if creatUnix.Valid {
-		entry.Created = time.Unix(creatUnix.Int64, 0).UTC()
-	}
-	if modUnix.Valid {
-		entry.Modified = time.Unix(modUnix.Int64, 0).UTC()
-	}
-
-	return &entry, nil
-}
-
 // Helper functions
 func boolToInt(b bool) int {
 	if b {
@@ -1217,3 +1267,8 @@ func boolToInt(b bool) int {
 func intToBool(i int) bool {
 	return i != 0
 }
+
+// nullIfEmpty stores an empty string as NULL
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
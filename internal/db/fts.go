@@ -0,0 +1,527 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Note is a markdown note as stored for full-text search
+type Note struct {
+	ID        string // Document ID: the file ID, or the relative path before the file is tracked
+	Path      string // Relative path from the vault root
+	Title     string
+	Content   string
+	Metadata  string // YAML frontmatter
+	Tags      []string
+	Wikilinks []string
+	SortTitle string // Lowercased title, or file name, that title sorts use
+	Created   time.Time
+	Modified  time.Time
+	Date      time.Time // Frontmatter date
+}
+
+// Fields notes can be sorted by
+const (
+	NoteSortScore    = "score"
+	NoteSortTitle    = "title"
+	NoteSortPath     = "path"
+	NoteSortCreated  = "created"
+	NoteSortModified = "modified"
+	NoteSortID       = "id"
+)
+
+// NoteSort is one key of a note sort order
+type NoteSort struct {
+	Field string // A NoteSort* constant
+	Desc  bool
+}
+
+// NoteQuery selects notes from the full-text tables. Match is an FTS5 query
+// over title, content and metadata. Every group in Tags and Wikilinks must be
+// matched by at least one of its values, ignoring case.
+type NoteQuery struct {
+	Match     string
+	Tags      [][]string
+	Wikilinks [][]string
+	Created   TimeRange
+	Modified  TimeRange
+	Date      TimeRange  // Frontmatter date
+	Highlight bool       // Return snippets with matches wrapped in <mark>
+	Sort      []NoteSort // Best score first, then path, when empty
+	After     []string   // Sort values of the note the page starts after, one per Sort key
+	Limit     int
+	Offset    int
+}
+
+// TimeRange bounds a time from After (inclusive) to Before (exclusive). A zero
+// bound is open; notes without the time never match a range with a bound.
+type TimeRange struct {
+	After  time.Time
+	Before time.Time
+}
+
+// filter returns the conditions a column holding unix nanos must meet
+func (r TimeRange) filter(column string) ([]string, []any) {
+	if r.After.IsZero() && r.Before.IsZero() {
+		return nil, nil
+	}
+	where := []string{column + ` != 0`}
+	var args []any
+	if !r.After.IsZero() {
+		where = append(where, column+` >= ?`)
+		args = append(args, r.After.UnixNano())
+	}
+	if !r.Before.IsZero() {
+		where = append(where, column+` < ?`)
+		args = append(args, r.Before.UnixNano())
+	}
+	return where, args
+}
+
+// NoteHit is a note matching a NoteQuery
+type NoteHit struct {
+	ID         string
+	Path       string
+	Title      string
+	Tags       []string
+	Wikilinks  []string
+	Created    time.Time
+	Modified   time.Time
+	Date       time.Time
+	Score      float64
+	Fragments  map[string][]string // Highlighted snippets by field
+	SortValues []string            // The note's values for the query's sort keys, to continue after
+}
+
+// noteSearchSchema holds the sqlite_fts search engine's tables. It is not a
+// migration because FTS5 is only compiled in with the sqlite_fts5 build tag,
+// and vaults using bleve must open without it.
+const noteSearchSchema = `
+CREATE TABLE IF NOT EXISTS note_documents (
+	seq INTEGER PRIMARY KEY,
+	doc_id TEXT NOT NULL UNIQUE,
+	path TEXT NOT NULL UNIQUE,
+	tags TEXT NOT NULL DEFAULT '[]',
+	wikilinks TEXT NOT NULL DEFAULT '[]',
+	sort_title TEXT NOT NULL DEFAULT '',
+	created INTEGER NOT NULL DEFAULT 0, -- unix nanos
+	modified INTEGER NOT NULL DEFAULT 0,
+	date INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS note_fts USING fts5(
+	title, content, metadata,
+	tokenize = 'unicode61 remove_diacritics 2',
+	prefix = '2 3'
+);
+
+CREATE TRIGGER IF NOT EXISTS note_documents_ad AFTER DELETE ON note_documents BEGIN
+	DELETE FROM note_fts WHERE rowid = old.seq;
+END;
+`
+
+// EnableNoteSearch creates the full-text tables used by the sqlite_fts search engine
+func (s *DBService) EnableNoteSearch() error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	// Tables from before notes had sort and date columns are dropped;
+	// initial indexing fills the new ones again
+	var outdated bool
+	if err := db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE name = 'note_documents')
+		AND NOT EXISTS (SELECT 1 FROM pragma_table_info('note_documents') WHERE name = 'date')`).Scan(&outdated); err != nil {
+		return fmt.Errorf("check note search tables: %w", err)
+	}
+	if outdated {
+		if _, err := db.ExecContext(ctx, `DROP TABLE note_documents; DROP TABLE IF EXISTS note_fts`); err != nil {
+			return fmt.Errorf("drop outdated note search tables: %w", err)
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, noteSearchSchema); err != nil {
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			return fmt.Errorf("SQLite was built without FTS5, rebuild with -tags sqlite_fts5: %w", err)
+		}
+		return fmt.Errorf("create note search tables: %w", err)
+	}
+	return nil
+}
+
+// IndexNotes adds notes to the full-text tables, replacing any note stored
+// under the same ID or path
+func (s *DBService) IndexNotes(notes []Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, note := range notes {
+		// The trigger drops the old note's text along with it
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM note_documents WHERE doc_id = ? OR path = ?`, note.ID, note.Path); err != nil {
+			return fmt.Errorf("replace note %s: %w", note.Path, err)
+		}
+		tags, err := json.Marshal(nonNilStrings(note.Tags))
+		if err != nil {
+			return err
+		}
+		wikilinks, err := json.Marshal(nonNilStrings(note.Wikilinks))
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO note_documents(doc_id, path, tags, wikilinks, sort_title, created, modified, date) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			note.ID, note.Path, string(tags), string(wikilinks), note.SortTitle,
+			unixNanos(note.Created), unixNanos(note.Modified), unixNanos(note.Date))
+		if err != nil {
+			return fmt.Errorf("insert note %s: %w", note.Path, err)
+		}
+		seq, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO note_fts(rowid, title, content, metadata) VALUES (?, ?, ?, ?)`,
+			seq, note.Title, note.Content, note.Metadata); err != nil {
+			return fmt.Errorf("insert note text %s: %w", note.Path, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteNote removes the note stored under id or path from the full-text tables
+func (s *DBService) DeleteNote(id, path string) error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx,
+		`DELETE FROM note_documents WHERE doc_id = ? OR path = ?`, id, path); err != nil {
+		return fmt.Errorf("delete note: %w", err)
+	}
+	return nil
+}
+
+// ClearNotes empties the full-text tables
+func (s *DBService) ClearNotes() error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM note_documents`); err != nil {
+		return fmt.Errorf("clear notes: %w", err)
+	}
+	return nil
+}
+
+// IndexedNotes returns the path of every note in the full-text tables, keyed by document ID
+func (s *DBService) IndexedNotes() (map[string]string, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `SELECT doc_id, path FROM note_documents`)
+	if err != nil {
+		return nil, fmt.Errorf("list notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := make(map[string]string)
+	for rows.Next() {
+		var id, path string
+		if err := rows.Scan(&id, &path); err != nil {
+			return nil, err
+		}
+		notes[id] = path
+	}
+	return notes, rows.Err()
+}
+
+// CountNotes returns the number of notes in the full-text tables
+func (s *DBService) CountNotes() (uint64, error) {
+	db := s.getDB()
+	if db == nil {
+		return 0, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+	defer cancel()
+
+	var count uint64
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM note_documents`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count notes: %w", err)
+	}
+	return count, nil
+}
+
+// SearchNotes returns a page of the notes matching q in its sort order, the
+// number of notes matching in total and the best score among all of them
+func (s *DBService) SearchNotes(q NoteQuery) ([]NoteHit, uint64, float64, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, 0, 0, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	var where []string
+	var args []any
+	if q.Match != "" {
+		where = append(where, `note_fts MATCH ?`)
+		args = append(args, q.Match)
+	}
+	for _, group := range q.Tags {
+		cond, groupArgs := jsonListFilter("d.tags", group)
+		where = append(where, cond)
+		args = append(args, groupArgs...)
+	}
+	for _, group := range q.Wikilinks {
+		cond, groupArgs := jsonListFilter("d.wikilinks", group)
+		where = append(where, cond)
+		args = append(args, groupArgs...)
+	}
+	if len(where) == 0 {
+		return []NoteHit{}, 0, 0, nil
+	}
+	for _, r := range []struct {
+		column string
+		TimeRange
+	}{{"d.created", q.Created}, {"d.modified", q.Modified}, {"d.date", q.Date}} {
+		cond, rangeArgs := r.filter(r.column)
+		where = append(where, cond...)
+		args = append(args, rangeArgs...)
+	}
+	from := `FROM note_fts INNER JOIN note_documents d ON d.seq = note_fts.rowid WHERE ` + strings.Join(where, " AND ")
+
+	// Without a text query there is nothing to rank or highlight. The score
+	// is a real, as ORDER BY reads a constant integer as a column number.
+	score := `0.0`
+	titleFragment, contentFragment := `''`, `''`
+	if q.Match != "" {
+		score = `-bm25(note_fts)`
+		if q.Highlight {
+			titleFragment = `highlight(note_fts, 0, '<mark>', '</mark>')`
+			contentFragment = `snippet(note_fts, 1, '<mark>', '</mark>', '…', 24)`
+		}
+	}
+
+	var total uint64
+	var maxScore sql.NullFloat64
+	// bm25 only works on the rows of a full-text query, so the scores are
+	// materialized before they are aggregated
+	if err := db.QueryRowContext(ctx, `WITH matches AS MATERIALIZED (SELECT `+score+` AS score `+from+`)
+		SELECT COUNT(*), MAX(score) FROM matches`, args...).Scan(&total, &maxScore); err != nil {
+		return nil, 0, 0, fmt.Errorf("count note matches: %w", err)
+	}
+
+	keys := q.Sort
+	if len(keys) == 0 {
+		keys = []NoteSort{{Field: NoteSortScore, Desc: true}, {Field: NoteSortPath}}
+	}
+	exprs := make([]string, len(keys))
+	order := make([]string, len(keys))
+	for i, key := range keys {
+		switch key.Field {
+		case NoteSortScore:
+			exprs[i] = score
+		case NoteSortTitle:
+			exprs[i] = `d.sort_title`
+		case NoteSortPath:
+			exprs[i] = `d.path`
+		case NoteSortCreated:
+			exprs[i] = `d.created`
+		case NoteSortModified:
+			exprs[i] = `d.modified`
+		case NoteSortID:
+			exprs[i] = `d.doc_id`
+		default:
+			return nil, 0, 0, fmt.Errorf("unknown note sort field %q", key.Field)
+		}
+		order[i] = exprs[i]
+		if key.Desc {
+			order[i] += ` DESC`
+		}
+	}
+
+	if len(q.After) > 0 {
+		cond, afterArgs, err := afterFilter(keys, exprs, q.After)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		from += ` AND ` + cond
+		args = append(args, afterArgs...)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT d.doc_id, d.path, note_fts.title, d.tags, d.wikilinks, d.sort_title, d.created, d.modified, d.date,
+			`+score+`, `+titleFragment+`, `+contentFragment+`
+		`+from+` ORDER BY `+strings.Join(order, ", ")+` LIMIT ? OFFSET ?`,
+		append(args, limit, q.Offset)...)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("search notes: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []NoteHit{}
+	for rows.Next() {
+		var hit NoteHit
+		var tags, wikilinks, sortTitle string
+		var created, modified, date int64
+		var titleFrag, contentFrag sql.NullString
+		if err := rows.Scan(&hit.ID, &hit.Path, &hit.Title, &tags, &wikilinks, &sortTitle, &created, &modified, &date,
+			&hit.Score, &titleFrag, &contentFrag); err != nil {
+			return nil, 0, 0, err
+		}
+		if err := json.Unmarshal([]byte(tags), &hit.Tags); err != nil {
+			return nil, 0, 0, fmt.Errorf("decode tags of %s: %w", hit.Path, err)
+		}
+		if err := json.Unmarshal([]byte(wikilinks), &hit.Wikilinks); err != nil {
+			return nil, 0, 0, fmt.Errorf("decode wikilinks of %s: %w", hit.Path, err)
+		}
+		hit.Created, hit.Modified, hit.Date = fromUnixNanos(created), fromUnixNanos(modified), fromUnixNanos(date)
+		for field, fragment := range map[string]string{"title": titleFrag.String, "content": contentFrag.String} {
+			if strings.Contains(fragment, "<mark>") {
+				if hit.Fragments == nil {
+					hit.Fragments = make(map[string][]string)
+				}
+				hit.Fragments[field] = []string{fragment}
+			}
+		}
+		hit.SortValues = make([]string, len(keys))
+		for i, key := range keys {
+			switch key.Field {
+			case NoteSortScore:
+				hit.SortValues[i] = strconv.FormatFloat(hit.Score, 'g', -1, 64)
+			case NoteSortTitle:
+				hit.SortValues[i] = sortTitle
+			case NoteSortPath:
+				hit.SortValues[i] = hit.Path
+			case NoteSortCreated:
+				hit.SortValues[i] = hit.Created.Format(time.RFC3339Nano)
+			case NoteSortModified:
+				hit.SortValues[i] = hit.Modified.Format(time.RFC3339Nano)
+			case NoteSortID:
+				hit.SortValues[i] = hit.ID
+			}
+		}
+		hits = append(hits, hit)
+	}
+	return hits, total, maxScore.Float64, rows.Err()
+}
+
+// afterFilter matches rows sorting after the given sort values, comparing
+// key by key while the earlier keys are equal
+func afterFilter(keys []NoteSort, exprs []string, after []string) (string, []any, error) {
+	if len(after) != len(keys) {
+		return "", nil, fmt.Errorf("need %d sort values to continue after, got %d", len(keys), len(after))
+	}
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		switch key.Field {
+		case NoteSortScore:
+			score, err := strconv.ParseFloat(after[i], 64)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid score to continue after %q", after[i])
+			}
+			values[i] = score
+		case NoteSortCreated, NoteSortModified:
+			t, err := time.Parse(time.RFC3339Nano, after[i])
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid %s time to continue after %q", key.Field, after[i])
+			}
+			values[i] = unixNanos(t)
+		default:
+			values[i] = after[i]
+		}
+	}
+
+	var alternatives []string
+	var args []any
+	for i, key := range keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, exprs[j]+` = ?`)
+			args = append(args, values[j])
+		}
+		op := ` > ?`
+		if key.Desc {
+			op = ` < ?`
+		}
+		parts = append(parts, exprs[i]+op)
+		args = append(args, values[i])
+		alternatives = append(alternatives, `(`+strings.Join(parts, " AND ")+`)`)
+	}
+	return `(` + strings.Join(alternatives, " OR ") + `)`, args, nil
+}
+
+// unixNanos stores a time as nanoseconds since the epoch, with 0 for no time
+func unixNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNanos reads a time stored by unixNanos
+func fromUnixNanos(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
+
+// jsonListFilter matches rows whose JSON list column holds any of values, ignoring case
+func jsonListFilter(column string, values []string) (string, []any) {
+	if len(values) == 0 {
+		return `0`, nil
+	}
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return `EXISTS (SELECT 1 FROM json_each(` + column + `) WHERE value COLLATE NOCASE IN (` +
+		strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ") + `))`, args
+}
+
+// nonNilStrings keeps empty lists encoding as [] rather than null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,291 @@
+package db
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newNoteSearchTestDB returns a DB with the full-text tables, skipping the
+// test when SQLite was built without FTS5
+func newNoteSearchTestDB(t *testing.T) *DBService {
+	t.Helper()
+	svc, _, _ := newMoveTestVault(t)
+	if err := svc.EnableNoteSearch(); err != nil {
+		if strings.Contains(err.Error(), "sqlite_fts5") {
+			t.Skip("SQLite built without FTS5, run with -tags sqlite_fts5")
+		}
+		t.Fatalf("EnableNoteSearch() error = %v", err)
+	}
+	return svc
+}
+
+func TestSearchNotes(t *testing.T) {
+	svc := newNoteSearchTestDB(t)
+
+	if err := svc.IndexNotes([]Note{
+		{ID: "a", Path: "a.md", Title: "Gardening", Content: "Tomatoes need plenty of sunlight and water.", Tags: []string{"garden", "Summer"}},
+		{ID: "b", Path: "b.md", Title: "Cooking", Content: "A sauce made from tomatoes and basil.", Tags: []string{"kitchen"}, Wikilinks: []string{"Gardening"}},
+		{ID: "c", Path: "c.md", Title: "Sunlight", Content: "Notes on light.", Tags: []string{"garden"}},
+	}); err != nil {
+		t.Fatalf("IndexNotes() error = %v", err)
+	}
+
+	ids := func(q NoteQuery) []string {
+		t.Helper()
+		hits, total, _, err := svc.SearchNotes(q)
+		if err != nil {
+			t.Fatalf("SearchNotes(%+v) error = %v", q, err)
+		}
+		if int(total) < len(hits) {
+			t.Errorf("SearchNotes(%+v) total = %d with %d hits", q, total, len(hits))
+		}
+		var ids []string
+		for _, hit := range hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name string
+		q    NoteQuery
+		want string
+	}{
+		{"term", NoteQuery{Match: `"tomatoes"`}, "a b"},
+		{"phrase", NoteQuery{Match: `"sauce made"`}, "b"},
+		{"prefix", NoteQuery{Match: `"tomat" *`}, "a b"},
+		{"title", NoteQuery{Match: `title : "sunlight"`}, "c"},
+		{"tag ignores case", NoteQuery{Tags: [][]string{{"summer"}}}, "a"},
+		{"tags all of", NoteQuery{Tags: [][]string{{"garden"}, {"summer"}}}, "a"},
+		{"tags any of", NoteQuery{Tags: [][]string{{"kitchen", "summer"}}}, "a b"},
+		{"wikilink", NoteQuery{Wikilinks: [][]string{{"gardening"}}}, "b"},
+		{"text and tag", NoteQuery{Match: `"sunlight"`, Tags: [][]string{{"garden"}}}, "a c"},
+		{"limit", NoteQuery{Tags: [][]string{{"garden"}}, Limit: 1}, "a"},
+		{"offset", NoteQuery{Tags: [][]string{{"garden"}}, Offset: 1}, "c"},
+		{"nothing", NoteQuery{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(tt.q)
+			// Ranked results are compared as sets
+			if tt.q.Match != "" {
+				sort.Strings(got)
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("SearchNotes() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("highlights", func(t *testing.T) {
+		hits, _, _, err := svc.SearchNotes(NoteQuery{Match: `"basil"`, Highlight: true})
+		if err != nil || len(hits) != 1 {
+			t.Fatalf("SearchNotes() = %v, %v", hits, err)
+		}
+		if got := hits[0].Fragments["content"]; len(got) != 1 || !strings.Contains(got[0], "<mark>basil</mark>") {
+			t.Errorf("content fragments = %v", got)
+		}
+		if _, ok := hits[0].Fragments["title"]; ok {
+			t.Errorf("title fragment without a title match: %v", hits[0].Fragments)
+		}
+		if hits[0].Score <= 0 {
+			t.Errorf("Score = %v, want positive", hits[0].Score)
+		}
+	})
+
+	t.Run("max score covers all matches", func(t *testing.T) {
+		all, _, best, err := svc.SearchNotes(NoteQuery{Match: `"tomatoes" OR "sunlight"`})
+		if err != nil || len(all) == 0 {
+			t.Fatalf("SearchNotes() = %v, %v", all, err)
+		}
+		if best != all[0].Score {
+			t.Errorf("max score = %v, want best hit's %v", best, all[0].Score)
+		}
+		page, _, pageBest, err := svc.SearchNotes(NoteQuery{Match: `"tomatoes" OR "sunlight"`, Sort: []NoteSort{{Field: NoteSortPath, Desc: true}}, Limit: 1})
+		if err != nil || len(page) != 1 {
+			t.Fatalf("SearchNotes() = %v, %v", page, err)
+		}
+		if page[0].Score >= best {
+			t.Fatalf("page hit %s scores %v, want one below the best %v", page[0].ID, page[0].Score, best)
+		}
+		if pageBest != best {
+			t.Errorf("max score of one page = %v, want %v", pageBest, best)
+		}
+		if _, _, tagBest, _ := svc.SearchNotes(NoteQuery{Tags: [][]string{{"garden"}}}); tagBest != 0 {
+			t.Errorf("max score without text = %v, want 0", tagBest)
+		}
+	})
+}
+
+func TestIndexNotesReplaces(t *testing.T) {
+	svc := newNoteSearchTestDB(t)
+
+	// Notes indexed by path are replaced once they are indexed by file ID
+	if err := svc.IndexNotes([]Note{{ID: "a.md", Path: "a.md", Content: "old words"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.IndexNotes([]Note{{ID: "file-a", Path: "a.md", Content: "new words"}}); err != nil {
+		t.Fatal(err)
+	}
+	notes, err := svc.IndexedNotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || notes["file-a"] != "a.md" {
+		t.Errorf("IndexedNotes() = %v, want only file-a", notes)
+	}
+	if hits, _, _, _ := svc.SearchNotes(NoteQuery{Match: `"old"`}); len(hits) != 0 {
+		t.Errorf("old text still matches: %+v", hits)
+	}
+
+	// Moving keeps the ID and changes the path
+	if err := svc.IndexNotes([]Note{{ID: "file-a", Path: "b.md", Content: "new words"}}); err != nil {
+		t.Fatal(err)
+	}
+	if notes, _ := svc.IndexedNotes(); len(notes) != 1 || notes["file-a"] != "b.md" {
+		t.Errorf("IndexedNotes() after move = %v", notes)
+	}
+
+	if err := svc.DeleteNote("", "b.md"); err != nil {
+		t.Fatalf("DeleteNote() error = %v", err)
+	}
+	if count, err := svc.CountNotes(); err != nil || count != 0 {
+		t.Errorf("CountNotes() = %d, %v; want 0", count, err)
+	}
+	if hits, _, _, _ := svc.SearchNotes(NoteQuery{Match: `"words"`}); len(hits) != 0 {
+		t.Errorf("deleted note still matches: %+v", hits)
+	}
+
+	if err := svc.IndexNotes([]Note{{ID: "x", Path: "x.md"}, {ID: "y", Path: "y.md"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ClearNotes(); err != nil {
+		t.Fatalf("ClearNotes() error = %v", err)
+	}
+	if count, _ := svc.CountNotes(); count != 0 {
+		t.Errorf("CountNotes() after clear = %d", count)
+	}
+}
+
+func TestSearchNotesSortAndAfter(t *testing.T) {
+	svc := newNoteSearchTestDB(t)
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := svc.IndexNotes([]Note{
+		{ID: "a", Path: "a.md", SortTitle: "zebra", Content: "walk", Tags: []string{"t"}, Created: day.Add(48 * time.Hour), Modified: day},
+		{ID: "b", Path: "b.md", SortTitle: "apple", Content: "walk walk", Tags: []string{"t"}, Created: day, Modified: day.Add(time.Hour)},
+		{ID: "c", Path: "c.md", SortTitle: "mango", Content: "walk walk walk", Tags: []string{"t"}, Created: day.Add(24 * time.Hour), Modified: day.Add(time.Hour)},
+	}); err != nil {
+		t.Fatalf("IndexNotes() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		q    NoteQuery
+		want string
+	}{
+		{"title", NoteQuery{Sort: []NoteSort{{Field: NoteSortTitle}}}, "b c a"},
+		{"path descending", NoteQuery{Sort: []NoteSort{{Field: NoteSortPath, Desc: true}}}, "c b a"},
+		{"created", NoteQuery{Sort: []NoteSort{{Field: NoteSortCreated}}}, "b c a"},
+		{"modified then id", NoteQuery{Sort: []NoteSort{{Field: NoteSortModified, Desc: true}, {Field: NoteSortID, Desc: true}}}, "c b a"},
+		{"score", NoteQuery{Match: `"walk"`, Sort: []NoteSort{{Field: NoteSortScore, Desc: true}, {Field: NoteSortID}}}, "c b a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.q
+			q.Tags = [][]string{{"t"}}
+
+			// Page one note at a time, continuing after the last one
+			var got []string
+			for {
+				q.Limit = 1
+				hits, total, _, err := svc.SearchNotes(q)
+				if err != nil {
+					t.Fatalf("SearchNotes(%+v) error = %v", q, err)
+				}
+				if total != 3 {
+					t.Errorf("total = %d, want 3 on every page", total)
+				}
+				if len(hits) == 0 {
+					break
+				}
+				if len(hits[0].SortValues) != len(q.Sort) {
+					t.Fatalf("SortValues = %v for %d sort keys", hits[0].SortValues, len(q.Sort))
+				}
+				got = append(got, hits[0].ID)
+				q.After = hits[0].SortValues
+				if len(got) > 3 {
+					t.Fatalf("paging did not stop: %v", got)
+				}
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("pages = %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	hits, _, _, err := svc.SearchNotes(NoteQuery{Tags: [][]string{{"t"}}, Sort: []NoteSort{{Field: NoteSortCreated}}, Limit: 1})
+	if err != nil || len(hits) != 1 {
+		t.Fatalf("SearchNotes() = %v, %v", hits, err)
+	}
+	if !hits[0].Created.Equal(day) || !hits[0].Modified.Equal(day.Add(time.Hour)) {
+		t.Errorf("times = %v, %v", hits[0].Created, hits[0].Modified)
+	}
+	if _, _, _, err := svc.SearchNotes(NoteQuery{Tags: [][]string{{"t"}}, Sort: []NoteSort{{Field: NoteSortCreated}}, After: []string{"yesterday"}}); err == nil {
+		t.Error("SearchNotes() with an invalid time to continue after succeeded")
+	}
+}
+
+func TestSearchNotesTimeRanges(t *testing.T) {
+	svc := newNoteSearchTestDB(t)
+
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := svc.IndexNotes([]Note{
+		{ID: "a", Path: "a.md", Content: "walk", Created: day, Modified: day.Add(48 * time.Hour), Date: day.Add(24 * time.Hour)},
+		{ID: "b", Path: "b.md", Content: "walk", Created: day.Add(24 * time.Hour), Modified: day.Add(24 * time.Hour)},
+		{ID: "c", Path: "c.md", Content: "walk", Created: day.Add(48 * time.Hour), Modified: day},
+	}); err != nil {
+		t.Fatalf("IndexNotes() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		q    NoteQuery
+		want string
+	}{
+		{"no range", NoteQuery{}, "a b c"},
+		{"modified after", NoteQuery{Modified: TimeRange{After: day.Add(24 * time.Hour)}}, "a b"},
+		{"modified before is exclusive", NoteQuery{Modified: TimeRange{Before: day.Add(48 * time.Hour)}}, "b c"},
+		{"created between", NoteQuery{Created: TimeRange{After: day, Before: day.Add(48 * time.Hour)}}, "a b"},
+		{"date skips notes without one", NoteQuery{Date: TimeRange{Before: day.Add(72 * time.Hour)}}, "a"},
+		{"combined", NoteQuery{Created: TimeRange{After: day.Add(time.Hour)}, Modified: TimeRange{Before: day.Add(time.Hour)}}, "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.q
+			q.Match = `"walk"`
+			q.Sort = []NoteSort{{Field: NoteSortPath}}
+			hits, total, _, err := svc.SearchNotes(q)
+			if err != nil {
+				t.Fatalf("SearchNotes() error = %v", err)
+			}
+			var ids []string
+			for _, hit := range hits {
+				ids = append(ids, hit.ID)
+			}
+			if got := strings.Join(ids, " "); got != tt.want || total != uint64(len(ids)) {
+				t.Errorf("hits = %s of %d, want %s", got, total, tt.want)
+			}
+		})
+	}
+
+	hits, _, _, err := svc.SearchNotes(NoteQuery{Match: `"walk"`, Sort: []NoteSort{{Field: NoteSortPath}}, Limit: 1})
+	if err != nil || len(hits) != 1 {
+		t.Fatalf("SearchNotes() = %v, %v", hits, err)
+	}
+	if !hits[0].Date.Equal(day.Add(24 * time.Hour)) {
+		t.Errorf("Date = %v, want %v", hits[0].Date, day.Add(24*time.Hour))
+	}
+}
@@ -0,0 +1,373 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Link is a wikilink or embed from one file to another
+type Link struct {
+	SourceID  string `json:"source_id"`
+	RawTarget string `json:"raw_target"`          // Text between the brackets, without the alias
+	Target    string `json:"target"`              // Note or file part of RawTarget
+	TargetID  string `json:"target_id,omitempty"` // Empty while the target does not exist
+	Line      int    `json:"line"`
+	Alias     string `json:"alias,omitempty"`
+	Heading   string `json:"heading,omitempty"` // Heading anchor, or ^id for a block reference
+	IsEmbed   bool   `json:"is_embed"`
+	Context   string `json:"context"` // The line holding the link
+}
+
+// SourcedLink is a link together with the name and path of the file it comes from
+type SourcedLink struct {
+	Link
+	SourceName string `json:"source_name"`
+	SourcePath string `json:"source_path"`
+}
+
+// sqlQuerier is satisfied by *sql.DB and *sql.Tx
+type sqlQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// SetFileLinks replaces the outgoing links of a file, resolving each target
+// to an active file. Links with no target point at the file itself.
+func (s *DBService) SetFileLinks(sourceID string, links []Link) error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM links WHERE source_id = ?`, sourceID); err != nil {
+		return fmt.Errorf("clear file links: %w", err)
+	}
+
+	// Targets are resolved from the source note's folder
+	var sourcePath string
+	if err := tx.QueryRowContext(ctx, `SELECT path FROM file_entries WHERE id = ?`, sourceID).Scan(&sourcePath); err != nil &&
+		!errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("get link source: %w", err)
+	}
+
+	resolved := make(map[string]sql.NullString)
+	for _, link := range links {
+		targetID, ok := resolved[link.Target]
+		if !ok {
+			if link.Target == "" {
+				targetID = sql.NullString{String: sourceID, Valid: true}
+			} else if targetID, err = resolveLinkTarget(ctx, tx, link.Target, sourcePath); err != nil {
+				return err
+			}
+			resolved[link.Target] = targetID
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO links(source_id, raw_target, target, target_folded, target_id, line, alias, heading, is_embed, context)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			sourceID, link.RawTarget, link.Target, foldName(link.Target), targetID, link.Line, link.Alias, link.Heading,
+			boolToInt(link.IsEmbed), link.Context); err != nil {
+			return fmt.Errorf("insert link: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ResolveLinksTo resolves again the links that may name a file, once it is
+// created, changed or moved: links to its name, to a path ending in it or to
+// one of its aliases, and the links already pointing at it
+func (s *DBService) ResolveLinksTo(fileID string) error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	entry, err := s.GetFileEntryByIDWithStatus(fileID, FileStatusActive)
+	if err != nil {
+		return err
+	}
+	if entry == nil || entry.IsDir {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	where := []string{`l.target_id = ?`}
+	args := []any{fileID}
+	name := foldName(entry.Name)
+	names := []string{name}
+	if stem, ok := strings.CutSuffix(name, ".md"); ok {
+		names = append(names, stem)
+	}
+	for _, name := range names {
+		// substr counts characters, so the suffix is measured in runes
+		where = append(where, `l.target_folded = ? OR substr(l.target_folded, -?) = ?`)
+		args = append(args, name, utf8.RuneCountInString(name)+1, "/"+name)
+	}
+
+	aliases, err := queryStrings(ctx, db, `
+		SELECT a.value FROM properties p, json_each(p.value) a
+		WHERE p.file_id = ? AND p.key IN (?, ?) AND a.type = 'text'`, fileID, aliasKeys[0], aliasKeys[1])
+	if err != nil {
+		return fmt.Errorf("query aliases: %w", err)
+	}
+	for _, alias := range aliases {
+		where = append(where, `l.target_folded = ?`)
+		args = append(args, foldName(alias))
+	}
+
+	// Each link is updated on its own, so workers writing meanwhile are not held up
+	return reresolveLinks(ctx, db, strings.Join(where, " OR "), args...)
+}
+
+// DeleteFileLinks removes the outgoing links of a deleted file and resolves
+// the links pointing at it again, so they move to another file of the same
+// name or become unresolved
+func (s *DBService) DeleteFileLinks(fileID string) error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM links WHERE source_id = ?`, fileID); err != nil {
+		return fmt.Errorf("delete file links: %w", err)
+	}
+
+	if err := reresolveLinks(ctx, tx, `l.target_id = ?`, fileID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// reresolveLinks resolves the links matching where again, updating the ones
+// whose target changed
+func reresolveLinks(ctx context.Context, q sqlQuerier, where string, args ...any) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT l.id, l.target, l.target_id, src.path FROM links l
+		INNER JOIN file_entries src ON l.source_id = src.id
+		WHERE l.target != '' AND (`+where+`)`, args...)
+	if err != nil {
+		return fmt.Errorf("query links to resolve: %w", err)
+	}
+	type linkRow struct {
+		id         int64
+		target     string
+		targetID   sql.NullString
+		sourcePath string
+	}
+	var links []linkRow
+	for rows.Next() {
+		var l linkRow
+		if err := rows.Scan(&l.id, &l.target, &l.targetID, &l.sourcePath); err != nil {
+			rows.Close()
+			return err
+		}
+		links = append(links, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Links to the same target from the same folder resolve alike
+	resolved := make(map[[2]string]sql.NullString)
+	for _, l := range links {
+		key := [2]string{l.target, filepath.Dir(l.sourcePath)}
+		targetID, ok := resolved[key]
+		if !ok {
+			if targetID, err = resolveLinkTarget(ctx, q, l.target, l.sourcePath); err != nil {
+				return err
+			}
+			resolved[key] = targetID
+		}
+		if targetID == l.targetID {
+			continue
+		}
+		if _, err := q.ExecContext(ctx, `UPDATE links SET target_id = ? WHERE id = ?`, targetID, l.id); err != nil {
+			return fmt.Errorf("re-resolve links: %w", err)
+		}
+	}
+	return nil
+}
+
+// queryStrings returns the single text column of a query
+func queryStrings(ctx context.Context, q sqlQuerier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// GetFileLinks returns the outgoing links of a file in the order they appear
+func (s *DBService) GetFileLinks(sourceID string) ([]Link, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT source_id, raw_target, target, target_id, line, alias, heading, is_embed, context
+		FROM links WHERE source_id = ?
+		ORDER BY id`, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("query file links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		var link Link
+		if err := scanLink(rows, &link); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// GetBacklinks returns the links from other active files to a file, by source path and line
+func (s *DBService) GetBacklinks(fileID string) ([]SourcedLink, error) {
+	return s.querySourcedLinks(`l.target_id = ? AND l.source_id != ?`, fileID, fileID)
+}
+
+// GetUnresolvedLinks returns the links from active files whose target does not exist
+func (s *DBService) GetUnresolvedLinks() ([]SourcedLink, error) {
+	return s.querySourcedLinks(`l.target != '' AND l.target_id IS NULL`)
+}
+
+// GetHeadingLinks returns the resolved links from active files that point at a
+// heading (not a block reference)
+func (s *DBService) GetHeadingLinks() ([]SourcedLink, error) {
+	return s.querySourcedLinks(`l.target_id IS NOT NULL AND l.heading != '' AND substr(l.heading, 1, 1) != '^'`)
+}
+
+// querySourcedLinks returns the links from active files matching where, by source path and line
+func (s *DBService) querySourcedLinks(where string, args ...any) ([]SourcedLink, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT l.source_id, l.raw_target, l.target, l.target_id, l.line, l.alias, l.heading, l.is_embed, l.context,
+		       fe.name, fe.path
+		FROM links l
+		INNER JOIN file_entries fe ON l.source_id = fe.id
+		INNER JOIN file_statuses fs ON fe.file_status_id = fs.id
+		WHERE fs.name = ? AND `+where+`
+		ORDER BY fe.path, l.line`, append([]any{string(FileStatusActive)}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("query links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []SourcedLink{}
+	for rows.Next() {
+		var l SourcedLink
+		if err := scanLink(rows, &l.Link, &l.SourceName, &l.SourcePath); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// LinkStats counts the links to and from a file
+type LinkStats struct {
+	ID       string
+	Path     string
+	Inbound  int // Links from other active files
+	Outbound int // Links to other notes or files, resolved or not
+}
+
+// GetLinkStats returns link counts for every active file
+func (s *DBService) GetLinkStats() ([]LinkStats, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT fe.id, fe.path,
+		       (SELECT COUNT(*) FROM links l
+		        INNER JOIN file_entries src ON l.source_id = src.id
+		        WHERE l.target_id = fe.id AND l.source_id != fe.id AND src.file_status_id = fe.file_status_id),
+		       (SELECT COUNT(*) FROM links l
+		        WHERE l.source_id = fe.id AND l.target != '' AND (l.target_id IS NULL OR l.target_id != fe.id))
+		FROM file_entries fe
+		INNER JOIN file_statuses fs ON fe.file_status_id = fs.id
+		WHERE fs.name = ? AND fe.is_dir = 0
+		ORDER BY fe.path`, string(FileStatusActive))
+	if err != nil {
+		return nil, fmt.Errorf("query link stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []LinkStats{}
+	for rows.Next() {
+		var st LinkStats
+		if err := rows.Scan(&st.ID, &st.Path, &st.Inbound, &st.Outbound); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+// scanLink reads the links columns in table order, followed by extra
+func scanLink(rows *sql.Rows, link *Link, extra ...any) error {
+	var targetID, alias, heading, linkContext sql.NullString
+	var isEmbed int
+	dest := append([]any{&link.SourceID, &link.RawTarget, &link.Target, &targetID, &link.Line,
+		&alias, &heading, &isEmbed, &linkContext}, extra...)
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+	link.TargetID = targetID.String
+	link.Alias = alias.String
+	link.Heading = heading.String
+	link.IsEmbed = intToBool(isEmbed)
+	link.Context = linkContext.String
+	return nil
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+)
+
+func TestFileLinks(t *testing.T) {
+	svc, store, vaultDir := newMoveTestVault(t, "a.md", "b.md", "sub/b.md", "img.png")
+	id := func(p string) string {
+		t.Helper()
+		entry, err := svc.GetFileEntryByPath(filepath.FromSlash(p))
+		if err != nil || entry == nil {
+			t.Fatalf("GetFileEntryByPath(%s) = %v, %v", p, entry, err)
+		}
+		return entry.ID
+	}
+	a, b, subB := id("a.md"), id("b.md"), id("sub/b.md")
+
+	if err := svc.SetFileLinks(a, []Link{
+		{RawTarget: "b", Target: "b", Line: 1, Context: "See [[b]]"},
+		{RawTarget: "sub/b#Intro", Target: "sub/b", Heading: "Intro", Alias: "intro", Line: 2, Context: "Also [[sub/b#Intro|intro]]"},
+		{RawTarget: "later", Target: "later", Line: 3, Context: "[[later]]"},
+		{RawTarget: "img.png", Target: "img.png", IsEmbed: true, Line: 4, Context: "![[img.png]]"},
+		{RawTarget: "#Top", Heading: "Top", Line: 5, Context: "[[#Top]]"},
+	}); err != nil {
+		t.Fatalf("SetFileLinks() error = %v", err)
+	}
+
+	links, err := svc.GetFileLinks(a)
+	if err != nil || len(links) != 5 {
+		t.Fatalf("GetFileLinks() = %+v, %v", links, err)
+	}
+	for i, want := range []string{b, subB, "", id("img.png"), a} {
+		if links[i].TargetID != want {
+			t.Errorf("link %q resolved to %q, want %q", links[i].RawTarget, links[i].TargetID, want)
+		}
+	}
+
+	backlinks, err := svc.GetBacklinks(b)
+	if err != nil || len(backlinks) != 1 {
+		t.Fatalf("GetBacklinks(b) = %+v, %v", backlinks, err)
+	}
+	if got := backlinks[0]; got.SourceID != a || got.SourcePath != "a.md" || got.Context != "See [[b]]" || got.Line != 1 {
+		t.Errorf("GetBacklinks(b)[0] = %+v", got)
+	}
+	if self, _ := svc.GetBacklinks(a); len(self) != 0 {
+		t.Errorf("links within a note are not backlinks: %+v", self)
+	}
+
+	// A note created later picks up the links already pointing at it
+	laterPath := filepath.Join(vaultDir, "later.md")
+	if err := os.WriteFile(laterPath, []byte("# later"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later, err := svc.PerformDatabaseUpdate(store, vaultDir, syncpkg.FileChangeEvent{
+		Path: laterPath, EventType: syncpkg.FileCreated, Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ResolveLinksTo(later); err != nil {
+		t.Fatalf("ResolveLinksTo() error = %v", err)
+	}
+	if backlinks, _ := svc.GetBacklinks(later); len(backlinks) != 1 {
+		t.Errorf("GetBacklinks(later) = %+v, want the link from a.md", backlinks)
+	}
+
+	// Deleting b moves its link to the other b; deleting that leaves it unresolved
+	for i, deleted := range []string{b, subB} {
+		if err := svc.DeleteFileEntry(deleted); err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.DeleteFileLinks(deleted); err != nil {
+			t.Fatalf("DeleteFileLinks() error = %v", err)
+		}
+		links, _ := svc.GetFileLinks(a)
+		want := []string{subB, ""}[i]
+		if links[0].TargetID != want {
+			t.Errorf("after deleting %d file(s), [[b]] resolves to %q, want %q", i+1, links[0].TargetID, want)
+		}
+	}
+
+	// A deleted source takes its links with it
+	if err := svc.DeleteFileLinks(a); err != nil {
+		t.Fatal(err)
+	}
+	if links, _ := svc.GetFileLinks(a); len(links) != 0 {
+		t.Errorf("GetFileLinks() after delete = %+v, want none", links)
+	}
+}
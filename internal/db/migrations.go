@@ -0,0 +1,418 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Migration is one numbered step of the database schema. Released migrations
+// are never edited: schema changes go into a new migration at the end of the list.
+type Migration struct {
+	Version int
+	Name    string
+
+	up func(ctx context.Context, tx *sql.Tx) error
+	// Set when the step adds data read from file contents, so files tracked
+	// before it ran have to be processed again
+	backfill bool
+}
+
+// ErrSchemaTooNew is returned for a database migrated by a newer build
+var ErrSchemaTooNew = errors.New("database schema is newer than this build supports")
+
+// migrations in version order. The first ones use IF NOT EXISTS because
+// databases created before versioning already have some of their tables.
+var migrations = []Migration{
+	{Version: 1, Name: "file entries", up: migrateFileEntries},
+	{Version: 2, Name: "tags", up: execMigration(tagsSchema), backfill: true},
+	{Version: 3, Name: "links", up: execMigration(linksSchema), backfill: true},
+	{Version: 4, Name: "properties", up: execMigration(propertiesSchema), backfill: true},
+	{Version: 5, Name: "content hash", up: execMigration(`ALTER TABLE file_entries ADD COLUMN content_hash TEXT`)},
+	{Version: 6, Name: "revisions", up: execMigration(revisionsSchema), backfill: true},
+	{Version: 7, Name: "trash", up: execMigration(trashSchema)},
+	{Version: 8, Name: "folded names", up: migrateFoldedNames},
+}
+
+// MigrationResult describes a migration run
+type MigrationResult struct {
+	From    int         // Schema version before the run
+	To      int         // Schema version after the run; unchanged by a dry run
+	Applied []Migration // Migrations applied, or that a dry run would apply
+}
+
+// LatestSchemaVersion returns the schema version this build migrates databases to
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// MigrateFile brings the database at path to the latest schema version. A dry
+// run applies the pending migrations in a transaction that is rolled back, so
+// it reports what would change and fails where a real run would.
+func MigrateFile(ctx context.Context, path string, dryRun bool) (*MigrationResult, error) {
+	db, err := openSQLite(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	return migrate(ctx, db, dryRun)
+}
+
+// migrateSchema applies pending migrations when the service starts
+func (s *DBService) migrateSchema() error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, time.Minute)
+	defer cancel()
+
+	hasFiles, err := tableExists(ctx, db, "file_entries")
+	if err != nil {
+		return err
+	}
+	result, err := migrate(ctx, db, false)
+	if err != nil {
+		return err
+	}
+
+	// Files tracked before a table filled from their contents existed have to be read again
+	for _, m := range result.Applied {
+		s.needsBackfill = s.needsBackfill || (hasFiles && m.backfill)
+	}
+	return nil
+}
+
+// migrate applies the migrations newer than the database's schema version in
+// order, each in its own transaction. A dry run applies them all in one
+// transaction and rolls it back.
+func migrate(ctx context.Context, db *sql.DB, dryRun bool) (*MigrationResult, error) {
+	from, err := schemaVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if latest := LatestSchemaVersion(); from > latest {
+		return nil, fmt.Errorf("%w: database is at version %d, latest known is %d", ErrSchemaTooNew, from, latest)
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > from {
+			pending = append(pending, m)
+		}
+	}
+	result := &MigrationResult{From: from, To: from, Applied: []Migration{}}
+
+	if dryRun {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("begin tx: %w", err)
+		}
+		defer tx.Rollback()
+		for _, m := range pending {
+			if err := applyMigration(ctx, tx, m); err != nil {
+				return nil, err
+			}
+			result.Applied = append(result.Applied, m)
+		}
+		return result, nil
+	}
+
+	for _, m := range pending {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return result, fmt.Errorf("begin tx: %w", err)
+		}
+		if err := applyMigration(ctx, tx, m); err != nil {
+			tx.Rollback()
+			return result, err
+		}
+		if err := tx.Commit(); err != nil {
+			return result, fmt.Errorf("commit migration %d: %w", m.Version, err)
+		}
+		result.Applied = append(result.Applied, m)
+		result.To = m.Version
+	}
+	return result, nil
+}
+
+// applyMigration runs a migration and records it in schema_version
+func applyMigration(ctx context.Context, tx *sql.Tx, m Migration) error {
+	if _, err := tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_version (
+		  version INTEGER PRIMARY KEY,
+		  name TEXT NOT NULL,
+		  applied INTEGER NOT NULL
+		)`); err != nil {
+		return fmt.Errorf("create schema_version: %w", err)
+	}
+	if err := m.up(ctx, tx); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_version(version, name, applied) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().Unix()); err != nil {
+		return fmt.Errorf("record migration %d: %w", m.Version, err)
+	}
+	return nil
+}
+
+// schemaVersion returns the version of the last applied migration, 0 for a
+// new database or one created before versioning
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	exists, err := tableExists(ctx, db, "schema_version")
+	if err != nil || !exists {
+		return 0, err
+	}
+	var version int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// tableExists reports whether the database has a table with the given name
+func tableExists(ctx context.Context, db *sql.DB, name string) (bool, error) {
+	var count int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("check table %s: %w", name, err)
+	}
+	return count > 0, nil
+}
+
+// execMigration returns a migration step that runs a fixed SQL script
+func execMigration(script string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, script)
+		return err
+	}
+}
+
+// migrateFileEntries creates the file tables and seeds the file types and statuses
+func migrateFileEntries(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, fileEntriesSchema); err != nil {
+		return err
+	}
+
+	types := []struct {
+		name        string
+		description string
+		isBinary    bool
+		mimeType    string
+	}{
+		{string(FileTypeDirectory), "Directory", false, ""},
+		{string(FileTypeMarkdown), "Markdown document", false, "text/markdown"},
+		{string(FileTypePNG), "PNG image", true, "image/png"},
+		{string(FileTypeJPEG), "JPEG image", true, "image/jpeg"},
+		{string(FileTypeJPG), "JPG image", true, "image/jpeg"},
+		{string(FileTypeGIF), "GIF image", true, "image/gif"},
+		{string(FileTypeWebP), "WebP image", true, "image/webp"},
+		{string(FileTypeSVG), "SVG image", false, "image/svg+xml"},
+		{string(FileTypePDF), "PDF document", true, "application/pdf"},
+		{string(FileTypeTXT), "Text file", false, "text/plain"},
+		{string(FileTypeJSON), "JSON file", false, "application/json"},
+		{string(FileTypeYAML), "YAML file", false, "application/x-yaml"},
+		{string(FileTypeXML), "XML file", false, "application/xml"},
+		{string(FileTypeCSV), "CSV file", false, "text/csv"},
+		{string(FileTypeUnknown), "Unknown file type", true, "application/octet-stream"},
+	}
+	for _, ft := range types {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO file_types(name, description, is_binary, mime_type) VALUES (?, ?, ?, ?)",
+			ft.name, ft.description, boolToInt(ft.isBinary), ft.mimeType); err != nil {
+			return fmt.Errorf("insert file type %s: %w", ft.name, err)
+		}
+	}
+
+	statuses := []struct {
+		name        string
+		description string
+	}{
+		{string(FileStatusActive), "File is active and available"},
+		{string(FileStatusDeleted), "File has been deleted"},
+		{string(FileStatusDisabled), "File has been disabled"},
+	}
+	for _, fs := range statuses {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO file_statuses(name, description) VALUES (?, ?)",
+			fs.name, fs.description); err != nil {
+			return fmt.Errorf("insert file status %s: %w", fs.name, err)
+		}
+	}
+	return nil
+}
+
+// migrateFoldedNames adds the case-folded file names and link targets that
+// links are matched on, filled in with the same fold used when writing them
+func migrateFoldedNames(ctx context.Context, tx *sql.Tx) error {
+	for _, step := range []struct {
+		table, column, folded string
+	}{
+		{"file_entries", "name", "name_folded"},
+		{"links", "target", "target_folded"},
+	} {
+		if _, err := tx.ExecContext(ctx, `ALTER TABLE `+step.table+` ADD COLUMN `+step.folded+` TEXT`); err != nil {
+			return err
+		}
+
+		// Read everything first: the transaction's connection cannot update while rows are open
+		type row struct {
+			id    any
+			value string
+		}
+		rows, err := tx.QueryContext(ctx, `SELECT id, `+step.column+` FROM `+step.table)
+		if err != nil {
+			return err
+		}
+		var pending []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.value); err != nil {
+				rows.Close()
+				return err
+			}
+			pending = append(pending, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, r := range pending {
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE `+step.table+` SET `+step.folded+` = ? WHERE id = ?`, foldName(r.value), r.id); err != nil {
+				return fmt.Errorf("fold %s: %w", step.table, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx,
+			`CREATE INDEX idx_`+step.table+`_`+step.folded+` ON `+step.table+`(`+step.folded+`)`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const fileEntriesSchema = `
+CREATE TABLE IF NOT EXISTS file_types (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL UNIQUE,
+  description TEXT,
+  is_binary INTEGER DEFAULT 0,
+  mime_type TEXT
+);
+
+CREATE TABLE IF NOT EXISTS file_statuses (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL UNIQUE,
+  description TEXT
+);
+
+CREATE TABLE IF NOT EXISTS file_entries (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  parent_id TEXT,
+  is_dir INTEGER NOT NULL,
+  file_type_id INTEGER,
+  file_status_id INTEGER,
+  created INTEGER NOT NULL,
+  modified INTEGER NOT NULL,
+  size INTEGER,
+  path TEXT NOT NULL UNIQUE,
+  FOREIGN KEY (parent_id) REFERENCES file_entries(id) ON DELETE CASCADE,
+  FOREIGN KEY (file_type_id) REFERENCES file_types(id),
+  FOREIGN KEY (file_status_id) REFERENCES file_statuses(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_parent_id ON file_entries(parent_id);
+CREATE INDEX IF NOT EXISTS idx_path ON file_entries(path);
+CREATE INDEX IF NOT EXISTS idx_file_type ON file_entries(file_type_id);
+CREATE INDEX IF NOT EXISTS idx_file_status ON file_entries(file_status_id);
+`
+
+const tagsSchema = `
+CREATE TABLE IF NOT EXISTS tags (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE IF NOT EXISTS file_tags (
+  file_id TEXT NOT NULL,
+  tag_id INTEGER NOT NULL,
+  PRIMARY KEY (file_id, tag_id),
+  FOREIGN KEY (file_id) REFERENCES file_entries(id) ON DELETE CASCADE,
+  FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_tags_tag ON file_tags(tag_id);
+`
+
+const linksSchema = `
+CREATE TABLE IF NOT EXISTS links (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  source_id TEXT NOT NULL,
+  raw_target TEXT NOT NULL,
+  target TEXT NOT NULL,
+  target_id TEXT,
+  line INTEGER NOT NULL,
+  alias TEXT,
+  heading TEXT,
+  is_embed INTEGER NOT NULL DEFAULT 0,
+  context TEXT,
+  FOREIGN KEY (source_id) REFERENCES file_entries(id) ON DELETE CASCADE,
+  FOREIGN KEY (target_id) REFERENCES file_entries(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_links_source ON links(source_id);
+CREATE INDEX IF NOT EXISTS idx_links_target_id ON links(target_id);
+CREATE INDEX IF NOT EXISTS idx_links_target ON links(target);
+`
+
+const propertiesSchema = `
+CREATE TABLE IF NOT EXISTS properties (
+  file_id TEXT NOT NULL,
+  key TEXT NOT NULL COLLATE NOCASE,
+  type TEXT NOT NULL,
+  value TEXT NOT NULL,
+  text_value TEXT,
+  num_value REAL,
+  PRIMARY KEY (file_id, key),
+  FOREIGN KEY (file_id) REFERENCES file_entries(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_properties_key ON properties(key, text_value);
+`
+
+const revisionsSchema = `
+CREATE TABLE IF NOT EXISTS revisions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  file_id TEXT NOT NULL,
+  content_hash TEXT NOT NULL,
+  size INTEGER NOT NULL,
+  content BLOB NOT NULL,
+  created INTEGER NOT NULL,
+  FOREIGN KEY (file_id) REFERENCES file_entries(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_revisions_file ON revisions(file_id, id);
+`
+
+const trashSchema = `
+CREATE TABLE IF NOT EXISTS trash (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  file_id TEXT,
+  original_path TEXT NOT NULL,
+  trash_path TEXT NOT NULL UNIQUE,
+  is_dir INTEGER NOT NULL DEFAULT 0,
+  size INTEGER NOT NULL DEFAULT 0,
+  deleted INTEGER NOT NULL,
+  FOREIGN KEY (file_id) REFERENCES file_entries(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_trash_deleted ON trash(deleted);
+`
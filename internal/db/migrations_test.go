@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateFile(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	latest := LatestSchemaVersion()
+
+	// A dry run reports every migration and leaves the database unversioned
+	result, err := MigrateFile(ctx, dbPath, true)
+	if err != nil {
+		t.Fatalf("MigrateFile(dry run) error = %v", err)
+	}
+	if result.From != 0 || result.To != 0 || len(result.Applied) != latest {
+		t.Errorf("dry run = %+v, want all %d migrations from 0", result, latest)
+	}
+	if result, err = MigrateFile(ctx, dbPath, true); err != nil || len(result.Applied) != latest {
+		t.Errorf("second dry run = %+v, %v, want nothing applied yet", result, err)
+	}
+
+	result, err = MigrateFile(ctx, dbPath, false)
+	if err != nil || result.To != latest || len(result.Applied) != latest {
+		t.Fatalf("MigrateFile() = %+v, %v", result, err)
+	}
+	if result, err = MigrateFile(ctx, dbPath, false); err != nil || result.From != latest || len(result.Applied) != 0 {
+		t.Errorf("MigrateFile() on a current database = %+v, %v", result, err)
+	}
+
+	// A failing migration is rolled back and stops the run
+	failing := Migration{Version: latest + 1, Name: "broken", up: execMigration(`CREATE TABLE extra (id INTEGER); SELECT * FROM missing;`)}
+	migrations = append(migrations, failing)
+	defer func() { migrations = migrations[:len(migrations)-1] }()
+	if _, err := MigrateFile(ctx, dbPath, false); err == nil {
+		t.Fatal("MigrateFile() with a broken migration succeeded")
+	}
+	raw, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	if version, _ := schemaVersion(ctx, raw); version != latest {
+		t.Errorf("schema version after failed migration = %d, want %d", version, latest)
+	}
+	if exists, _ := tableExists(ctx, raw, "extra"); exists {
+		t.Error("failed migration was not rolled back")
+	}
+}
+
+func TestStartRefusesNewerSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	if _, err := MigrateFile(context.Background(), dbPath, false); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Exec(`INSERT INTO schema_version(version, name, applied) VALUES (?, 'future', 0)`, LatestSchemaVersion()+1); err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+
+	svc, err := NewDBService(context.Background(), &dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svc.Stop()
+	if err := svc.Start(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Start() error = %v, want ErrSchemaTooNew", err)
+	}
+	if svc.GetStatus() != StatusError {
+		t.Errorf("status = %v, want error", svc.GetStatus())
+	}
+}
+
+func TestMigrateFoldedNames(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// A database from before the folded columns, holding a file and a link
+	all := migrations
+	migrations = all[:7]
+	_, err := MigrateFile(ctx, dbPath, false)
+	migrations = all
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	if _, err := raw.Exec(`
+		INSERT INTO file_entries(id, name, is_dir, file_type_id, file_status_id, created, modified, size, path)
+		VALUES ('f1', 'Über.md', 0, 1, 1, 0, 0, 0, 'Über.md');
+		INSERT INTO links(source_id, raw_target, target, line) VALUES ('f1', 'Places/Zürich', 'Places/Zürich', 1);`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MigrateFile(ctx, dbPath, false); err != nil {
+		t.Fatalf("MigrateFile() error = %v", err)
+	}
+	var name, target string
+	if err := raw.QueryRow(`SELECT name_folded FROM file_entries WHERE id = 'f1'`).Scan(&name); err != nil || name != "über.md" {
+		t.Errorf("name_folded = %q, %v, want über.md", name, err)
+	}
+	if err := raw.QueryRow(`SELECT target_folded FROM links WHERE source_id = 'f1'`).Scan(&target); err != nil || target != "places/zürich" {
+		t.Errorf("target_folded = %q, %v, want places/zürich", target, err)
+	}
+}
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/storage"
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+)
+
+// newMoveTestVault starts a DB and a vault directory with the given files tracked
+func newMoveTestVault(t *testing.T, files ...string) (*DBService, storage.VaultStorage, string) {
+	t.Helper()
+	vaultDir := t.TempDir()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	svc, err := NewDBService(context.Background(), &dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create DBService: %v", err)
+	}
+	if err := svc.Start(); err != nil {
+		t.Fatalf("Failed to start DBService: %v", err)
+	}
+	t.Cleanup(func() { svc.Stop() })
+
+	store := storage.NewLocalStorage(vaultDir)
+	for _, f := range files {
+		path := filepath.Join(vaultDir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("# "+f), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := svc.PerformDatabaseUpdate(store, vaultDir, syncpkg.FileChangeEvent{
+			Path:      path,
+			EventType: syncpkg.FileCreated,
+			Timestamp: time.Now(),
+		}); err != nil {
+			t.Fatalf("Failed to track %s: %v", f, err)
+		}
+	}
+	return svc, store, vaultDir
+}
+
+// move renames a path on disk and applies the FileMoved event
+func move(t *testing.T, svc *DBService, store storage.VaultStorage, vaultDir, from, to string) string {
+	t.Helper()
+	oldPath := filepath.Join(vaultDir, from)
+	newPath := filepath.Join(vaultDir, to)
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := svc.PerformDatabaseUpdate(store, vaultDir, syncpkg.FileChangeEvent{
+		Path:      newPath,
+		OldPath:   oldPath,
+		EventType: syncpkg.FileMoved,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("PerformDatabaseUpdate(move %s -> %s) error = %v", from, to, err)
+	}
+	return id
+}
+
+func TestPerformDatabaseUpdate_MoveKeepsID(t *testing.T) {
+	svc, store, vaultDir := newMoveTestVault(t, "note.md", "archive/keep.md")
+
+	before, _ := svc.GetFileEntryByPath("note.md")
+	id := move(t, svc, store, vaultDir, "note.md", filepath.Join("archive", "renamed.txt"))
+
+	if id != before.ID {
+		t.Errorf("moved ID = %s, want %s", id, before.ID)
+	}
+	if old, _ := svc.GetFileEntryByPath("note.md"); old != nil {
+		t.Errorf("old path still has an entry: %+v", old)
+	}
+
+	moved, err := svc.GetFileEntryByID(before.ID)
+	if err != nil || moved == nil {
+		t.Fatalf("GetFileEntryByID() = %v, %v", moved, err)
+	}
+	if moved.Path != filepath.Join("archive", "renamed.txt") || moved.Name != "renamed.txt" {
+		t.Errorf("moved entry path/name = %s/%s", moved.Path, moved.Name)
+	}
+
+	archive, _ := svc.GetFileEntryByPath("archive")
+	if moved.ParentID == nil || *moved.ParentID != archive.ID {
+		t.Errorf("moved entry parent = %v, want %s", moved.ParentID, archive.ID)
+	}
+	txtType, _ := svc.GetFileTypeID(FileTypeTXT)
+	if moved.FileTypeID == nil || *moved.FileTypeID != *txtType {
+		t.Errorf("moved entry file type = %v, want text", moved.FileTypeID)
+	}
+}
+
+func TestPerformDatabaseUpdate_MoveDirectoryRewritesDescendants(t *testing.T) {
+	svc, store, vaultDir := newMoveTestVault(t, "proj/a.md", "proj/sub/b.md", "project.md")
+
+	dir, _ := svc.GetFileEntryByPath("proj")
+	b, _ := svc.GetFileEntryByPath(filepath.Join("proj", "sub", "b.md"))
+
+	if id := move(t, svc, store, vaultDir, "proj", "work"); id != dir.ID {
+		t.Errorf("moved directory ID = %s, want %s", id, dir.ID)
+	}
+
+	got, _ := svc.GetFileEntryByID(b.ID)
+	if got == nil || got.Path != filepath.Join("work", "sub", "b.md") {
+		t.Errorf("descendant after move = %+v, want path work/sub/b.md", got)
+	}
+	if a, _ := svc.GetFileEntryByPath(filepath.Join("work", "a.md")); a == nil {
+		t.Error("direct child was not rewritten")
+	}
+	// Sibling sharing the name prefix must be left alone
+	if p, _ := svc.GetFileEntryByPath("project.md"); p == nil {
+		t.Error("project.md should not be touched by the proj move")
+	}
+}
+
+func TestPerformDatabaseUpdate_MoveOverExistingFile(t *testing.T) {
+	svc, store, vaultDir := newMoveTestVault(t, "note.md", "draft.md")
+
+	target, _ := svc.GetFileEntryByPath("note.md")
+	source, _ := svc.GetFileEntryByPath("draft.md")
+
+	if id := move(t, svc, store, vaultDir, "draft.md", "note.md"); id != target.ID {
+		t.Errorf("replaced file ID = %s, want target ID %s", id, target.ID)
+	}
+
+	got, _ := svc.GetFileEntryByID(source.ID)
+	deleted, _ := svc.GetFileStatusID(FileStatusDeleted)
+	if got == nil || got.FileStatusID == nil || *got.FileStatusID != *deleted {
+		t.Errorf("source entry should be marked deleted, got %+v", got)
+	}
+}
+
+func TestPerformDatabaseUpdate_MoveReplacesDeletedEntry(t *testing.T) {
+	svc, store, vaultDir := newMoveTestVault(t, "old.md", "note.md")
+
+	stale, _ := svc.GetFileEntryByPath("old.md")
+	if err := os.Remove(filepath.Join(vaultDir, "old.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteFileEntry(stale.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	note, _ := svc.GetFileEntryByPath("note.md")
+	if id := move(t, svc, store, vaultDir, "note.md", "old.md"); id != note.ID {
+		t.Errorf("moved ID = %s, want %s", id, note.ID)
+	}
+	if got, _ := svc.GetFileEntryByID(stale.ID); got != nil {
+		t.Errorf("deleted entry at destination should be removed, got %+v", got)
+	}
+}
+
+func TestPerformDatabaseUpdate_MoveUntrackedCreates(t *testing.T) {
+	svc, store, vaultDir := newMoveTestVault(t)
+
+	if err := os.WriteFile(filepath.Join(vaultDir, "tmp.md"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	id := move(t, svc, store, vaultDir, "tmp.md", "note.md")
+
+	got, _ := svc.GetFileEntryByPath("note.md")
+	if got == nil || got.ID != id {
+		t.Errorf("untracked move should create the entry, got %+v (id %s)", got, id)
+	}
+}
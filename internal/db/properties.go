@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Property types, inferred from the frontmatter value
+const (
+	PropertyString = "string"
+	PropertyNumber = "number"
+	PropertyDate   = "date"
+	PropertyBool   = "bool"
+	PropertyList   = "list"
+)
+
+// Property is a frontmatter key of a note with its typed value
+type Property struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value"` // string, float64, bool or []string; dates are YYYY-MM-DD or RFC 3339 strings
+}
+
+// dateValue matches the date and date-time strings treated as dates
+var dateValue = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([T ]\d{2}:\d{2}(:\d{2})?(\.\d+)?(Z|[+-]\d{2}:?\d{2})?)?$`)
+
+// InferProperty types a value decoded from YAML frontmatter. Nested maps are
+// kept as JSON strings; empty values are skipped.
+func InferProperty(key string, value any) (Property, bool) {
+	p := Property{Key: key}
+	switch v := value.(type) {
+	case nil:
+		return p, false
+	case bool:
+		p.Type, p.Value = PropertyBool, v
+	case int:
+		p.Type, p.Value = PropertyNumber, float64(v)
+	case int64:
+		p.Type, p.Value = PropertyNumber, float64(v)
+	case uint64:
+		p.Type, p.Value = PropertyNumber, float64(v)
+	case float64:
+		p.Type, p.Value = PropertyNumber, v
+	case time.Time:
+		p.Type, p.Value = PropertyDate, formatDate(v)
+	case string:
+		if dateValue.MatchString(v) {
+			p.Type = PropertyDate
+		} else {
+			p.Type = PropertyString
+		}
+		p.Value = v
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if item == nil {
+				continue
+			}
+			if ip, ok := InferProperty(key, item); ok && ip.Type != PropertyList {
+				items = append(items, fmt.Sprint(ip.Value))
+			}
+		}
+		p.Type, p.Value = PropertyList, items
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return p, false
+		}
+		p.Type, p.Value = PropertyString, string(data)
+	}
+	return p, true
+}
+
+// formatDate writes dates without a time of day as YYYY-MM-DD
+func formatDate(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// propertyColumns returns the comparable forms of a property value: text for
+// strings, dates and lists (items joined), a number for numbers and bools
+func propertyColumns(p Property) (text any, num any) {
+	switch v := p.Value.(type) {
+	case bool:
+		return strconv.FormatBool(v), boolToInt(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), v
+	case []string:
+		return strings.Join(v, ", "), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// SetFileProperties replaces the properties of a file with the keys of its frontmatter
+func (s *DBService) SetFileProperties(fileID string, frontmatter map[string]any) error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+
+	// Keys are case-insensitive; sort so the first spelling kept is stable
+	keys := make([]string, 0, len(frontmatter))
+	for key := range frontmatter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM properties WHERE file_id = ?`, fileID); err != nil {
+		return fmt.Errorf("clear file properties: %w", err)
+	}
+	for _, key := range keys {
+		p, ok := InferProperty(strings.TrimSpace(key), frontmatter[key])
+		if !ok || p.Key == "" {
+			continue
+		}
+		value, err := json.Marshal(p.Value)
+		if err != nil {
+			return fmt.Errorf("encode property %s: %w", p.Key, err)
+		}
+		text, num := propertyColumns(p)
+		if _, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO properties(file_id, key, type, value, text_value, num_value) VALUES (?, ?, ?, ?, ?, ?)`,
+			fileID, p.Key, p.Type, string(value), text, num); err != nil {
+			return fmt.Errorf("insert property %s: %w", p.Key, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFileProperties removes all properties from a file
+func (s *DBService) DeleteFileProperties(fileID string) error {
+	return s.SetFileProperties(fileID, nil)
+}
+
+// GetFileProperties returns the properties of a file, sorted by key
+func (s *DBService) GetFileProperties(fileID string) ([]Property, error) {
+	props, err := s.getProperties([]string{fileID}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if props[fileID] == nil {
+		return []Property{}, nil
+	}
+	return props[fileID], nil
+}
+
+// getProperties returns the properties of several files by file ID, limited
+// to keys when it is not empty
+func (s *DBService) getProperties(fileIDs, keys []string) (map[string][]Property, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	result := make(map[string][]Property, len(fileIDs))
+	if len(fileIDs) == 0 {
+		return result, nil
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	query := `SELECT file_id, key, type, value FROM properties WHERE file_id IN (` + placeholders(len(fileIDs)) + `)`
+	args := make([]any, 0, len(fileIDs)+len(keys))
+	for _, id := range fileIDs {
+		args = append(args, id)
+	}
+	if len(keys) > 0 {
+		query += ` AND key IN (` + placeholders(len(keys)) + `)`
+		for _, key := range keys {
+			args = append(args, key)
+		}
+	}
+	rows, err := db.QueryContext(ctx, query+` ORDER BY key`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query properties: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fileID, value string
+		var p Property
+		if err := rows.Scan(&fileID, &p.Key, &p.Type, &value); err != nil {
+			return nil, err
+		}
+		if p.Value, err = decodePropertyValue(p.Type, value); err != nil {
+			return nil, fmt.Errorf("decode property %s: %w", p.Key, err)
+		}
+		result[fileID] = append(result[fileID], p)
+	}
+	return result, rows.Err()
+}
+
+// decodePropertyValue reads a stored JSON value back into its Go type
+func decodePropertyValue(typ, value string) (any, error) {
+	switch typ {
+	case PropertyList:
+		var items []string
+		err := json.Unmarshal([]byte(value), &items)
+		return items, err
+	case PropertyNumber:
+		var n float64
+		err := json.Unmarshal([]byte(value), &n)
+		return n, err
+	case PropertyBool:
+		var b bool
+		err := json.Unmarshal([]byte(value), &b)
+		return b, err
+	default:
+		var str string
+		err := json.Unmarshal([]byte(value), &str)
+		return str, err
+	}
+}
+
+// placeholders returns n comma-separated ? placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestInferProperty(t *testing.T) {
+	var frontmatter map[string]any
+	if err := yaml.Unmarshal([]byte(`
+status: done
+priority: 2
+score: 1.5
+due: 2026-11-01
+at: 2026-11-01T10:30:00Z
+quoted: "2026-12-24"
+draft: false
+tags: [work, 2]
+meta: {a: 1}
+empty:
+`), &frontmatter); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]Property{
+		"status":   {"status", PropertyString, "done"},
+		"priority": {"priority", PropertyNumber, 2.0},
+		"score":    {"score", PropertyNumber, 1.5},
+		"due":      {"due", PropertyDate, "2026-11-01"},
+		"at":       {"at", PropertyDate, "2026-11-01T10:30:00Z"},
+		"quoted":   {"quoted", PropertyDate, "2026-12-24"},
+		"draft":    {"draft", PropertyBool, false},
+		"tags":     {"tags", PropertyList, []string{"work", "2"}},
+		"meta":     {"meta", PropertyString, `{"a":1}`},
+	} {
+		got, ok := InferProperty(key, frontmatter[key])
+		if !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("InferProperty(%s) = %#v, %v, want %#v", key, got, ok, want)
+		}
+	}
+	if _, ok := InferProperty("empty", frontmatter["empty"]); ok {
+		t.Error("InferProperty(empty) kept a nil value")
+	}
+}
+
+func TestQueryProperties(t *testing.T) {
+	svc, _, _ := newMoveTestVault(t, "a.md", "b.md", "c.md", "d.md")
+	for path, fm := range map[string]string{
+		"a.md": "status: done\ndue: 2026-10-15\npriority: 1\ntags: [work]",
+		"b.md": "status: Done\ndue: 2026-11-15\npriority: 3",
+		"c.md": "status: open\ndue: 2026-10-31T09:00:00Z\ntags: [home, work]",
+		"d.md": "title: no status",
+	} {
+		entry, err := svc.GetFileEntryByPath(path)
+		if err != nil || entry == nil {
+			t.Fatalf("GetFileEntryByPath(%s) = %v, %v", path, entry, err)
+		}
+		var frontmatter map[string]any
+		if err := yaml.Unmarshal([]byte(fm), &frontmatter); err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.SetFileProperties(entry.ID, frontmatter); err != nil {
+			t.Fatalf("SetFileProperties(%s) error = %v", path, err)
+		}
+	}
+
+	paths := func(q PropertyQuery) []string {
+		t.Helper()
+		matches, _, err := svc.QueryProperties(q)
+		if err != nil {
+			t.Fatalf("QueryProperties(%+v) error = %v", q, err)
+		}
+		out := []string{}
+		for _, m := range matches {
+			out = append(out, m.Path)
+		}
+		return out
+	}
+
+	for _, tc := range []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"a.md", "b.md", "c.md", "d.md"}},
+		{"status = done", []string{"a.md", "b.md"}},
+		{"status = done AND due < 2026-11-01", []string{"a.md"}},
+		{"due = 2026-10-31", []string{"c.md"}},
+		{"priority >= 2 OR tags = home", []string{"b.md", "c.md"}},
+		{"tags = WORK AND (status != done)", []string{"c.md"}},
+		{"status != done", []string{"c.md", "d.md"}},
+		{`"title" = 'no status'`, []string{"d.md"}},
+		{"priority = '1'", []string{}},
+	} {
+		if got := paths(PropertyQuery{Filter: tc.filter}); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("filter %q = %v, want %v", tc.filter, got, tc.want)
+		}
+	}
+
+	if got := paths(PropertyQuery{Sort: []string{"-priority"}}); !reflect.DeepEqual(got, []string{"b.md", "a.md", "c.md", "d.md"}) {
+		t.Errorf("sort by -priority = %v", got)
+	}
+	if got := paths(PropertyQuery{Sort: []string{"due"}, Offset: 1, Limit: 2}); !reflect.DeepEqual(got, []string{"c.md", "b.md"}) {
+		t.Errorf("sort by due, second page = %v", got)
+	}
+
+	matches, total, err := svc.QueryProperties(PropertyQuery{Filter: "status = done", Fields: []string{"Due"}, Limit: 1})
+	if err != nil || total != 2 || len(matches) != 1 {
+		t.Fatalf("QueryProperties() = %v, %d, %v", matches, total, err)
+	}
+	if !reflect.DeepEqual(matches[0].Properties, map[string]any{"due": "2026-10-15"}) {
+		t.Errorf("projected properties = %v", matches[0].Properties)
+	}
+
+	for _, filter := range []string{"status =", "status done", "(status = done", "status = done AND", "due ! 3", `status = "done`} {
+		if _, _, err := svc.QueryProperties(PropertyQuery{Filter: filter}); !errors.Is(err, ErrInvalidPropertyQuery) {
+			t.Errorf("filter %q error = %v, want ErrInvalidPropertyQuery", filter, err)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPropertyQuery is returned for a filter or sort that cannot be parsed
+var ErrInvalidPropertyQuery = errors.New("invalid property query")
+
+// PropertyQuery selects notes by their frontmatter properties.
+//
+// Filter is a list of comparisons joined by AND and OR, with parentheses for
+// grouping, e.g. `status = done AND (due < 2026-11-01 OR priority >= 2)`.
+// Operators are = != < <= > >=. Keys and values with spaces or operator
+// characters are quoted. Values are compared as numbers, true/false, dates
+// (YYYY-MM-DD, matching the start of date-times) or case-insensitive text,
+// and only match properties of that type; quoted values are always text or
+// dates. = on a list property matches any item. != matches notes that do not
+// have the value, including notes without the key.
+type PropertyQuery struct {
+	Filter string
+	Sort   []string // Property keys; prefix with - for descending. Notes missing a key sort last.
+	Fields []string // Properties to return; all when empty
+	Offset int
+	Limit  int // No limit when 0
+}
+
+// PropertyMatch is a note selected by a property query
+type PropertyMatch struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Path       string         `json:"path"`
+	Properties map[string]any `json:"properties"`
+}
+
+// QueryProperties returns one page of the active notes with frontmatter
+// matching a property query, and the number of matches across all pages
+func (s *DBService) QueryProperties(q PropertyQuery) ([]PropertyMatch, int, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, 0, errors.New("db not ready")
+	}
+
+	where := `fs.name = ? AND fe.is_dir = 0 AND EXISTS (SELECT 1 FROM properties p WHERE p.file_id = fe.id)`
+	whereArgs := []any{string(FileStatusActive)}
+	if strings.TrimSpace(q.Filter) != "" {
+		filter, args, err := parsePropertyFilter(q.Filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		where += ` AND (` + filter + `)`
+		whereArgs = append(whereArgs, args...)
+	}
+	orderBy, orderArgs, err := propertyOrderBy(q.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	from := `FROM file_entries fe INNER JOIN file_statuses fs ON fe.file_status_id = fs.id WHERE ` + where
+
+	var total int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) `+from, whereArgs...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count property matches: %w", err)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	args := append(append(whereArgs, orderArgs...), limit, q.Offset)
+	rows, err := db.QueryContext(ctx, `SELECT fe.id, fe.name, fe.path `+from+` ORDER BY `+orderBy+` LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query property matches: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []PropertyMatch{}
+	var ids []string
+	for rows.Next() {
+		var m PropertyMatch
+		if err := rows.Scan(&m.ID, &m.Name, &m.Path); err != nil {
+			return nil, 0, err
+		}
+		m.Properties = map[string]any{}
+		matches = append(matches, m)
+		ids = append(ids, m.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	rows.Close()
+
+	props, err := s.getProperties(ids, q.Fields)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range matches {
+		for _, p := range props[matches[i].ID] {
+			matches[i].Properties[p.Key] = p.Value
+		}
+	}
+	return matches, total, nil
+}
+
+// propertyOrderBy builds the ORDER BY clause for the sort keys, ending with the path
+func propertyOrderBy(keys []string) (string, []any, error) {
+	var terms []string
+	var args []any
+	for _, key := range keys {
+		dir := "ASC"
+		if strings.HasPrefix(key, "-") {
+			dir, key = "DESC", key[1:]
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return "", nil, fmt.Errorf("%w: empty sort key", ErrInvalidPropertyQuery)
+		}
+		value := `(SELECT p.%s FROM properties p WHERE p.file_id = fe.id AND p.key = ?)`
+		terms = append(terms,
+			fmt.Sprintf(value+` IS NULL`, "text_value"),
+			fmt.Sprintf(value+` %s`, "num_value", dir),
+			fmt.Sprintf(value+` COLLATE NOCASE %s`, "text_value", dir))
+		args = append(args, key, key, key)
+	}
+	return strings.Join(append(terms, "fe.path"), ", "), args, nil
+}
+
+// filterToken is a word, quoted string, operator or parenthesis of a filter
+type filterToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeFilter splits a filter into tokens
+func tokenizeFilter(filter string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(filter); {
+		c := filter[i]
+		switch {
+		case strings.IndexByte(" \t\r\n", c) >= 0:
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, filterToken{text: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(filter[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidPropertyQuery)
+			}
+			tokens = append(tokens, filterToken{text: filter[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		case strings.IndexByte("=!<>", c) >= 0:
+			op := string(c)
+			if i+1 < len(filter) && filter[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("%w: unknown operator !", ErrInvalidPropertyQuery)
+			}
+			tokens = append(tokens, filterToken{text: op})
+			i += len(op)
+		default:
+			start := i
+			for i < len(filter) && strings.IndexByte(" \t\r\n()=!<>\"'", filter[i]) < 0 {
+				i++
+			}
+			tokens = append(tokens, filterToken{text: filter[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+// filterParser turns a filter into an SQL condition over file_entries fe
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+	args   []any
+}
+
+// parsePropertyFilter returns the SQL condition for a filter and its arguments
+func parsePropertyFilter(filter string) (string, []any, error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return "", nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	cond, err := p.parseOr()
+	if err != nil {
+		return "", nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return "", nil, fmt.Errorf("%w: unexpected %q", ErrInvalidPropertyQuery, p.tokens[p.pos].text)
+	}
+	return cond, p.args, nil
+}
+
+func (p *filterParser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+func (p *filterParser) parseOr() (string, error) {
+	return p.parseJoined("OR", p.parseAnd)
+}
+
+func (p *filterParser) parseAnd() (string, error) {
+	return p.parseJoined("AND", p.parseTerm)
+}
+
+// parseJoined parses operands separated by a keyword
+func (p *filterParser) parseJoined(keyword string, operand func() (string, error)) (string, error) {
+	first, err := operand()
+	if err != nil {
+		return "", err
+	}
+	parts := []string{first}
+	for p.peekKeyword(keyword) {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, next)
+	}
+	if len(parts) == 1 {
+		return first, nil
+	}
+	return "(" + strings.Join(parts, " "+keyword+" ") + ")", nil
+}
+
+// parseTerm parses a parenthesized filter or a comparison
+func (p *filterParser) parseTerm() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", fmt.Errorf("%w: unexpected end of filter", ErrInvalidPropertyQuery)
+	}
+	if tok := p.tokens[p.pos]; !tok.quoted && tok.text == "(" {
+		p.pos++
+		cond, err := p.parseOr()
+		if err != nil {
+			return "", err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted || p.tokens[p.pos].text != ")" {
+			return "", fmt.Errorf("%w: missing )", ErrInvalidPropertyQuery)
+		}
+		p.pos++
+		return cond, nil
+	}
+
+	if p.pos+3 > len(p.tokens) {
+		return "", fmt.Errorf("%w: incomplete comparison", ErrInvalidPropertyQuery)
+	}
+	key, op, value := p.tokens[p.pos], p.tokens[p.pos+1], p.tokens[p.pos+2]
+	if key.text == "" || (!key.quoted && strings.ContainsAny(key.text, "()=!<>")) {
+		return "", fmt.Errorf("%w: expected a property key, got %q", ErrInvalidPropertyQuery, key.text)
+	}
+	switch op.text {
+	case "=", "!=", "<", "<=", ">", ">=":
+		if op.quoted {
+			return "", fmt.Errorf("%w: expected an operator after %s", ErrInvalidPropertyQuery, key.text)
+		}
+	default:
+		return "", fmt.Errorf("%w: expected an operator after %s, got %q", ErrInvalidPropertyQuery, key.text, op.text)
+	}
+	if !value.quoted && (value.text == "" || value.text == "(" || value.text == ")") {
+		return "", fmt.Errorf("%w: expected a value after %s %s", ErrInvalidPropertyQuery, key.text, op.text)
+	}
+	p.pos += 3
+
+	if op.text == "!=" {
+		return "NOT " + p.comparison(key.text, "=", value), nil
+	}
+	return p.comparison(key.text, op.text, value), nil
+}
+
+// comparison returns the condition that a note has a property comparing to value
+func (p *filterParser) comparison(key, op string, value filterToken) string {
+	var match string
+	var args []any
+	switch {
+	case dateValue.MatchString(value.text):
+		match = fmt.Sprintf(`p.type = '%s' AND substr(p.text_value, 1, %d) %s ?`, PropertyDate, len(value.text), op)
+		args = append(args, value.text)
+	case !value.quoted && (strings.EqualFold(value.text, "true") || strings.EqualFold(value.text, "false")):
+		match = fmt.Sprintf(`p.type = '%s' AND p.num_value %s ?`, PropertyBool, op)
+		args = append(args, boolToInt(strings.EqualFold(value.text, "true")))
+	default:
+		if n, err := strconv.ParseFloat(value.text, 64); err == nil && !value.quoted {
+			match = fmt.Sprintf(`p.type = '%s' AND p.num_value %s ?`, PropertyNumber, op)
+			args = append(args, n)
+		} else {
+			match = fmt.Sprintf(`p.type = '%s' AND p.text_value %s ? COLLATE NOCASE`, PropertyString, op)
+			args = append(args, value.text)
+		}
+	}
+	if op == "=" {
+		match = fmt.Sprintf(`(%s) OR (p.type = '%s' AND EXISTS (SELECT 1 FROM json_each(p.value) j WHERE j.value = ? COLLATE NOCASE))`,
+			match, PropertyList)
+		args = append(args, value.text)
+	}
+
+	p.args = append(p.args, key)
+	p.args = append(p.args, args...)
+	return `EXISTS (SELECT 1 FROM properties p WHERE p.file_id = fe.id AND p.key = ? AND (` + match + `))`
+}
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// aliasKeys are the frontmatter properties holding a note's other names
+var aliasKeys = []string{"aliases", "alias"}
+
+// linkCandidate is an active file a link target may name
+type linkCandidate struct {
+	id   string
+	path string // Slash path
+}
+
+// ResolveLink returns the active file a wikilink target names when written in
+// the note at sourcePath, or nil if there is none. See resolveLinkTarget.
+func (s *DBService) ResolveLink(target, sourcePath string) (*FileEntry, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+	defer cancel()
+
+	id, err := resolveLinkTarget(ctx, db, target, sourcePath)
+	if err != nil || !id.Valid {
+		return nil, err
+	}
+	return s.GetFileEntryByIDWithStatus(id.String, FileStatusActive)
+}
+
+// resolveLinkTarget finds the active file a link target names from the note
+// at sourcePath, following Obsidian's rules. Names match ignoring case in any
+// script, and notes may leave out .md, while other files need their extension.
+//  1. A path relative to the source note's folder ("./" and "../" links only match this way)
+//  2. A path from the vault root
+//  3. The file whose path ends with the target, preferring the shortest path
+//  4. A note listing the target among its frontmatter aliases
+func resolveLinkTarget(ctx context.Context, q sqlQuerier, target, sourcePath string) (sql.NullString, error) {
+	var id sql.NullString
+	sourceDir := path.Dir(filepath.ToSlash(sourcePath))
+
+	t := strings.TrimSpace(strings.ReplaceAll(target, `\`, "/"))
+	relativeOnly := strings.HasPrefix(t, "./") || strings.HasPrefix(t, "../")
+	t = strings.TrimPrefix(t, "/")
+	if t == "" {
+		return id, nil
+	}
+	fromSource := path.Join(sourceDir, t)
+	if strings.HasPrefix(fromSource, "../") {
+		return id, nil
+	}
+
+	name := foldName(path.Base(t))
+	candidates, err := queryLinkCandidates(ctx, q, `fe.name_folded IN (?, ?)`, name, name+".md")
+	if err != nil {
+		return id, err
+	}
+
+	bestRank := -1
+	var best linkCandidate
+	for _, c := range candidates {
+		rank := -1
+		switch {
+		case pathNames(c.path, fromSource):
+			rank = 0
+		case relativeOnly:
+		case pathNames(c.path, t):
+			rank = 1
+		case pathNames(c.path, "*/"+t):
+			rank = 2
+		}
+		if rank >= 0 && (bestRank < 0 || rank < bestRank || (rank == bestRank && shorterPath(c.path, best.path))) {
+			best, bestRank = c, rank
+		}
+	}
+	if bestRank >= 0 {
+		return sql.NullString{String: best.id, Valid: true}, nil
+	}
+	if relativeOnly || strings.Contains(t, "/") {
+		return id, nil
+	}
+
+	// Aliases come last, with notes in the source note's folder first
+	ids, err := aliasedFileIDs(ctx, q, t)
+	if err != nil || len(ids) == 0 {
+		return id, err
+	}
+	candidates, err = queryLinkCandidates(ctx, q, `fe.id IN (`+placeholders(len(ids))+`)`, ids...)
+	if err != nil {
+		return id, err
+	}
+	var bestInFolder bool
+	for i, c := range candidates {
+		inFolder := path.Dir(c.path) == sourceDir
+		if i == 0 || (inFolder && !bestInFolder) || (inFolder == bestInFolder && shorterPath(c.path, best.path)) {
+			best, bestInFolder = c, inFolder
+		}
+	}
+	if len(candidates) > 0 {
+		id = sql.NullString{String: best.id, Valid: true}
+	}
+	return id, nil
+}
+
+// aliasedFileIDs returns the files listing alias among their frontmatter
+// aliases. The values are kept as JSON, so they are folded here.
+func aliasedFileIDs(ctx context.Context, q sqlQuerier, alias string) ([]any, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT p.file_id, a.value FROM properties p, json_each(p.value) a
+		WHERE p.key IN (?, ?) AND a.type = 'text'`, aliasKeys[0], aliasKeys[1])
+	if err != nil {
+		return nil, fmt.Errorf("resolve link alias: %w", err)
+	}
+	defer rows.Close()
+
+	alias = foldName(alias)
+	var ids []any
+	for rows.Next() {
+		var id, value string
+		if err := rows.Scan(&id, &value); err != nil {
+			return nil, err
+		}
+		if foldName(value) == alias {
+			ids = append(ids, id)
+		}
+	}
+	return ids, rows.Err()
+}
+
+// queryLinkCandidates returns the active files matching where. The unary +
+// keeps SQLite from walking every active file by status instead of using
+// where's index.
+func queryLinkCandidates(ctx context.Context, q sqlQuerier, where string, args ...any) ([]linkCandidate, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT fe.id, fe.path FROM file_entries fe
+		INNER JOIN file_statuses fs ON +fe.file_status_id = fs.id
+		WHERE fe.is_dir = 0 AND fs.name = ? AND (`+where+`)`,
+		append([]any{string(FileStatusActive)}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("resolve link target: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []linkCandidate
+	for rows.Next() {
+		var c linkCandidate
+		if err := rows.Scan(&c.id, &c.path); err != nil {
+			return nil, err
+		}
+		c.path = filepath.ToSlash(c.path)
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// pathNames reports whether a link path names the file at p, ignoring case
+// and a missing .md. A leading "*/" matches the path's end after any folder.
+func pathNames(p, link string) bool {
+	p = foldName(p)
+	link = foldName(link)
+	if rest, ok := strings.CutPrefix(link, "*/"); ok {
+		return strings.HasSuffix(p, "/"+rest) || strings.HasSuffix(p, "/"+rest+".md")
+	}
+	return p == link || p == link+".md"
+}
+
+// foldName folds the case of a file name or link target in any script, as
+// stored in name_folded and target_folded. SQLite's lower() only folds ASCII.
+func foldName(s string) string {
+	return strings.ToLower(s)
+}
+
+// shorterPath orders paths by length, then by name
+func shorterPath(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
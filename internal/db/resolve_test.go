@@ -0,0 +1,128 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveLink(t *testing.T) {
+	svc, _, _ := newMoveTestVault(t,
+		"Note.md", "a/Note.md", "a/b/Note.md", "c/Note.md",
+		"Projects/Plan.md", "archive/Projects/Plan.md",
+		"img/photo.png", "a/Photo.png.md",
+		"x/y/Deep.md", "x/z/Deep.md",
+		"people/Ada Lovelace.md", "people/Ada.md",
+		"über.md", "places/Zürich.md",
+	)
+	id := func(p string) string {
+		t.Helper()
+		entry, err := svc.GetFileEntryByPath(filepath.FromSlash(p))
+		if err != nil || entry == nil {
+			t.Fatalf("GetFileEntryByPath(%s) = %v, %v", p, entry, err)
+		}
+		return entry.ID
+	}
+	if err := svc.SetFileProperties(id("people/Ada Lovelace.md"), map[string]any{"aliases": []any{"Countess", "Ada"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetFileProperties(id("x/y/Deep.md"), map[string]any{"alias": "Abyss"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetFileProperties(id("places/Zürich.md"), map[string]any{"aliases": []any{"Ärger", 42}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, target, source, want string
+	}{
+		{"root path", "Note", "e/d.md", "Note.md"},
+		{"source folder first", "Note", "a/b/other.md", "a/b/Note.md"},
+		{"case insensitive", "nOTE", "a/other.md", "a/Note.md"},
+		{"case insensitive beyond ASCII", "Über", "Note.md", "über.md"},
+		{"path case beyond ASCII", "PLACES/ZÜRICH", "Note.md", "places/Zürich.md"},
+		{"with extension", "Note.md", "other/x.md", "Note.md"},
+		{"full path", "a/b/Note", "Note.md", "a/b/Note.md"},
+		{"path from source folder", "b/Note", "a/other.md", "a/b/Note.md"},
+		{"vault path before partial path", "Projects/Plan", "elsewhere/x.md", "Projects/Plan.md"},
+		{"partial path", "b/note", "Note.md", "a/b/Note.md"},
+		{"shortest of several", "Deep", "Note.md", "x/y/Deep.md"},
+		{"attachment needs extension", "photo.png", "Note.md", "img/photo.png"},
+		{"attachment without extension", "photo", "Note.md", ""},
+		{"relative", "./Note", "a/b/other.md", "a/b/Note.md"},
+		{"parent relative", "../Note", "a/b/other.md", "a/Note.md"},
+		{"relative only from source", "./Plan", "a/other.md", ""},
+		{"outside the vault", "../../Note", "a/x.md", ""},
+		{"alias", "countess", "Note.md", "people/Ada Lovelace.md"},
+		{"single alias", "Abyss", "Note.md", "x/y/Deep.md"},
+		{"alias beyond ASCII", "äRGER", "Note.md", "places/Zürich.md"},
+		{"name before alias", "Ada", "Note.md", "people/Ada.md"},
+		{"missing", "Nothing", "Note.md", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := svc.ResolveLink(tt.target, filepath.FromSlash(tt.source))
+			if err != nil {
+				t.Fatalf("ResolveLink() error = %v", err)
+			}
+			got := ""
+			if entry != nil {
+				got = filepath.ToSlash(entry.Path)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveLink(%q from %s) = %q, want %q", tt.target, tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveLinksTo(t *testing.T) {
+	svc, _, _ := newMoveTestVault(t, "notes/a.md", "b.md", "other/b.md")
+	id := func(p string) string {
+		t.Helper()
+		entry, _ := svc.GetFileEntryByPath(filepath.FromSlash(p))
+		if entry == nil {
+			t.Fatalf("%s not tracked", p)
+		}
+		return entry.ID
+	}
+	a, b := id("notes/a.md"), id("b.md")
+
+	if err := svc.SetFileLinks(a, []Link{
+		{RawTarget: "B", Target: "B", Line: 1},
+		{RawTarget: "nickname", Target: "nickname", Line: 2},
+		{RawTarget: "ärger", Target: "ärger", Line: 3},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	links, _ := svc.GetFileLinks(a)
+	if links[0].TargetID != b || links[1].TargetID != "" {
+		t.Fatalf("links = %+v, want [[B]] on b.md and [[nickname]] unresolved", links)
+	}
+
+	// An alias added to a note picks up the links naming it
+	otherB := id("other/b.md")
+	if err := svc.SetFileProperties(otherB, map[string]any{"aliases": []any{"Nickname", "ÄRGER"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ResolveLinksTo(otherB); err != nil {
+		t.Fatalf("ResolveLinksTo() error = %v", err)
+	}
+	links, _ = svc.GetFileLinks(a)
+	if links[1].TargetID != otherB {
+		t.Errorf("[[nickname]] resolves to %q, want other/b.md", links[1].TargetID)
+	}
+	if links[2].TargetID != otherB {
+		t.Errorf("[[ärger]] resolves to %q, want other/b.md", links[2].TargetID)
+	}
+
+	// Dropping it again leaves the link unresolved
+	if err := svc.SetFileProperties(otherB, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ResolveLinksTo(otherB); err != nil {
+		t.Fatal(err)
+	}
+	if links, _ := svc.GetFileLinks(a); links[1].TargetID != "" {
+		t.Errorf("[[nickname]] resolves to %q after the alias was removed, want unresolved", links[1].TargetID)
+	}
+}
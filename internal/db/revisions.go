@@ -0,0 +1,178 @@
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DefaultRevisionKeep is the number of revisions kept per note when the retention does not set one
+const DefaultRevisionKeep = 50
+
+// Revision is a stored version of a note. The newest revision of a note is
+// its content as last synced, so the version an overwrite replaced is the
+// one before it.
+type Revision struct {
+	ID          int64     `json:"id"`
+	FileID      string    `json:"file_id"`
+	ContentHash string    `json:"content_hash"`
+	Size        int64     `json:"size"` // Uncompressed size in bytes
+	Created     time.Time `json:"created"`
+}
+
+// RevisionRetention limits the revisions kept per note. The newest revision is always kept.
+type RevisionRetention struct {
+	Keep   int           // Revisions kept per note; DefaultRevisionKeep when 0
+	MaxAge time.Duration // Revisions older than this are dropped; 0 keeps them regardless of age
+}
+
+// AddRevision stores content as the newest revision of a file, unless it is
+// the same as the newest one, then prunes the file's revisions to the retention.
+// Reports whether a revision was added.
+func (s *DBService) AddRevision(fileID string, content []byte, retention RevisionRetention) (bool, error) {
+	db := s.getDB()
+	if db == nil {
+		return false, errors.New("db not ready")
+	}
+
+	hash := ContentHash(content)
+	compressed, err := compressRevision(content)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Writing first takes the write lock up front, so concurrent workers wait instead of failing
+	now := time.Now()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO revisions(file_id, content_hash, size, content, created)
+		SELECT ?, ?, ?, ?, ?
+		WHERE COALESCE((SELECT content_hash FROM revisions WHERE file_id = ? ORDER BY id DESC LIMIT 1), '') <> ?`,
+		fileID, hash, len(content), compressed, now.UnixNano(), fileID, hash)
+	if err != nil {
+		return false, fmt.Errorf("insert revision: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	keep := retention.Keep
+	if keep <= 0 {
+		keep = DefaultRevisionKeep
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM revisions WHERE file_id = ? AND id NOT IN (
+		  SELECT id FROM revisions WHERE file_id = ? ORDER BY id DESC LIMIT ?
+		)`, fileID, fileID, keep); err != nil {
+		return false, fmt.Errorf("prune revisions by count: %w", err)
+	}
+	if retention.MaxAge > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM revisions WHERE file_id = ? AND created < ? AND id <> (
+			  SELECT MAX(id) FROM revisions WHERE file_id = ?
+			)`, fileID, now.Add(-retention.MaxAge).UnixNano(), fileID); err != nil {
+			return false, fmt.Errorf("prune revisions by age: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit revision: %w", err)
+	}
+	return true, nil
+}
+
+// GetRevisions returns the revisions of a file, newest first
+func (s *DBService) GetRevisions(fileID string) ([]Revision, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, file_id, content_hash, size, created FROM revisions WHERE file_id = ? ORDER BY id DESC`, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("query revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []Revision{}
+	for rows.Next() {
+		var r Revision
+		var created int64
+		if err := rows.Scan(&r.ID, &r.FileID, &r.ContentHash, &r.Size, &created); err != nil {
+			return nil, err
+		}
+		r.Created = time.Unix(0, created)
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+// GetRevision returns a revision of a file and its content, or nil if the
+// file has no revision with that ID
+func (s *DBService) GetRevision(fileID string, id int64) (*Revision, []byte, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	var r Revision
+	var created int64
+	var compressed []byte
+	err := db.QueryRowContext(ctx,
+		`SELECT id, file_id, content_hash, size, created, content FROM revisions WHERE file_id = ? AND id = ?`, fileID, id).
+		Scan(&r.ID, &r.FileID, &r.ContentHash, &r.Size, &created, &compressed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("get revision: %w", err)
+	}
+	r.Created = time.Unix(0, created)
+
+	content, err := decompressRevision(compressed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decompress revision %d: %w", id, err)
+	}
+	return &r, content, nil
+}
+
+// compressRevision gzips revision content for storage
+func compressRevision(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(content); err != nil {
+		return nil, fmt.Errorf("compress revision: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress revision: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressRevision reverses compressRevision
+func decompressRevision(compressed []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
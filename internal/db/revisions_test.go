@@ -0,0 +1,71 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddRevision(t *testing.T) {
+	svc, _, _ := newMoveTestVault(t, "note.md")
+	entry, err := svc.GetFileEntryByPath("note.md")
+	if err != nil || entry == nil {
+		t.Fatalf("GetFileEntryByPath() = %v, %v", entry, err)
+	}
+	retention := RevisionRetention{Keep: 3}
+
+	for i, content := range []string{"one", "one", "two", "three", "four"} {
+		added, err := svc.AddRevision(entry.ID, []byte(content), retention)
+		if err != nil {
+			t.Fatalf("AddRevision(%q) error = %v", content, err)
+		}
+		if want := i != 1; added != want {
+			t.Errorf("AddRevision(%q) added = %v, want %v", content, added, want)
+		}
+	}
+
+	revisions, err := svc.GetRevisions(entry.ID)
+	if err != nil {
+		t.Fatalf("GetRevisions() error = %v", err)
+	}
+	var contents []string
+	for _, r := range revisions {
+		rev, content, err := svc.GetRevision(entry.ID, r.ID)
+		if err != nil || rev == nil {
+			t.Fatalf("GetRevision(%d) = %v, %v", r.ID, rev, err)
+		}
+		if rev.Size != int64(len(content)) || rev.ContentHash != ContentHash(content) {
+			t.Errorf("revision %d = %+v, does not describe %q", r.ID, rev, content)
+		}
+		contents = append(contents, string(content))
+	}
+	if want := []string{"four", "three", "two"}; len(contents) != len(want) ||
+		contents[0] != want[0] || contents[1] != want[1] || contents[2] != want[2] {
+		t.Errorf("revisions = %v, want %v (newest first, pruned to 3)", contents, want)
+	}
+
+	if rev, _, err := svc.GetRevision("other-file", revisions[0].ID); err != nil || rev != nil {
+		t.Errorf("GetRevision() of another file = %v, %v; want nil", rev, err)
+	}
+}
+
+func TestAddRevision_MaxAge(t *testing.T) {
+	svc, _, _ := newMoveTestVault(t, "note.md")
+	entry, _ := svc.GetFileEntryByPath("note.md")
+	retention := RevisionRetention{MaxAge: 50 * time.Millisecond}
+
+	if _, err := svc.AddRevision(entry.ID, []byte("old"), retention); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err := svc.AddRevision(entry.ID, []byte("new"), retention); err != nil {
+		t.Fatal(err)
+	}
+
+	revisions, err := svc.GetRevisions(entry.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 || revisions[0].ContentHash != ContentHash([]byte("new")) {
+		t.Errorf("revisions = %+v, want only the newest", revisions)
+	}
+}
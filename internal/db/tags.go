@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TagCount is a tag with the number of active files carrying it
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// NormalizeTag strips the leading # and surrounding space (and quotes left
+// over from frontmatter) from a tag. Tags compare case-insensitively.
+func NormalizeTag(tag string) string {
+	tag = strings.Trim(strings.TrimSpace(tag), `"'`)
+	return strings.TrimPrefix(tag, "#")
+}
+
+// SetFileTags replaces the tags of a file. Tags no file uses anymore are removed.
+func (s *DBService) SetFileTags(fileID string, tags []string) error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+
+	// Deduplicate case-insensitively, keeping the first spelling
+	seen := make(map[string]bool, len(tags))
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		name := NormalizeTag(tag)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM file_tags WHERE file_id = ?`, fileID); err != nil {
+		return fmt.Errorf("clear file tags: %w", err)
+	}
+	for _, name := range names {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags(name) VALUES (?)`, name); err != nil {
+			return fmt.Errorf("insert tag %s: %w", name, err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO file_tags(file_id, tag_id) SELECT ?, id FROM tags WHERE name = ?`,
+			fileID, name); err != nil {
+			return fmt.Errorf("tag file with %s: %w", name, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM file_tags)`); err != nil {
+		return fmt.Errorf("remove unused tags: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFileTags removes all tags from a file
+func (s *DBService) DeleteFileTags(fileID string) error {
+	return s.SetFileTags(fileID, nil)
+}
+
+// GetFileTags returns the tags of a file, sorted by name
+func (s *DBService) GetFileTags(fileID string) ([]string, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.name FROM tags t
+		INNER JOIN file_tags ft ON ft.tag_id = t.id
+		WHERE ft.file_id = ?
+		ORDER BY t.name`, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("query file tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tags = append(tags, name)
+	}
+	return tags, rows.Err()
+}
+
+// GetTagCount returns how many active files carry a tag
+func (s *DBService) GetTagCount(tag string) (int, error) {
+	db := s.getDB()
+	if db == nil {
+		return 0, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+	defer cancel()
+
+	var count int
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM file_tags ft
+		INNER JOIN tags t ON ft.tag_id = t.id
+		INNER JOIN file_entries fe ON ft.file_id = fe.id
+		INNER JOIN file_statuses fs ON fe.file_status_id = fs.id
+		WHERE t.name = ? AND fs.name = ?`, NormalizeTag(tag), string(FileStatusActive)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count tag: %w", err)
+	}
+	return count, nil
+}
+
+// GetTagCounts returns every tag carried by an active file, most used first
+func (s *DBService) GetTagCounts() ([]TagCount, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT t.name, COUNT(*) AS file_count FROM file_tags ft
+		INNER JOIN tags t ON ft.tag_id = t.id
+		INNER JOIN file_entries fe ON ft.file_id = fe.id
+		INNER JOIN file_statuses fs ON fe.file_status_id = fs.id
+		WHERE fs.name = ?
+		GROUP BY t.id
+		ORDER BY file_count DESC, t.name`, string(FileStatusActive))
+	if err != nil {
+		return nil, fmt.Errorf("query tag counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []TagCount{}
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Name, &tc.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, tc)
+	}
+	return counts, rows.Err()
+}
+
+// GetFilesByTag returns the active files carrying a tag, sorted by path
+func (s *DBService) GetFilesByTag(tag string) ([]FileEntry, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT fe.id, fe.name, fe.parent_id, fe.is_dir, fe.file_type_id, fe.file_status_id,
+		       fe.created, fe.modified, fe.size, fe.path, fe.content_hash
+		FROM file_tags ft
+		INNER JOIN tags t ON ft.tag_id = t.id
+		INNER JOIN file_entries fe ON ft.file_id = fe.id
+		INNER JOIN file_statuses fs ON fe.file_status_id = fs.id
+		WHERE t.name = ? AND fs.name = ?
+		ORDER BY fe.path`, NormalizeTag(tag), string(FileStatusActive))
+	if err != nil {
+		return nil, fmt.Errorf("query files by tag: %w", err)
+	}
+	defer rows.Close()
+
+	return scanFileEntries(rows)
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileTags(t *testing.T) {
+	svc, _, _ := newMoveTestVault(t, "a.md", "b.md", "notes/c.md")
+	ids := make(map[string]string)
+	for _, p := range []string{"a.md", "b.md", "notes/c.md"} {
+		entry, err := svc.GetFileEntryByPath(p)
+		if err != nil {
+			t.Fatalf("GetFileEntryByPath(%s) error = %v", p, err)
+		}
+		ids[p] = entry.ID
+	}
+
+	// The first spelling of a tag is the one kept
+	for _, tc := range []struct {
+		path string
+		tags []string
+	}{
+		{"a.md", []string{"project", "#Work", "project"}},
+		{"b.md", []string{"work", "project/alpha"}},
+		{"notes/c.md", []string{"work", " 'draft' "}},
+	} {
+		if err := svc.SetFileTags(ids[tc.path], tc.tags); err != nil {
+			t.Fatalf("SetFileTags(%s) error = %v", tc.path, err)
+		}
+	}
+
+	if tags, _ := svc.GetFileTags(ids["a.md"]); !reflect.DeepEqual(tags, []string{"project", "Work"}) {
+		t.Errorf("GetFileTags(a.md) = %v, want [project Work]", tags)
+	}
+	if count, err := svc.GetTagCount("#WORK"); err != nil || count != 3 {
+		t.Errorf("GetTagCount(#WORK) = %d, %v, want 3", count, err)
+	}
+
+	want := []TagCount{{"Work", 3}, {"draft", 1}, {"project", 1}, {"project/alpha", 1}}
+	if counts, err := svc.GetTagCounts(); err != nil || !reflect.DeepEqual(counts, want) {
+		t.Errorf("GetTagCounts() = %v, %v, want %v", counts, err, want)
+	}
+
+	// Deleted files no longer count, and their tags go with them
+	if err := svc.DeleteFileEntry(ids["b.md"]); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := svc.GetTagCount("work"); count != 2 {
+		t.Errorf("GetTagCount(work) after delete = %d, want 2", count)
+	}
+	if err := svc.DeleteFileTags(ids["b.md"]); err != nil {
+		t.Fatal(err)
+	}
+	var unused int
+	if err := svc.getDB().QueryRow(`SELECT COUNT(*) FROM tags WHERE name = 'project/alpha'`).Scan(&unused); err != nil || unused != 0 {
+		t.Errorf("tag without files kept (count %d, err %v)", unused, err)
+	}
+
+	files, err := svc.GetFilesByTag("work")
+	if err != nil {
+		t.Fatalf("GetFilesByTag() error = %v", err)
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{"a.md", filepath.Join("notes", "c.md")}) {
+		t.Errorf("GetFilesByTag(work) = %v, want [a.md notes/c.md]", paths)
+	}
+}
+
+func TestNeedsBackfill(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// A database from before the tags table existed
+	old, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Exec(`CREATE TABLE file_entries (id TEXT PRIMARY KEY, name TEXT NOT NULL, parent_id TEXT,
+		is_dir INTEGER NOT NULL, file_type_id INTEGER, file_status_id INTEGER, created INTEGER NOT NULL,
+		modified INTEGER NOT NULL, size INTEGER, path TEXT NOT NULL UNIQUE)`); err != nil {
+		t.Fatal(err)
+	}
+	old.Close()
+
+	for i, want := range []bool{true, false} {
+		svc, err := NewDBService(context.Background(), &dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		if got := svc.NeedsBackfill(); got != want {
+			t.Errorf("start %d: NeedsBackfill() = %v, want %v", i+1, got, want)
+		}
+		svc.Stop()
+	}
+
+	fresh := filepath.Join(t.TempDir(), "fresh.db")
+	svc, err := NewDBService(context.Background(), &fresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer svc.Stop()
+	if svc.NeedsBackfill() {
+		t.Error("NeedsBackfill() = true for a new database")
+	}
+}
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+// TrashItem is a file or folder moved to the vault trash. Paths are vault
+// relative with forward slashes.
+type TrashItem struct {
+	ID           int64     `json:"id"`
+	FileID       string    `json:"file_id,omitempty"` // Entry the item was deleted as; empty once it is gone
+	OriginalPath string    `json:"original_path"`
+	TrashPath    string    `json:"trash_path"`
+	IsDir        bool      `json:"is_dir"`
+	Size         int64     `json:"size"`
+	Deleted      time.Time `json:"deleted"`
+}
+
+// AddTrashItem records an item moved to the trash and sets its ID
+func (s *DBService) AddTrashItem(item *TrashItem) error {
+	if item == nil {
+		return errors.New("nil trash item")
+	}
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	if item.Deleted.IsZero() {
+		item.Deleted = time.Now()
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+	defer cancel()
+
+	res, err := db.ExecContext(ctx,
+		`INSERT INTO trash(file_id, original_path, trash_path, is_dir, size, deleted) VALUES (?, ?, ?, ?, ?, ?)`,
+		nullIfEmpty(item.FileID), item.OriginalPath, item.TrashPath, boolToInt(item.IsDir), item.Size, item.Deleted.UnixNano())
+	if err != nil {
+		return fmt.Errorf("insert trash item: %w", err)
+	}
+	item.ID, err = res.LastInsertId()
+	return err
+}
+
+// GetTrashItems returns the items in the trash, most recently deleted first
+func (s *DBService) GetTrashItems() ([]TrashItem, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, file_id, original_path, trash_path, is_dir, size, deleted FROM trash ORDER BY deleted DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("query trash: %w", err)
+	}
+	defer rows.Close()
+
+	items := []TrashItem{}
+	for rows.Next() {
+		item, err := scanTrashItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// GetTrashItem returns a trash item by ID, or nil if there is none
+func (s *DBService) GetTrashItem(id int64) (*TrashItem, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+	defer cancel()
+
+	item, err := scanTrashItem(db.QueryRowContext(ctx,
+		`SELECT id, file_id, original_path, trash_path, is_dir, size, deleted FROM trash WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get trash item: %w", err)
+	}
+	return item, nil
+}
+
+// RemoveTrashItem drops the record of a trash item
+func (s *DBService) RemoveTrashItem(id int64) error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, 3*time.Second)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM trash WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("remove trash item: %w", err)
+	}
+	return nil
+}
+
+// DeleteFileEntryTree marks the active entry at path and every active entry
+// below it as deleted, and returns the entries it marked.
+func (s *DBService) DeleteFileEntryTree(path string) ([]FileEntry, error) {
+	db := s.getDB()
+	if db == nil {
+		return nil, errors.New("db not ready")
+	}
+	activeStatusID, err := s.GetFileStatusID(FileStatusActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active status ID: %w", err)
+	}
+	deletedStatusID, err := s.GetFileStatusID(FileStatusDeleted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted status ID: %w", err)
+	}
+	if activeStatusID == nil || deletedStatusID == nil {
+		return nil, errors.New("file statuses not found in database")
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	// substr counts characters, so the prefix is measured in runes
+	prefix := path + string(filepath.Separator)
+	rows, err := db.QueryContext(ctx, `
+		UPDATE file_entries SET file_status_id = ?, modified = ?
+		WHERE file_status_id = ? AND (path = ? OR substr(path, 1, ?) = ?)
+		RETURNING id, name, parent_id, is_dir, file_type_id, file_status_id, created, modified, size, path, content_hash`,
+		*deletedStatusID, time.Now().UTC().Unix(), *activeStatusID, path, utf8.RuneCountInString(prefix), prefix)
+	if err != nil {
+		return nil, fmt.Errorf("mark entries as deleted: %w", err)
+	}
+	defer rows.Close()
+
+	return scanFileEntries(rows)
+}
+
+// PurgeDeletedFileEntries removes the deleted entries at path and below it,
+// along with their tags, links, properties and revisions
+func (s *DBService) PurgeDeletedFileEntries(path string) error {
+	db := s.getDB()
+	if db == nil {
+		return errors.New("db not ready")
+	}
+	deletedStatusID, err := s.GetFileStatusID(FileStatusDeleted)
+	if err != nil {
+		return fmt.Errorf("failed to get deleted status ID: %w", err)
+	}
+	if deletedStatusID == nil {
+		return errors.New("deleted status not found in database")
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	prefix := path + string(filepath.Separator)
+	if _, err := db.ExecContext(ctx,
+		`DELETE FROM file_entries WHERE file_status_id = ? AND (path = ? OR substr(path, 1, ?) = ?)`,
+		*deletedStatusID, path, utf8.RuneCountInString(prefix), prefix); err != nil {
+		return fmt.Errorf("purge deleted entries: %w", err)
+	}
+	return nil
+}
+
+// scanTrashItem reads a trash row from a query selecting its columns in table order
+func scanTrashItem(row interface{ Scan(...interface{}) error }) (*TrashItem, error) {
+	var item TrashItem
+	var fileID sql.NullString
+	var isDir int
+	var deleted int64
+	if err := row.Scan(&item.ID, &fileID, &item.OriginalPath, &item.TrashPath, &isDir, &item.Size, &deleted); err != nil {
+		return nil, err
+	}
+	item.FileID = fileID.String
+	item.IsDir = intToBool(isDir)
+	item.Deleted = time.Unix(0, deleted)
+	return &item, nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestDeleteFileEntryTree(t *testing.T) {
+	svc, _, _ := newMoveTestVault(t, "folder/a.md", "folder/sub/b.md", "folder2/c.md")
+
+	entries, err := svc.DeleteFileEntryTree("folder")
+	if err != nil {
+		t.Fatalf("DeleteFileEntryTree() error = %v", err)
+	}
+	if len(entries) != 4 {
+		t.Errorf("DeleteFileEntryTree() marked %d entries, want 4 (folder, sub and two notes)", len(entries))
+	}
+
+	for path, wantActive := range map[string]bool{
+		"folder": false, "folder/a.md": false, "folder/sub/b.md": false, "folder2/c.md": true,
+	} {
+		entry, err := svc.GetFileEntryByPathWithStatus(path, FileStatusActive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if active := entry != nil; active != wantActive {
+			t.Errorf("%s active = %v, want %v", path, active, wantActive)
+		}
+	}
+
+	if entries, err := svc.DeleteFileEntryTree("folder"); err != nil || len(entries) != 0 {
+		t.Errorf("DeleteFileEntryTree() again = %d entries, %v; want none", len(entries), err)
+	}
+
+	if err := svc.PurgeDeletedFileEntries("folder"); err != nil {
+		t.Fatalf("PurgeDeletedFileEntries() error = %v", err)
+	}
+	for _, path := range []string{"folder", "folder/a.md", "folder/sub/b.md"} {
+		if entry, err := svc.GetFileEntryByPath(path); err != nil || entry != nil {
+			t.Errorf("%s after purge = %v, %v; want gone", path, entry, err)
+		}
+	}
+	if entry, _ := svc.GetFileEntryByPath("folder2/c.md"); entry == nil {
+		t.Error("folder2/c.md was purged")
+	}
+}
+
+func TestTrashItems(t *testing.T) {
+	svc, _, _ := newMoveTestVault(t, "note.md")
+	entry, _ := svc.GetFileEntryByPath("note.md")
+
+	first := &TrashItem{FileID: entry.ID, OriginalPath: "note.md", TrashPath: ".trash/note.md", Size: 9}
+	second := &TrashItem{OriginalPath: "old.md", TrashPath: ".trash/old.md"}
+	for _, item := range []*TrashItem{first, second} {
+		if err := svc.AddTrashItem(item); err != nil {
+			t.Fatalf("AddTrashItem() error = %v", err)
+		}
+	}
+
+	items, err := svc.GetTrashItems()
+	if err != nil {
+		t.Fatalf("GetTrashItems() error = %v", err)
+	}
+	if len(items) != 2 || items[0].ID != second.ID || items[1].ID != first.ID {
+		t.Fatalf("GetTrashItems() = %+v, want newest first", items)
+	}
+
+	item, err := svc.GetTrashItem(first.ID)
+	if err != nil || item == nil {
+		t.Fatalf("GetTrashItem() = %v, %v", item, err)
+	}
+	if item.FileID != entry.ID || item.TrashPath != ".trash/note.md" || item.Size != 9 {
+		t.Errorf("GetTrashItem() = %+v", item)
+	}
+
+	if err := svc.RemoveTrashItem(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if item, err := svc.GetTrashItem(first.ID); err != nil || item != nil {
+		t.Errorf("GetTrashItem() after remove = %v, %v; want nil", item, err)
+	}
+}
@@ -0,0 +1,222 @@
+// Package diff compares texts line by line and writes the differences as a unified diff
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+// maxEditDistance bounds the alignment search. Texts further apart than this
+// are shown as the differing middle section removed and re-added.
+const maxEditDistance = 4000
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is one line of the edit script; aLine and bLine are the 0-based
+// positions in the old and new text before the line is applied
+type op struct {
+	kind         opKind
+	line         string
+	aLine, bLine int
+}
+
+// Unified returns the unified diff turning from into to, with fromName and
+// toName on the --- and +++ lines. It returns "" when the texts are equal.
+func Unified(fromName, toName, from, to string) string {
+	a, b := splitLines(from), splitLines(to)
+	ops := editScript(a, b)
+
+	var hunks [][]op
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+		// Extend the hunk while the next change is close enough to share context
+		start := max(i-contextLines, 0)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != opEqual {
+				end = j + 1
+			} else if j-end >= 2*contextLines {
+				break
+			}
+		}
+		end = min(end+contextLines, len(ops))
+		hunks = append(hunks, ops[start:end])
+		i = end
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for _, hunk := range hunks {
+		var aCount, bCount int
+		for _, o := range hunk {
+			if o.kind != opInsert {
+				aCount++
+			}
+			if o.kind != opDelete {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(hunk[0].aLine, aCount), hunkRange(hunk[0].bLine, bCount))
+		for _, o := range hunk {
+			prefix := " "
+			switch o.kind {
+			case opDelete:
+				prefix = "-"
+			case opInsert:
+				prefix = "+"
+			}
+			sb.WriteString(prefix)
+			sb.WriteString(o.line)
+			if !strings.HasSuffix(o.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// hunkRange formats the start,count of a hunk side the way diff -u does: lines
+// are numbered from 1, and an empty side names the line before it
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+// splitLines splits text into lines that keep their line endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editScript returns the lines of a and b as a shortest edit script (Myers'
+// algorithm), after setting aside the common prefix and suffix
+func editScript(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{kind: opEqual, line: a[i], aLine: i, bLine: i})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix, prefix)...)
+	for i := suffix; i > 0; i-- {
+		ai, bi := len(a)-i, len(b)-i
+		ops = append(ops, op{kind: opEqual, line: a[ai], aLine: ai, bLine: bi})
+	}
+	return ops
+}
+
+// myers aligns a and b, whose first lines are at aOff and bOff in the full texts
+func myers(a, b []string, aOff, bOff int) []op {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEditDistance)
+
+	// v[k+offset] is the furthest x reached on diagonal k; trace keeps the
+	// part of v each round started from, for walking the path back
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+	found := -1
+	for d := 0; d <= limit && found < 0; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = d
+				break
+			}
+		}
+	}
+	if found < 0 {
+		// Too far apart to align: everything old goes, everything new comes
+		ops := make([]op, 0, n+m)
+		for i := range a {
+			ops = append(ops, op{kind: opDelete, line: a[i], aLine: aOff + i, bLine: bOff})
+		}
+		for i := range b {
+			ops = append(ops, op{kind: opInsert, line: b[i], aLine: aOff + n, bLine: bOff + i})
+		}
+		return ops
+	}
+
+	var reversed []op
+	x, y := n, m
+	for d := found; d >= 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, op{kind: opEqual, line: a[x], aLine: aOff + x, bLine: bOff + y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				reversed = append(reversed, op{kind: opInsert, line: b[y], aLine: aOff + x, bLine: bOff + y})
+			} else {
+				x--
+				reversed = append(reversed, op{kind: opDelete, line: a[x], aLine: aOff + x, bLine: bOff + y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	ops := make([]op, len(reversed))
+	for i, o := range reversed {
+		ops[len(reversed)-1-i] = o
+	}
+	return ops
+}
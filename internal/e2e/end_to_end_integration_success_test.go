@@ -14,6 +14,7 @@ import (
 
 	"github.com/susamn/obsidian-web/internal/config"
 	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/search"
 	"github.com/susamn/obsidian-web/internal/vault"
 	"github.com/susamn/obsidian-web/internal/web"
 )
@@ -198,7 +199,7 @@ func TestEndToEndIntegrationSuccess(t *testing.T) {
 	// 3.3: Verify Search index
 	t.Log("3.3: Validating search index...")
 	time.Sleep(2 * time.Second) // Wait for indexing
-	searchResults, err := searchService.SearchByText("TODO")
+	searchResults, err := searchService.SearchByText("TODO", search.SearchOptions{})
 	if err != nil {
 		t.Errorf("Search failed: %v", err)
 	} else {
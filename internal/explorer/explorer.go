@@ -3,14 +3,15 @@ package explorer
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/ignore"
 	"github.com/susamn/obsidian-web/internal/logger"
+	"github.com/susamn/obsidian-web/internal/storage"
 	syncpkg "github.com/susamn/obsidian-web/internal/sync"
 )
 
@@ -24,17 +25,18 @@ const (
 
 // NodeMetadata represents metadata about a file or directory
 type NodeMetadata struct {
-	ID          string    `json:"id"`            // Unique identifier from database
-	Path        string    `json:"path"`          // Relative path from vault root (read-only, NEVER used as input)
-	Name        string    `json:"name"`          // File/directory name
-	Type        NodeType  `json:"type"`          // file or directory
-	IsDirectory bool      `json:"is_directory"`  // True if directory (convenience field)
-	Size        int64     `json:"size"`          // Size in bytes (0 for directories)
-	ModTime     time.Time `json:"modified_time"` // Last modification time
-	IsMarkdown  bool      `json:"is_markdown"`   // True if .md file
-	HasChildren bool      `json:"has_children"`  // True if directory has children
-	ChildCount  int       `json:"child_count"`   // Number of direct children
-	CachedAt    time.Time `json:"-"`             // When this was cached
+	ID          string    `json:"id"`                     // Unique identifier from database
+	Path        string    `json:"path"`                   // Relative path from vault root (read-only, NEVER used as input)
+	Name        string    `json:"name"`                   // File/directory name
+	Type        NodeType  `json:"type"`                   // file or directory
+	IsDirectory bool      `json:"is_directory"`           // True if directory (convenience field)
+	Size        int64     `json:"size"`                   // Size in bytes (0 for directories)
+	ModTime     time.Time `json:"modified_time"`          // Last modification time
+	IsMarkdown  bool      `json:"is_markdown"`            // True if .md file
+	HasChildren bool      `json:"has_children"`           // True if directory has children
+	ChildCount  int       `json:"child_count"`            // Number of direct children
+	ContentHash string    `json:"content_hash,omitempty"` // SHA-256 of a note as last synced
+	CachedAt    time.Time `json:"-"`                      // When this was cached
 }
 
 // TreeNode represents a node in the directory tree with lazy-loaded children
@@ -49,7 +51,8 @@ type ExplorerService struct {
 	ctx       context.Context
 	cancel    context.CancelFunc
 	vaultID   string
-	vaultPath string // Base directory for the vault
+	vaultPath string // Base directory for the vault (event paths are rooted here)
+	storage   storage.VaultStorage
 
 	// Cache: path -> TreeNode
 	cache   map[string]*TreeNode
@@ -62,19 +65,31 @@ type ExplorerService struct {
 	// Database service for storing file metadata
 	dbService *db.DBService
 
+	// Vault ignore rules; ignored entries are left out of the tree
+	ignore *ignore.Rules
+
 	// Configuration
 	maxCacheSize int           // Maximum number of cached nodes
 	cacheTTL     time.Duration // Time to live for cache entries
 }
 
-// NewExplorerService creates a new explorer service
+// NewExplorerService creates a new explorer service backed by the local directory at vaultPath
 func NewExplorerService(ctx context.Context, vaultID, vaultPath string, dbSvc *db.DBService) (*ExplorerService, error) {
+	return NewExplorerServiceWithStorage(ctx, vaultID, vaultPath, storage.NewLocalStorage(vaultPath), dbSvc)
+}
+
+// NewExplorerServiceWithStorage creates a new explorer service that reads the tree through store
+func NewExplorerServiceWithStorage(ctx context.Context, vaultID, vaultPath string, store storage.VaultStorage, dbSvc *db.DBService) (*ExplorerService, error) {
 	if vaultPath == "" {
 		return nil, fmt.Errorf("vault path cannot be empty")
 	}
 
-	// Validate base directory exists
-	if _, err := os.Stat(vaultPath); err != nil {
+	if store == nil {
+		return nil, fmt.Errorf("vault storage cannot be nil")
+	}
+
+	// Validate vault root exists
+	if _, err := store.Stat(ctx, ""); err != nil {
 		return nil, fmt.Errorf("vault path does not exist: %w", err)
 	}
 
@@ -85,6 +100,7 @@ func NewExplorerService(ctx context.Context, vaultID, vaultPath string, dbSvc *d
 		cancel:       cancel,
 		vaultID:      vaultID,
 		vaultPath:    vaultPath,
+		storage:      store,
 		cache:        make(map[string]*TreeNode),
 		eventChan:    make(chan syncpkg.FileChangeEvent, 10000), // Match sync channel size
 		dbService:    dbSvc,
@@ -93,6 +109,11 @@ func NewExplorerService(ctx context.Context, vaultID, vaultPath string, dbSvc *d
 	}, nil
 }
 
+// SetIgnoreRules sets the rules for entries that are left out of the tree
+func (e *ExplorerService) SetIgnoreRules(rules *ignore.Rules) {
+	e.ignore = rules
+}
+
 // Start starts the explorer service
 func (e *ExplorerService) Start() error {
 	logger.WithField("vault_id", e.vaultID).Info("Starting explorer service")
@@ -163,21 +184,19 @@ func (e *ExplorerService) GetFullTree() ([]*TreeNode, error) {
 	logger.WithField("vault_id", e.vaultID).Info("Building full recursive tree")
 
 	// Start from root
-	fullPath := e.vaultPath
-
-	entries, err := os.ReadDir(fullPath)
+	entries, err := e.storage.List(e.ctx, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to read vault root: %w", err)
 	}
 
 	nodes := make([]*TreeNode, 0, len(entries))
 	for _, entry := range entries {
-		// Skip hidden files/directories
-		if strings.HasPrefix(entry.Name(), ".") {
+		// Skip hidden and ignored files/directories
+		if e.skipEntry(entry) {
 			continue
 		}
 
-		childPath := entry.Name()
+		childPath := entry.Name
 		node, err := e.buildFullTreeNode(childPath)
 		if err != nil {
 			logger.WithError(err).WithFields(map[string]interface{}{
@@ -200,10 +219,8 @@ func (e *ExplorerService) GetFullTree() ([]*TreeNode, error) {
 
 // buildFullTreeNode recursively builds a tree node with all its children
 func (e *ExplorerService) buildFullTreeNode(relativePath string) (*TreeNode, error) {
-	fullPath := e.buildFullPath(relativePath)
-
 	// Get metadata for the node
-	metadata, err := e.getNodeMetadata(fullPath, relativePath)
+	metadata, err := e.getNodeMetadata(relativePath)
 	if err != nil {
 		return nil, err
 	}
@@ -216,7 +233,7 @@ func (e *ExplorerService) buildFullTreeNode(relativePath string) (*TreeNode, err
 
 	// If directory, recursively load all children
 	if metadata.Type == NodeTypeDirectory {
-		entries, err := os.ReadDir(fullPath)
+		entries, err := e.storage.List(e.ctx, relativePath)
 		if err != nil {
 			logger.WithError(err).WithField("path", relativePath).Warn("Failed to read directory")
 			// Not a fatal error, just return node without children
@@ -225,12 +242,12 @@ func (e *ExplorerService) buildFullTreeNode(relativePath string) (*TreeNode, err
 
 		children := make([]*TreeNode, 0, len(entries))
 		for _, entry := range entries {
-			// Skip hidden files/directories
-			if strings.HasPrefix(entry.Name(), ".") {
+			// Skip hidden and ignored files/directories
+			if e.skipEntry(entry) {
 				continue
 			}
 
-			childPath := filepath.Join(relativePath, entry.Name())
+			childPath := filepath.Join(relativePath, entry.Name)
 			childNode, err := e.buildFullTreeNode(childPath)
 			if err != nil {
 				logger.WithError(err).WithField("path", childPath).Warn("Failed to build child node")
@@ -276,8 +293,7 @@ func (e *ExplorerService) GetMetadata(path string) (*NodeMetadata, error) {
 		return nil, err
 	}
 
-	fullPath := e.buildFullPath(cleanPath)
-	return e.getNodeMetadata(fullPath, cleanPath)
+	return e.getNodeMetadata(cleanPath)
 }
 
 // InvalidateCacheSync invalidates cache for a file change SYNCHRONOUSLY
@@ -348,10 +364,8 @@ func (e *ExplorerService) buildFullPath(relativePath string) string {
 
 // scanDirectory scans a directory and creates a TreeNode
 func (e *ExplorerService) scanDirectory(relativePath string) (*TreeNode, error) {
-	fullPath := e.buildFullPath(relativePath)
-
 	// Get metadata for the node itself
-	metadata, err := e.getNodeMetadata(fullPath, relativePath)
+	metadata, err := e.getNodeMetadata(relativePath)
 	if err != nil {
 		return nil, err
 	}
@@ -371,24 +385,21 @@ func (e *ExplorerService) loadChildren(node *TreeNode) error {
 		return fmt.Errorf("cannot load children of non-directory")
 	}
 
-	fullPath := e.buildFullPath(node.Metadata.Path)
-
-	entries, err := os.ReadDir(fullPath)
+	entries, err := e.storage.List(e.ctx, node.Metadata.Path)
 	if err != nil {
 		return fmt.Errorf("failed to read directory: %w", err)
 	}
 
 	children := make([]*TreeNode, 0, len(entries))
 	for _, entry := range entries {
-		// Skip hidden files/directories
-		if strings.HasPrefix(entry.Name(), ".") {
+		// Skip hidden and ignored files/directories
+		if e.skipEntry(entry) {
 			continue
 		}
 
-		childPath := filepath.Join(node.Metadata.Path, entry.Name())
-		childFullPath := e.buildFullPath(childPath)
+		childPath := filepath.Join(node.Metadata.Path, entry.Name)
 
-		metadata, err := e.getNodeMetadata(childFullPath, childPath)
+		metadata, err := e.getNodeMetadata(childPath)
 		if err != nil {
 			logger.WithError(err).WithFields(map[string]interface{}{
 				"vault_id": e.vaultID,
@@ -415,25 +426,30 @@ func (e *ExplorerService) loadChildren(node *TreeNode) error {
 }
 
 // getNodeMetadata gets metadata for a file or directory
-func (e *ExplorerService) getNodeMetadata(fullPath, relativePath string) (*NodeMetadata, error) {
-	info, err := os.Stat(fullPath)
+func (e *ExplorerService) getNodeMetadata(relativePath string) (*NodeMetadata, error) {
+	info, err := e.storage.Stat(e.ctx, relativePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat path: %w", err)
 	}
 
+	name := info.Name
+	if relativePath == "" {
+		name = filepath.Base(e.vaultPath)
+	}
+
 	nodeType := NodeTypeFile
 	hasChildren := false
 	childCount := 0
 
-	if info.IsDir() {
+	if info.IsDir {
 		nodeType = NodeTypeDirectory
 
 		// Check if directory has children (quick check)
-		entries, err := os.ReadDir(fullPath)
+		entries, err := e.storage.List(e.ctx, relativePath)
 		if err == nil {
-			// Count non-hidden entries
+			// Count entries that are shown
 			for _, entry := range entries {
-				if !strings.HasPrefix(entry.Name(), ".") {
+				if !e.skipEntry(entry) {
 					childCount++
 				}
 			}
@@ -443,19 +459,20 @@ func (e *ExplorerService) getNodeMetadata(fullPath, relativePath string) (*NodeM
 
 	isMarkdown := false
 	if nodeType == NodeTypeFile {
-		isMarkdown = strings.HasSuffix(strings.ToLower(info.Name()), ".md")
+		isMarkdown = strings.HasSuffix(strings.ToLower(name), ".md")
 	}
 
 	isDirectory := nodeType == NodeTypeDirectory
 
 	// Fetch ID from database if available and check if file is ACTIVE using optimized query
-	id := ""
+	id, contentHash := "", ""
 	if e.dbService != nil {
 		// Try to get entry with ACTIVE status
 		entry, err := e.dbService.GetFileEntryByPathWithStatus(relativePath, db.FileStatusActive)
 		if err == nil && entry != nil {
 			// Found and ACTIVE
 			id = entry.ID
+			contentHash = entry.ContentHash
 		} else if err != nil {
 			// Error occurred - could be not found or DB error
 			// Check if file exists in DB at all (without status check)
@@ -472,18 +489,24 @@ func (e *ExplorerService) getNodeMetadata(fullPath, relativePath string) (*NodeM
 	return &NodeMetadata{
 		ID:          id,
 		Path:        relativePath,
-		Name:        info.Name(),
+		Name:        name,
 		Type:        nodeType,
 		IsDirectory: isDirectory,
-		Size:        info.Size(),
-		ModTime:     info.ModTime(),
+		Size:        info.Size,
+		ModTime:     info.ModTime,
 		IsMarkdown:  isMarkdown,
 		HasChildren: hasChildren,
 		ChildCount:  childCount,
+		ContentHash: contentHash,
 		CachedAt:    time.Now(),
 	}, nil
 }
 
+// skipEntry reports whether a directory entry is hidden or ignored
+func (e *ExplorerService) skipEntry(entry storage.FileInfo) bool {
+	return strings.HasPrefix(entry.Name, ".") || e.ignore.Ignored(entry.Path, entry.IsDir)
+}
+
 // updateCache updates the cache with a node
 func (e *ExplorerService) updateCache(path string, node *TreeNode) {
 	e.cacheMu.Lock()
@@ -516,6 +539,24 @@ func (e *ExplorerService) invalidateCache(path string) {
 	}).Debug("Invalidated cache entry")
 }
 
+// invalidateTree removes a path and every cached path below it
+func (e *ExplorerService) invalidateTree(path string) {
+	e.cacheMu.Lock()
+	defer e.cacheMu.Unlock()
+
+	prefix := path + string(filepath.Separator)
+	for cached := range e.cache {
+		if cached == path || strings.HasPrefix(cached, prefix) {
+			delete(e.cache, cached)
+		}
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"vault_id": e.vaultID,
+		"path":     path,
+	}).Debug("Invalidated cache subtree")
+}
+
 // invalidateParent invalidates the parent directory cache
 func (e *ExplorerService) invalidateParent(path string) {
 	parentPath := filepath.Dir(path)
@@ -655,6 +696,25 @@ func (e *ExplorerService) handleFileEvent(event syncpkg.FileChangeEvent) {
 		if parent, err := e.GetTree(parentPath); err == nil && parent != nil {
 			e.updateCache(parentPath, parent)
 		}
+
+	case syncpkg.FileMoved:
+		oldRelPath, err := filepath.Rel(e.vaultPath, event.OldPath)
+		if err != nil {
+			logger.WithError(err).WithField("vault_id", e.vaultID).Warn("Failed to get old relative path")
+			return
+		}
+		// Drop the old node and, for directories, everything cached below it
+		e.invalidateTree(oldRelPath)
+		e.invalidateParent(oldRelPath)
+		// Both parents' child lists changed; refresh the destination
+		parentPath = filepath.Dir(relPath)
+		if parentPath == "." {
+			parentPath = ""
+		}
+		e.invalidateCache(parentPath)
+		if parent, err := e.GetTree(parentPath); err == nil && parent != nil {
+			e.updateCache(parentPath, parent)
+		}
 	}
 
 	// NOTE: SSE broadcasting is disabled here to prevent duplicate events.
@@ -669,7 +729,7 @@ func (e *ExplorerService) handleFileEvent(event syncpkg.FileChangeEvent) {
 
 // buildFileEventData constructs rich metadata for SSE events (legacy interface{} version)
 func (e *ExplorerService) buildFileEventData(relativePath, parentPath, fullPath string) interface{} {
-	info, err := os.Stat(fullPath)
+	info, err := e.storage.Stat(e.ctx, relativePath)
 	if err != nil {
 		// File may have been deleted, return minimal data
 		return map[string]interface{}{
@@ -679,17 +739,17 @@ func (e *ExplorerService) buildFileEventData(relativePath, parentPath, fullPath
 	}
 
 	isMarkdown := false
-	if !info.IsDir() {
-		isMarkdown = strings.HasSuffix(strings.ToLower(info.Name()), ".md")
+	if !info.IsDir {
+		isMarkdown = strings.HasSuffix(strings.ToLower(info.Name), ".md")
 	}
 
 	return map[string]interface{}{
-		"name":        info.Name(),
-		"is_dir":      info.IsDir(),
+		"name":        info.Name,
+		"is_dir":      info.IsDir,
 		"is_markdown": isMarkdown,
 		"parent_path": parentPath,
-		"size":        info.Size(),
-		"mod_time":    info.ModTime().Unix(),
+		"size":        info.Size,
+		"mod_time":    info.ModTime.Unix(),
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/ignore"
 	syncpkg "github.com/susamn/obsidian-web/internal/sync"
 )
 
@@ -290,6 +291,36 @@ func TestGetChildren(t *testing.T) {
 	}
 }
 
+func TestGetChildren_IgnoreRules(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	ctx := context.Background()
+
+	svc, err := NewExplorerService(ctx, "test-vault", tmpDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to create explorer service: %v", err)
+	}
+	defer svc.Stop()
+	svc.SetIgnoreRules(ignore.NewRules([]string{"folder2/", "*.txt", "subfolder1/"}))
+
+	children, err := svc.GetChildren("")
+	if err != nil {
+		t.Fatalf("Failed to get children: %v", err)
+	}
+	for _, child := range children {
+		if child.Metadata.Name == "folder2" || child.Metadata.Name == "file2.txt" {
+			t.Errorf("Ignored entry %s should not appear in children", child.Metadata.Name)
+		}
+	}
+
+	meta, err := svc.GetMetadata("folder1")
+	if err != nil {
+		t.Fatalf("Failed to get metadata: %v", err)
+	}
+	if meta.ChildCount != 1 {
+		t.Errorf("folder1 child count = %d, want 1 (subfolder1 is ignored)", meta.ChildCount)
+	}
+}
+
 func TestGetChildren_Nested(t *testing.T) {
 	tmpDir := setupTestDir(t)
 	ctx := context.Background()
@@ -1058,6 +1089,61 @@ func TestFileDeletedUpdatesParentCache(t *testing.T) {
 	}
 }
 
+// TestFileMovedUpdatesCache tests that a directory move drops the old subtree and refreshes the destination
+func TestFileMovedUpdatesCache(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	ctx := context.Background()
+
+	svc, err := NewExplorerService(ctx, "test-vault", tmpDir, nil)
+	if err != nil {
+		t.Fatalf("Failed to create explorer service: %v", err)
+	}
+	defer svc.Stop()
+
+	for _, path := range []string{"", "folder1", filepath.Join("folder1", "subfolder1"), "folder2"} {
+		if _, err := svc.GetTree(path); err != nil {
+			t.Fatalf("Failed to get tree %q: %v", path, err)
+		}
+	}
+
+	oldPath := filepath.Join(tmpDir, "folder1")
+	newPath := filepath.Join(tmpDir, "folder2", "folder1")
+	if err := os.Rename(oldPath, newPath); err != nil {
+		t.Fatalf("Failed to move directory: %v", err)
+	}
+
+	svc.InvalidateCacheSync(syncpkg.FileChangeEvent{
+		VaultID:   "test-vault",
+		Path:      newPath,
+		OldPath:   oldPath,
+		EventType: syncpkg.FileMoved,
+		Timestamp: time.Now(),
+	})
+
+	svc.cacheMu.RLock()
+	defer svc.cacheMu.RUnlock()
+
+	for _, stale := range []string{"", "folder1", filepath.Join("folder1", "subfolder1")} {
+		if _, exists := svc.cache[stale]; exists {
+			t.Errorf("Expected cache entry %q to be invalidated", stale)
+		}
+	}
+
+	folder2, exists := svc.cache["folder2"]
+	if !exists {
+		t.Fatal("Expected destination parent to be re-cached")
+	}
+	found := false
+	for _, child := range folder2.Children {
+		if child.Metadata.Name == "folder1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected moved directory in destination children")
+	}
+}
+
 // TestFileModifiedInvalidatesFileCache tests that file modification invalidates file cache
 func TestFileModifiedInvalidatesFileCache(t *testing.T) {
 	tmpDir := setupTestDir(t)
@@ -0,0 +1,89 @@
+// Package gitrepotest provides a local bare repository acting as a git remote for tests
+package gitrepotest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Remote is a bare repository with a scratch clone used to push commits to it
+type Remote struct {
+	URL string // Path of the bare repository, usable as a clone URL
+
+	t       *testing.T
+	scratch string
+}
+
+// NewRemote creates a bare repository on branch main seeded with files, and
+// skips the test when git is not installed
+func NewRemote(t *testing.T, files map[string]string) *Remote {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git executable not found")
+	}
+
+	root := t.TempDir()
+	r := &Remote{
+		URL:     filepath.Join(root, "remote.git"),
+		t:       t,
+		scratch: filepath.Join(root, "scratch"),
+	}
+
+	r.git(root, "init", "--quiet", "--bare", "--initial-branch=main", r.URL)
+	r.git(root, "init", "--quiet", "--initial-branch=main", r.scratch)
+	r.git(r.scratch, "remote", "add", "origin", r.URL)
+
+	if files == nil {
+		files = map[string]string{"README.md": "# Vault\n"}
+	}
+	r.Push("Initial commit", files)
+
+	return r
+}
+
+// Push commits the given files (content "" deletes the file) and pushes them to the remote
+func (r *Remote) Push(message string, files map[string]string) {
+	r.t.Helper()
+
+	for path, content := range files {
+		full := filepath.Join(r.scratch, filepath.FromSlash(path))
+		if content == "" {
+			if err := os.Remove(full); err != nil {
+				r.t.Fatalf("remove %s: %v", path, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			r.t.Fatalf("mkdir for %s: %v", path, err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			r.t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	r.git(r.scratch, "add", "--all")
+	r.git(r.scratch, "commit", "--quiet", "-m", message)
+	r.git(r.scratch, "push", "--quiet", "origin", "main")
+}
+
+// git runs a git command in dir with a fixed identity and fails the test on error
+func (r *Remote) git(dir string, args ...string) string {
+	r.t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Remote",
+		"GIT_AUTHOR_EMAIL=remote@example.com",
+		"GIT_COMMITTER_NAME=Remote",
+		"GIT_COMMITTER_EMAIL=remote@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
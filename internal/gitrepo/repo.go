@@ -0,0 +1,327 @@
+package gitrepo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/susamn/obsidian-web/internal/config"
+)
+
+const (
+	// remoteName is the remote pulled from; it is the one git clone creates
+	remoteName = "origin"
+
+	defaultAuthorName  = "obsidian-web"
+	defaultAuthorEmail = "obsidian-web@localhost"
+)
+
+// ChangeType describes how a file changed between two commits
+type ChangeType int
+
+const (
+	ChangeAdded ChangeType = iota
+	ChangeModified
+	ChangeDeleted
+	ChangeRenamed
+)
+
+// String returns the string representation of ChangeType
+func (c ChangeType) String() string {
+	switch c {
+	case ChangeAdded:
+		return "added"
+	case ChangeModified:
+		return "modified"
+	case ChangeDeleted:
+		return "deleted"
+	case ChangeRenamed:
+		return "renamed"
+	default:
+		return "unknown"
+	}
+}
+
+// Change is a single file changed between two commits
+type Change struct {
+	Path    string // Slash path relative to the repository root
+	OldPath string // Previous path, set only for ChangeRenamed
+	Type    ChangeType
+}
+
+// ConflictError is returned by Pull when remote changes could not be merged.
+// The merge is aborted, so the working tree stays at the local commit.
+type ConflictError struct {
+	Paths []string // Slash paths with conflicting changes
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("merge conflict in %d file(s): %s", len(e.Paths), strings.Join(e.Paths, ", "))
+}
+
+// Repo is a git working copy driven through the git command line.
+// All commands on a repository are serialized, so storage writes and
+// background pulls never race on the index lock.
+type Repo struct {
+	dir         string
+	branch      string
+	hasRemote   bool
+	authorName  string
+	authorEmail string
+	mu          sync.Mutex
+}
+
+// repos holds one Repo per working directory so every user shares its lock
+var (
+	repos   = make(map[string]*Repo)
+	reposMu sync.Mutex
+)
+
+// Open returns the repository at cfg.Path, cloning cfg.URL into it first
+// when the directory does not contain a repository yet
+func Open(ctx context.Context, cfg *config.GitStorageConfig) (*Repo, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("git config cannot be nil")
+	}
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("git path cannot be empty")
+	}
+
+	dir, err := filepath.Abs(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid git path: %w", err)
+	}
+
+	reposMu.Lock()
+	defer reposMu.Unlock()
+
+	if repo, ok := repos[dir]; ok {
+		return repo, nil
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git executable not found: %w", err)
+	}
+
+	repo := &Repo{
+		dir:         dir,
+		branch:      cfg.Branch,
+		authorName:  cfg.AuthorName,
+		authorEmail: cfg.AuthorEmail,
+	}
+	if repo.authorName == "" {
+		repo.authorName = defaultAuthorName
+	}
+	if repo.authorEmail == "" {
+		repo.authorEmail = defaultAuthorEmail
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("no git repository at %s and no url configured to clone", dir)
+		}
+		if err := repo.clone(ctx, cfg.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	if repo.branch == "" {
+		branch, err := repo.run(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine branch: %w", err)
+		}
+		repo.branch = branch
+	}
+
+	remotes, err := repo.run(ctx, "remote")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	for _, name := range strings.Fields(remotes) {
+		if name == remoteName {
+			repo.hasRemote = true
+		}
+	}
+
+	repos[dir] = repo
+	return repo, nil
+}
+
+// clone clones url into the repository directory
+func (r *Repo) clone(ctx context.Context, url string) error {
+	if err := os.MkdirAll(filepath.Dir(r.dir), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	args := []string{"clone"}
+	if r.branch != "" {
+		args = append(args, "--branch", r.branch)
+	}
+	args = append(args, url, r.dir)
+
+	if _, err := r.runIn(ctx, filepath.Dir(r.dir), args...); err != nil {
+		return fmt.Errorf("failed to clone %s: %w", url, err)
+	}
+	return nil
+}
+
+// Dir returns the working directory of the repository
+func (r *Repo) Dir() string {
+	return r.dir
+}
+
+// Branch returns the branch that is pulled
+func (r *Repo) Branch() string {
+	return r.branch
+}
+
+// Head returns the commit hash of HEAD
+func (r *Repo) Head(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.head(ctx)
+}
+
+func (r *Repo) head(ctx context.Context) (string, error) {
+	return r.run(ctx, "rev-parse", "HEAD")
+}
+
+// Pull fetches the tracked branch and merges it into the working tree.
+// It returns HEAD before and after the merge; they are equal when nothing changed.
+// A repository without an origin remote is never pulled.
+func (r *Repo) Pull(ctx context.Context) (from, to string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	from, err = r.head(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if !r.hasRemote {
+		return from, from, nil
+	}
+
+	if _, err := r.run(ctx, "fetch", remoteName, r.branch); err != nil {
+		return from, from, fmt.Errorf("failed to fetch: %w", err)
+	}
+
+	if _, mergeErr := r.run(ctx, "merge", "--no-edit", "FETCH_HEAD"); mergeErr != nil {
+		unmerged, err := r.run(ctx, "diff", "--name-only", "--diff-filter=U")
+		if err != nil || unmerged == "" {
+			return from, from, fmt.Errorf("failed to merge: %w", mergeErr)
+		}
+
+		if _, err := r.run(ctx, "merge", "--abort"); err != nil {
+			return from, from, fmt.Errorf("failed to abort conflicting merge: %w", err)
+		}
+		return from, from, &ConflictError{Paths: strings.Split(unmerged, "\n")}
+	}
+
+	to, err = r.head(ctx)
+	if err != nil {
+		return from, from, err
+	}
+	return from, to, nil
+}
+
+// Changes lists the files that differ between two commits
+func (r *Repo) Changes(ctx context.Context, from, to string) ([]Change, error) {
+	if from == to {
+		return nil, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// -z keeps paths with special characters unquoted: status NUL path NUL ...
+	// Renames carry two paths: R<score> NUL old NUL new
+	out, err := r.run(ctx, "diff", "--name-status", "--find-renames", "-z", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s..%s: %w", from, to, err)
+	}
+
+	fields := strings.Split(strings.TrimRight(out, "\x00"), "\x00")
+	changes := make([]Change, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		change := Change{Path: fields[i+1]}
+		switch status := fields[i]; {
+		case status == "A":
+			change.Type = ChangeAdded
+		case status == "D":
+			change.Type = ChangeDeleted
+		case strings.HasPrefix(status, "R") && i+2 < len(fields):
+			change.Type = ChangeRenamed
+			change.OldPath = fields[i+1]
+			change.Path = fields[i+2]
+			i++
+		default:
+			// M, T (type change) and anything else is reported as a modification
+			change.Type = ChangeModified
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// Commit stages every change in the working tree and commits it with the configured author.
+// It does nothing when the working tree is clean.
+func (r *Repo) Commit(ctx context.Context, message string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.run(ctx, "add", "--all"); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+
+	staged, err := r.run(ctx, "diff", "--cached", "--name-only")
+	if err != nil {
+		return fmt.Errorf("failed to list staged changes: %w", err)
+	}
+	if staged == "" {
+		return nil
+	}
+
+	if _, err := r.run(ctx, "commit", "--quiet", "-m", message); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// run executes a git command in the repository directory and returns its trimmed stdout
+func (r *Repo) run(ctx context.Context, args ...string) (string, error) {
+	return r.runIn(ctx, r.dir, args...)
+}
+
+// runIn executes a git command in dir with the repository's identity
+func (r *Repo) runIn(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"LC_ALL=C",
+		"GIT_AUTHOR_NAME="+r.authorName,
+		"GIT_AUTHOR_EMAIL="+r.authorEmail,
+		"GIT_COMMITTER_NAME="+r.authorName,
+		"GIT_COMMITTER_EMAIL="+r.authorEmail,
+	)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,172 @@
+package gitrepo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/gitrepo/gitrepotest"
+)
+
+func openClone(t *testing.T, remote *gitrepotest.Remote) *Repo {
+	t.Helper()
+	repo, err := Open(context.Background(), &config.GitStorageConfig{
+		Path:        filepath.Join(t.TempDir(), "vault"),
+		URL:         remote.URL,
+		AuthorName:  "Vault Bot",
+		AuthorEmail: "bot@example.com",
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return repo
+}
+
+func TestOpen_ClonesAndReusesRepo(t *testing.T) {
+	remote := gitrepotest.NewRemote(t, map[string]string{"note.md": "# Note\n"})
+	repo := openClone(t, remote)
+
+	if repo.Branch() != "main" {
+		t.Errorf("Branch() = %q, want main", repo.Branch())
+	}
+	if _, err := os.Stat(filepath.Join(repo.Dir(), "note.md")); err != nil {
+		t.Errorf("cloned file missing: %v", err)
+	}
+
+	again, err := Open(context.Background(), &config.GitStorageConfig{Path: repo.Dir()})
+	if err != nil {
+		t.Fatalf("Open(existing) error = %v", err)
+	}
+	if again != repo {
+		t.Error("Open() on the same directory should return the shared repo")
+	}
+}
+
+func TestOpen_RequiresURLWithoutRepository(t *testing.T) {
+	gitrepotest.NewRemote(t, nil) // Skips when git is missing
+
+	_, err := Open(context.Background(), &config.GitStorageConfig{Path: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "no url configured") {
+		t.Errorf("Open() error = %v, want missing url error", err)
+	}
+}
+
+func TestPull_ReportsChanges(t *testing.T) {
+	remote := gitrepotest.NewRemote(t, map[string]string{
+		"keep.md":  "a",
+		"gone.md":  "b",
+		"moved.md": "# Moved\n\nSame content after the move\n",
+	})
+	repo := openClone(t, remote)
+	ctx := context.Background()
+
+	remote.Push("Remote edits", map[string]string{
+		"keep.md":          "changed",
+		"gone.md":          "",
+		"new dir/new.md":   "new",
+		"moved.md":         "",
+		"archive/moved.md": "# Moved\n\nSame content after the move\n",
+	})
+
+	from, to, err := repo.Pull(ctx)
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if from == to {
+		t.Fatal("Pull() should move HEAD")
+	}
+
+	changes, err := repo.Changes(ctx, from, to)
+	if err != nil {
+		t.Fatalf("Changes() error = %v", err)
+	}
+	got := make(map[string]ChangeType)
+	for _, c := range changes {
+		got[c.Path] = c.Type
+		if c.Type == ChangeRenamed && c.OldPath != "moved.md" {
+			t.Errorf("rename of %s has OldPath %q, want moved.md", c.Path, c.OldPath)
+		}
+	}
+	want := map[string]ChangeType{
+		"keep.md":          ChangeModified,
+		"gone.md":          ChangeDeleted,
+		"new dir/new.md":   ChangeAdded,
+		"archive/moved.md": ChangeRenamed,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes() = %v, want %v", got, want)
+	}
+
+	// Nothing new on the remote
+	from, to, err = repo.Pull(ctx)
+	if err != nil || from != to {
+		t.Errorf("second Pull() = %s..%s, %v; want no change", from, to, err)
+	}
+}
+
+func TestCommit_UsesAuthorAndSkipsCleanTree(t *testing.T) {
+	remote := gitrepotest.NewRemote(t, nil)
+	repo := openClone(t, remote)
+	ctx := context.Background()
+
+	before, _ := repo.Head(ctx)
+	if err := repo.Commit(ctx, "nothing"); err != nil {
+		t.Fatalf("Commit(clean) error = %v", err)
+	}
+	if head, _ := repo.Head(ctx); head != before {
+		t.Error("Commit() on a clean tree should not create a commit")
+	}
+
+	if err := os.WriteFile(filepath.Join(repo.Dir(), "api.md"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Commit(ctx, "create api.md"); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	out, err := repo.run(ctx, "log", "-1", "--format=%an <%ae>|%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "Vault Bot <bot@example.com>|create api.md" {
+		t.Errorf("last commit = %q", out)
+	}
+}
+
+func TestPull_ConflictAbortsMerge(t *testing.T) {
+	remote := gitrepotest.NewRemote(t, map[string]string{"shared.md": "base\n"})
+	repo := openClone(t, remote)
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(repo.Dir(), "shared.md"), []byte("local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Commit(ctx, "local edit"); err != nil {
+		t.Fatal(err)
+	}
+	remote.Push("remote edit", map[string]string{"shared.md": "remote\n"})
+
+	before, _ := repo.Head(ctx)
+	_, _, err := repo.Pull(ctx)
+
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Pull() error = %v, want ConflictError", err)
+	}
+	if !reflect.DeepEqual(conflict.Paths, []string{"shared.md"}) {
+		t.Errorf("conflict paths = %v", conflict.Paths)
+	}
+
+	if head, _ := repo.Head(ctx); head != before {
+		t.Error("conflicting pull should leave HEAD untouched")
+	}
+	data, _ := os.ReadFile(filepath.Join(repo.Dir(), "shared.md"))
+	if string(data) != "local\n" {
+		t.Errorf("working tree after aborted merge = %q, want local content", data)
+	}
+}
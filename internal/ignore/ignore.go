@@ -0,0 +1,251 @@
+// Package ignore decides which vault paths are left out of sync, the explorer
+// and the search index, using gitignore pattern syntax and an optional
+// allowlist of file types.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/susamn/obsidian-web/internal/storage"
+)
+
+// FileName is the ignore file read from the vault root
+const FileName = ".obsidianignore"
+
+// rule is a single parsed pattern line
+type rule struct {
+	segments []string // Slash-separated pattern parts; "**" matches any number of parts
+	negate   bool     // "!pattern" re-includes a previously ignored path
+	dirOnly  bool     // "pattern/" only matches directories
+}
+
+// Matcher is an immutable set of gitignore rules
+type Matcher struct {
+	rules []rule
+}
+
+// Parse builds a matcher from gitignore-style lines.
+// Blank lines and "#" comments are skipped; later lines take precedence.
+// Malformed glob patterns never match.
+func Parse(lines []string) *Matcher {
+	m := &Matcher{}
+	for _, line := range lines {
+		if r, ok := parseRule(line); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+	return m
+}
+
+// parseRule converts one pattern line into a rule
+func parseRule(line string) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+
+	// Trailing spaces are dropped unless escaped with a backslash
+	if strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line[:len(line)-2], " ") + " "
+	} else {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	var r rule
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, "\\!"), strings.HasPrefix(line, "\\#"):
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to the vault root;
+	// otherwise it matches at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if !anchored {
+		line = "**/" + line
+	}
+
+	for _, seg := range strings.Split(line, "/") {
+		if seg == "" {
+			continue
+		}
+		// Consecutive "**" are the same as one
+		if seg == "**" && len(r.segments) > 0 && r.segments[len(r.segments)-1] == "**" {
+			continue
+		}
+		r.segments = append(r.segments, seg)
+	}
+	return r, len(r.segments) > 0
+}
+
+// Match reports whether a slash path relative to the vault root is ignored.
+// A path inside an ignored directory is ignored too and cannot be re-included,
+// matching git.
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+	relPath = strings.Trim(relPath, "/")
+	if relPath == "" || relPath == "." {
+		return false
+	}
+
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.matchParts(parts[:i], true) {
+			return true
+		}
+	}
+	return m.matchParts(parts, isDir)
+}
+
+// matchParts applies every rule to one path; the last matching rule decides
+func (m *Matcher) matchParts(parts []string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if ignored == !r.negate {
+			// This rule could not change the outcome
+			continue
+		}
+		if matchSegments(r.segments, parts) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// matchSegments matches pattern parts against path parts
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				// Trailing "/**" matches everything inside, not the directory itself
+				return len(parts) > 0
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], parts[0]); err != nil || !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// Rules is a vault's ignore configuration: the patterns from the vault config
+// followed by the vault's ignore file, which can be reloaded at runtime.
+// A nil *Rules ignores nothing. Safe for concurrent use.
+type Rules struct {
+	configured []string
+
+	mu        sync.RWMutex
+	matcher   *Matcher
+	fileTypes map[string]bool // Allowed lowercase extensions without the dot; nil allows all
+}
+
+// NewRules creates rules from the configured patterns; call Reload to add the ignore file
+func NewRules(patterns []string) *Rules {
+	return &Rules{
+		configured: patterns,
+		matcher:    Parse(patterns),
+	}
+}
+
+// Reload re-reads the ignore file from the vault root. A missing file leaves only the configured patterns.
+func (r *Rules) Reload(ctx context.Context, store storage.VaultStorage) error {
+	data, err := store.Read(ctx, FileName)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+	r.SetFile(data)
+	return nil
+}
+
+// SetFile replaces the ignore file contents the rules are built from
+func (r *Rules) SetFile(data []byte) {
+	lines := append([]string(nil), r.configured...)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	matcher := Parse(lines)
+	r.mu.Lock()
+	r.matcher = matcher
+	r.mu.Unlock()
+}
+
+// SetFileTypes limits tracked files to the given extensions ("png" or ".png").
+// Files with any other extension are ignored; directories are not affected.
+// An empty list tracks every file.
+func (r *Rules) SetFileTypes(extensions []string) {
+	var fileTypes map[string]bool
+	if len(extensions) > 0 {
+		fileTypes = make(map[string]bool, len(extensions))
+		for _, ext := range extensions {
+			fileTypes[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))] = true
+		}
+	}
+
+	r.mu.Lock()
+	r.fileTypes = fileTypes
+	r.mu.Unlock()
+}
+
+// Ignored reports whether a path relative to the vault root is ignored.
+// Either path separator is accepted.
+func (r *Rules) Ignored(relPath string, isDir bool) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	matcher := r.matcher
+	fileTypes := r.fileTypes
+	r.mu.RUnlock()
+
+	if !isDir && fileTypes != nil {
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(filepath.ToSlash(relPath)), "."))
+		if !fileTypes[ext] {
+			return true
+		}
+	}
+	return matcher.Match(filepath.ToSlash(relPath), isDir)
+}
+
+// IsIgnoreFile reports whether a path relative to the vault root is the ignore file
+func IsIgnoreFile(relPath string) bool {
+	return filepath.ToSlash(relPath) == FileName
+}
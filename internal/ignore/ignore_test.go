@@ -0,0 +1,127 @@
+package ignore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/susamn/obsidian-web/internal/storage"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	m := Parse([]string{
+		"# comment",
+		"",
+		"*.tmp",
+		"/build",
+		"drafts/",
+		"docs/**/private.md",
+		"archive/**",
+		"!archive/keep.md",
+		"node_modules/",
+		"!node_modules/pkg.md",
+		"\\#literal.md",
+	})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"note.md", false, false},
+		{"scratch.tmp", false, true},
+		{"deep/nested/scratch.tmp", false, true},
+		{"build", true, true},
+		{"build/out.md", false, true},
+		{"src/build", true, false}, // Anchored to the root
+		{"drafts", true, true},
+		{"drafts", false, false}, // Directory-only pattern
+		{"journal/drafts/idea.md", false, true},
+		{"docs/private.md", false, true}, // "**" matches zero directories
+		{"docs/a/b/private.md", false, true},
+		{"other/private.md", false, false},
+		{"archive", true, false}, // Trailing "/**" matches contents only
+		{"archive/old.md", false, true},
+		{"archive/keep.md", false, false},
+		{"node_modules/pkg.md", false, true}, // Parent directory stays excluded
+		{"#literal.md", false, true},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher_Empty(t *testing.T) {
+	var m *Matcher
+	if m.Match("anything.md", false) {
+		t.Error("nil matcher should ignore nothing")
+	}
+	if Parse(nil).Match("", true) {
+		t.Error("vault root should never be ignored")
+	}
+}
+
+func TestRules_Reload(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewLocalStorage(dir)
+	ctx := context.Background()
+
+	rules := NewRules([]string{"*.tmp"})
+	if err := rules.Reload(ctx, store); err != nil {
+		t.Fatalf("Reload() without file error = %v", err)
+	}
+	if !rules.Ignored("a.tmp", false) || rules.Ignored(filepath.Join("private", "a.md"), false) {
+		t.Fatal("configured patterns not applied")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("private/\n!b.tmp\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := rules.Reload(ctx, store); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if !rules.Ignored(filepath.Join("private", "a.md"), false) {
+		t.Error("ignore file pattern not applied")
+	}
+	if rules.Ignored("b.tmp", false) {
+		t.Error("ignore file should be able to negate configured patterns")
+	}
+
+	var none *Rules
+	if none.Ignored("a.tmp", false) {
+		t.Error("nil rules should ignore nothing")
+	}
+}
+
+func TestRules_SetFileTypes(t *testing.T) {
+	rules := NewRules([]string{"private/"})
+	rules.SetFileTypes([]string{"md", ".PNG"})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"note.md", false, false},
+		{"img/photo.png", false, false},
+		{"img/Photo.PNG", false, false},
+		{"doc.pdf", false, true},
+		{"Makefile", false, true},
+		{"img", true, false},             // Directories are never filtered by type
+		{"private/note.md", false, true}, // Patterns still apply
+	}
+	for _, tt := range tests {
+		if got := rules.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	rules.SetFileTypes(nil)
+	if rules.Ignored("doc.pdf", false) {
+		t.Error("empty file type list should track every file")
+	}
+}
@@ -7,21 +7,42 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
 	"github.com/blevesearch/bleve/v2/mapping"
 	"github.com/susamn/obsidian-web/internal/logger"
 )
 
 type MarkdownDoc struct {
-	ID        string   `json:"id"`   // File ID from database
-	Path      string   `json:"path"` // Relative path from vault root
-	Title     string   `json:"title"`
-	Content   string   `json:"content"`
-	Tags      []string `json:"tags"`
-	Wikilinks []string `json:"wikilinks"` // [[note]], [[note|alias]], [[note#heading]]
-	Metadata  string   `json:"metadata"`  // YAML frontmatter
+	ID        string    `json:"id"`   // File ID from database
+	Path      string    `json:"path"` // Relative path from vault root
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Tags      []string  `json:"tags"`
+	Wikilinks []string  `json:"wikilinks"`  // [[note]], [[note|alias]], [[note#heading]]
+	Metadata  string    `json:"metadata"`   // YAML frontmatter
+	File      string    `json:"file"`       // File name, for file: searches
+	Folder    string    `json:"folder"`     // Top-level folder, or "/" at the vault root, for facets
+	SortTitle string    `json:"sort_title"` // Lowercased title, or file name without extension, for sorting
+	Created   time.Time `json:"created"`
+	Modified  time.Time `json:"modified"`
+	Date      time.Time `json:"date"` // Frontmatter date, if any
+}
+
+// dateLayouts are the frontmatter date formats understood, tried in order;
+// dates without a zone are UTC
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
 }
 
 // Parse markdown file with frontmatter
@@ -49,7 +70,11 @@ func parseMarkdownFileWithID(fullPath string, relPath string, fileID string) (*M
 		return nil, err
 	}
 
-	text := string(content)
+	return parseMarkdownBytes(content, relPath, fileID)
+}
+
+// parseMarkdownBytes parses markdown content that has already been read from vault storage
+func parseMarkdownBytes(content []byte, relPath string, fileID string) (*MarkdownDoc, error) {
 	doc := &MarkdownDoc{
 		ID:        fileID,
 		Path:      relPath,
@@ -57,10 +82,16 @@ func parseMarkdownFileWithID(fullPath string, relPath string, fileID string) (*M
 		Wikilinks: []string{},
 	}
 
-	doc.Content = text
+	doc.Content = string(content)
 	return parseMarkdownContent(doc)
 }
 
+// ParseMarkdown extracts the title, tags, wikilinks and frontmatter from
+// markdown content the same way documents are parsed for the index
+func ParseMarkdown(content []byte, relPath string) (*MarkdownDoc, error) {
+	return parseMarkdownBytes(content, relPath, "")
+}
+
 // parseMarkdownContent parses the markdown content and extracts metadata
 func parseMarkdownContent(doc *MarkdownDoc) (*MarkdownDoc, error) {
 	text := doc.Content
@@ -72,8 +103,9 @@ func parseMarkdownContent(doc *MarkdownDoc) (*MarkdownDoc, error) {
 			doc.Metadata = strings.TrimSpace(parts[1])
 			text = strings.TrimSpace(parts[2])
 
-			// Extract tags from frontmatter
+			// Extract tags and date from frontmatter
 			doc.Tags = extractTags(doc.Metadata)
+			doc.Date = extractDate(doc.Metadata)
 		}
 	}
 
@@ -108,6 +140,18 @@ func parseMarkdownContent(doc *MarkdownDoc) (*MarkdownDoc, error) {
 	// Extract wikilinks from content
 	doc.Wikilinks = extractWikilinks(text)
 
+	// Notes without a heading sort by file name
+	doc.File = filepath.Base(doc.Path)
+	doc.Folder = "/"
+	if folder, _, ok := strings.Cut(filepath.ToSlash(doc.Path), "/"); ok {
+		doc.Folder = folder
+	}
+	sortTitle := doc.Title
+	if sortTitle == "" {
+		sortTitle = strings.TrimSuffix(doc.File, filepath.Ext(doc.File))
+	}
+	doc.SortTitle = strings.ToLower(sortTitle)
+
 	return doc, nil
 }
 
@@ -147,6 +191,25 @@ func extractTags(metadata string) []string {
 	return tags
 }
 
+// Extract the date from YAML frontmatter, or the zero time when there is
+// none or it is not a date
+func extractDate(metadata string) time.Time {
+	for _, line := range strings.Split(metadata, "\n") {
+		value, ok := strings.CutPrefix(line, "date:")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t
+			}
+		}
+		return time.Time{}
+	}
+	return time.Time{}
+}
+
 // Extract inline tags from markdown content (e.g., #tag, #nested/tag)
 // Ignores tags in code blocks and inline code
 func extractInlineTags(content string) []string {
@@ -342,8 +405,21 @@ func extractLinkFromWikilink(content string) string {
 	return content
 }
 
+// lowercaseKeyword indexes a whole value as one lowercased term, so tags,
+// paths and file names match and sort ignoring case
+const lowercaseKeyword = "lowercase_keyword"
+
 // Create index mapping for better search
 func buildIndexMapping() mapping.IndexMapping {
+	indexMapping := bleve.NewIndexMapping()
+	// Built only from registered components, so this cannot fail; if it did,
+	// creating the index would report the missing analyzer
+	_ = indexMapping.AddCustomAnalyzer(lowercaseKeyword, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name},
+	})
+
 	// Create document mapping
 	docMapping := bleve.NewDocumentMapping()
 
@@ -353,9 +429,9 @@ func buildIndexMapping() mapping.IndexMapping {
 	docMapping.AddFieldMappingsAt("content", textFieldMapping)
 	docMapping.AddFieldMappingsAt("metadata", textFieldMapping)
 
-	// Keyword field for exact tag matching
+	// Keyword field for exact tag matching, ignoring case as Obsidian does
 	keywordFieldMapping := bleve.NewTextFieldMapping()
-	keywordFieldMapping.Analyzer = keyword.Name
+	keywordFieldMapping.Analyzer = lowercaseKeyword
 	docMapping.AddFieldMappingsAt("tags", keywordFieldMapping)
 
 	// Keyword field for exact wikilink matching
@@ -369,19 +445,66 @@ func buildIndexMapping() mapping.IndexMapping {
 	idFieldMapping.Index = false
 	docMapping.AddFieldMappingsAt("id", idFieldMapping)
 
-	// Path field - stored but not analyzed
+	// Path and file name - indexed whole for path: and file: searches and sorting
 	pathFieldMapping := bleve.NewTextFieldMapping()
+	pathFieldMapping.Analyzer = lowercaseKeyword
 	pathFieldMapping.Store = true
-	pathFieldMapping.Index = false
+	pathFieldMapping.IncludeInAll = false
 	docMapping.AddFieldMappingsAt("path", pathFieldMapping)
 
-	indexMapping := bleve.NewIndexMapping()
+	fileFieldMapping := bleve.NewTextFieldMapping()
+	fileFieldMapping.Analyzer = lowercaseKeyword
+	fileFieldMapping.Store = false
+	fileFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("file", fileFieldMapping)
+
+	// Top-level folder - indexed whole for facets
+	folderFieldMapping := bleve.NewTextFieldMapping()
+	folderFieldMapping.Analyzer = keyword.Name
+	folderFieldMapping.Store = false
+	folderFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("folder", folderFieldMapping)
+
+	// Sort title - indexed whole for sorting only
+	sortTitleFieldMapping := bleve.NewTextFieldMapping()
+	sortTitleFieldMapping.Analyzer = keyword.Name
+	sortTitleFieldMapping.Store = false
+	sortTitleFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("sort_title", sortTitleFieldMapping)
+
+	// Created, modified and frontmatter dates - stored and indexed for
+	// sorting and date ranges
+	timeFieldMapping := bleve.NewDateTimeFieldMapping()
+	timeFieldMapping.Store = true
+	timeFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("created", timeFieldMapping)
+	docMapping.AddFieldMappingsAt("modified", timeFieldMapping)
+	docMapping.AddFieldMappingsAt("date", timeFieldMapping)
+
 	indexMapping.AddDocumentMapping("markdown", docMapping)
 	indexMapping.DefaultMapping = docMapping
 
 	return indexMapping
 }
 
+// hasCurrentMapping reports whether an index was created with the fields
+// buildIndexMapping adds, as older indexes cannot be sorted by title,
+// searched by file name or frontmatter date, counted by folder or searched
+// by tag ignoring case
+func hasCurrentMapping(index bleve.Index) bool {
+	impl, ok := index.Mapping().(*mapping.IndexMappingImpl)
+	if !ok || impl.DefaultMapping == nil {
+		return false
+	}
+	for _, field := range []string{"sort_title", "file", "folder", "date"} {
+		if _, ok := impl.DefaultMapping.Properties[field]; !ok {
+			return false
+		}
+	}
+	tags, ok := impl.DefaultMapping.Properties["tags"]
+	return ok && len(tags.Fields) > 0 && tags.Fields[0].Analyzer == lowercaseKeyword
+}
+
 // IndexMarkdownFiles indexes all markdown files from docsPath into the bleve index
 func IndexMarkdownFiles(indexPath, docsPath string) (bleve.Index, error) {
 	var index bleve.Index
@@ -3,9 +3,13 @@ package indexing
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/mapping"
 )
 
 func TestParseMarkdownFile(t *testing.T) {
@@ -337,6 +341,30 @@ author: John`,
 	}
 }
 
+func TestExtractDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		want     time.Time
+	}{
+		{"date", "title: Test\ndate: 2024-05-01", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"quoted", `date: "2024-05-01"`, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{"date and time", "date: 2024-05-01 14:30", time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)},
+		{"with zone", "date: 2024-05-01T14:30:00+02:00", time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)},
+		{"not a date", "date: tomorrow", time.Time{}},
+		{"other key", "updated: 2024-05-01", time.Time{}},
+		{"no date", "title: Test", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractDate(tt.metadata); !got.Equal(tt.want) {
+				t.Errorf("extractDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestBuildIndexMapping(t *testing.T) {
 	mapping := buildIndexMapping()
 	if mapping == nil {
@@ -887,3 +915,84 @@ func TestParseMarkdownFileWithWikilinks(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractLinks(t *testing.T) {
+	content := "---\nrelated: \"[[In Frontmatter]]\"\n---\n" +
+		"# Title\n" +
+		"See [[Note A]] and [[folder/Note B#Setup|the setup]].\n" +
+		"![[diagram.png]] then [[#Local heading]] and [[Note A#^block1]]\n" +
+		"```\n[[In Code]]\n```\n" +
+		"Inline `[[In Inline Code]]` and [[ ]] and [[Unclosed"
+
+	want := []Link{
+		{Raw: "Note A", Target: "Note A", Line: 5},
+		{Raw: "folder/Note B#Setup", Target: "folder/Note B", Heading: "Setup", Alias: "the setup", Line: 5},
+		{Raw: "diagram.png", Target: "diagram.png", Embed: true, Line: 6},
+		{Raw: "#Local heading", Heading: "Local heading", Line: 6},
+		{Raw: "Note A#^block1", Target: "Note A", Heading: "^block1", Line: 6},
+	}
+
+	got := ExtractLinks(content)
+	if len(got) != len(want) {
+		t.Fatalf("ExtractLinks() returned %d links, want %d: %+v", len(got), len(want), got)
+	}
+	lines := strings.Split(content, "\n")
+	for i := range want {
+		want[i].Context = strings.TrimSpace(lines[want[i].Line-1])
+		if got[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseMarkdownDerivedFields(t *testing.T) {
+	tests := []struct {
+		content, path           string
+		sortTitle, file, folder string
+	}{
+		{"# Weekly Review\n", filepath.Join("work", "notes", "review.md"), "weekly review", "review.md", "work"},
+		{"no heading\n", "Inbox Item.md", "inbox item", "Inbox Item.md", "/"},
+	}
+	for _, tt := range tests {
+		doc, err := ParseMarkdown([]byte(tt.content), tt.path)
+		if err != nil {
+			t.Fatalf("ParseMarkdown(%q) error = %v", tt.path, err)
+		}
+		if doc.SortTitle != tt.sortTitle || doc.File != tt.file || doc.Folder != tt.folder {
+			t.Errorf("ParseMarkdown(%q) = sort title %q, file %q, folder %q; want %q, %q, %q",
+				tt.path, doc.SortTitle, doc.File, doc.Folder, tt.sortTitle, tt.file, tt.folder)
+		}
+	}
+}
+
+func TestHasCurrentMapping(t *testing.T) {
+	current, err := bleve.NewMemOnly(buildIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer current.Close()
+	if !hasCurrentMapping(current) {
+		t.Error("hasCurrentMapping() = false for an index built with buildIndexMapping")
+	}
+
+	outdated, err := bleve.NewMemOnly(bleve.NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outdated.Close()
+	if hasCurrentMapping(outdated) {
+		t.Error("hasCurrentMapping() = true for an index without the sort and facet fields")
+	}
+
+	// Tags used to be indexed keeping their case
+	caseSensitive := buildIndexMapping().(*mapping.IndexMappingImpl)
+	caseSensitive.DefaultMapping.Properties["tags"].Fields[0].Analyzer = keyword.Name
+	oldTags, err := bleve.NewMemOnly(caseSensitive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer oldTags.Close()
+	if hasCurrentMapping(oldTags) {
+		t.Error("hasCurrentMapping() = true for an index with case-sensitive tags")
+	}
+}
@@ -0,0 +1,92 @@
+package indexing
+
+import (
+	"strings"
+)
+
+// Link is a wikilink or embed found in a note
+type Link struct {
+	Raw     string // Text between the brackets, without the alias
+	Target  string // Note or file linked to; empty for a heading in the same note
+	Heading string // Heading anchor, or ^id for a block reference
+	Alias   string
+	Embed   bool
+	Line    int    // 1-based line in the file
+	Context string // The line holding the link, trimmed
+}
+
+// ExtractLinks returns every wikilink ([[note]], [[note#heading|alias]]) and
+// embed (![[file]]) in markdown content in order, including repeats. Links in
+// frontmatter, code blocks and inline code are skipped, as in extractWikilinks.
+func ExtractLinks(content string) []Link {
+	var links []Link
+
+	lines := strings.Split(content, "\n")
+	start := frontmatterEnd(lines)
+	inCodeBlock := false
+
+	for n := start; n < len(lines); n++ {
+		line := lines[n]
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		processedLine := removeInlineCode(line)
+		for i := 0; i+1 < len(processedLine); {
+			if processedLine[i] != '[' || processedLine[i+1] != '[' {
+				i++
+				continue
+			}
+			end := strings.Index(processedLine[i+2:], "]]")
+			if end < 0 {
+				break
+			}
+			inner := processedLine[i+2 : i+2+end]
+			embed := i > 0 && processedLine[i-1] == '!'
+			i += end + 4
+
+			if link, ok := parseLink(inner); ok {
+				link.Embed = embed
+				link.Line = n + 1
+				link.Context = strings.TrimSpace(line)
+				links = append(links, link)
+			}
+		}
+	}
+
+	return links
+}
+
+// parseLink splits the text between [[ and ]] into its parts
+func parseLink(inner string) (Link, bool) {
+	raw, alias, _ := strings.Cut(inner, "|")
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Link{}, false
+	}
+
+	target, heading, _ := strings.Cut(raw, "#")
+	return Link{
+		Raw:     raw,
+		Target:  strings.TrimSpace(target),
+		Heading: strings.TrimSpace(heading),
+		Alias:   strings.TrimSpace(alias),
+	}, true
+}
+
+// frontmatterEnd returns the index of the first line after the frontmatter, 0 if there is none
+func frontmatterEnd(lines []string) int {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return i + 1
+		}
+	}
+	return 0
+}
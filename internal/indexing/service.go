@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,7 +13,10 @@ import (
 
 	"github.com/blevesearch/bleve/v2"
 	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/ignore"
 	"github.com/susamn/obsidian-web/internal/logger"
+	"github.com/susamn/obsidian-web/internal/storage"
 	syncpkg "github.com/susamn/obsidian-web/internal/sync"
 )
 
@@ -75,17 +77,38 @@ type IndexUpdateNotifier interface {
 	NotifyIndexUpdate(event IndexUpdateEvent)
 }
 
+// NoteStore keeps indexed notes in place of the bleve index, for vaults
+// using the sqlite_fts search engine. Documents are identified as in the
+// bleve index.
+type NoteStore interface {
+	IndexNotes(notes []db.Note) error
+	DeleteNote(id, path string) error
+	ClearNotes() error
+	IndexedNotes() (map[string]string, error)
+	CountNotes() (uint64, error)
+}
+
+// FileEntries looks up the vault's tracked files, whose created and modified
+// times are indexed with each note
+type FileEntries interface {
+	GetFileEntryByPath(path string) (*db.FileEntry, error)
+}
+
 // IndexService provides indexing functionality for a vault
-// It only works with local file paths - the sync service handles
-// storage abstraction and ensures files are available locally
+// Document content is read through the vault's storage; event paths are
+// absolute paths rooted at vaultPath and converted to relative paths
 type IndexService struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	vaultID    string
 	vaultName  string
-	vaultPath  string // Local path to vault (or local cache for S3/MinIO)
+	vaultPath  string // Root that event paths are relative to (local dir or remote vault root)
+	storage    storage.VaultStorage
+	ignore     *ignore.Rules // Ignored files are never indexed
 	indexPath  string
 	index      bleve.Index
+	notes      NoteStore   // Replaces the bleve index when set
+	files      FileEntries // File modification times are used when unset
 	status     ServiceStatus
 	statusChan chan StatusUpdate
 	eventChan  chan syncpkg.FileChangeEvent // Input channel for sync events
@@ -107,9 +130,13 @@ type IndexService struct {
 	mu sync.RWMutex
 }
 
-// NewIndexService creates a new index service for a vault
-// vaultPath should be a local filesystem path (sync service handles storage abstraction)
+// NewIndexService creates a new index service for a vault stored in the local directory vaultPath
 func NewIndexService(ctx context.Context, vault *config.VaultConfig, vaultPath string) (*IndexService, error) {
+	return NewIndexServiceWithStorage(ctx, vault, vaultPath, storage.NewLocalStorage(vaultPath))
+}
+
+// NewIndexServiceWithStorage creates a new index service that reads documents through store
+func NewIndexServiceWithStorage(ctx context.Context, vault *config.VaultConfig, vaultPath string, store storage.VaultStorage) (*IndexService, error) {
 	if vault == nil {
 		return nil, fmt.Errorf("vault config cannot be nil")
 	}
@@ -122,6 +149,10 @@ func NewIndexService(ctx context.Context, vault *config.VaultConfig, vaultPath s
 		return nil, fmt.Errorf("vault path cannot be empty")
 	}
 
+	if store == nil {
+		return nil, fmt.Errorf("vault storage cannot be nil")
+	}
+
 	// Create a cancellable context
 	svcCtx, cancel := context.WithCancel(ctx)
 
@@ -143,6 +174,7 @@ func NewIndexService(ctx context.Context, vault *config.VaultConfig, vaultPath s
 		vaultID:       vault.ID,
 		vaultName:     vault.Name,
 		vaultPath:     vaultPath,
+		storage:       store,
 		indexPath:     vault.IndexPath,
 		status:        StatusStandby,
 		statusChan:    statusChan,
@@ -154,6 +186,24 @@ func NewIndexService(ctx context.Context, vault *config.VaultConfig, vaultPath s
 	}, nil
 }
 
+// SetIgnoreRules sets the rules for files that are kept out of the index.
+// Must be called before Start.
+func (s *IndexService) SetIgnoreRules(rules *ignore.Rules) {
+	s.ignore = rules
+}
+
+// SetNoteStore indexes notes into store instead of a bleve index.
+// Must be called before Start.
+func (s *IndexService) SetNoteStore(store NoteStore) {
+	s.notes = store
+}
+
+// SetFileEntries takes indexed created and modified times from the files'
+// tracked entries. Must be called before Start.
+func (s *IndexService) SetFileEntries(files FileEntries) {
+	s.files = files
+}
+
 // Start begins the initial indexing process in a non-blocking goroutine
 func (s *IndexService) Start() error {
 	s.mu.Lock()
@@ -219,39 +269,58 @@ func (s *IndexService) performInitialIndexing() error {
 	}).Info("Indexing vault")
 
 	var err error
-	var index bleve.Index
+	batch := &docBatch{notes: s.notes}
+
+	// The note store needs no index of its own
+	if s.notes == nil {
+		var index bleve.Index
+
+		// Try to open existing index
+		index, err = bleve.Open(s.indexPath)
+		needsCreate := errors.Is(err, bleve.ErrorIndexPathDoesNotExist) ||
+			(err != nil && strings.Contains(err.Error(), "metadata missing"))
+
+		if needsCreate {
+			// Create new index (either path doesn't exist or metadata is missing)
+			docMapping := buildIndexMapping()
+			index, err = bleve.New(s.indexPath, docMapping)
+			if err != nil {
+				return fmt.Errorf("failed to create index: %w", err)
+			}
+			logger.WithField("vault_id", s.vaultID).Info("Created new index")
+		} else if err != nil {
+			return fmt.Errorf("failed to open index: %w", err)
+		} else if !hasCurrentMapping(index) {
+			// Documents are indexed again below, so the old index can go
+			logger.WithField("vault_id", s.vaultID).Info("Index mapping outdated, rebuilding")
+			index.Close()
+			if err := os.RemoveAll(s.indexPath); err != nil {
+				return fmt.Errorf("failed to remove outdated index: %w", err)
+			}
+			index, err = bleve.New(s.indexPath, buildIndexMapping())
+			if err != nil {
+				return fmt.Errorf("failed to create index: %w", err)
+			}
+		} else {
+			logger.WithField("vault_id", s.vaultID).Info("Opened existing index")
+		}
 
-	// Try to open existing index
-	index, err = bleve.Open(s.indexPath)
-	needsCreate := errors.Is(err, bleve.ErrorIndexPathDoesNotExist) ||
-		(err != nil && strings.Contains(err.Error(), "metadata missing"))
+		// Set the index with proper locking
+		s.mu.Lock()
+		s.index = index
+		s.mu.Unlock()
 
-	if needsCreate {
-		// Create new index (either path doesn't exist or metadata is missing)
-		docMapping := buildIndexMapping()
-		index, err = bleve.New(s.indexPath, docMapping)
-		if err != nil {
-			return fmt.Errorf("failed to create index: %w", err)
-		}
-		logger.WithField("vault_id", s.vaultID).Info("Created new index")
-	} else if err != nil {
-		return fmt.Errorf("failed to open index: %w", err)
-	} else {
-		logger.WithField("vault_id", s.vaultID).Info("Opened existing index")
+		batch.index = index
+		batch.batch = index.NewBatch()
 	}
 
-	// Set the index with proper locking
-	s.mu.Lock()
-	s.index = index
-	s.mu.Unlock()
-
 	// First, count total files
 	totalFiles := 0
-	err = filepath.WalkDir(s.vaultPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
+	err = storage.Walk(s.ctx, s.storage, "", func(info storage.FileInfo) error {
+		if s.ignore.Ignored(info.Path, info.IsDir) {
+			return skipIgnored(info)
 		}
-		if !d.IsDir() && strings.HasSuffix(path, ".md") && !strings.HasPrefix(filepath.Base(path), ".") {
+		if !info.IsDir && IsIndexable(info.Path) {
 			totalFiles++
 		}
 		return nil
@@ -270,58 +339,52 @@ func (s *IndexService) performInitialIndexing() error {
 	})
 
 	// Walk through markdown files and index them
-	batch := index.NewBatch()
 	indexedCount := 0
 
-	err = filepath.WalkDir(s.vaultPath, func(path string, d fs.DirEntry, err error) error {
-		// Check for cancellation
-		select {
-		case <-s.ctx.Done():
-			return s.ctx.Err()
-		default:
-		}
-
-		if err != nil {
-			return err
+	// Walk checks for cancellation and skips hidden files and directories
+	err = storage.Walk(s.ctx, s.storage, "", func(info storage.FileInfo) error {
+		if s.ignore.Ignored(info.Path, info.IsDir) {
+			return skipIgnored(info)
 		}
 
 		// Skip directories and non-markdown files
-		if d.IsDir() || !strings.HasSuffix(path, ".md") {
-			return nil
-		}
-
-		// Skip hidden files
-		if strings.HasPrefix(filepath.Base(path), ".") {
+		if info.IsDir || !IsIndexable(info.Path) {
 			return nil
 		}
 
-		// Get relative path
-		relPath, _ := filepath.Rel(s.vaultPath, path)
+		relPath := filepath.FromSlash(info.Path)
 
 		// During initial indexing, use relative path as ID
 		// The worker will update with proper file IDs later
-		doc, err := parseMarkdownFile(path)
+		content, err := s.storage.Read(s.ctx, info.Path)
 		if err != nil {
 			logger.WithError(err).WithFields(map[string]interface{}{
 				"vault_id": s.vaultID,
-				"path":     path,
-			}).Warn("Error parsing file")
+				"path":     relPath,
+			}).Warn("Error reading file")
 			return nil // Continue processing other files
 		}
 
 		// Set the relative path as both ID and Path
-		doc.ID = relPath
-		doc.Path = relPath
+		doc, err := parseMarkdownBytes(content, relPath, relPath)
+		if err != nil {
+			logger.WithError(err).WithFields(map[string]interface{}{
+				"vault_id": s.vaultID,
+				"path":     relPath,
+			}).Warn("Error parsing file")
+			return nil // Continue processing other files
+		}
+		s.stampTimes(doc, &info)
 
-		err = batch.Index(relPath, doc)
+		err = batch.add(doc)
 		if err != nil {
 			return err
 		}
 		indexedCount++
 
 		// Batch index every 100 documents
-		if batch.Size() >= 100 {
-			if err := index.Batch(batch); err != nil {
+		if batch.size() >= 100 {
+			if err := batch.flush(); err != nil {
 				return fmt.Errorf("batch index failed: %w", err)
 			}
 			logger.WithFields(map[string]interface{}{
@@ -338,8 +401,6 @@ func (s *IndexService) performInitialIndexing() error {
 				IndexedCount:   indexedCount,
 				Message:        fmt.Sprintf("Indexed %d/%d documents", indexedCount, totalFiles),
 			})
-
-			batch = index.NewBatch()
 		}
 
 		return nil
@@ -350,8 +411,8 @@ func (s *IndexService) performInitialIndexing() error {
 	}
 
 	// Index remaining documents
-	if batch.Size() > 0 {
-		if err := index.Batch(batch); err != nil {
+	if batch.size() > 0 {
+		if err := batch.flush(); err != nil {
 			return fmt.Errorf("final batch index failed: %w", err)
 		}
 	}
@@ -376,6 +437,98 @@ func (s *IndexService) performInitialIndexing() error {
 	return nil
 }
 
+// docBatch collects documents for the bleve index, or for the note store when
+// the service has one
+type docBatch struct {
+	index bleve.Index
+	batch *bleve.Batch
+	notes NoteStore
+	queue []db.Note
+}
+
+// add queues a document
+func (b *docBatch) add(doc *MarkdownDoc) error {
+	if b.notes != nil {
+		b.queue = append(b.queue, noteFromDoc(doc))
+		return nil
+	}
+	return b.batch.Index(doc.ID, doc)
+}
+
+// size returns the number of queued documents
+func (b *docBatch) size() int {
+	if b.notes != nil {
+		return len(b.queue)
+	}
+	return b.batch.Size()
+}
+
+// flush writes the queued documents and starts a new batch
+func (b *docBatch) flush() error {
+	if b.notes != nil {
+		err := b.notes.IndexNotes(b.queue)
+		b.queue = nil
+		return err
+	}
+	err := b.index.Batch(b.batch)
+	b.batch = b.index.NewBatch()
+	return err
+}
+
+// noteFromDoc converts a parsed document for the note store
+func noteFromDoc(doc *MarkdownDoc) db.Note {
+	return db.Note{
+		ID:        doc.ID,
+		Path:      doc.Path,
+		Title:     doc.Title,
+		Content:   doc.Content,
+		Metadata:  doc.Metadata,
+		Tags:      doc.Tags,
+		Wikilinks: doc.Wikilinks,
+		SortTitle: doc.SortTitle,
+		Created:   doc.Created,
+		Modified:  doc.Modified,
+		Date:      doc.Date,
+	}
+}
+
+// stampTimes sets a document's created and modified times from its tracked
+// file entry, or else from the file's modification time. info is looked up
+// when nil.
+func (s *IndexService) stampTimes(doc *MarkdownDoc, info *storage.FileInfo) {
+	if s.files != nil {
+		if entry, err := s.files.GetFileEntryByPath(doc.Path); err == nil && entry != nil {
+			doc.Created, doc.Modified = entry.Created, entry.Modified
+			return
+		}
+	}
+	if info == nil {
+		info, _ = s.storage.Stat(s.ctx, filepath.ToSlash(doc.Path))
+	}
+	if info != nil {
+		doc.Created, doc.Modified = info.ModTime, info.ModTime
+	}
+}
+
+// IsIndexable reports whether a file belongs in the search index.
+// Only markdown notes are indexed; attachments are tracked in the DB and explorer only.
+func IsIndexable(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return true
+	default:
+		return false
+	}
+}
+
+// skipIgnored tells storage.Walk to leave out an ignored entry
+func skipIgnored(info storage.FileInfo) error {
+	if info.IsDir {
+		return storage.SkipDir
+	}
+	return nil
+}
+
 // updateStatus sends a status update to the channel (non-blocking)
 func (s *IndexService) updateStatus(update StatusUpdate) {
 	select {
@@ -400,7 +553,7 @@ func (s *IndexService) GetStatus() ServiceStatus {
 
 // ReIndexSync updates a single document in the index SYNCHRONOUSLY
 // This is the preferred method for workers and UI operations
-// docPath should be a local file path
+// docPath should be an absolute path rooted at the vault path
 // fileID is the database file ID (if empty, will use relative path as ID)
 func (s *IndexService) ReIndexSync(docPath string, fileID string) error {
 	return s.reIndex(docPath, fileID)
@@ -408,17 +561,17 @@ func (s *IndexService) ReIndexSync(docPath string, fileID string) error {
 
 // DeleteFromIndexSync removes a document from the index SYNCHRONOUSLY
 // This is the preferred method for workers and UI operations
-// docPath should be a local file path
+// docPath should be an absolute path rooted at the vault path
 // fileID is the database file ID to delete
 func (s *IndexService) DeleteFromIndexSync(docPath string, fileID string) error {
 	return s.deleteFromIndex(docPath, fileID)
 }
 
 // reIndex updates a single document in the index
-// docPath should be a local file path (sync service provides this)
+// docPath is an absolute path rooted at vaultPath (sync service provides this)
 // fileID is the database file ID (if empty, will use relative path as ID)
 func (s *IndexService) reIndex(docPath string, fileID string) error {
-	if s.index == nil {
+	if s.index == nil && s.notes == nil {
 		return fmt.Errorf("index not initialized, call Index() first")
 	}
 
@@ -428,20 +581,36 @@ func (s *IndexService) reIndex(docPath string, fileID string) error {
 		return fmt.Errorf("failed to get relative path: %w", err)
 	}
 
+	// Attachments are tracked elsewhere but never indexed
+	if !IsIndexable(relPath) || s.ignore.Ignored(relPath, false) {
+		return nil
+	}
+
 	// Use file ID as document ID (fallback to relPath if no ID provided)
 	docID := fileID
 	if docID == "" {
 		docID = relPath
 	}
 
-	// Parse the markdown file with file ID
-	doc, err := parseMarkdownFileWithID(docPath, relPath, docID)
+	// Read through vault storage and parse with file ID
+	content, err := s.storage.Read(s.ctx, filepath.ToSlash(relPath))
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	doc, err := parseMarkdownBytes(content, relPath, docID)
 	if err != nil {
 		return fmt.Errorf("failed to parse file: %w", err)
 	}
+	s.stampTimes(doc, nil)
 
 	// Index the document using the file ID
-	if err := s.index.Index(docID, doc); err != nil {
+	if s.notes != nil {
+		err = s.notes.IndexNotes([]db.Note{noteFromDoc(doc)})
+	} else {
+		err = s.index.Index(docID, doc)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to index document: %w", err)
 	}
 
@@ -454,10 +623,10 @@ func (s *IndexService) reIndex(docPath string, fileID string) error {
 }
 
 // deleteFromIndex removes a document from the index
-// docPath should be a local file path (sync service provides this)
+// docPath is an absolute path rooted at vaultPath (sync service provides this)
 // fileID is the database file ID to delete (if empty, will use relative path as ID)
 func (s *IndexService) deleteFromIndex(docPath string, fileID string) error {
-	if s.index == nil {
+	if s.index == nil && s.notes == nil {
 		return fmt.Errorf("index not initialized, call Index() first")
 	}
 
@@ -473,7 +642,13 @@ func (s *IndexService) deleteFromIndex(docPath string, fileID string) error {
 		docID = relPath
 	}
 
-	if err := s.index.Delete(docID); err != nil {
+	// The note store also drops whatever note it holds at the path
+	if s.notes != nil {
+		err = s.notes.DeleteNote(docID, relPath)
+	} else {
+		err = s.index.Delete(docID)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to delete document: %w", err)
 	}
 
@@ -486,13 +661,57 @@ func (s *IndexService) deleteFromIndex(docPath string, fileID string) error {
 }
 
 // GetIndex returns the underlying bleve index
-// This allows search operations to be performed
+// This allows search operations to be performed. It is nil when notes are
+// kept in a note store.
 func (s *IndexService) GetIndex() bleve.Index {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.index
 }
 
+// IndexedDocuments returns the stored path of every indexed document, keyed by document ID
+func (s *IndexService) IndexedDocuments() (map[string]string, error) {
+	if s.notes != nil {
+		return s.notes.IndexedNotes()
+	}
+	index := s.GetIndex()
+	if index == nil {
+		return nil, fmt.Errorf("index not initialized")
+	}
+
+	const pageSize = 1000
+	docs := make(map[string]string)
+	for from := 0; ; from += pageSize {
+		req := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), pageSize, from, false)
+		req.Fields = []string{"path"}
+		req.SortBy([]string{"_id"})
+
+		result, err := index.SearchInContext(s.ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		for _, hit := range result.Hits {
+			path, _ := hit.Fields["path"].(string)
+			docs[hit.ID] = path
+		}
+		if len(result.Hits) < pageSize {
+			return docs, nil
+		}
+	}
+}
+
+// DocCount returns the number of indexed documents
+func (s *IndexService) DocCount() (uint64, error) {
+	if s.notes != nil {
+		return s.notes.CountNotes()
+	}
+	index := s.GetIndex()
+	if index == nil {
+		return 0, fmt.Errorf("index not initialized")
+	}
+	return index.DocCount()
+}
+
 // RegisterIndexNotifier registers a notifier to be called when the index is updated
 func (s *IndexService) RegisterIndexNotifier(notifier IndexUpdateNotifier) {
 	if notifier == nil {
@@ -693,6 +912,27 @@ func (s *IndexService) processEvent(event syncpkg.FileChangeEvent) {
 			}).Debug("Deleted from index")
 		}
 
+	case syncpkg.FileMoved:
+		// Documents are keyed by relative path here, so drop the old one and index the new
+		if err := s.deleteFromIndex(event.OldPath, ""); err != nil {
+			logger.WithError(err).WithFields(map[string]interface{}{
+				"vault_id": s.vaultID,
+				"path":     event.OldPath,
+			}).Error("Failed to delete moved document from index")
+		}
+		if err := s.reIndex(event.Path, ""); err != nil {
+			logger.WithError(err).WithFields(map[string]interface{}{
+				"vault_id": s.vaultID,
+				"path":     event.Path,
+			}).Error("Failed to re-index moved document")
+		} else {
+			logger.WithFields(map[string]interface{}{
+				"vault_id": s.vaultID,
+				"old_path": event.OldPath,
+				"path":     event.Path,
+			}).Debug("Re-indexed moved file")
+		}
+
 	default:
 		logger.WithFields(map[string]interface{}{
 			"vault_id":   s.vaultID,
@@ -703,7 +943,7 @@ func (s *IndexService) processEvent(event syncpkg.FileChangeEvent) {
 }
 
 // UpdateIndex sends a sync event to be processed (non-blocking)
-// This is called by the sync service when files are created, modified, or deleted
+// This is called by the sync service when files are created, modified, deleted or moved
 // The sync service passes its FileChangeEvent directly to this method
 func (s *IndexService) UpdateIndex(event syncpkg.FileChangeEvent) {
 	// Validate event
@@ -714,7 +954,7 @@ func (s *IndexService) UpdateIndex(event syncpkg.FileChangeEvent) {
 
 	// Validate event type
 	switch event.EventType {
-	case syncpkg.FileCreated, syncpkg.FileModified, syncpkg.FileDeleted:
+	case syncpkg.FileCreated, syncpkg.FileModified, syncpkg.FileDeleted, syncpkg.FileMoved:
 		// Valid event type, continue
 	default:
 		logger.WithFields(map[string]interface{}{
@@ -781,10 +1021,21 @@ func (s *IndexService) Stop() error {
 }
 
 // Clear closes the existing index, removes the index directory, and creates a new empty index.
+// A note store is emptied instead.
 func (s *IndexService) Clear() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.notes != nil {
+		if err := s.notes.ClearNotes(); err != nil {
+			return fmt.Errorf("failed to clear notes: %w", err)
+		}
+		s.status = StatusReady
+		s.notifyIndexUpdate("rebuild")
+		logger.WithField("vault_id", s.vaultID).Info("Notes cleared")
+		return nil
+	}
+
 	// Close existing index if open
 	if s.index != nil {
 		logger.WithField("vault_id", s.vaultID).Info("Closing index for clearing")
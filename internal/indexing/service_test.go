@@ -265,6 +265,48 @@ Updated content with #newtag.
 	t.Log("✓ Successfully re-indexed document")
 }
 
+func TestIndexService_ReIndexSkipsAttachments(t *testing.T) {
+	vaultDir := t.TempDir()
+	indexDir := t.TempDir()
+
+	image := filepath.Join(vaultDir, "photo.png")
+	if err := os.WriteFile(image, []byte{0x89, 'P', 'N', 'G'}, 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	vault := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		IndexPath: filepath.Join(indexDir, "test.bleve"),
+	}
+	svc, err := NewIndexService(context.Background(), vault, vaultDir)
+	if err != nil {
+		t.Fatalf("NewIndexService() error = %v", err)
+	}
+	defer svc.Stop()
+	if err := svc.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	for update := range svc.StatusUpdates() {
+		if update.Status == StatusReady || update.Status == StatusError {
+			break
+		}
+	}
+
+	if err := svc.ReIndexSync(image, "img-1"); err != nil {
+		t.Fatalf("ReIndexSync() error = %v", err)
+	}
+	if count, _ := svc.GetIndex().DocCount(); count != 0 {
+		t.Errorf("Expected attachments to stay out of the index, got %d documents", count)
+	}
+
+	for path, want := range map[string]bool{"a.md": true, "b/C.MARKDOWN": true, "photo.png": false, "data.csv": false, "md": false} {
+		if got := IsIndexable(path); got != want {
+			t.Errorf("IsIndexable(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
 func TestIndexService_DeleteFromIndex(t *testing.T) {
 	// Create temporary directories
 	vaultDir := t.TempDir()
@@ -77,6 +77,18 @@ func TestUpdateIndex_Validation(t *testing.T) {
 			shouldQueue: true,
 			description: "Should queue valid FileDeleted event",
 		},
+		{
+			name: "valid moved event",
+			event: sync.FileChangeEvent{
+				VaultID:   "test-vault",
+				Path:      filepath.Join(vaultDir, "renamed.md"),
+				OldPath:   filepath.Join(vaultDir, "valid.md"),
+				EventType: sync.FileMoved,
+				Timestamp: time.Now(),
+			},
+			shouldQueue: true,
+			description: "Should queue valid FileMoved event",
+		},
 		{
 			name: "empty path",
 			event: sync.FileChangeEvent{
@@ -0,0 +1,298 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when the requested object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Options configures a Client
+type Options struct {
+	Endpoint   string // Base URL, e.g. "https://s3.us-east-1.amazonaws.com" or "http://localhost:9000"
+	Region     string
+	Bucket     string
+	AccessKey  string // Empty access key sends anonymous requests
+	SecretKey  string
+	PathStyle  bool // Use endpoint/bucket/key instead of bucket.endpoint/key
+	HTTPClient *http.Client
+}
+
+// Client is a minimal S3-compatible object storage client
+// It speaks the S3 REST API directly and signs requests with AWS Signature V4
+type Client struct {
+	endpoint   *url.URL
+	region     string
+	bucket     string
+	accessKey  string
+	secretKey  string
+	pathStyle  bool
+	httpClient *http.Client
+}
+
+// ObjectInfo describes a single object in a bucket
+type ObjectInfo struct {
+	Key          string
+	ETag         string
+	Size         int64
+	LastModified time.Time
+}
+
+// NewClient creates a new object storage client
+func NewClient(opts Options) (*Client, error) {
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("bucket cannot be empty")
+	}
+	if opts.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint cannot be empty")
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(opts.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme and host are required", opts.Endpoint)
+	}
+
+	region := opts.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	return &Client{
+		endpoint:   endpoint,
+		region:     region,
+		bucket:     opts.Bucket,
+		accessKey:  opts.AccessKey,
+		secretKey:  opts.SecretKey,
+		pathStyle:  opts.PathStyle,
+		httpClient: httpClient,
+	}, nil
+}
+
+// Bucket returns the bucket this client operates on
+func (c *Client) Bucket() string {
+	return c.bucket
+}
+
+// listBucketResult mirrors the ListObjectsV2 XML response
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int64  `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// ListObjects lists every object under prefix using ListObjectsV2, following continuation tokens
+func (c *Client) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode list response: %w", err)
+		}
+
+		for _, obj := range result.Contents {
+			modified, _ := time.Parse(time.RFC3339Nano, obj.LastModified)
+			objects = append(objects, ObjectInfo{
+				Key:          obj.Key,
+				ETag:         strings.Trim(obj.ETag, `"`),
+				Size:         obj.Size,
+				LastModified: modified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	return objects, nil
+}
+
+// StatObject returns metadata for a single object using HEAD
+func (c *Client) StatObject(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, err := c.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return objectInfoFromHeader(key, resp.Header), nil
+}
+
+// GetObject returns a reader for the object content; the caller must close it
+func (c *Client) GetObject(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp.Body, objectInfoFromHeader(key, resp.Header), nil
+}
+
+// PutObject uploads data under key
+func (c *Client) PutObject(ctx context.Context, key string, data []byte) error {
+	resp, err := c.do(ctx, http.MethodPut, key, nil, nil, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// CopyObject copies srcKey to dstKey within the bucket
+func (c *Client) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	header := http.Header{}
+	header.Set("X-Amz-Copy-Source", "/"+c.bucket+"/"+encodePath(srcKey))
+
+	resp, err := c.do(ctx, http.MethodPut, dstKey, nil, header, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// DeleteObject removes the object stored under key
+func (c *Client) DeleteObject(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do builds, signs and executes a request; non-2xx responses are returned as errors
+func (c *Client) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	reqURL := c.objectURL(key, query)
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	for k, values := range header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if body != nil {
+		req.ContentLength = int64(len(body))
+	}
+
+	if c.accessKey != "" {
+		signRequest(req, body, c.accessKey, c.secretKey, c.region, time.Now().UTC())
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, reqURL.Path, err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound && key != "" {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, decodeError(method, reqURL.Path, resp)
+	}
+
+	return resp, nil
+}
+
+// objectURL returns the request URL for key (empty key addresses the bucket)
+func (c *Client) objectURL(key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	basePath := strings.TrimSuffix(u.Path, "/")
+
+	if c.pathStyle {
+		u.Path = basePath + "/" + c.bucket + "/" + key
+		u.RawPath = basePath + "/" + encodePath(c.bucket) + "/" + encodePath(key)
+	} else {
+		u.Host = c.bucket + "." + u.Host
+		u.Path = basePath + "/" + key
+		u.RawPath = basePath + "/" + encodePath(key)
+	}
+
+	if query != nil {
+		u.RawQuery = canonicalQuery(query)
+	}
+	return &u
+}
+
+// s3Error mirrors the S3 XML error document
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// decodeError turns an S3 error response into a Go error
+func decodeError(method, path string, resp *http.Response) error {
+	var apiErr s3Error
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := xml.Unmarshal(data, &apiErr); err == nil && apiErr.Code != "" {
+		return fmt.Errorf("%s %s: %s (%d): %s", method, path, apiErr.Code, resp.StatusCode, apiErr.Message)
+	}
+	return fmt.Errorf("%s %s: unexpected status %d", method, path, resp.StatusCode)
+}
+
+// objectInfoFromHeader builds ObjectInfo from GET/HEAD response headers
+func objectInfoFromHeader(key string, header http.Header) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:  key,
+		ETag: strings.Trim(header.Get("ETag"), `"`),
+	}
+	if size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64); err == nil {
+		info.Size = size
+	}
+	if modified, err := http.ParseTime(header.Get("Last-Modified")); err == nil {
+		info.LastModified = modified.UTC()
+	}
+	return info
+}
@@ -0,0 +1,172 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/susamn/obsidian-web/internal/objectstore/objectstoretest"
+)
+
+func newTestClient(t *testing.T, srv *objectstoretest.Server) *Client {
+	t.Helper()
+	client, err := NewClient(Options{
+		Endpoint:  srv.URL,
+		Bucket:    "vault",
+		AccessKey: "test-access",
+		SecretKey: "test-secret",
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+func TestNewClient_Validation(t *testing.T) {
+	if _, err := NewClient(Options{Endpoint: "http://localhost:9000"}); err == nil {
+		t.Error("expected error for empty bucket")
+	}
+	if _, err := NewClient(Options{Bucket: "b"}); err == nil {
+		t.Error("expected error for empty endpoint")
+	}
+	if _, err := NewClient(Options{Bucket: "b", Endpoint: "localhost:9000"}); err == nil {
+		t.Error("expected error for endpoint without scheme")
+	}
+}
+
+func TestClient_ListObjectsPaginates(t *testing.T) {
+	srv := objectstoretest.NewServer("vault")
+	defer srv.Close()
+	srv.SetMaxKeys(2)
+
+	for i := 0; i < 5; i++ {
+		srv.PutObject(fmt.Sprintf("notes/n%d.md", i), []byte("content"))
+	}
+	srv.PutObject("other/skip.md", []byte("x"))
+
+	client := newTestClient(t, srv)
+	objects, err := client.ListObjects(context.Background(), "notes/")
+	if err != nil {
+		t.Fatalf("ListObjects() error = %v", err)
+	}
+
+	if len(objects) != 5 {
+		t.Fatalf("expected 5 objects, got %d", len(objects))
+	}
+	for _, obj := range objects {
+		if !strings.HasPrefix(obj.Key, "notes/") {
+			t.Errorf("unexpected key %s", obj.Key)
+		}
+		if obj.ETag == "" || strings.Contains(obj.ETag, `"`) {
+			t.Errorf("expected unquoted ETag, got %q", obj.ETag)
+		}
+		if obj.LastModified.IsZero() {
+			t.Errorf("expected LastModified for %s", obj.Key)
+		}
+	}
+}
+
+func TestClient_ObjectRoundTrip(t *testing.T) {
+	srv := objectstoretest.NewServer("vault")
+	defer srv.Close()
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	key := "folder/My Note (1).md"
+	if err := client.PutObject(ctx, key, []byte("# Hello")); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+
+	body, info, err := client.GetObject(ctx, key)
+	if err != nil {
+		t.Fatalf("GetObject() error = %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "# Hello" {
+		t.Errorf("GetObject() content = %q", data)
+	}
+	if info.Size != int64(len("# Hello")) {
+		t.Errorf("GetObject() size = %d", info.Size)
+	}
+
+	if err := client.CopyObject(ctx, key, "copy.md"); err != nil {
+		t.Fatalf("CopyObject() error = %v", err)
+	}
+	if _, ok := srv.Object("copy.md"); !ok {
+		t.Error("expected copied object to exist")
+	}
+
+	if err := client.DeleteObject(ctx, key); err != nil {
+		t.Fatalf("DeleteObject() error = %v", err)
+	}
+	if _, err := client.StatObject(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("StatObject() after delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_SignsRequests(t *testing.T) {
+	var authHeader, dateHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		dateHeader = r.Header.Get("X-Amz-Date")
+		w.Header().Set("ETag", `"abc"`)
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(Options{Endpoint: srv.URL, Bucket: "vault", Region: "eu-west-1", AccessKey: "AKID", SecretKey: "secret", PathStyle: true})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if err := client.PutObject(context.Background(), "a.md", []byte("x")); err != nil {
+		t.Fatalf("PutObject() error = %v", err)
+	}
+
+	if !strings.HasPrefix(authHeader, "AWS4-HMAC-SHA256 Credential=AKID/") {
+		t.Errorf("unexpected Authorization header %q", authHeader)
+	}
+	if !strings.Contains(authHeader, "/eu-west-1/s3/aws4_request") {
+		t.Errorf("Authorization header missing scope: %q", authHeader)
+	}
+	if !strings.Contains(authHeader, "SignedHeaders=host;x-amz-content-sha256;x-amz-date") {
+		t.Errorf("Authorization header missing signed headers: %q", authHeader)
+	}
+	if dateHeader == "" {
+		t.Error("expected X-Amz-Date header")
+	}
+}
+
+func TestClient_ErrorResponse(t *testing.T) {
+	srv := objectstoretest.NewServer("vault")
+	defer srv.Close()
+
+	client, _ := NewClient(Options{Endpoint: srv.URL, Bucket: "missing", PathStyle: true})
+	_, err := client.ListObjects(context.Background(), "")
+	if err == nil || !strings.Contains(err.Error(), "NoSuchBucket") {
+		t.Errorf("ListObjects() error = %v, want NoSuchBucket", err)
+	}
+}
+
+func TestURIEncode(t *testing.T) {
+	tests := []struct {
+		in          string
+		encodeSlash bool
+		want        string
+	}{
+		{"notes/a b.md", false, "notes/a%20b.md"},
+		{"notes/a b.md", true, "notes%2Fa%20b.md"},
+		{"a~b_c-d.e", false, "a~b_c-d.e"},
+		{"x+y=z", false, "x%2By%3Dz"},
+	}
+	for _, tt := range tests {
+		if got := uriEncode(tt.in, tt.encodeSlash); got != tt.want {
+			t.Errorf("uriEncode(%q, %v) = %q, want %q", tt.in, tt.encodeSlash, got, tt.want)
+		}
+	}
+}
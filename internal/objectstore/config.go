@@ -0,0 +1,57 @@
+package objectstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/susamn/obsidian-web/internal/config"
+)
+
+// NewS3Client creates a client from an S3 storage configuration
+// A custom endpoint (S3-compatible service) uses path-style addressing,
+// AWS itself uses virtual-hosted style
+func NewS3Client(cfg *config.S3StorageConfig) (*Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("s3 config cannot be nil")
+	}
+
+	endpoint := cfg.Endpoint
+	pathStyle := true
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+		pathStyle = false
+	}
+
+	return NewClient(Options{
+		Endpoint:  endpoint,
+		Region:    cfg.Region,
+		Bucket:    cfg.Bucket,
+		AccessKey: cfg.AccessKey,
+		SecretKey: cfg.SecretKey,
+		PathStyle: pathStyle,
+	})
+}
+
+// NewMinIOClient creates a client from a MinIO storage configuration
+func NewMinIOClient(cfg *config.MinIOStorageConfig) (*Client, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("minio config cannot be nil")
+	}
+
+	endpoint := cfg.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		scheme := "http"
+		if cfg.UseSSL {
+			scheme = "https"
+		}
+		endpoint = scheme + "://" + endpoint
+	}
+
+	return NewClient(Options{
+		Endpoint:  endpoint,
+		Bucket:    cfg.Bucket,
+		AccessKey: cfg.AccessKey,
+		SecretKey: cfg.SecretKey,
+		PathStyle: true,
+	})
+}
@@ -0,0 +1,128 @@
+package objectstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Bucket notification event name patterns understood by MinIO
+const (
+	EventObjectCreatedAll = "s3:ObjectCreated:*"
+	EventObjectRemovedAll = "s3:ObjectRemoved:*"
+)
+
+// NotificationEvent is a single object event delivered by a bucket notification stream
+type NotificationEvent struct {
+	EventName string
+	Key       string
+	ETag      string
+	Size      int64
+	EventTime time.Time
+}
+
+// IsCreated reports whether the event is an ObjectCreated event
+func (e NotificationEvent) IsCreated() bool {
+	return strings.HasPrefix(e.EventName, "s3:ObjectCreated:")
+}
+
+// IsRemoved reports whether the event is an ObjectRemoved event
+func (e NotificationEvent) IsRemoved() bool {
+	return strings.HasPrefix(e.EventName, "s3:ObjectRemoved:")
+}
+
+// notificationInfo mirrors the JSON documents streamed by ListenBucketNotification
+type notificationInfo struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		EventTime string `json:"eventTime"`
+		S3        struct {
+			Object struct {
+				Key  string `json:"key"`
+				Size int64  `json:"size"`
+				ETag string `json:"eTag"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+// NotificationStream reads events from an open ListenBucketNotification connection
+type NotificationStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	pending []NotificationEvent
+}
+
+// ListenBucketNotification opens a MinIO bucket notification stream
+// This is a MinIO extension to the S3 API; the returned stream must be closed
+func (c *Client) ListenBucketNotification(ctx context.Context, prefix string, events []string) (*NotificationStream, error) {
+	query := url.Values{}
+	query.Set("prefix", prefix)
+	query.Set("suffix", "")
+	query["events"] = events
+
+	resp, err := c.do(ctx, http.MethodGet, "", query, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	return &NotificationStream{body: resp.Body, scanner: scanner}, nil
+}
+
+// Next blocks until the next event arrives
+// Returns io.EOF when the server closes the stream
+func (s *NotificationStream) Next() (NotificationEvent, error) {
+	for len(s.pending) == 0 {
+		if !s.scanner.Scan() {
+			if err := s.scanner.Err(); err != nil {
+				return NotificationEvent{}, err
+			}
+			return NotificationEvent{}, io.EOF
+		}
+
+		// MinIO sends whitespace as keep-alive between documents
+		line := bytes.TrimSpace(s.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var info notificationInfo
+		if err := json.Unmarshal(line, &info); err != nil {
+			return NotificationEvent{}, fmt.Errorf("decode notification: %w", err)
+		}
+
+		for _, record := range info.Records {
+			key, err := url.QueryUnescape(record.S3.Object.Key)
+			if err != nil {
+				key = record.S3.Object.Key
+			}
+			eventTime, _ := time.Parse(time.RFC3339Nano, record.EventTime)
+			s.pending = append(s.pending, NotificationEvent{
+				EventName: record.EventName,
+				Key:       key,
+				ETag:      strings.Trim(record.S3.Object.ETag, `"`),
+				Size:      record.S3.Object.Size,
+				EventTime: eventTime,
+			})
+		}
+	}
+
+	event := s.pending[0]
+	s.pending = s.pending[1:]
+	return event, nil
+}
+
+// Close closes the underlying connection
+func (s *NotificationStream) Close() error {
+	return s.body.Close()
+}
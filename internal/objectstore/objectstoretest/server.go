@@ -0,0 +1,363 @@
+// Package objectstoretest provides an in-process S3-compatible server for tests
+package objectstoretest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// object is a stored object
+type object struct {
+	data     []byte
+	etag     string
+	modified time.Time
+}
+
+// Server is a minimal in-memory S3-compatible server supporting path-style
+// ListObjectsV2, GET, HEAD, PUT (including copy) and DELETE on a single bucket,
+// plus MinIO's ListenBucketNotification extension
+type Server struct {
+	*httptest.Server
+
+	bucket        string
+	mu            sync.Mutex
+	objects       map[string]*object
+	maxKeys       int
+	requests      int
+	notifications bool
+	listeners     map[chan []byte]struct{}
+}
+
+// NewServer starts a new server hosting bucket
+func NewServer(bucket string) *Server {
+	s := &Server{
+		bucket:        bucket,
+		objects:       make(map[string]*object),
+		maxKeys:       1000,
+		notifications: true,
+		listeners:     make(map[chan []byte]struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Close closes open notification streams and shuts the server down
+func (s *Server) Close() {
+	s.SetNotifications(false)
+	s.Server.Close()
+}
+
+// SetMaxKeys sets the page size used for list responses
+func (s *Server) SetMaxKeys(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxKeys = n
+}
+
+// PutObject stores data under key, bypassing HTTP
+func (s *Server) PutObject(key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.putLocked(key, data)
+}
+
+// DeleteObject removes key, bypassing HTTP
+func (s *Server) DeleteObject(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.objects[key]; ok {
+		delete(s.objects, key)
+		s.notifyLocked("s3:ObjectRemoved:Delete", key, nil)
+	}
+}
+
+// SetNotifications enables or disables bucket notifications
+// Disabling closes every open notification stream and rejects new ones,
+// simulating a dropped connection
+func (s *Server) SetNotifications(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifications = enabled
+	if !enabled {
+		for ch := range s.listeners {
+			close(ch)
+			delete(s.listeners, ch)
+		}
+	}
+}
+
+// ListenerCount returns the number of open notification streams
+func (s *Server) ListenerCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.listeners)
+}
+
+// Object returns the stored content for key
+func (s *Server) Object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), obj.data...), true
+}
+
+// RequestCount returns the number of HTTP requests served
+func (s *Server) RequestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) putLocked(key string, data []byte) {
+	sum := md5.Sum(data)
+	s.objects[key] = &object{
+		data:     append([]byte(nil), data...),
+		etag:     hex.EncodeToString(sum[:]),
+		modified: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	s.notifyLocked("s3:ObjectCreated:Put", key, s.objects[key])
+}
+
+// notifyLocked broadcasts an event record to every open notification stream
+func (s *Server) notifyLocked(eventName, key string, obj *object) {
+	if len(s.listeners) == 0 {
+		return
+	}
+
+	record := map[string]interface{}{
+		"eventName": eventName,
+		"eventTime": time.Now().UTC().Format(time.RFC3339Nano),
+		"s3": map[string]interface{}{
+			"object": map[string]interface{}{
+				"key": url.QueryEscape(key),
+			},
+		},
+	}
+	if obj != nil {
+		objInfo := record["s3"].(map[string]interface{})["object"].(map[string]interface{})
+		objInfo["eTag"] = obj.etag
+		objInfo["size"] = len(obj.data)
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{"Records": []interface{}{record}})
+	for ch := range s.listeners {
+		select {
+		case ch <- payload:
+		default:
+		}
+	}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket != s.bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+
+	if key == "" {
+		if r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+			s.handleList(w, r.URL.Query())
+			return
+		}
+		if r.Method == http.MethodGet && r.URL.Query().Has("events") {
+			s.handleListen(w, r)
+			return
+		}
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "unsupported bucket operation")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		s.handleGet(w, r, key)
+	case http.MethodPut:
+		s.handlePut(w, r, key)
+	case http.MethodDelete:
+		s.DeleteObject(key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "method not allowed")
+	}
+}
+
+type listContents struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+}
+
+type listResult struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	KeyCount              int            `xml:"KeyCount"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	Contents              []listContents `xml:"Contents"`
+}
+
+func (s *Server) handleList(w http.ResponseWriter, query url.Values) {
+	prefix := query.Get("prefix")
+	start, _ := strconv.Atoi(query.Get("continuation-token"))
+
+	s.mu.Lock()
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	result := listResult{Name: s.bucket, Prefix: prefix}
+	end := start + s.maxKeys
+	if end < len(keys) {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(end)
+	} else {
+		end = len(keys)
+	}
+	for _, k := range keys[min(start, end):end] {
+		obj := s.objects[k]
+		result.Contents = append(result.Contents, listContents{
+			Key:          k,
+			LastModified: obj.modified.Format("2006-01-02T15:04:05.000Z"),
+			ETag:         `"` + obj.etag + `"`,
+			Size:         int64(len(obj.data)),
+		})
+	}
+	s.mu.Unlock()
+
+	result.KeyCount = len(result.Contents)
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func (s *Server) handleListen(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if !s.notifications {
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "ServiceUnavailable", "notifications disabled")
+		return
+	}
+	ch := make(chan []byte, 100)
+	s.listeners[ch] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		if _, ok := s.listeners[ch]; ok {
+			delete(s.listeners, ch)
+			close(ch)
+		}
+		s.mu.Unlock()
+	}()
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	// Keep-alive whitespace, as MinIO sends
+	_, _ = w.Write([]byte(" \n"))
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case payload, ok := <-ch:
+			if !ok {
+				return
+			}
+			_, _ = w.Write(append(payload, '\n'))
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request, key string) {
+	s.mu.Lock()
+	obj, ok := s.objects[key]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	w.Header().Set("ETag", `"`+obj.etag+`"`)
+	w.Header().Set("Last-Modified", obj.modified.Format(http.TimeFormat))
+	w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(obj.data)
+	}
+}
+
+func (s *Server) handlePut(w http.ResponseWriter, r *http.Request, key string) {
+	if source := r.Header.Get("X-Amz-Copy-Source"); source != "" {
+		srcPath, err := url.PathUnescape(strings.TrimPrefix(source, "/"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidArgument", "invalid copy source")
+			return
+		}
+		_, srcKey, _ := strings.Cut(srcPath, "/")
+
+		s.mu.Lock()
+		src, ok := s.objects[srcKey]
+		if ok {
+			s.putLocked(key, src.data)
+		}
+		s.mu.Unlock()
+
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, "<CopyObjectResult></CopyObjectResult>")
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	s.PutObject(key, data)
+
+	obj, _ := s.Object(key)
+	sum := md5.Sum(obj)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
@@ -0,0 +1,207 @@
+package recon
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/ignore"
+	"github.com/susamn/obsidian-web/internal/indexing"
+	"github.com/susamn/obsidian-web/internal/logger"
+	"github.com/susamn/obsidian-web/internal/storage"
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+)
+
+// driftReport counts the differences found by one drift check
+type driftReport struct {
+	Missing   int // Files on disk with no active DB entry
+	Changed   int // Files whose size or modification time is newer than the DB entry
+	Orphaned  int // Active DB entries whose file is gone
+	Unindexed int // Markdown files that have no index document
+	Stale     int // Index documents whose file is gone
+}
+
+// total returns the number of drifted paths
+func (d driftReport) total() int {
+	return d.Missing + d.Changed + d.Orphaned + d.Unindexed + d.Stale
+}
+
+// SetStorage sets the storage and root path drift checks walk
+func (r *ReconciliationService) SetStorage(store storage.VaultStorage, vaultPath string) {
+	r.storage = store
+	r.vaultPath = vaultPath
+}
+
+// SetIgnoreRules sets the rules for paths drift checks leave alone
+func (r *ReconciliationService) SetIgnoreRules(rules *ignore.Rules) {
+	r.ignore = rules
+}
+
+// SetDriftInterval sets how often the vault is compared with the DB and index.
+// Zero disables drift checks. Must be called before Start.
+func (r *ReconciliationService) SetDriftInterval(interval time.Duration) {
+	r.driftInterval = interval
+}
+
+// runDriftChecks compares the vault with the DB and index on every interval
+func (r *ReconciliationService) runDriftChecks() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.driftInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			r.checkDrift(r.ctx)
+		}
+	}
+}
+
+// checkDrift walks the vault, compares every path with its DB entry and index
+// document, and injects events for the paths that differ. Unlike a full reindex
+// nothing is disabled or cleared, so the UI keeps working while drift is fixed.
+func (r *ReconciliationService) checkDrift(ctx context.Context) driftReport {
+	var report driftReport
+	if r.storage == nil || r.dbService == nil || r.syncServiceRef == nil {
+		return report
+	}
+
+	// Events still in flight would show up as drift
+	if atomic.LoadInt32(&r.reindexing) == 1 || r.syncServiceRef.PendingEventsCount() > 0 {
+		logger.WithField("vault_id", r.vaultID).Debug("Vault busy, skipping drift check")
+		return report
+	}
+
+	entries, err := r.dbService.GetActiveFileEntries()
+	if err != nil {
+		logger.WithError(err).WithField("vault_id", r.vaultID).Warn("Drift check failed to load file entries")
+		return report
+	}
+	byPath := make(map[string]*db.FileEntry, len(entries))
+	activeIDs := make(map[string]bool, len(entries))
+	for i := range entries {
+		byPath[filepath.ToSlash(entries[i].Path)] = &entries[i]
+		activeIDs[entries[i].ID] = true
+	}
+
+	// The index is only compared once initial indexing has finished
+	var indexed map[string]string
+	if r.indexService != nil && r.indexService.GetStatus() == indexing.StatusReady {
+		if indexed, err = r.indexService.IndexedDocuments(); err != nil {
+			logger.WithError(err).WithField("vault_id", r.vaultID).Warn("Drift check failed to list index documents")
+		}
+	}
+
+	var events []syncpkg.FileChangeEvent
+	emit := func(relPath string, eventType syncpkg.FileEventType) {
+		events = append(events, syncpkg.FileChangeEvent{
+			VaultID:   r.vaultID,
+			Path:      filepath.Join(r.vaultPath, filepath.FromSlash(relPath)),
+			EventType: eventType,
+			Timestamp: time.Now(),
+			Force:     true, // The DB may be current while the index is not
+		})
+	}
+
+	onDisk := make(map[string]bool)
+	err = storage.Walk(ctx, r.storage, "", func(info storage.FileInfo) error {
+		if r.ignore.Ignored(info.Path, info.IsDir) {
+			if info.IsDir {
+				return storage.SkipDir
+			}
+			return nil
+		}
+		onDisk[info.Path] = true
+
+		entry := byPath[info.Path]
+		switch {
+		case entry == nil:
+			report.Missing++
+			emit(info.Path, syncpkg.FileCreated)
+		case info.IsDir:
+			// Directories only need to exist
+		case entry.Size != info.Size || info.ModTime.Truncate(time.Second).After(entry.Modified):
+			report.Changed++
+			emit(info.Path, syncpkg.FileModified)
+		case indexed != nil && indexing.IsIndexable(info.Path) &&
+			!hasDocument(indexed, entry.ID) && !hasDocument(indexed, filepath.FromSlash(info.Path)):
+			report.Unindexed++
+			emit(info.Path, syncpkg.FileModified)
+		}
+		return nil
+	})
+	if err != nil {
+		// A partial walk would report everything it missed as deleted
+		logger.WithError(err).WithField("vault_id", r.vaultID).Warn("Drift check failed to walk vault")
+		return driftReport{}
+	}
+
+	for relPath := range byPath {
+		if relPath != "" && relPath != "." && !onDisk[relPath] {
+			report.Orphaned++
+			emit(relPath, syncpkg.FileDeleted)
+		}
+	}
+
+	for docID, docPath := range indexed {
+		relPath := filepath.ToSlash(docPath)
+		if !activeIDs[docID] && !onDisk[relPath] && byPath[relPath] == nil {
+			report.Stale++
+			emit(relPath, syncpkg.FileDeleted)
+		}
+	}
+
+	r.injectDrift(events)
+	r.recordDrift(report)
+	return report
+}
+
+// hasDocument reports whether the index holds a document with the given ID
+func hasDocument(indexed map[string]string, docID string) bool {
+	_, ok := indexed[docID]
+	return ok
+}
+
+// injectDrift sends drift events to the sync channel.
+// Whatever does not fit is picked up again by the next check.
+func (r *ReconciliationService) injectDrift(events []syncpkg.FileChangeEvent) {
+	for i, event := range events {
+		if !r.syncServiceRef.InjectEvent(event) {
+			logger.WithFields(map[string]interface{}{
+				"vault_id": r.vaultID,
+				"skipped":  len(events) - i,
+			}).Warn("Sync channel full, deferring drift events to next check")
+			return
+		}
+	}
+}
+
+// recordDrift adds a check's findings to the metrics
+func (r *ReconciliationService) recordDrift(report driftReport) {
+	atomic.AddInt64(&r.driftChecks, 1)
+	atomic.AddInt64(&r.driftMissing, int64(report.Missing))
+	atomic.AddInt64(&r.driftChanged, int64(report.Changed))
+	atomic.AddInt64(&r.driftOrphaned, int64(report.Orphaned))
+	atomic.AddInt64(&r.driftUnindexed, int64(report.Unindexed))
+	atomic.AddInt64(&r.driftStale, int64(report.Stale))
+	atomic.StoreInt64(&r.lastDriftCheck, time.Now().UnixNano())
+
+	fields := map[string]interface{}{
+		"vault_id":  r.vaultID,
+		"missing":   report.Missing,
+		"changed":   report.Changed,
+		"orphaned":  report.Orphaned,
+		"unindexed": report.Unindexed,
+		"stale":     report.Stale,
+	}
+	if report.total() > 0 {
+		logger.WithFields(fields).Info("Drift check found out-of-date files")
+	} else {
+		logger.WithFields(fields).Debug("Drift check found no differences")
+	}
+}
@@ -0,0 +1,140 @@
+package recon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/indexing"
+	"github.com/susamn/obsidian-web/internal/storage"
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+)
+
+// TestReconciliationService_CheckDrift tests that only drifted paths are re-processed
+func TestReconciliationService_CheckDrift(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vaultDir := t.TempDir()
+	store := storage.NewLocalStorage(vaultDir)
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(vaultDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"keep.md", "changed.md", "gone.md", "ghost.md"} {
+		write(name, "# "+name)
+	}
+
+	// Index everything on disk, then let the index fall behind
+	idxSvc, err := indexing.NewIndexService(ctx, &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		IndexPath: filepath.Join(t.TempDir(), "index"),
+	}, vaultDir)
+	if err != nil {
+		t.Fatalf("Failed to create index service: %v", err)
+	}
+	if err := idxSvc.Start(); err != nil {
+		t.Fatalf("Failed to start index service: %v", err)
+	}
+	defer idxSvc.Stop()
+	deadline := time.Now().Add(5 * time.Second)
+	for idxSvc.GetStatus() != indexing.StatusReady {
+		if time.Now().After(deadline) {
+			t.Fatal("Index service did not become ready")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	dbSvc, err := db.NewDBService(ctx, &dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create db service: %v", err)
+	}
+	if err := dbSvc.Start(); err != nil {
+		t.Fatalf("Failed to start db service: %v", err)
+	}
+	defer dbSvc.Stop()
+
+	write("unindexed.md", "# not in the index")
+	for _, name := range []string{"keep.md", "changed.md", "gone.md", "unindexed.md"} {
+		if _, err := dbSvc.PerformDatabaseUpdate(store, vaultDir, syncpkg.FileChangeEvent{
+			Path:      filepath.Join(vaultDir, name),
+			EventType: syncpkg.FileCreated,
+			Timestamp: time.Now(),
+		}); err != nil {
+			t.Fatalf("Failed to track %s: %v", name, err)
+		}
+	}
+
+	// Changes the watcher never reported
+	write("new.md", "# new")
+	write("changed.md", "# changed.md with more content")
+	for _, name := range []string{"gone.md", "ghost.md"} {
+		if err := os.Remove(filepath.Join(vaultDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	syncSvc, err := syncpkg.NewSyncService(ctx, "test-vault", &config.StorageConfig{
+		Type:  "local",
+		Local: &config.LocalStorageConfig{Path: vaultDir},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create sync service: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	recon := NewReconciliationService("test-vault", ctx, &wg, dbSvc, nil, idxSvc, nil, nil)
+	recon.SetSyncService(syncSvc)
+	recon.SetStorage(store, vaultDir)
+
+	report := recon.checkDrift(ctx)
+	want := driftReport{Missing: 1, Changed: 1, Orphaned: 1, Unindexed: 1, Stale: 1}
+	if report != want {
+		t.Errorf("checkDrift() = %+v, want %+v", report, want)
+	}
+
+	got := make(map[string]syncpkg.FileEventType)
+	for len(syncSvc.Events()) > 0 {
+		event := <-syncSvc.Events()
+		rel, _ := filepath.Rel(vaultDir, event.Path)
+		got[rel] = event.EventType
+	}
+	wantEvents := map[string]syncpkg.FileEventType{
+		"new.md":       syncpkg.FileCreated,
+		"changed.md":   syncpkg.FileModified,
+		"gone.md":      syncpkg.FileDeleted,
+		"unindexed.md": syncpkg.FileModified,
+		"ghost.md":     syncpkg.FileDeleted,
+	}
+	if len(got) != len(wantEvents) {
+		t.Errorf("Injected events = %v, want %v", got, wantEvents)
+	}
+	for path, eventType := range wantEvents {
+		if got[path] != eventType {
+			t.Errorf("Event for %s = %v, want %v", path, got[path], eventType)
+		}
+	}
+
+	metrics := recon.GetMetrics()
+	if metrics.DriftChecks != 1 || metrics.DriftMissing != 1 || metrics.DriftStale != 1 {
+		t.Errorf("Unexpected drift metrics: %+v", metrics)
+	}
+	if metrics.LastDriftCheck.IsZero() {
+		t.Error("Expected last drift check time to be set")
+	}
+
+	// Busy vaults are not checked
+	syncSvc.InjectEvent(syncpkg.FileChangeEvent{Path: filepath.Join(vaultDir, "new.md"), EventType: syncpkg.FileCreated})
+	if report := recon.checkDrift(ctx); report.total() != 0 {
+		t.Errorf("checkDrift() with pending events = %+v, want no drift", report)
+	}
+}
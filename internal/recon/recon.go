@@ -8,9 +8,11 @@ import (
 
 	"github.com/susamn/obsidian-web/internal/db"
 	"github.com/susamn/obsidian-web/internal/explorer"
+	"github.com/susamn/obsidian-web/internal/ignore"
 	"github.com/susamn/obsidian-web/internal/indexing"
 	"github.com/susamn/obsidian-web/internal/logger"
 	"github.com/susamn/obsidian-web/internal/sse"
+	"github.com/susamn/obsidian-web/internal/storage"
 	syncpkg "github.com/susamn/obsidian-web/internal/sync"
 )
 
@@ -20,6 +22,7 @@ type ReconciliationService struct {
 	vaultID        string
 	dlq            chan syncpkg.FileChangeEvent
 	syncServiceRef *syncpkg.SyncService // Reference to sync service to retry events
+	journal        *syncpkg.Journal     // Records DLQ entries so they survive a restart
 	ctx            context.Context
 	wg             *sync.WaitGroup
 	retryInterval  time.Duration
@@ -30,11 +33,25 @@ type ReconciliationService struct {
 	indexService    *indexing.IndexService
 	sseManager      *sse.Manager
 	setStatus       func(reindexing bool) // Callback to update vault status
+	reindexing      int32                 // 1 while a full reindex runs
+
+	// Drift checks between the vault, the DB and the index
+	storage       storage.VaultStorage
+	vaultPath     string
+	ignore        *ignore.Rules
+	driftInterval time.Duration
 
 	// Metrics
-	dlqCount     int64
-	retriedCount int64
-	droppedCount int64
+	dlqCount       int64
+	retriedCount   int64
+	droppedCount   int64
+	driftChecks    int64
+	driftMissing   int64
+	driftChanged   int64
+	driftOrphaned  int64
+	driftUnindexed int64
+	driftStale     int64
+	lastDriftCheck int64 // Unix nanoseconds
 }
 
 // NewReconciliationService creates a new reconciliation service
@@ -67,14 +84,25 @@ func (r *ReconciliationService) SetSyncService(syncService *syncpkg.SyncService)
 	r.syncServiceRef = syncService
 }
 
+// SetJournal records events sent to the DLQ, with their retry counts, in j
+func (r *ReconciliationService) SetJournal(j *syncpkg.Journal) {
+	r.journal = j
+}
+
 // Start starts the reconciliation service
 func (r *ReconciliationService) Start() {
 	r.wg.Add(1)
 	go r.processDLQ()
 
+	if r.driftInterval > 0 && r.storage != nil {
+		r.wg.Add(1)
+		go r.runDriftChecks()
+	}
+
 	logger.WithFields(map[string]interface{}{
 		"vault_id":       r.vaultID,
 		"retry_interval": r.retryInterval,
+		"drift_interval": r.driftInterval,
 	}).Info("Reconciliation service started")
 }
 
@@ -86,6 +114,10 @@ func (r *ReconciliationService) TriggerReindex() {
 	go func() {
 		defer r.wg.Done()
 
+		// Drift checks would race the rebuild
+		atomic.StoreInt32(&r.reindexing, 1)
+		defer atomic.StoreInt32(&r.reindexing, 0)
+
 		// 1. Set status to reindexing and notify
 		if r.setStatus != nil {
 			r.setStatus(true)
@@ -163,7 +195,16 @@ func (r *ReconciliationService) TriggerReindex() {
 
 // SendToDLQ sends a failed event to the DLQ for retry
 // Non-blocking - drops event if DLQ is full
+// With a journal, a dropped event stays journaled and is replayed on the next start
 func (r *ReconciliationService) SendToDLQ(event syncpkg.FileChangeEvent) {
+	retries := 0
+	if r.journal != nil && event.Seq != 0 {
+		var err error
+		if retries, err = r.journal.MarkDLQ(event.Seq); err != nil {
+			logger.WithError(err).WithField("path", event.Path).Warn("Failed to journal DLQ entry")
+		}
+	}
+
 	select {
 	case r.dlq <- event:
 		atomic.AddInt64(&r.dlqCount, 1)
@@ -171,13 +212,25 @@ func (r *ReconciliationService) SendToDLQ(event syncpkg.FileChangeEvent) {
 			"vault_id": r.vaultID,
 			"path":     event.Path,
 			"dlq_size": len(r.dlq),
+			"retries":  retries,
 		}).Debug("Event sent to DLQ")
 	default:
 		atomic.AddInt64(&r.droppedCount, 1)
 		logger.WithFields(map[string]interface{}{
 			"vault_id": r.vaultID,
 			"path":     event.Path,
-		}).Error("DLQ full, event permanently dropped")
+		}).Error("DLQ full, event dropped")
+	}
+}
+
+// RestoreDLQ puts an event from the journal back in the DLQ without counting a retry.
+// Returns false if the DLQ is full; the event stays journaled.
+func (r *ReconciliationService) RestoreDLQ(event syncpkg.FileChangeEvent) bool {
+	select {
+	case r.dlq <- event:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -260,12 +313,22 @@ func (r *ReconciliationService) GetDLQDepth() int {
 
 // GetMetrics returns reconciliation service metrics
 func (r *ReconciliationService) GetMetrics() ReconciliationMetrics {
-	return ReconciliationMetrics{
-		DLQDepth:     r.GetDLQDepth(),
-		DLQCount:     atomic.LoadInt64(&r.dlqCount),
-		RetriedCount: atomic.LoadInt64(&r.retriedCount),
-		DroppedCount: atomic.LoadInt64(&r.droppedCount),
+	metrics := ReconciliationMetrics{
+		DLQDepth:       r.GetDLQDepth(),
+		DLQCount:       atomic.LoadInt64(&r.dlqCount),
+		RetriedCount:   atomic.LoadInt64(&r.retriedCount),
+		DroppedCount:   atomic.LoadInt64(&r.droppedCount),
+		DriftChecks:    atomic.LoadInt64(&r.driftChecks),
+		DriftMissing:   atomic.LoadInt64(&r.driftMissing),
+		DriftChanged:   atomic.LoadInt64(&r.driftChanged),
+		DriftOrphaned:  atomic.LoadInt64(&r.driftOrphaned),
+		DriftUnindexed: atomic.LoadInt64(&r.driftUnindexed),
+		DriftStale:     atomic.LoadInt64(&r.driftStale),
 	}
+	if last := atomic.LoadInt64(&r.lastDriftCheck); last != 0 {
+		metrics.LastDriftCheck = time.Unix(0, last)
+	}
+	return metrics
 }
 
 // ReconciliationMetrics represents reconciliation service metrics
@@ -274,4 +337,13 @@ type ReconciliationMetrics struct {
 	DLQCount     int64 // Total events sent to DLQ
 	RetriedCount int64 // Total events retried
 	DroppedCount int64 // Total events dropped (DLQ full)
+
+	// Drift checks; counts are totals over all checks
+	DriftChecks    int64     // Completed drift checks
+	DriftMissing   int64     // Files found on disk but not in the DB
+	DriftChanged   int64     // Files changed on disk since their DB entry
+	DriftOrphaned  int64     // DB entries whose file was gone
+	DriftUnindexed int64     // Markdown files missing from the index
+	DriftStale     int64     // Index documents whose file was gone
+	LastDriftCheck time.Time // Zero until the first check completes
 }
@@ -120,7 +120,7 @@ func (sr *StructuredRenderer) ProcessMarkdown(content string, vaultID string, fi
 	allTags := sr.mergeTags(frontmatterTags, inlineTags, vaultID)
 
 	// Extract headings
-	headings := sr.extractHeadings(cleanContent)
+	headings := sr.ExtractHeadings(cleanContent)
 
 	// Extract wikilinks
 	wikilinks := sr.extractWikiLinks(cleanContent, vaultID)
@@ -290,8 +290,8 @@ func (sr *StructuredRenderer) mergeTags(frontmatterTags, inlineTags []string, va
 	return tags
 }
 
-// extractHeadings extracts headings from markdown content
-func (sr *StructuredRenderer) extractHeadings(content string) []Heading {
+// ExtractHeadings extracts headings from markdown content
+func (sr *StructuredRenderer) ExtractHeadings(content string) []Heading {
 	headingRegex := regexp.MustCompile(`(?m)^(#{1,6})\s+(.+)$`)
 	matches := headingRegex.FindAllStringSubmatch(content, -1)
 
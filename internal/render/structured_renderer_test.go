@@ -146,7 +146,7 @@ func TestExtractHeadings(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			sr := NewStructuredRenderer(nil)
-			headings := sr.extractHeadings(tt.content)
+			headings := sr.ExtractHeadings(tt.content)
 
 			if len(headings) != tt.expected {
 				t.Errorf("Expected %d headings, got %d", tt.expected, len(headings))
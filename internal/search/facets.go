@@ -0,0 +1,194 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Facets accepted in SearchOptions.Facets and SearchOptions.Selection
+const (
+	FacetTags     = "tags"     // Most used tags, lowercased
+	FacetFolders  = "folders"  // Top-level folders, "/" for notes at the vault root
+	FacetModified = "modified" // Months notes were last modified in, labelled YYYY-MM
+)
+
+// DefaultFacetSize is the number of tags and folders counted when
+// SearchOptions.FacetSize is not set
+const DefaultFacetSize = 10
+
+// ModifiedOlder labels the modified bucket for notes older than the last
+// modifiedMonths months
+const ModifiedOlder = "older"
+
+// modifiedMonths is the number of monthly buckets in the modified facet
+const modifiedMonths = 12
+
+// monthLayout formats the modified bucket labels
+const monthLayout = "2006-01"
+
+// facetFields maps each facet to the index field it counts
+var facetFields = map[string]string{
+	FacetTags:     "tags",
+	FacetFolders:  "folder",
+	FacetModified: "modified",
+}
+
+// FacetSelection narrows results to the selected facet values. Values of
+// one facet match any of them; different facets must all match.
+type FacetSelection map[string][]string
+
+// validate reports unknown facets and modified labels
+func (sel FacetSelection) validate() error {
+	for facet, values := range sel {
+		if _, ok := facetFields[facet]; !ok {
+			return fmt.Errorf("unknown facet %q (use tags, folders or modified)", facet)
+		}
+		if facet != FacetModified {
+			continue
+		}
+		for _, v := range values {
+			if _, _, err := monthRange(v, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// filter returns a query matching the selection, leaving out the skip
+// facet, or nil when nothing is selected
+func (sel FacetSelection) filter(skip string, now time.Time) query.Query {
+	var filters []query.Query
+	for _, facet := range []string{FacetTags, FacetFolders, FacetModified} {
+		values := sel[facet]
+		if facet == skip || len(values) == 0 {
+			continue
+		}
+		var anyOf []query.Query
+		for _, v := range values {
+			if facet == FacetModified {
+				start, end, _ := monthRange(v, now)
+				inclusive, exclusive := true, false
+				q := query.NewDateRangeInclusiveQuery(start, end, &inclusive, &exclusive)
+				q.SetField(facetFields[facet])
+				anyOf = append(anyOf, q)
+				continue
+			}
+			if facet == FacetTags {
+				// Tags are indexed lowercased
+				v = strings.ToLower(v)
+			}
+			q := bleve.NewTermQuery(v)
+			q.SetField(facetFields[facet])
+			anyOf = append(anyOf, q)
+		}
+		filters = append(filters, bleve.NewDisjunctionQuery(anyOf...))
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+	return bleve.NewConjunctionQuery(filters...)
+}
+
+// narrow restricts q to documents matching the filter without changing scores
+func narrow(q, filter query.Query) query.Query {
+	if filter == nil {
+		return q
+	}
+	bq := query.NewBooleanQuery([]query.Query{q}, nil, nil)
+	bq.AddFilter(filter)
+	return bq
+}
+
+// monthRange returns the dates a modified label covers. The current month
+// has no end, so notes dated in the future count in it, and older has no start.
+func monthRange(label string, now time.Time) (time.Time, time.Time, error) {
+	current := startOfMonth(now)
+	if label == ModifiedOlder {
+		return time.Time{}, current.AddDate(0, 1-modifiedMonths, 0), nil
+	}
+	start, err := time.Parse(monthLayout, label)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid modified facet value %q (use YYYY-MM or %s)", label, ModifiedOlder)
+	}
+	if !start.Before(current) {
+		return start, time.Time{}, nil
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// startOfMonth returns the start of the UTC month containing t
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// facetRequest builds the bleve facet request counting a facet
+func facetRequest(facet string, size int, now time.Time) *bleve.FacetRequest {
+	if facet != FacetModified {
+		return bleve.NewFacetRequest(facetFields[facet], size)
+	}
+	fr := bleve.NewFacetRequest(facetFields[facet], modifiedMonths+1)
+	month := startOfMonth(now)
+	for i := 0; i < modifiedMonths; i++ {
+		start, end, _ := monthRange(month.Format(monthLayout), now)
+		fr.AddDateTimeRange(month.Format(monthLayout), start, end)
+		month = month.AddDate(0, -1, 0)
+	}
+	start, end, _ := monthRange(ModifiedOlder, now)
+	fr.AddDateTimeRange(ModifiedOlder, start, end)
+	return fr
+}
+
+// addFacets adds the requested facets to a search request and narrows its
+// query to the selection
+func (o SearchOptions) addFacets(req *bleve.SearchRequest, now time.Time) {
+	size := DefaultFacetSize
+	if o.FacetSize > 0 {
+		size = o.FacetSize
+	}
+	for _, facet := range o.Facets {
+		req.AddFacet(facet, facetRequest(facet, size, now))
+	}
+	req.Query = narrow(req.Query, o.Selection.filter("", now))
+}
+
+// recountSelected recounts each requested facet that has a selection using
+// only the other facets' selections, so the facet still lists the values
+// that can be added to or swapped for the selected ones
+func recountSelected(index bleve.Index, base query.Query, req *bleve.SearchRequest, result *bleve.SearchResult, o SearchOptions, now time.Time) error {
+	for facet := range req.Facets {
+		if len(o.Selection[facet]) == 0 {
+			continue
+		}
+		sub := bleve.NewSearchRequestOptions(narrow(base, o.Selection.filter(facet, now)), 0, 0, false)
+		sub.AddFacet(facet, req.Facets[facet])
+		subResult, err := index.Search(sub)
+		if err != nil {
+			return err
+		}
+		result.Facets[facet] = subResult.Facets[facet]
+	}
+	return nil
+}
+
+// sortModifiedBuckets lists modified buckets newest first, older last,
+// instead of by count
+func sortModifiedBuckets(result *bleve.SearchResult) {
+	fr, ok := result.Facets[FacetModified]
+	if !ok || fr == nil {
+		return
+	}
+	sort.SliceStable(fr.DateRanges, func(i, j int) bool {
+		a, b := fr.DateRanges[i].Name, fr.DateRanges[j].Name
+		if a == ModifiedOlder || b == ModifiedOlder {
+			return b == ModifiedOlder && a != ModifiedOlder
+		}
+		return a > b
+	})
+}
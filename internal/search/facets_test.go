@@ -0,0 +1,170 @@
+package search
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
+)
+
+// createFacetTestService indexes notes with the facet fields the indexer
+// adds: tags lowercased and folder as whole values, modified as a date
+func createFacetTestService(t *testing.T) *SearchService {
+	t.Helper()
+
+	indexMapping := bleve.NewIndexMapping()
+	if err := indexMapping.AddCustomAnalyzer("lowercase_keyword", map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	docMapping := bleve.NewDocumentMapping()
+	for field, analyzer := range map[string]string{"tags": "lowercase_keyword", "folder": keyword.Name} {
+		fm := bleve.NewTextFieldMapping()
+		fm.Analyzer = analyzer
+		fm.IncludeInAll = false
+		docMapping.AddFieldMappingsAt(field, fm)
+	}
+	modified := bleve.NewDateTimeFieldMapping()
+	modified.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("modified", modified)
+	indexMapping.DefaultMapping = docMapping
+
+	index, err := bleve.NewMemOnly(indexMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	month := startOfMonth(time.Now())
+	docs := map[string]map[string]interface{}{
+		"a": {"content": "walk", "tags": []string{"Work", "urgent"}, "folder": "work", "modified": month.Add(time.Hour)},
+		"b": {"content": "walk", "tags": []string{"work"}, "folder": "work", "modified": month.AddDate(0, -1, 0)},
+		"c": {"content": "walk", "tags": []string{"home"}, "folder": "home", "modified": month.AddDate(0, -1, 1)},
+		"d": {"content": "walk", "tags": []string{"work"}, "folder": "/", "modified": month.AddDate(-3, 0, 0)},
+		"e": {"content": "run", "tags": []string{"home"}, "folder": "home", "modified": month},
+	}
+	for id, doc := range docs {
+		if err := index.Index(id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := NewSearchService(context.Background(), "test-vault", index)
+	if err := svc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		svc.Stop()
+		index.Close()
+	})
+	return svc
+}
+
+// facetCounts formats a facet result as "value:count" pairs in result order
+func facetCounts(result *bleve.SearchResult, facet string) string {
+	fr := result.Facets[facet]
+	if fr == nil {
+		return ""
+	}
+	var counts []string
+	for _, term := range fr.Terms.Terms() {
+		counts = append(counts, term.Term+":"+strconv.Itoa(term.Count))
+	}
+	for _, r := range fr.DateRanges {
+		counts = append(counts, r.Name+":"+strconv.Itoa(r.Count))
+	}
+	return strings.Join(counts, " ")
+}
+
+func TestSearchFacets(t *testing.T) {
+	svc := createFacetTestService(t)
+	month := startOfMonth(time.Now())
+	thisMonth, lastMonth := month.Format(monthLayout), month.AddDate(0, -1, 0).Format(monthLayout)
+	allFacets := []string{FacetTags, FacetFolders, FacetModified}
+
+	t.Run("counts", func(t *testing.T) {
+		result, err := svc.SearchByText("walk", SearchOptions{Facets: allFacets})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := facetCounts(result, FacetTags), "work:3 home:1 urgent:1"; got != want {
+			t.Errorf("tags = %s, want %s", got, want)
+		}
+		if got, want := facetCounts(result, FacetFolders), "work:2 /:1 home:1"; got != want {
+			t.Errorf("folders = %s, want %s", got, want)
+		}
+		if got, want := facetCounts(result, FacetModified), thisMonth+":1 "+lastMonth+":2 older:1"; got != want {
+			t.Errorf("modified = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("size", func(t *testing.T) {
+		result, err := svc.SearchByText("walk", SearchOptions{Facets: []string{FacetTags}, FacetSize: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := facetCounts(result, FacetTags); got != "work:3" {
+			t.Errorf("tags = %s, want work:3", got)
+		}
+		if other := result.Facets[FacetTags].Other; other != 2 {
+			t.Errorf("tags other = %d, want 2", other)
+		}
+	})
+
+	t.Run("selection", func(t *testing.T) {
+		result, err := svc.SearchByText("walk", SearchOptions{
+			Facets:    allFacets,
+			Selection: FacetSelection{FacetFolders: {"work", "home"}, FacetModified: {lastMonth}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hitIDs(result); got != "b c" || result.Total != 2 {
+			t.Errorf("hits = %s of %d, want b c", got, result.Total)
+		}
+		// Selected facets are counted without their own selection
+		if got, want := facetCounts(result, FacetFolders), "home:1 work:1"; got != want {
+			t.Errorf("folders = %s, want %s", got, want)
+		}
+		if got, want := facetCounts(result, FacetModified), thisMonth+":1 "+lastMonth+":2"; got != want {
+			t.Errorf("modified = %s, want %s", got, want)
+		}
+		if got, want := facetCounts(result, FacetTags), "home:1 work:1"; got != want {
+			t.Errorf("tags = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("older selection", func(t *testing.T) {
+		result, err := svc.SearchByText("walk", SearchOptions{Selection: FacetSelection{FacetModified: {ModifiedOlder}, FacetTags: {"WORK"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hitIDs(result); got != "d" {
+			t.Errorf("hits = %s, want d", got)
+		}
+		if len(result.Facets) != 0 {
+			t.Errorf("facets = %v, want none requested", result.Facets)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, opts := range []SearchOptions{
+			{Facets: []string{"authors"}},
+			{Selection: FacetSelection{"authors": {"x"}}},
+			{Selection: FacetSelection{FacetModified: {"last week"}}},
+			{FacetSize: -1},
+		} {
+			if _, err := svc.SearchByText("walk", opts); err == nil {
+				t.Errorf("SearchByText() with %+v = nil error", opts)
+			}
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/susamn/obsidian-web/internal/db"
+)
+
+// NoteSearcher runs queries against the notes kept by the sqlite_fts search engine
+type NoteSearcher interface {
+	SearchNotes(q db.NoteQuery) ([]db.NoteHit, uint64, float64, error)
+}
+
+// ErrUnsupportedQuery is returned for queries the sqlite_fts engine cannot run
+var ErrUnsupportedQuery = errors.New("not supported by the sqlite_fts engine")
+
+// noteColumns are the document fields the full-text tables can search by column
+var noteColumns = map[string]bool{"title": true, "content": true, "metadata": true}
+
+// searchNotes runs a bleve search request, narrowed to the date ranges of
+// opts, against a note searcher, so the search methods work the same for both engines
+func searchNotes(notes NoteSearcher, req *bleve.SearchRequest, opts SearchOptions) (*bleve.SearchResult, error) {
+	start := time.Now()
+
+	q, err := toNoteQuery(req.Query)
+	if err != nil {
+		return nil, err
+	}
+	q.Highlight = req.Highlight != nil
+	q.Limit = req.Size
+	q.Offset = req.From
+	q.After = req.SearchAfter
+	q.Created = db.TimeRange(opts.Created)
+	q.Modified = db.TimeRange(opts.Modified)
+	q.Date = db.TimeRange(opts.Date)
+	for _, s := range req.Sort {
+		switch s := s.(type) {
+		case *bsearch.SortScore:
+			q.Sort = append(q.Sort, db.NoteSort{Field: db.NoteSortScore, Desc: s.Desc})
+		case *bsearch.SortDocID:
+			q.Sort = append(q.Sort, db.NoteSort{Field: db.NoteSortID, Desc: s.Desc})
+		case *bsearch.SortField:
+			field := s.Field
+			if field == "sort_title" {
+				field = db.NoteSortTitle
+			}
+			q.Sort = append(q.Sort, db.NoteSort{Field: field, Desc: s.Desc})
+		default:
+			return nil, fmt.Errorf("sort %T is %w", s, ErrUnsupportedQuery)
+		}
+	}
+
+	hits, total, maxScore, err := notes.SearchNotes(q)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &bleve.SearchResult{
+		Status:   &bleve.SearchStatus{Total: 1, Successful: 1},
+		Request:  req,
+		Hits:     make(bsearch.DocumentMatchCollection, 0, len(hits)),
+		Total:    total,
+		MaxScore: maxScore,
+	}
+	for _, hit := range hits {
+		fields := make(map[string]interface{}, len(req.Fields))
+		for _, field := range req.Fields {
+			switch field {
+			case "title":
+				fields[field] = hit.Title
+			case "path":
+				fields[field] = hit.Path
+			case "tags":
+				if len(hit.Tags) > 0 {
+					fields[field] = hit.Tags
+				}
+			case "wikilinks":
+				if len(hit.Wikilinks) > 0 {
+					fields[field] = hit.Wikilinks
+				}
+			case "created":
+				if !hit.Created.IsZero() {
+					fields[field] = hit.Created.Format(time.RFC3339Nano)
+				}
+			case "modified":
+				if !hit.Modified.IsZero() {
+					fields[field] = hit.Modified.Format(time.RFC3339Nano)
+				}
+			case "date":
+				if !hit.Date.IsZero() {
+					fields[field] = hit.Date.Format(time.RFC3339Nano)
+				}
+			}
+		}
+		match := &bsearch.DocumentMatch{
+			ID:          hit.ID,
+			Score:       hit.Score,
+			Fields:      fields,
+			DecodedSort: hit.SortValues,
+		}
+		if len(hit.Fragments) > 0 {
+			match.Fragments = bsearch.FieldFragmentMap(hit.Fragments)
+		}
+		result.Hits = append(result.Hits, match)
+	}
+	result.Took = time.Since(start)
+	return result, nil
+}
+
+// toNoteQuery rewrites the bleve queries built by the search methods as an
+// FTS5 match expression plus tag and wikilink filters. Fuzzy queries match
+// their term exactly, as FTS5 has no edit distance.
+func toNoteQuery(q query.Query) (db.NoteQuery, error) {
+	var nq db.NoteQuery
+	switch q := q.(type) {
+	case *query.MatchQuery:
+		op := " OR "
+		if q.Operator == query.MatchQueryOperatorAnd {
+			op = " AND "
+		}
+		return fieldQuery(q.FieldVal, q.Match, strings.Join(ftsTerms(q.FieldVal, q.Match), op))
+
+	case *query.MatchPhraseQuery:
+		return fieldQuery(q.FieldVal, q.MatchPhrase, ftsColumn(q.FieldVal)+ftsPhrase(q.MatchPhrase))
+
+	case *query.PrefixQuery:
+		if isListField(q.FieldVal) {
+			return nq, fmt.Errorf("prefix search on %s is %w", q.FieldVal, ErrUnsupportedQuery)
+		}
+		return fieldQuery(q.FieldVal, q.Prefix, ftsColumn(q.FieldVal)+ftsPhrase(q.Prefix)+" *")
+
+	case *query.FuzzyQuery:
+		return fieldQuery(q.FieldVal, q.Term, strings.Join(ftsTerms(q.FieldVal, q.Term), " OR "))
+
+	case *query.ConjunctionQuery:
+		var matches []string
+		for _, child := range q.Conjuncts {
+			cq, err := toNoteQuery(child)
+			if err != nil {
+				return nq, err
+			}
+			if cq.Match != "" {
+				matches = append(matches, "("+cq.Match+")")
+			}
+			nq.Tags = append(nq.Tags, cq.Tags...)
+			nq.Wikilinks = append(nq.Wikilinks, cq.Wikilinks...)
+		}
+		nq.Match = strings.Join(matches, " AND ")
+		return nq, nil
+
+	case *query.DisjunctionQuery:
+		// Text is ORed in the match expression and tags or wikilinks in a
+		// single filter group; mixing the two cannot be expressed
+		var matches []string
+		var tags, wikilinks []string
+		for _, child := range q.Disjuncts {
+			cq, err := toNoteQuery(child)
+			if err != nil {
+				return nq, err
+			}
+			switch {
+			case cq.Match != "" && len(cq.Tags) == 0 && len(cq.Wikilinks) == 0:
+				matches = append(matches, "("+cq.Match+")")
+			case cq.Match == "" && len(cq.Tags) == 1 && len(cq.Wikilinks) == 0:
+				tags = append(tags, cq.Tags[0]...)
+			case cq.Match == "" && len(cq.Tags) == 0 && len(cq.Wikilinks) == 1:
+				wikilinks = append(wikilinks, cq.Wikilinks[0]...)
+			default:
+				return nq, fmt.Errorf("this combination of queries is %w", ErrUnsupportedQuery)
+			}
+		}
+		if (len(matches) > 0 && len(tags)+len(wikilinks) > 0) || (len(tags) > 0 && len(wikilinks) > 0) {
+			return nq, fmt.Errorf("this combination of queries is %w", ErrUnsupportedQuery)
+		}
+		nq.Match = strings.Join(matches, " OR ")
+		if len(tags) > 0 {
+			nq.Tags = [][]string{tags}
+		}
+		if len(wikilinks) > 0 {
+			nq.Wikilinks = [][]string{wikilinks}
+		}
+		return nq, nil
+
+	default:
+		return nq, fmt.Errorf("%s is %w", unsupportedFeature(q), ErrUnsupportedQuery)
+	}
+}
+
+// unsupportedFeature names the query syntax behind a query the sqlite_fts
+// engine cannot run
+func unsupportedFeature(q query.Query) string {
+	switch q.(type) {
+	case *query.BooleanQuery:
+		return "negation"
+	case *query.RegexpQuery:
+		return "file: and path: search"
+	case *lineQuery:
+		return "line: search"
+	default:
+		return fmt.Sprintf("query type %T", q)
+	}
+}
+
+// fieldQuery returns a query on one field: a filter for tags and wikilinks,
+// which match the whole value, or the match expression for text fields
+func fieldQuery(field, value, match string) (db.NoteQuery, error) {
+	var nq db.NoteQuery
+	switch {
+	case strings.TrimSpace(value) == "":
+		// Nothing to match, like an empty bleve query
+	case field == "tags":
+		nq.Tags = [][]string{{value}}
+	case field == "wikilinks":
+		nq.Wikilinks = [][]string{{value}}
+	case field == "" || noteColumns[field]:
+		nq.Match = match
+	default:
+		return nq, fmt.Errorf("searching field %q is %w", field, ErrUnsupportedQuery)
+	}
+	return nq, nil
+}
+
+// isListField reports whether a field holds whole values rather than text
+func isListField(field string) bool {
+	return field == "tags" || field == "wikilinks"
+}
+
+// ftsTerms splits text into quoted FTS5 terms, limited to field's column if it has one
+func ftsTerms(field, text string) []string {
+	words := strings.Fields(text)
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = ftsColumn(field) + ftsPhrase(word)
+	}
+	return terms
+}
+
+// ftsPhrase quotes text as an FTS5 phrase, so its syntax characters match literally
+func ftsPhrase(text string) string {
+	return `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+}
+
+// ftsColumn returns the FTS5 column filter for a text field
+func ftsColumn(field string) string {
+	if noteColumns[field] {
+		return field + " : "
+	}
+	return ""
+}
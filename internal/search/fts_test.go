@@ -0,0 +1,146 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/db"
+)
+
+// recordingSearcher remembers the last query it was given
+type recordingSearcher struct {
+	query db.NoteQuery
+	hits  []db.NoteHit
+}
+
+func (r *recordingSearcher) SearchNotes(q db.NoteQuery) ([]db.NoteHit, uint64, float64, error) {
+	r.query = q
+	var maxScore float64
+	for _, hit := range r.hits {
+		maxScore = max(maxScore, hit.Score)
+	}
+	return r.hits, uint64(len(r.hits)), maxScore, nil
+}
+
+func TestSearchService_NoteSearcher(t *testing.T) {
+	notes := &recordingSearcher{}
+	service := NewSearchService(context.Background(), "test-vault", nil)
+	service.SetNoteSearcher(notes)
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer service.Stop()
+
+	if service.GetStatus() != StatusReady {
+		t.Fatalf("Status = %v, want ready with a note searcher", service.GetStatus())
+	}
+
+	tests := []struct {
+		name   string
+		search func() error
+		want   db.NoteQuery
+	}{
+		{"text", func() error { _, err := service.SearchByText(`golang "tips"`, SearchOptions{}); return err },
+			db.NoteQuery{Match: `"golang" OR """tips"""`, Highlight: true, Limit: 20}},
+		{"phrase", func() error { _, err := service.PhraseSearch("quick brown fox", SearchOptions{}); return err },
+			db.NoteQuery{Match: `"quick brown fox"`, Highlight: true, Limit: 20}},
+		{"prefix", func() error { _, err := service.PrefixSearch("prog", SearchOptions{}); return err },
+			db.NoteQuery{Match: `"prog" *`, Limit: 20}},
+		{"title", func() error { _, err := service.SearchByTitleOnly("daily notes", SearchOptions{}); return err },
+			db.NoteQuery{Match: `title : "daily" OR title : "notes"`, Limit: 20}},
+		{"fuzzy", func() error { _, err := service.FuzzySearch("golang", 2, SearchOptions{}); return err },
+			db.NoteQuery{Match: `"golang"`, Highlight: true, Limit: 20}},
+		{"tag", func() error { _, err := service.SearchByTag("project/alpha", SearchOptions{}); return err },
+			db.NoteQuery{Tags: [][]string{{"project/alpha"}}, Limit: 20}},
+		{"all tags", func() error { _, err := service.SearchByMultipleTags([]string{"a", "b"}, SearchOptions{}); return err },
+			db.NoteQuery{Tags: [][]string{{"a"}, {"b"}}, Limit: 20}},
+		{"any tag", func() error { _, err := service.SearchByTagsOR([]string{"a", "b"}, SearchOptions{}); return err },
+			db.NoteQuery{Tags: [][]string{{"a", "b"}}, Limit: 20}},
+		{"wikilink", func() error { _, err := service.SearchByWikilink("Home", SearchOptions{}); return err },
+			db.NoteQuery{Wikilinks: [][]string{{"Home"}}, Limit: 20}},
+		{"any wikilink", func() error { _, err := service.SearchByWikilinksOR([]string{"A", "B"}, SearchOptions{}); return err },
+			db.NoteQuery{Wikilinks: [][]string{{"A", "B"}}, Limit: 20}},
+		{"combined", func() error {
+			_, err := service.SearchCombined("go", []string{"dev"}, []string{"Home"}, SearchOptions{})
+			return err
+		},
+			db.NoteQuery{Match: `("go")`, Tags: [][]string{{"dev"}}, Wikilinks: [][]string{{"Home"}}, Highlight: true, Limit: 20}},
+		{"query", func() error {
+			_, err := service.SearchByQuery(`go OR content:"fast code"`, SearchOptions{})
+			return err
+		}, db.NoteQuery{Match: `("go") OR (content : "fast code")`, Highlight: true, Limit: 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.search(); err != nil {
+				t.Fatalf("search error = %v", err)
+			}
+			// Results are sorted best first, with the ID breaking ties
+			got := notes.query
+			if want := []db.NoteSort{{Field: db.NoteSortScore, Desc: true}, {Field: db.NoteSortID}}; !reflect.DeepEqual(got.Sort, want) {
+				t.Errorf("sort = %+v, want %+v", got.Sort, want)
+			}
+			got.Sort = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("query = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("results", func(t *testing.T) {
+		notes.hits = []db.NoteHit{
+			{ID: "1", Path: "a.md", Title: "A", Tags: []string{"x"}, Score: 2, Fragments: map[string][]string{"content": {"<mark>go</mark>"}}},
+			{ID: "2", Path: "b.md", Title: "B", Score: 1},
+		}
+		result, err := service.SearchByText("go", SearchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Total != 2 || len(result.Hits) != 2 || result.MaxScore != 2 {
+			t.Fatalf("result = total %d, %d hits, max score %v", result.Total, len(result.Hits), result.MaxScore)
+		}
+		first := result.Hits[0]
+		if first.ID != "1" || first.Fields["path"] != "a.md" || first.Fields["title"] != "A" || first.Fragments["content"][0] != "<mark>go</mark>" {
+			t.Errorf("first hit = %+v", first)
+		}
+		if _, ok := result.Hits[1].Fields["tags"]; ok {
+			t.Errorf("hit without tags has a tags field: %+v", result.Hits[1].Fields)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		notes.hits = []db.NoteHit{{ID: "1", Path: "a.md", Title: "A", Created: created, SortValues: []string{"2024-05-01T12:00:00Z", "1"}}}
+		result, err := service.SearchByTag("x", SearchOptions{
+			Limit:       5,
+			Sort:        []string{"-modified"},
+			SearchAfter: []string{"2024-06-01T00:00:00Z", "9"},
+			Fields:      []string{"path", "created"},
+			Modified:    DateRange{After: created},
+			Date:        DateRange{Before: created},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := db.NoteQuery{
+			Tags:     [][]string{{"x"}},
+			Sort:     []db.NoteSort{{Field: db.NoteSortModified, Desc: true}, {Field: db.NoteSortID}},
+			After:    []string{"2024-06-01T00:00:00Z", "9"},
+			Modified: db.TimeRange{After: created},
+			Date:     db.TimeRange{Before: created},
+			Limit:    5,
+		}
+		if !reflect.DeepEqual(notes.query, want) {
+			t.Errorf("query = %#v, want %#v", notes.query, want)
+		}
+		hit := result.Hits[0]
+		if len(hit.Fields) != 2 || hit.Fields["path"] != "a.md" || hit.Fields["created"] != "2024-05-01T12:00:00Z" {
+			t.Errorf("fields = %v, want path and created", hit.Fields)
+		}
+		if !reflect.DeepEqual(hit.DecodedSort, notes.hits[0].SortValues) {
+			t.Errorf("DecodedSort = %v, want %v", hit.DecodedSort, notes.hits[0].SortValues)
+		}
+	})
+}
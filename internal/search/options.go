@@ -0,0 +1,212 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Sort keys accepted in SearchOptions.Sort
+const (
+	SortScore    = "score"
+	SortTitle    = "title"
+	SortPath     = "path"
+	SortCreated  = "created"
+	SortModified = "modified"
+)
+
+// DefaultLimit is the page size when SearchOptions.Limit is not set
+const DefaultLimit = 20
+
+// DefaultFields are returned with each result when SearchOptions.Fields is empty
+var DefaultFields = []string{"title", "path", "tags", "wikilinks"}
+
+// resultFields are the stored fields results can return
+var resultFields = map[string]bool{
+	"title": true, "path": true, "tags": true, "wikilinks": true, "created": true, "modified": true, "date": true,
+}
+
+// DateRange bounds a date from After (inclusive) to Before (exclusive). A zero
+// bound is open; notes without the date never match a range with a bound.
+type DateRange struct {
+	After  time.Time
+	Before time.Time
+}
+
+// isSet reports whether the range has a bound
+func (r DateRange) isSet() bool {
+	return !r.After.IsZero() || !r.Before.IsZero()
+}
+
+// fieldRange is a date range with the index field it bounds
+type fieldRange struct {
+	field string
+	DateRange
+}
+
+// SearchOptions pages, sorts and trims search results. The zero value returns
+// the best DefaultLimit results by score with DefaultFields.
+type SearchOptions struct {
+	Limit       int            // Results per page
+	Offset      int            // Results to skip; cannot be combined with SearchAfter
+	SearchAfter []string       // Sort values of the last result on the previous page
+	Sort        []string       // Sort keys, "-" reverses one: score is best first, title and path A-Z, created and modified oldest first
+	Fields      []string       // Fields returned with each result
+	Facets      []string       // Facets counted over all matching results
+	FacetSize   int            // Tags and folders counted per facet
+	Selection   FacetSelection // Facet values results must match
+	Created     DateRange      // Range the created time must fall in
+	Modified    DateRange      // Range the modified time must fall in
+	Date        DateRange      // Range the frontmatter date must fall in
+}
+
+// sortKey is a parsed sort key
+type sortKey struct {
+	field string // A Sort* constant, or "id" for the tiebreaker
+	desc  bool
+}
+
+// sortKeys parses the sort order. The result ID is appended as a tiebreaker,
+// so every result has distinct sort values to continue after.
+func (o SearchOptions) sortKeys() ([]sortKey, error) {
+	var keys []sortKey
+	for _, s := range o.Sort {
+		field, reversed := strings.CutPrefix(strings.TrimSpace(s), "-")
+		switch field {
+		case SortScore:
+			keys = append(keys, sortKey{field: field, desc: !reversed})
+		case SortTitle, SortPath, SortCreated, SortModified:
+			keys = append(keys, sortKey{field: field, desc: reversed})
+		default:
+			return nil, fmt.Errorf("unknown sort key %q (use score, title, path, created or modified)", s)
+		}
+	}
+	if len(keys) == 0 {
+		keys = append(keys, sortKey{field: SortScore, desc: true})
+	}
+	return append(keys, sortKey{field: "id"}), nil
+}
+
+// fields returns the fields to return with each result
+func (o SearchOptions) fields() ([]string, error) {
+	if len(o.Fields) == 0 {
+		return DefaultFields, nil
+	}
+	for _, f := range o.Fields {
+		if !resultFields[f] {
+			return nil, fmt.Errorf("unknown field %q (use title, path, tags, wikilinks, created, modified or date)", f)
+		}
+	}
+	return o.Fields, nil
+}
+
+// Validate reports options that cannot be applied to a search
+func (o SearchOptions) Validate() error {
+	if o.Limit < 0 || o.Offset < 0 {
+		return fmt.Errorf("limit and offset cannot be negative")
+	}
+	if o.Offset > 0 && len(o.SearchAfter) > 0 {
+		return fmt.Errorf("offset cannot be combined with search_after")
+	}
+	keys, err := o.sortKeys()
+	if err != nil {
+		return err
+	}
+	if len(o.SearchAfter) > 0 && len(o.SearchAfter) != len(keys) {
+		return fmt.Errorf("search_after needs %d values, one per sort key and the result ID", len(keys))
+	}
+	if _, err := o.fields(); err != nil {
+		return err
+	}
+	if o.FacetSize < 0 {
+		return fmt.Errorf("facet_size cannot be negative")
+	}
+	for _, r := range o.dateRanges() {
+		if !r.After.IsZero() && !r.Before.IsZero() && !r.After.Before(r.Before) {
+			return fmt.Errorf("%s range is empty: after must be earlier than before", r.field)
+		}
+	}
+	for _, facet := range o.Facets {
+		if _, ok := facetFields[facet]; !ok {
+			return fmt.Errorf("unknown facet %q (use tags, folders or modified)", facet)
+		}
+	}
+	return o.Selection.validate()
+}
+
+// apply sets the page, sort order and fields of a search request
+func (o SearchOptions) apply(req *bleve.SearchRequest) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	keys, _ := o.sortKeys()
+	fields, _ := o.fields()
+
+	order := make(bsearch.SortOrder, len(keys))
+	for i, key := range keys {
+		switch key.field {
+		case SortScore:
+			order[i] = &bsearch.SortScore{Desc: key.desc}
+		case SortTitle:
+			order[i] = &bsearch.SortField{Field: "sort_title", Type: bsearch.SortFieldAsString, Desc: key.desc}
+		case SortPath:
+			order[i] = &bsearch.SortField{Field: "path", Type: bsearch.SortFieldAsString, Desc: key.desc}
+		case SortCreated, SortModified:
+			order[i] = &bsearch.SortField{Field: key.field, Type: bsearch.SortFieldAsDate, Desc: key.desc}
+		default:
+			order[i] = &bsearch.SortDocID{Desc: key.desc}
+		}
+	}
+
+	req.Size = DefaultLimit
+	if o.Limit > 0 {
+		req.Size = o.Limit
+	}
+	req.From = o.Offset
+	req.SortByCustom(order)
+	if len(o.SearchAfter) > 0 {
+		req.SetSearchAfter(o.SearchAfter)
+	}
+	req.Fields = fields
+	return nil
+}
+
+// dateRanges returns the date ranges of the options by field
+func (o SearchOptions) dateRanges() []fieldRange {
+	return []fieldRange{{"created", o.Created}, {"modified", o.Modified}, {"date", o.Date}}
+}
+
+// dateFilter returns a query matching the date ranges, or nil when none is set
+func (o SearchOptions) dateFilter() query.Query {
+	var filters []query.Query
+	for _, r := range o.dateRanges() {
+		if !r.isSet() {
+			continue
+		}
+		inclusive, exclusive := true, false
+		q := query.NewDateRangeInclusiveQuery(r.After, r.Before, &inclusive, &exclusive)
+		q.SetField(r.field)
+		filters = append(filters, q)
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+	return bleve.NewConjunctionQuery(filters...)
+}
+
+// fillScoreSortValues replaces the placeholder bleve reports as the sort
+// value of a score key with the score, so results can be continued after
+func fillScoreSortValues(req *bleve.SearchRequest, result *bleve.SearchResult) {
+	for _, hit := range result.Hits {
+		for i, s := range req.Sort {
+			if _, ok := s.(*bsearch.SortScore); ok && i < len(hit.DecodedSort) {
+				hit.DecodedSort[i] = strconv.FormatFloat(hit.Score, 'g', -1, 64)
+			}
+		}
+	}
+}
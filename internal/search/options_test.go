@@ -0,0 +1,226 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+)
+
+// createSortableSearchService indexes notes with the sortable fields the
+// indexer adds: path and sort_title as whole values, created, modified and
+// the frontmatter date as dates
+func createSortableSearchService(t *testing.T) *SearchService {
+	t.Helper()
+
+	docMapping := bleve.NewDocumentMapping()
+	for _, field := range []string{"path", "sort_title"} {
+		fm := bleve.NewTextFieldMapping()
+		fm.Analyzer = keyword.Name
+		fm.IncludeInAll = false
+		docMapping.AddFieldMappingsAt(field, fm)
+	}
+	for _, field := range []string{"created", "modified", "date"} {
+		fm := bleve.NewDateTimeFieldMapping()
+		fm.IncludeInAll = false
+		docMapping.AddFieldMappingsAt(field, fm)
+	}
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = docMapping
+
+	index, err := bleve.NewMemOnly(indexMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	docs := map[string]map[string]interface{}{
+		"a": {"title": "Zebra", "sort_title": "zebra", "path": "notes/a.md", "content": "walk",
+			"created": day.Add(48 * time.Hour), "modified": day},
+		"b": {"title": "Apple", "sort_title": "apple", "path": "notes/b.md", "content": "walk walk",
+			"created": day, "modified": day.Add(2 * time.Hour), "date": day.AddDate(0, 0, 7)},
+		"c": {"title": "Mango", "sort_title": "mango", "path": "c.md", "content": "walk walk walk",
+			"created": day.Add(24 * time.Hour), "modified": day.Add(time.Hour)},
+	}
+	for id, doc := range docs {
+		if err := index.Index(id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := NewSearchService(context.Background(), "test-vault", index)
+	if err := svc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		svc.Stop()
+		index.Close()
+	})
+	return svc
+}
+
+func hitIDs(result *bleve.SearchResult) string {
+	var ids []string
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestSearchOptions_Sort(t *testing.T) {
+	svc := createSortableSearchService(t)
+
+	tests := []struct {
+		sort []string
+		want string
+	}{
+		{nil, "c b a"},
+		{[]string{"-score"}, "a b c"},
+		{[]string{"title"}, "b c a"},
+		{[]string{"-title"}, "a c b"},
+		{[]string{"path"}, "c a b"},
+		{[]string{"created"}, "b c a"},
+		{[]string{"-modified"}, "b c a"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.sort, ","), func(t *testing.T) {
+			result, err := svc.SearchByText("walk", SearchOptions{Sort: tt.sort})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hitIDs(result); got != tt.want {
+				t.Errorf("order = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchOptions_Paging(t *testing.T) {
+	svc := createSortableSearchService(t)
+
+	for _, sort := range [][]string{nil, {"title"}, {"-created"}} {
+		t.Run("search_after "+strings.Join(sort, ","), func(t *testing.T) {
+			all, err := svc.SearchByText("walk", SearchOptions{Sort: sort})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// One result per page, each continuing after the last
+			opts := SearchOptions{Sort: sort, Limit: 1}
+			var pages []string
+			for len(pages) <= 3 {
+				result, err := svc.SearchByText("walk", opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Total != 3 {
+					t.Errorf("Total = %d on page %d, want 3", result.Total, len(pages)+1)
+				}
+				if len(result.Hits) == 0 {
+					break
+				}
+				pages = append(pages, result.Hits[0].ID)
+				opts.SearchAfter = result.Hits[0].DecodedSort
+			}
+			if got := strings.Join(pages, " "); got != hitIDs(all) {
+				t.Errorf("pages = %s, want %s", got, hitIDs(all))
+			}
+		})
+	}
+
+	t.Run("offset", func(t *testing.T) {
+		result, err := svc.SearchByText("walk", SearchOptions{Sort: []string{"title"}, Offset: 1, Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hitIDs(result); got != "c" || result.Total != 3 {
+			t.Errorf("page = %s of %d, want c of 3", got, result.Total)
+		}
+	})
+}
+
+func TestSearchOptions_Fields(t *testing.T) {
+	svc := createSortableSearchService(t)
+
+	result, err := svc.SearchByText("walk", SearchOptions{Fields: []string{"path", "created"}, Sort: []string{"created"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := result.Hits[0].Fields
+	if len(fields) != 2 || fields["path"] != "notes/b.md" || fields["created"] != "2024-01-01T00:00:00Z" {
+		t.Errorf("fields = %v, want path and created", fields)
+	}
+}
+
+func TestSearchOptions_DateRanges(t *testing.T) {
+	svc := createSortableSearchService(t)
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want string
+	}{
+		{"modified after", SearchOptions{Modified: DateRange{After: day.Add(time.Hour)}}, "b c"},
+		{"modified before is exclusive", SearchOptions{Modified: DateRange{Before: day.Add(time.Hour)}}, "a"},
+		{"created between", SearchOptions{Created: DateRange{After: day, Before: day.Add(48 * time.Hour)}}, "b c"},
+		{"date skips notes without one", SearchOptions{Date: DateRange{After: day}}, "b"},
+		{"combined", SearchOptions{Created: DateRange{After: day.Add(time.Hour)}, Modified: DateRange{After: day.Add(time.Hour)}}, "c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Sort = []string{"title"}
+			// Ranges narrow every kind of search
+			for name, search := range map[string]func() (*bleve.SearchResult, error){
+				"text":   func() (*bleve.SearchResult, error) { return svc.SearchByText("walk", opts) },
+				"prefix": func() (*bleve.SearchResult, error) { return svc.PrefixSearch("wal", opts) },
+				"phrase": func() (*bleve.SearchResult, error) { return svc.PhraseSearch("walk", opts) },
+			} {
+				result, err := search()
+				if err != nil {
+					t.Fatalf("%s search error = %v", name, err)
+				}
+				if got := hitIDs(result); got != tt.want || result.Total != uint64(len(strings.Fields(tt.want))) {
+					t.Errorf("%s search = %s of %d, want %s", name, got, result.Total, tt.want)
+				}
+			}
+		})
+	}
+
+	result, err := svc.SearchByText("walk", SearchOptions{Fields: []string{"date"}, Sort: []string{"title"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.Hits[0].Fields["date"]; got != "2024-01-08T00:00:00Z" {
+		t.Errorf("date = %v, want 2024-01-08T00:00:00Z", got)
+	}
+}
+
+func TestSearchOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want string
+	}{
+		{"negative limit", SearchOptions{Limit: -1}, "negative"},
+		{"offset and search_after", SearchOptions{Offset: 1, SearchAfter: []string{"1", "a"}}, "offset"},
+		{"unknown sort", SearchOptions{Sort: []string{"size"}}, "unknown sort key"},
+		{"search_after length", SearchOptions{Sort: []string{"title"}, SearchAfter: []string{"a"}}, "2 values"},
+		{"unknown field", SearchOptions{Fields: []string{"content"}}, "unknown field"},
+		{"empty date range", SearchOptions{Modified: DateRange{After: time.Unix(10, 0), Before: time.Unix(10, 0)}}, "modified range is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+	if err := (SearchOptions{Sort: []string{"-score", " title"}, SearchAfter: []string{"1", "a", "b"}}).Validate(); err != nil {
+		t.Errorf("Validate() = %v for valid options", err)
+	}
+}
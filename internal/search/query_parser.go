@@ -0,0 +1,518 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/blevesearch/bleve/v2/search/searcher"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// ParseError is a search query that does not parse
+type ParseError struct {
+	Pos int // Byte offset of the error in the query
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// Search operators, written as operator:value or operator:(...)
+const (
+	opFile    = "file"    // File name contains the value
+	opPath    = "path"    // Path from the vault root contains the value
+	opContent = "content" // Note text matches
+	opTag     = "tag"     // Note has the tag or one nested under it
+	opLine    = "line"    // A single line of the note matches
+)
+
+var queryOperators = map[string]bool{opFile: true, opPath: true, opContent: true, opTag: true, opLine: true}
+
+// ParseQuery compiles an Obsidian-style search query into a bleve query.
+// Words must all match, "quoted phrases" match exactly, OR joins
+// alternatives, -x excludes matches of x and parentheses group. The file:,
+// path:, content:, tag: and line: operators narrow a word, phrase or group.
+func ParseQuery(input string) (query.Query, error) {
+	tokens, err := tokenizeQuery(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, &ParseError{Pos: 0, Msg: "empty query"}
+	}
+	p := &queryParser{tokens: tokens, end: len(input)}
+	expr, err := p.parseOr("")
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		return nil, &ParseError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok.text)}
+	}
+	return compileQuery(expr, false)
+}
+
+// queryTokenKind tells apart the tokens of a query
+type queryTokenKind int
+
+const (
+	tokWord queryTokenKind = iota
+	tokPhrase
+	tokOpen
+	tokClose
+	tokNot
+)
+
+// queryToken is a word, quoted phrase, parenthesis or negation of a query
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	pos  int // Byte offset in the query
+	end  int // Byte offset just after the token
+}
+
+// tokenizeQuery splits a query into tokens
+func tokenizeQuery(input string) ([]queryToken, error) {
+	var tokens []queryToken
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case strings.IndexByte(" \t\r\n", c) >= 0:
+			i++
+		case c == '(' || c == ')':
+			kind := tokOpen
+			if c == ')' {
+				kind = tokClose
+			}
+			tokens = append(tokens, queryToken{kind: kind, text: string(c), pos: i, end: i + 1})
+			i++
+		case c == '"':
+			end := strings.IndexByte(input[i+1:], '"')
+			if end < 0 {
+				return nil, &ParseError{Pos: i, Msg: "unterminated quote"}
+			}
+			tokens = append(tokens, queryToken{kind: tokPhrase, text: input[i+1 : i+1+end], pos: i, end: i + end + 2})
+			i += end + 2
+		case c == '-' && (len(tokens) == 0 || tokens[len(tokens)-1].end < i || tokens[len(tokens)-1].kind == tokOpen):
+			// A dash starting a term negates it; inside a word it is part of the word
+			tokens = append(tokens, queryToken{kind: tokNot, text: "-", pos: i, end: i + 1})
+			i++
+		default:
+			start := i
+			for i < len(input) && strings.IndexByte(" \t\r\n()\"", input[i]) < 0 {
+				i++
+			}
+			tokens = append(tokens, queryToken{kind: tokWord, text: input[start:i], pos: start, end: i})
+		}
+	}
+	return tokens, nil
+}
+
+// queryNode is a parsed query expression
+type queryNode interface{}
+
+// termNode matches a word or phrase, in field if set
+type termNode struct {
+	field  string // An op* constant, or "" for any text
+	text   string
+	phrase bool
+	pos    int
+}
+
+type andNode struct{ children []queryNode }
+
+type orNode struct{ children []queryNode }
+
+type notNode struct{ child queryNode }
+
+// lineNode matches notes with a line that matches expr
+type lineNode struct{ expr queryNode }
+
+// queryParser parses query tokens into an expression
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	end    int // Length of the query, for errors at its end
+}
+
+// parseOr parses alternatives joined by OR, in field if set
+func (p *queryParser) parseOr(field string) (queryNode, error) {
+	first, err := p.parseAnd(field)
+	if err != nil {
+		return nil, err
+	}
+	children := []queryNode{first}
+	for p.peekOr() {
+		p.pos++
+		next, err := p.parseAnd(field)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &orNode{children: children}, nil
+}
+
+// parseAnd parses terms that must all match
+func (p *queryParser) parseAnd(field string) (queryNode, error) {
+	var children []queryNode
+	for p.pos < len(p.tokens) && p.tokens[p.pos].kind != tokClose && !p.peekOr() {
+		child, err := p.parseUnary(field)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	if len(children) == 0 {
+		return nil, p.errorHere("expected a search term")
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &andNode{children: children}, nil
+}
+
+// parseUnary parses a term with any negations before it
+func (p *queryParser) parseUnary(field string) (queryNode, error) {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokNot {
+		p.pos++
+		child, err := p.parseUnary(field)
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{child: child}, nil
+	}
+	return p.parsePrimary(field)
+}
+
+// parsePrimary parses a group, an operator, a phrase or a word
+func (p *queryParser) parsePrimary(field string) (queryNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, p.errorHere("expected a search term")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokOpen:
+		return p.parseGroup(tok, field)
+	case tokPhrase:
+		return &termNode{field: field, text: tok.text, phrase: true, pos: tok.pos}, nil
+	case tokWord:
+		name, value, ok := strings.Cut(tok.text, ":")
+		if !ok || !queryOperators[name] {
+			return &termNode{field: field, text: tok.text, pos: tok.pos}, nil
+		}
+		if field != "" {
+			return nil, &ParseError{Pos: tok.pos, Msg: fmt.Sprintf("%s: cannot be used inside %s:", name, field)}
+		}
+		operand, err := p.parseOperand(tok, name, value)
+		if err != nil {
+			return nil, err
+		}
+		if name == opLine {
+			return &lineNode{expr: operand}, nil
+		}
+		return operand, nil
+	default:
+		return nil, &ParseError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok.text)}
+	}
+}
+
+// parseOperand parses the value of an operator: the rest of its word, or the
+// phrase or group right after it
+func (p *queryParser) parseOperand(tok queryToken, name, value string) (queryNode, error) {
+	if value != "" {
+		return &termNode{field: name, text: value, pos: tok.pos + len(name) + 1}, nil
+	}
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].pos != tok.end {
+		return nil, &ParseError{Pos: tok.end, Msg: fmt.Sprintf("expected a value after %s:", name)}
+	}
+	next := p.tokens[p.pos]
+	p.pos++
+	switch next.kind {
+	case tokPhrase:
+		return &termNode{field: name, text: next.text, phrase: true, pos: next.pos}, nil
+	case tokOpen:
+		return p.parseGroup(next, name)
+	default:
+		return nil, &ParseError{Pos: next.pos, Msg: fmt.Sprintf("expected a value after %s:", name)}
+	}
+}
+
+// parseGroup parses the rest of a parenthesized group
+func (p *queryParser) parseGroup(open queryToken, field string) (queryNode, error) {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokClose {
+		return nil, &ParseError{Pos: open.pos, Msg: "empty group"}
+	}
+	expr, err := p.parseOr(field)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokClose {
+		return nil, &ParseError{Pos: open.pos, Msg: "missing )"}
+	}
+	p.pos++
+	return expr, nil
+}
+
+func (p *queryParser) peekOr() bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokWord && p.tokens[p.pos].text == "OR"
+}
+
+// errorHere reports an error at the next token, or at the end of the query
+func (p *queryParser) errorHere(msg string) error {
+	if p.pos < len(p.tokens) {
+		return &ParseError{Pos: p.tokens[p.pos].pos, Msg: msg}
+	}
+	return &ParseError{Pos: p.end, Msg: msg}
+}
+
+// compileQuery turns an expression into a bleve query. For line candidates,
+// negations match everything, as a note is only excluded when every line is.
+func compileQuery(n queryNode, candidates bool) (query.Query, error) {
+	switch n := n.(type) {
+	case *termNode:
+		return compileTerm(n)
+
+	case *andNode:
+		var must, mustNot []query.Query
+		for _, child := range n.children {
+			if not, ok := child.(*notNode); ok && !candidates {
+				q, err := compileQuery(not.child, candidates)
+				if err != nil {
+					return nil, err
+				}
+				mustNot = append(mustNot, q)
+				continue
+			}
+			q, err := compileQuery(child, candidates)
+			if err != nil {
+				return nil, err
+			}
+			must = append(must, q)
+		}
+		if len(mustNot) == 0 {
+			return bleve.NewConjunctionQuery(must...), nil
+		}
+		if len(must) == 0 {
+			must = append(must, bleve.NewMatchAllQuery())
+		}
+		return query.NewBooleanQuery(must, nil, mustNot), nil
+
+	case *orNode:
+		children := make([]query.Query, len(n.children))
+		for i, child := range n.children {
+			q, err := compileQuery(child, candidates)
+			if err != nil {
+				return nil, err
+			}
+			children[i] = q
+		}
+		return bleve.NewDisjunctionQuery(children...), nil
+
+	case *notNode:
+		if candidates {
+			return bleve.NewMatchAllQuery(), nil
+		}
+		q, err := compileQuery(n.child, candidates)
+		if err != nil {
+			return nil, err
+		}
+		return query.NewBooleanQuery([]query.Query{bleve.NewMatchAllQuery()}, nil, []query.Query{q}), nil
+
+	case *lineNode:
+		q, err := compileQuery(n.expr, true)
+		if err != nil {
+			return nil, err
+		}
+		return &lineQuery{expr: n.expr, candidates: q}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown query node %T", n)
+	}
+}
+
+// compileTerm returns the query for a word or phrase
+func compileTerm(n *termNode) (query.Query, error) {
+	switch n.field {
+	case opTag:
+		// Tags are indexed lowercased; the prefix query is not analyzed
+		tag := strings.ToLower(strings.TrimPrefix(n.text, "#"))
+		if tag == "" {
+			return nil, &ParseError{Pos: n.pos, Msg: "expected a tag"}
+		}
+		exact := bleve.NewMatchQuery(tag)
+		exact.SetField("tags")
+		nested := bleve.NewPrefixQuery(tag + "/")
+		nested.SetField("tags")
+		return bleve.NewDisjunctionQuery(exact, nested), nil
+
+	case opFile, opPath:
+		// Paths are indexed lowercased with the OS separator
+		value := strings.ToLower(n.text)
+		if n.field == opPath {
+			value = filepath.FromSlash(value)
+		}
+		q := bleve.NewRegexpQuery(".*" + regexp.QuoteMeta(value) + ".*")
+		q.SetField(n.field)
+		return q, nil
+
+	case opContent, opLine, "":
+		field := ""
+		if n.field != "" {
+			field = "content"
+		}
+		if n.phrase {
+			q := bleve.NewMatchPhraseQuery(n.text)
+			q.SetField(field)
+			return q, nil
+		}
+		q := bleve.NewMatchQuery(n.text)
+		q.SetField(field)
+		q.SetOperator(query.MatchQueryOperatorAnd)
+		return q, nil
+
+	default:
+		return nil, &ParseError{Pos: n.pos, Msg: fmt.Sprintf("unknown operator %s:", n.field)}
+	}
+}
+
+// lineQuery matches notes with a line of content matching expr. The
+// candidates query finds notes that may match; their stored content is then
+// checked line by line.
+type lineQuery struct {
+	expr       queryNode
+	candidates query.Query
+}
+
+// Searcher implements query.Query
+func (q *lineQuery) Searcher(ctx context.Context, i index.IndexReader, m mapping.IndexMapping, options bsearch.SearcherOptions) (bsearch.Searcher, error) {
+	analyzer := m.AnalyzerNamed(m.AnalyzerNameForPath("content"))
+	if analyzer == nil {
+		return nil, fmt.Errorf("no analyzer for content")
+	}
+	analyze := func(text string) []string {
+		stream := analyzer.Analyze([]byte(text))
+		terms := make([]string, len(stream))
+		for i, token := range stream {
+			terms[i] = string(token.Term)
+		}
+		return terms
+	}
+	matches := lineMatcher(q.expr, analyze)
+
+	candidates, err := q.candidates.Searcher(ctx, i, m, options)
+	if err != nil {
+		return nil, err
+	}
+	return searcher.NewFilteringSearcher(ctx, candidates, func(_ *bsearch.SearchContext, d *bsearch.DocumentMatch) bool {
+		id, err := i.ExternalID(d.IndexInternalID)
+		if err != nil {
+			return false
+		}
+		doc, err := i.Document(id)
+		if err != nil || doc == nil {
+			return false
+		}
+		var content string
+		doc.VisitFields(func(f index.Field) {
+			if f.Name() == "content" {
+				content = string(f.Value())
+			}
+		})
+		for _, line := range strings.Split(content, "\n") {
+			if matches(analyze(line)) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// lineMatcher returns a function reporting whether the analyzed terms of a
+// line match expr. Words match when all their terms are on the line, phrases
+// when their terms are consecutive.
+func lineMatcher(expr queryNode, analyze func(string) []string) func(line []string) bool {
+	switch n := expr.(type) {
+	case *termNode:
+		want := analyze(n.text)
+		if len(want) == 0 {
+			return func([]string) bool { return false }
+		}
+		if n.phrase {
+			return func(line []string) bool {
+				for start := 0; start+len(want) <= len(line); start++ {
+					if equalTerms(line[start:start+len(want)], want) {
+						return true
+					}
+				}
+				return false
+			}
+		}
+		return func(line []string) bool {
+			for _, term := range want {
+				if !containsTerm(line, term) {
+					return false
+				}
+			}
+			return true
+		}
+
+	case *andNode, *orNode:
+		var children []queryNode
+		all := false
+		if and, ok := n.(*andNode); ok {
+			children, all = and.children, true
+		} else {
+			children = n.(*orNode).children
+		}
+		matchers := make([]func([]string) bool, len(children))
+		for i, child := range children {
+			matchers[i] = lineMatcher(child, analyze)
+		}
+		return func(line []string) bool {
+			for _, match := range matchers {
+				if match(line) != all {
+					return !all
+				}
+			}
+			return all
+		}
+
+	case *notNode:
+		match := lineMatcher(n.child, analyze)
+		return func(line []string) bool { return !match(line) }
+
+	default:
+		return func([]string) bool { return false }
+	}
+}
+
+func equalTerms(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsTerm(terms []string, term string) bool {
+	for _, t := range terms {
+		if t == term {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
+)
+
+// createQueryTestService indexes notes the way the indexer does: tags, path
+// and file name whole and lowercased, and content stored
+func createQueryTestService(t *testing.T) *SearchService {
+	t.Helper()
+
+	indexMapping := bleve.NewIndexMapping()
+	if err := indexMapping.AddCustomAnalyzer("lowercase_keyword", map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	docMapping := bleve.NewDocumentMapping()
+	for _, field := range []string{"tags", "path", "file"} {
+		fm := bleve.NewTextFieldMapping()
+		fm.Analyzer = "lowercase_keyword"
+		fm.IncludeInAll = false
+		docMapping.AddFieldMappingsAt(field, fm)
+	}
+	indexMapping.DefaultMapping = docMapping
+
+	index, err := bleve.NewMemOnly(indexMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := map[string]map[string]interface{}{
+		"meeting": {"path": "work/Meeting-2024.md", "file": "Meeting-2024.md", "title": "Weekly",
+			"content": "todo urgent call\nthe exact phrase here\nfoo", "tags": []string{"Project/Alpha"}},
+		"draft": {"path": "work/draft.md", "file": "draft.md",
+			"content": "draft todo\nurgent later\nbar", "tags": []string{"project"}},
+		"list": {"path": "home/list.md", "file": "list.md",
+			"content": "buy milk foo\ntodo", "tags": []string{"home"}},
+		"plan": {"path": "Projects/Plan.md", "file": "Plan.md",
+			"content": "bar exact phrase", "tags": []string{"projectx"}},
+	}
+	for id, doc := range docs {
+		if err := index.Index(id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := NewSearchService(context.Background(), "test-vault", index)
+	if err := svc.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		svc.Stop()
+		index.Close()
+	})
+	return svc
+}
+
+func TestSearchByQuery(t *testing.T) {
+	svc := createQueryTestService(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"foo", "list meeting"},
+		{"foo OR bar", "draft list meeting plan"},
+		{"todo foo", "list meeting"},
+		{`"exact phrase"`, "meeting plan"},
+		{`"phrase exact"`, ""},
+		{"tag:#project", "draft meeting"},
+		{"tag:project/alpha", "meeting"},
+		{"tag:#PROJECT", "draft meeting"},
+		{"tag:(home OR projectx)", "list plan"},
+		{"path:work/", "draft meeting"},
+		{"path:WORK/", "draft meeting"},
+		{"file:meeting", "meeting"},
+		{`file:"plan.md"`, "plan"},
+		{"content:milk", "list"},
+		{"path:work/ -draft", "meeting"},
+		{"-(foo OR bar)", ""},
+		{"-tag:#project", "list plan"},
+		{"(foo OR bar) tag:#project", "draft meeting"},
+		{"foo OR bar -todo", "list meeting plan"}, // AND binds tighter than OR
+		{"line:(todo urgent)", "meeting"},
+		{"line:(todo -urgent)", "draft list"},
+		{`line:"exact phrase"`, "meeting plan"},
+		{"line:(milk OR later)", "draft list"},
+		{`tag:#project path:work/ file:meeting "exact phrase" -draft (foo OR bar) line:(todo urgent)`, "meeting"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			result, err := svc.SearchByQuery(tt.query, SearchOptions{})
+			if err != nil {
+				t.Fatalf("SearchByQuery() error = %v", err)
+			}
+			var ids []string
+			for _, hit := range result.Hits {
+				ids = append(ids, hit.ID)
+			}
+			sort.Strings(ids)
+			if got := strings.Join(ids, " "); got != tt.want {
+				t.Errorf("SearchByQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+			if result.Total != uint64(len(ids)) {
+				t.Errorf("Total = %d, want %d", result.Total, len(ids))
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+		msg   string
+	}{
+		{"", 0, "empty query"},
+		{"   ", 0, "empty query"},
+		{`foo "open`, 4, "unterminated quote"},
+		{"(foo bar", 0, "missing )"},
+		{"foo)", 3, "unexpected )"},
+		{"foo ()", 4, "empty group"},
+		{"foo OR", 6, "expected a search term"},
+		{"OR foo", 0, "expected a search term"},
+		{"tag: foo", 4, "expected a value after tag:"},
+		{"tag:#", 4, "expected a tag"},
+		{"line:(tag:#x)", 6, "tag: cannot be used inside line:"},
+		{"foo -", 5, "expected a search term"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("ParseQuery(%q) error = %v, want a ParseError", tt.query, err)
+			}
+			if perr.Pos != tt.pos || perr.Msg != tt.msg {
+				t.Errorf("ParseQuery(%q) = %q at %d, want %q at %d", tt.query, perr.Msg, perr.Pos, tt.msg, tt.pos)
+			}
+		})
+	}
+}
@@ -47,6 +47,7 @@ type SearchService struct {
 
 	// Index reference
 	index   bleve.Index
+	notes   NoteSearcher // Searched instead of the index when set
 	indexMu sync.RWMutex
 
 	// Status
@@ -89,6 +90,14 @@ func NewSearchService(ctx context.Context, vaultID string, index bleve.Index) *S
 	}
 }
 
+// SetNoteSearcher searches notes in searcher instead of a bleve index, for
+// vaults using the sqlite_fts search engine. Must be called before Start.
+func (s *SearchService) SetNoteSearcher(searcher NoteSearcher) {
+	s.indexMu.Lock()
+	s.notes = searcher
+	s.indexMu.Unlock()
+}
+
 // Start starts the search service
 func (s *SearchService) Start() error {
 	s.statusMu.Lock()
@@ -105,11 +114,7 @@ func (s *SearchService) Start() error {
 	go s.processIndexUpdates()
 
 	// Check if we have an index
-	s.indexMu.RLock()
-	hasIndex := s.index != nil
-	s.indexMu.RUnlock()
-
-	if hasIndex {
+	if s.hasBackend() {
 		s.setStatus(StatusReady)
 		logger.WithField("vault_id", s.vaultID).Info("Search service ready")
 	} else {
@@ -206,11 +211,7 @@ func (s *SearchService) processIndexUpdateEvent(event indexing.IndexUpdateEvent)
 		logger.WithFields(map[string]interface{}{"vault_id": s.vaultID, "refresh": refreshCount}).Debug("Index updated incrementally")
 
 		// If we have an index and were waiting, move to ready
-		s.indexMu.RLock()
-		hasIndex := s.index != nil
-		s.indexMu.RUnlock()
-
-		if hasIndex {
+		if s.hasBackend() {
 			s.statusMu.Lock()
 			if s.status == StatusError || s.status == StatusInitializing {
 				s.status = StatusReady
@@ -263,9 +264,7 @@ func (s *SearchService) GetMetrics() SearchMetrics {
 	indexRefreshes := s.indexRefreshes
 	s.metricsmu.RUnlock()
 
-	s.indexMu.RLock()
-	hasIndex := s.index != nil
-	s.indexMu.RUnlock()
+	hasIndex := s.hasBackend()
 
 	return SearchMetrics{
 		Status:         status,
@@ -276,11 +275,47 @@ func (s *SearchService) GetMetrics() SearchMetrics {
 	}
 }
 
-// getIndex returns the current index (thread-safe)
-func (s *SearchService) getIndex() bleve.Index {
+// hasBackend reports whether there is an index or note searcher to search
+func (s *SearchService) hasBackend() bool {
 	s.indexMu.RLock()
 	defer s.indexMu.RUnlock()
-	return s.index
+	return s.index != nil || s.notes != nil
+}
+
+// run applies the options to a search request and executes it against the
+// index, or the note searcher when there is one
+func (s *SearchService) run(req *bleve.SearchRequest, opts SearchOptions) (*bleve.SearchResult, error) {
+	if err := opts.apply(req); err != nil {
+		return nil, err
+	}
+
+	s.indexMu.RLock()
+	index, notes := s.index, s.notes
+	s.indexMu.RUnlock()
+
+	if notes != nil {
+		if len(opts.Facets) > 0 || len(opts.Selection) > 0 {
+			return nil, fmt.Errorf("facets are %w", ErrUnsupportedQuery)
+		}
+		return searchNotes(notes, req, opts)
+	}
+	if index == nil {
+		return nil, fmt.Errorf("search service not ready: index not available")
+	}
+
+	req.Query = narrow(req.Query, opts.dateFilter())
+	base, now := req.Query, time.Now()
+	opts.addFacets(req, now)
+	result, err := index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := recountSelected(index, base, req, result, opts, now); err != nil {
+		return nil, err
+	}
+	sortModifiedBuckets(result)
+	fillScoreSortValues(req, result)
+	return result, nil
 }
 
 // recordSearch increments search count and updates timestamp
@@ -291,12 +326,11 @@ func (s *SearchService) recordSearch() {
 	s.metricsmu.Unlock()
 }
 
-// Search Methods (all use getIndex() and recordSearch())
+// Search Methods (all use run() and recordSearch())
 
 // SearchByText performs full-text search across all indexed content
-func (s *SearchService) SearchByText(queryStr string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) SearchByText(queryStr string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -305,16 +339,12 @@ func (s *SearchService) SearchByText(queryStr string) (*bleve.SearchResult, erro
 	q := bleve.NewMatchQuery(queryStr)
 	search := bleve.NewSearchRequest(q)
 	search.Highlight = bleve.NewHighlight()
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // SearchByTag searches for documents with a specific tag
-func (s *SearchService) SearchByTag(tag string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) SearchByTag(tag string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -323,16 +353,12 @@ func (s *SearchService) SearchByTag(tag string) (*bleve.SearchResult, error) {
 	q := bleve.NewMatchQuery(tag)
 	q.SetField("tags")
 	search := bleve.NewSearchRequest(q)
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // SearchByMultipleTags searches for documents matching all specified tags (AND)
-func (s *SearchService) SearchByMultipleTags(tags []string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) SearchByMultipleTags(tags []string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -347,16 +373,12 @@ func (s *SearchService) SearchByMultipleTags(tags []string) (*bleve.SearchResult
 
 	q := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(q)
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // SearchByWikilink searches for documents that contain a specific wikilink
-func (s *SearchService) SearchByWikilink(wikilink string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) SearchByWikilink(wikilink string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -365,21 +387,17 @@ func (s *SearchService) SearchByWikilink(wikilink string) (*bleve.SearchResult,
 	q := bleve.NewMatchQuery(wikilink)
 	q.SetField("wikilinks")
 	search := bleve.NewSearchRequest(q)
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // SearchByBacklinks finds all documents that link to a specific note
-func (s *SearchService) SearchByBacklinks(noteName string) (*bleve.SearchResult, error) {
-	return s.SearchByWikilink(noteName)
+func (s *SearchService) SearchByBacklinks(noteName string, opts SearchOptions) (*bleve.SearchResult, error) {
+	return s.SearchByWikilink(noteName, opts)
 }
 
 // SearchByTagsOR searches for documents matching ANY of the specified tags (OR logic)
-func (s *SearchService) SearchByTagsOR(tags []string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) SearchByTagsOR(tags []string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -394,16 +412,12 @@ func (s *SearchService) SearchByTagsOR(tags []string) (*bleve.SearchResult, erro
 
 	q := bleve.NewDisjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(q)
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // SearchByMultipleWikilinks searches for documents containing all specified wikilinks (AND)
-func (s *SearchService) SearchByMultipleWikilinks(wikilinks []string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) SearchByMultipleWikilinks(wikilinks []string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -418,16 +432,12 @@ func (s *SearchService) SearchByMultipleWikilinks(wikilinks []string) (*bleve.Se
 
 	q := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(q)
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // SearchByWikilinksOR searches for documents containing ANY of the specified wikilinks (OR)
-func (s *SearchService) SearchByWikilinksOR(wikilinks []string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) SearchByWikilinksOR(wikilinks []string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -442,16 +452,12 @@ func (s *SearchService) SearchByWikilinksOR(wikilinks []string) (*bleve.SearchRe
 
 	q := bleve.NewDisjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(q)
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // SearchByTitleOnly searches only in the title field
-func (s *SearchService) SearchByTitleOnly(queryStr string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) SearchByTitleOnly(queryStr string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -460,16 +466,12 @@ func (s *SearchService) SearchByTitleOnly(queryStr string) (*bleve.SearchResult,
 	q := bleve.NewMatchQuery(queryStr)
 	q.SetField("title")
 	search := bleve.NewSearchRequest(q)
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // FuzzySearch performs fuzzy text search (allows typos/misspellings)
-func (s *SearchService) FuzzySearch(queryStr string, fuzziness int) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) FuzzySearch(queryStr string, fuzziness int, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -479,16 +481,12 @@ func (s *SearchService) FuzzySearch(queryStr string, fuzziness int) (*bleve.Sear
 	q.Fuzziness = fuzziness
 	search := bleve.NewSearchRequest(q)
 	search.Highlight = bleve.NewHighlight()
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // PhraseSearch searches for an exact phrase
-func (s *SearchService) PhraseSearch(phrase string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) PhraseSearch(phrase string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -497,16 +495,12 @@ func (s *SearchService) PhraseSearch(phrase string) (*bleve.SearchResult, error)
 	q := bleve.NewMatchPhraseQuery(phrase)
 	search := bleve.NewSearchRequest(q)
 	search.Highlight = bleve.NewHighlight()
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // PrefixSearch searches for terms starting with a prefix
-func (s *SearchService) PrefixSearch(prefix string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) PrefixSearch(prefix string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -514,16 +508,12 @@ func (s *SearchService) PrefixSearch(prefix string) (*bleve.SearchResult, error)
 
 	q := bleve.NewPrefixQuery(prefix)
 	search := bleve.NewSearchRequest(q)
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // AdvancedSearch performs combined text and tag search
-func (s *SearchService) AdvancedSearch(text string, tags []string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) AdvancedSearch(text string, tags []string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -544,16 +534,12 @@ func (s *SearchService) AdvancedSearch(text string, tags []string) (*bleve.Searc
 	q := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(q)
 	search.Highlight = bleve.NewHighlight()
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
-
-	return index.Search(search)
+	return s.run(search, opts)
 }
 
 // SearchCombined performs a comprehensive search with text, tags, and wikilinks
-func (s *SearchService) SearchCombined(text string, tags []string, wikilinks []string) (*bleve.SearchResult, error) {
-	index := s.getIndex()
-	if index == nil {
+func (s *SearchService) SearchCombined(text string, tags []string, wikilinks []string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
 		return nil, fmt.Errorf("search service not ready: index not available")
 	}
 
@@ -584,8 +570,25 @@ func (s *SearchService) SearchCombined(text string, tags []string, wikilinks []s
 	q := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(q)
 	search.Highlight = bleve.NewHighlight()
-	search.Fields = []string{"title", "path", "tags", "wikilinks"}
-	search.Size = 20
+	return s.run(search, opts)
+}
+
+// SearchByQuery runs an Obsidian-style query, such as
+// `tag:#project path:work/ "exact phrase" -draft (foo OR bar)`.
+// Queries that do not parse return a *ParseError.
+func (s *SearchService) SearchByQuery(queryStr string, opts SearchOptions) (*bleve.SearchResult, error) {
+	if !s.hasBackend() {
+		return nil, fmt.Errorf("search service not ready: index not available")
+	}
+
+	q, err := ParseQuery(queryStr)
+	if err != nil {
+		return nil, err
+	}
 
-	return index.Search(search)
+	s.recordSearch()
+
+	search := bleve.NewSearchRequest(q)
+	search.Highlight = bleve.NewHighlight()
+	return s.run(search, opts)
 }
@@ -105,7 +105,7 @@ func TestSearchService_SearchByMultipleTags(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := svc.SearchByMultipleTags(tt.tags)
+			results, err := svc.SearchByMultipleTags(tt.tags, SearchOptions{})
 			if err != nil {
 				t.Fatalf("SearchByMultipleTags failed: %v", err)
 			}
@@ -138,7 +138,7 @@ func TestSearchService_SearchByWikilink(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := svc.SearchByWikilink(tt.wikilink)
+			results, err := svc.SearchByWikilink(tt.wikilink, SearchOptions{})
 			if err != nil {
 				t.Fatalf("SearchByWikilink failed: %v", err)
 			}
@@ -158,7 +158,7 @@ func TestSearchService_SearchByBacklinks(t *testing.T) {
 	svc, cleanup := createTestSearchService(t)
 	defer cleanup()
 
-	results, err := svc.SearchByBacklinks("Docker")
+	results, err := svc.SearchByBacklinks("Docker", SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchByBacklinks failed: %v", err)
 	}
@@ -185,7 +185,7 @@ func TestSearchService_SearchByTagsOR(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := svc.SearchByTagsOR(tt.tags)
+			results, err := svc.SearchByTagsOR(tt.tags, SearchOptions{})
 			if err != nil {
 				t.Fatalf("SearchByTagsOR failed: %v", err)
 			}
@@ -214,7 +214,7 @@ func TestSearchService_SearchByMultipleWikilinks(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := svc.SearchByMultipleWikilinks(tt.wikilinks)
+			results, err := svc.SearchByMultipleWikilinks(tt.wikilinks, SearchOptions{})
 			if err != nil {
 				t.Fatalf("SearchByMultipleWikilinks failed: %v", err)
 			}
@@ -234,7 +234,7 @@ func TestSearchService_SearchByWikilinksOR(t *testing.T) {
 	svc, cleanup := createTestSearchService(t)
 	defer cleanup()
 
-	results, err := svc.SearchByWikilinksOR([]string{"Docker", "Pandas", "NonExistent"})
+	results, err := svc.SearchByWikilinksOR([]string{"Docker", "Pandas", "NonExistent"}, SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchByWikilinksOR failed: %v", err)
 	}
@@ -261,7 +261,7 @@ func TestSearchService_SearchByTitleOnly(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := svc.SearchByTitleOnly(tt.query)
+			results, err := svc.SearchByTitleOnly(tt.query, SearchOptions{})
 			if err != nil {
 				t.Fatalf("SearchByTitleOnly failed: %v", err)
 			}
@@ -295,7 +295,7 @@ func TestSearchService_FuzzySearch(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := svc.FuzzySearch(tt.query, tt.fuzziness)
+			results, err := svc.FuzzySearch(tt.query, tt.fuzziness, SearchOptions{})
 			if err != nil && !tt.wantError {
 				t.Fatalf("FuzzySearch failed: %v", err)
 			}
@@ -326,7 +326,7 @@ func TestSearchService_PhraseSearch(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := svc.PhraseSearch(tt.phrase)
+			results, err := svc.PhraseSearch(tt.phrase, SearchOptions{})
 			if err != nil {
 				t.Fatalf("PhraseSearch failed: %v", err)
 			}
@@ -356,7 +356,7 @@ func TestSearchService_PrefixSearch(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := svc.PrefixSearch(tt.prefix)
+			results, err := svc.PrefixSearch(tt.prefix, SearchOptions{})
 			if err != nil {
 				t.Fatalf("PrefixSearch failed: %v", err)
 			}
@@ -387,7 +387,7 @@ func TestSearchService_AdvancedSearch(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := svc.AdvancedSearch(tt.text, tt.tags)
+			results, err := svc.AdvancedSearch(tt.text, tt.tags, SearchOptions{})
 			if err != nil {
 				t.Fatalf("AdvancedSearch failed: %v", err)
 			}
@@ -460,7 +460,7 @@ func TestSearchService_SearchCombined(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			results, err := svc.SearchCombined(tt.text, tt.tags, tt.wikilinks)
+			results, err := svc.SearchCombined(tt.text, tt.tags, tt.wikilinks, SearchOptions{})
 			if err != nil {
 				t.Fatalf("SearchCombined failed: %v", err)
 			}
@@ -484,20 +484,20 @@ func TestSearchService_AllMethodsRecordMetrics(t *testing.T) {
 	initialCount := initialMetrics.SearchCount
 
 	// Call each search method once
-	svc.SearchByText("test")
-	svc.SearchByTag("test")
-	svc.SearchByMultipleTags([]string{"test"})
-	svc.SearchByWikilink("test")
-	svc.SearchByBacklinks("test")
-	svc.SearchByTagsOR([]string{"test"})
-	svc.SearchByMultipleWikilinks([]string{"test"})
-	svc.SearchByWikilinksOR([]string{"test"})
-	svc.SearchByTitleOnly("test")
-	svc.FuzzySearch("test", 1)
-	svc.PhraseSearch("test")
-	svc.PrefixSearch("test")
-	svc.AdvancedSearch("test", []string{"tag"})
-	svc.SearchCombined("test", []string{"tag"}, []string{"link"})
+	svc.SearchByText("test", SearchOptions{})
+	svc.SearchByTag("test", SearchOptions{})
+	svc.SearchByMultipleTags([]string{"test"}, SearchOptions{})
+	svc.SearchByWikilink("test", SearchOptions{})
+	svc.SearchByBacklinks("test", SearchOptions{})
+	svc.SearchByTagsOR([]string{"test"}, SearchOptions{})
+	svc.SearchByMultipleWikilinks([]string{"test"}, SearchOptions{})
+	svc.SearchByWikilinksOR([]string{"test"}, SearchOptions{})
+	svc.SearchByTitleOnly("test", SearchOptions{})
+	svc.FuzzySearch("test", 1, SearchOptions{})
+	svc.PhraseSearch("test", SearchOptions{})
+	svc.PrefixSearch("test", SearchOptions{})
+	svc.AdvancedSearch("test", []string{"tag"}, SearchOptions{})
+	svc.SearchCombined("test", []string{"tag"}, []string{"link"}, SearchOptions{})
 
 	finalMetrics := svc.GetMetrics()
 	expectedCount := initialCount + 14 // 14 search method calls
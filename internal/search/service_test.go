@@ -172,7 +172,7 @@ func TestSearchService_SearchMethods(t *testing.T) {
 	defer svc.Stop()
 
 	// Test SearchByText
-	results, err := svc.SearchByText("Test")
+	results, err := svc.SearchByText("Test", SearchOptions{})
 	if err != nil {
 		t.Errorf("SearchByText failed: %v", err)
 	}
@@ -181,7 +181,7 @@ func TestSearchService_SearchMethods(t *testing.T) {
 	}
 
 	// Test SearchByTag
-	results, err = svc.SearchByTag("golang")
+	results, err = svc.SearchByTag("golang", SearchOptions{})
 	if err != nil {
 		t.Errorf("SearchByTag failed: %v", err)
 	}
@@ -207,7 +207,7 @@ func TestSearchService_SearchWithoutIndex(t *testing.T) {
 	defer svc.Stop()
 
 	// Try to search (should return error)
-	_, err := svc.SearchByText("test")
+	_, err := svc.SearchByText("test", SearchOptions{})
 	if err == nil {
 		t.Error("Expected error when searching without index, got nil")
 	}
@@ -268,7 +268,7 @@ func TestSearchService_ConcurrentAccess(t *testing.T) {
 		go func() {
 			defer func() { done <- true }()
 			for j := 0; j < 10; j++ {
-				_, _ = svc.SearchByText("test")
+				_, _ = svc.SearchByText("test", SearchOptions{})
 				_ = svc.GetMetrics()
 				_ = svc.GetStatus()
 			}
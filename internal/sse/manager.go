@@ -32,9 +32,10 @@ const (
 
 // FileChange represents a single file change
 type FileChange struct {
-	ID     string     `json:"id"`     // DB ID
-	Path   string     `json:"path"`   // Relative path
-	Action ActionType `json:"action"` // create, delete, move
+	ID      string     `json:"id"`                 // DB ID
+	Path    string     `json:"path"`               // Relative path
+	OldPath string     `json:"old_path,omitempty"` // Previous relative path (for move)
+	Action  ActionType `json:"action"`             // create, delete, move
 }
 
 // Event represents an SSE event to be sent to clients
@@ -364,6 +365,23 @@ func (m *Manager) QueueFileChange(vaultID, fileID, path string, action ActionTyp
 	})
 }
 
+// QueueFileMove adds a move to the event queue for a vault; the file keeps its ID
+func (m *Manager) QueueFileMove(vaultID, fileID, oldPath, path string) {
+	m.eventQueuesMu.Lock()
+	defer m.eventQueuesMu.Unlock()
+
+	if m.eventQueues[vaultID] == nil {
+		m.eventQueues[vaultID] = make([]FileChange, 0, 100)
+	}
+
+	m.eventQueues[vaultID] = append(m.eventQueues[vaultID], FileChange{
+		ID:      fileID,
+		Path:    path,
+		OldPath: oldPath,
+		Action:  ActionMove,
+	})
+}
+
 // SetError sets an error message for a vault
 func (m *Manager) SetError(vaultID, errorMsg string) {
 	m.errorMessagesMu.Lock()
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/susamn/obsidian-web/internal/gitrepo"
+)
+
+// defaultCommitMessage is used when the config does not set commit_message
+const defaultCommitMessage = "{{.Action}} {{if .OldPath}}{{.OldPath}} -> {{end}}{{.Path}}"
+
+// commitData is passed to the commit message template
+type commitData struct {
+	Action  string // create, update, delete or rename
+	Path    string // Vault-relative path that was written
+	OldPath string // Previous path for renames
+}
+
+// GitStorage serves a vault from a git working copy.
+// Reads go straight to the working tree; every write is committed.
+type GitStorage struct {
+	*LocalStorage
+	repo    *gitrepo.Repo
+	message *template.Template
+}
+
+// NewGitStorage creates a storage for the repository's working tree.
+// messageTemplate is a Go template over .Action, .Path and .OldPath; empty uses the default.
+func NewGitStorage(repo *gitrepo.Repo, messageTemplate string) (*GitStorage, error) {
+	if messageTemplate == "" {
+		messageTemplate = defaultCommitMessage
+	}
+	tmpl, err := template.New("commit").Parse(messageTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit message template: %w", err)
+	}
+
+	return &GitStorage{
+		LocalStorage: NewLocalStorage(repo.Dir()),
+		repo:         repo,
+		message:      tmpl,
+	}, nil
+}
+
+// Repo returns the underlying repository
+func (g *GitStorage) Repo() *gitrepo.Repo {
+	return g.repo
+}
+
+// Write creates or replaces a file and commits it
+func (g *GitStorage) Write(ctx context.Context, p string, data []byte) error {
+	action := "create"
+	if _, err := g.LocalStorage.Stat(ctx, p); err == nil {
+		action = "update"
+	}
+
+	if err := g.LocalStorage.Write(ctx, p, data); err != nil {
+		return err
+	}
+	return g.commit(ctx, commitData{Action: action, Path: p})
+}
+
+// Delete removes a file or directory and commits the removal
+func (g *GitStorage) Delete(ctx context.Context, p string) error {
+	if err := g.LocalStorage.Delete(ctx, p); err != nil {
+		return err
+	}
+	return g.commit(ctx, commitData{Action: "delete", Path: p})
+}
+
+// Rename moves a file or directory and commits the move
+func (g *GitStorage) Rename(ctx context.Context, oldPath, newPath string) error {
+	if err := g.LocalStorage.Rename(ctx, oldPath, newPath); err != nil {
+		return err
+	}
+	return g.commit(ctx, commitData{Action: "rename", Path: newPath, OldPath: oldPath})
+}
+
+// commit records the working tree change. Mkdir is not committed since git does not track empty directories.
+func (g *GitStorage) commit(ctx context.Context, data commitData) error {
+	data.Path, _ = cleanPath(data.Path)
+	if data.OldPath != "" {
+		data.OldPath, _ = cleanPath(data.OldPath)
+	}
+
+	var msg bytes.Buffer
+	if err := g.message.Execute(&msg, data); err != nil {
+		return fmt.Errorf("failed to render commit message: %w", err)
+	}
+
+	if err := g.repo.Commit(ctx, msg.String()); err != nil {
+		return fmt.Errorf("file written but not committed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// LocalStorage serves a vault from a directory on the local filesystem
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage creates a storage rooted at the given directory
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{root: root}
+}
+
+// Root returns the directory the storage is rooted at
+func (l *LocalStorage) Root() string {
+	return l.root
+}
+
+// fullPath resolves a vault-relative path to a filesystem path
+func (l *LocalStorage) fullPath(p string) (string, string, error) {
+	clean, err := cleanPath(p)
+	if err != nil {
+		return "", "", err
+	}
+	if clean == "" {
+		return l.root, clean, nil
+	}
+	return filepath.Join(l.root, filepath.FromSlash(clean)), clean, nil
+}
+
+// Open opens a file for streaming reads
+func (l *LocalStorage) Open(ctx context.Context, p string) (io.ReadCloser, error) {
+	full, _, err := l.fullPath(p)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(full)
+}
+
+// Read returns the full content of a file
+func (l *LocalStorage) Read(ctx context.Context, p string) ([]byte, error) {
+	full, _, err := l.fullPath(p)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(full)
+}
+
+// Write creates or replaces a file, creating parent directories as needed
+func (l *LocalStorage) Write(ctx context.Context, p string, data []byte) error {
+	full, clean, err := l.fullPath(p)
+	if err != nil {
+		return err
+	}
+	if clean == "" {
+		return fmt.Errorf("cannot write to vault root")
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	return os.WriteFile(full, data, 0644)
+}
+
+// Mkdir creates a directory and any missing parents
+func (l *LocalStorage) Mkdir(ctx context.Context, p string) error {
+	full, _, err := l.fullPath(p)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(full, 0755)
+}
+
+// Stat returns information about a file or directory
+func (l *LocalStorage) Stat(ctx context.Context, p string) (*FileInfo, error) {
+	full, clean, err := l.fullPath(p)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfoFromOS(clean, info), nil
+}
+
+// List returns the direct children of a directory
+func (l *LocalStorage) List(ctx context.Context, dir string) ([]FileInfo, error) {
+	full, clean, err := l.fullPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			// Entry vanished between ReadDir and Info
+			continue
+		}
+		infos = append(infos, *fileInfoFromOS(path.Join(clean, entry.Name()), info))
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Delete removes a file or a directory and everything under it
+func (l *LocalStorage) Delete(ctx context.Context, p string) error {
+	full, clean, err := l.fullPath(p)
+	if err != nil {
+		return err
+	}
+	if clean == "" {
+		return fmt.Errorf("cannot delete vault root")
+	}
+	if _, err := os.Stat(full); err != nil {
+		return err
+	}
+	return os.RemoveAll(full)
+}
+
+// Rename moves a file or directory to a new path
+func (l *LocalStorage) Rename(ctx context.Context, oldPath, newPath string) error {
+	oldFull, oldClean, err := l.fullPath(oldPath)
+	if err != nil {
+		return err
+	}
+	newFull, newClean, err := l.fullPath(newPath)
+	if err != nil {
+		return err
+	}
+	if oldClean == "" || newClean == "" {
+		return fmt.Errorf("cannot rename vault root")
+	}
+	if err := os.MkdirAll(filepath.Dir(newFull), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	return os.Rename(oldFull, newFull)
+}
+
+// fileInfoFromOS converts an os.FileInfo into a storage FileInfo
+func fileInfoFromOS(relPath string, info os.FileInfo) *FileInfo {
+	fi := &FileInfo{
+		Path:    relPath,
+		Name:    info.Name(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+	if relPath != "" {
+		fi.Name = path.Base(relPath)
+	}
+	if !fi.IsDir {
+		fi.Size = info.Size()
+	}
+	return fi
+}
@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/susamn/obsidian-web/internal/objectstore"
+)
+
+// ObjectStorage serves a vault from an S3-compatible bucket (AWS S3 or MinIO).
+// Directories are implicit: a directory exists while any key lives under it,
+// or when an empty "dir/" marker object has been written by Mkdir.
+type ObjectStorage struct {
+	client *objectstore.Client
+	prefix string
+}
+
+// NewObjectStorage creates a storage for the vault stored under prefix in the client's bucket
+func NewObjectStorage(client *objectstore.Client, prefix string) *ObjectStorage {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &ObjectStorage{client: client, prefix: prefix}
+}
+
+// key converts a vault-relative path into an object key
+func (o *ObjectStorage) key(p string) (string, string, error) {
+	clean, err := cleanPath(p)
+	if err != nil {
+		return "", "", err
+	}
+	return o.prefix + clean, clean, nil
+}
+
+// dirPrefix returns the key prefix for everything under a directory
+func (o *ObjectStorage) dirPrefix(clean string) string {
+	if clean == "" {
+		return o.prefix
+	}
+	return o.prefix + clean + "/"
+}
+
+// Open opens a file for streaming reads
+func (o *ObjectStorage) Open(ctx context.Context, p string) (io.ReadCloser, error) {
+	key, clean, err := o.key(p)
+	if err != nil {
+		return nil, err
+	}
+	if clean == "" {
+		return nil, fmt.Errorf("cannot open vault root")
+	}
+
+	body, _, err := o.client.GetObject(ctx, key)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return nil, notExist("open", clean)
+	}
+	return body, err
+}
+
+// Read returns the full content of a file
+func (o *ObjectStorage) Read(ctx context.Context, p string) ([]byte, error) {
+	body, err := o.Open(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// Write creates or replaces a file
+func (o *ObjectStorage) Write(ctx context.Context, p string, data []byte) error {
+	key, clean, err := o.key(p)
+	if err != nil {
+		return err
+	}
+	if clean == "" {
+		return fmt.Errorf("cannot write to vault root")
+	}
+	return o.client.PutObject(ctx, key, data)
+}
+
+// Mkdir writes an empty directory marker so the directory shows up before it has files
+func (o *ObjectStorage) Mkdir(ctx context.Context, p string) error {
+	_, clean, err := o.key(p)
+	if err != nil {
+		return err
+	}
+	if clean == "" {
+		return nil
+	}
+	return o.client.PutObject(ctx, o.dirPrefix(clean), nil)
+}
+
+// Stat returns information about a file or directory
+func (o *ObjectStorage) Stat(ctx context.Context, p string) (*FileInfo, error) {
+	key, clean, err := o.key(p)
+	if err != nil {
+		return nil, err
+	}
+	if clean == "" {
+		return &FileInfo{IsDir: true}, nil
+	}
+
+	obj, err := o.client.StatObject(ctx, key)
+	if err == nil {
+		return &FileInfo{
+			Path:    clean,
+			Name:    path.Base(clean),
+			Size:    obj.Size,
+			ModTime: obj.LastModified,
+		}, nil
+	}
+	if !errors.Is(err, objectstore.ErrNotFound) {
+		return nil, err
+	}
+
+	// No object under the exact key, check whether it is an implicit directory
+	objects, err := o.client.ListObjects(ctx, o.dirPrefix(clean))
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, notExist("stat", clean)
+	}
+	return &FileInfo{Path: clean, Name: path.Base(clean), IsDir: true}, nil
+}
+
+// List returns the direct children of a directory, synthesizing subdirectories from key prefixes
+func (o *ObjectStorage) List(ctx context.Context, dir string) ([]FileInfo, error) {
+	_, clean, err := o.key(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := o.dirPrefix(clean)
+	objects, err := o.client.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 && clean != "" {
+		return nil, notExist("list", clean)
+	}
+
+	entries := make(map[string]FileInfo)
+	for _, obj := range objects {
+		rest := strings.TrimPrefix(obj.Key, prefix)
+		if rest == "" {
+			// The directory's own marker
+			continue
+		}
+
+		name, remainder, nested := strings.Cut(rest, "/")
+		childPath := path.Join(clean, name)
+		if nested || remainder != "" {
+			if _, seen := entries[name]; !seen {
+				entries[name] = FileInfo{Path: childPath, Name: name, IsDir: true}
+			}
+			continue
+		}
+
+		entries[name] = FileInfo{
+			Path:    childPath,
+			Name:    name,
+			Size:    obj.Size,
+			ModTime: obj.LastModified,
+		}
+	}
+
+	infos := make([]FileInfo, 0, len(entries))
+	for _, info := range entries {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// Delete removes a file, or every object under a directory
+func (o *ObjectStorage) Delete(ctx context.Context, p string) error {
+	info, err := o.Stat(ctx, p)
+	if err != nil {
+		return err
+	}
+	if info.Path == "" {
+		return fmt.Errorf("cannot delete vault root")
+	}
+
+	if !info.IsDir {
+		return o.client.DeleteObject(ctx, o.prefix+info.Path)
+	}
+
+	objects, err := o.client.ListObjects(ctx, o.dirPrefix(info.Path))
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := o.client.DeleteObject(ctx, obj.Key); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", obj.Key, err)
+		}
+	}
+	return nil
+}
+
+// Rename copies a file (or every object under a directory) to the new path and deletes the originals.
+// Object stores have no atomic rename, so a failure part-way can leave both copies behind.
+func (o *ObjectStorage) Rename(ctx context.Context, oldPath, newPath string) error {
+	info, err := o.Stat(ctx, oldPath)
+	if err != nil {
+		return err
+	}
+	_, newClean, err := o.key(newPath)
+	if err != nil {
+		return err
+	}
+	if info.Path == "" || newClean == "" {
+		return fmt.Errorf("cannot rename vault root")
+	}
+
+	if !info.IsDir {
+		return o.move(ctx, o.prefix+info.Path, o.prefix+newClean)
+	}
+
+	oldPrefix := o.dirPrefix(info.Path)
+	newPrefix := o.dirPrefix(newClean)
+	objects, err := o.client.ListObjects(ctx, oldPrefix)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := o.move(ctx, obj.Key, newPrefix+strings.TrimPrefix(obj.Key, oldPrefix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// move copies a single object to a new key and removes the source
+func (o *ObjectStorage) move(ctx context.Context, srcKey, dstKey string) error {
+	if err := o.client.CopyObject(ctx, srcKey, dstKey); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", srcKey, err)
+	}
+	if err := o.client.DeleteObject(ctx, srcKey); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", srcKey, err)
+	}
+	return nil
+}
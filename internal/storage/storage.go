@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/gitrepo"
+	"github.com/susamn/obsidian-web/internal/objectstore"
+)
+
+// FileInfo describes a file or directory in vault storage
+type FileInfo struct {
+	Path    string    // Relative slash path from the vault root ("" for the root)
+	Name    string    // Base name
+	Size    int64     // Size in bytes (0 for directories)
+	ModTime time.Time // Last modification time (zero for synthesized directories)
+	IsDir   bool
+}
+
+// VaultStorage is the read/write interface all vault file access goes through.
+// Paths are relative to the vault root and use forward slashes; "" is the root.
+// Missing paths are reported with errors matching fs.ErrNotExist.
+type VaultStorage interface {
+	// Open opens a file for streaming reads
+	Open(ctx context.Context, p string) (io.ReadCloser, error)
+	// Read returns the full content of a file
+	Read(ctx context.Context, p string) ([]byte, error)
+	// Write creates or replaces a file, creating parent directories as needed
+	Write(ctx context.Context, p string, data []byte) error
+	// Mkdir creates a directory and any missing parents
+	Mkdir(ctx context.Context, p string) error
+	// Stat returns information about a file or directory
+	Stat(ctx context.Context, p string) (*FileInfo, error)
+	// List returns the direct children of a directory
+	List(ctx context.Context, dir string) ([]FileInfo, error)
+	// Delete removes a file or a directory and everything under it
+	Delete(ctx context.Context, p string) error
+	// Rename moves a file or directory to a new path
+	Rename(ctx context.Context, oldPath, newPath string) error
+}
+
+// New creates the VaultStorage for a vault's storage configuration
+func New(cfg *config.StorageConfig) (VaultStorage, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("storage config cannot be nil")
+	}
+
+	switch cfg.GetType() {
+	case config.LocalStorage:
+		localCfg := cfg.GetLocalConfig()
+		if localCfg == nil || localCfg.Path == "" {
+			return nil, fmt.Errorf("local storage config missing or path empty")
+		}
+		return NewLocalStorage(localCfg.Path), nil
+
+	case config.S3Storage:
+		s3Cfg := cfg.GetS3Config()
+		if s3Cfg == nil {
+			return nil, fmt.Errorf("s3 storage config missing")
+		}
+		client, err := objectstore.NewS3Client(s3Cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create s3 storage: %w", err)
+		}
+		return NewObjectStorage(client, s3Cfg.Prefix), nil
+
+	case config.MinIOStorage:
+		minioCfg := cfg.GetMinIOConfig()
+		if minioCfg == nil {
+			return nil, fmt.Errorf("minio storage config missing")
+		}
+		client, err := objectstore.NewMinIOClient(minioCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create minio storage: %w", err)
+		}
+		return NewObjectStorage(client, ""), nil
+
+	case config.GitStorage:
+		gitCfg := cfg.GetGitConfig()
+		if gitCfg == nil {
+			return nil, fmt.Errorf("git storage config missing")
+		}
+		repo, err := gitrepo.Open(context.Background(), gitCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open git repository: %w", err)
+		}
+		return NewGitStorage(repo, gitCfg.CommitMessage)
+
+	default:
+		return nil, fmt.Errorf("unknown storage type: %s", cfg.GetType())
+	}
+}
+
+// WalkFunc is called by Walk for every file and directory below the root
+type WalkFunc func(info FileInfo) error
+
+// SkipDir can be returned by a WalkFunc to skip the contents of a directory
+var SkipDir = fs.SkipDir
+
+// Walk visits every entry under dir in lexical order, depth first.
+// Hidden entries (names starting with ".") are skipped.
+func Walk(ctx context.Context, s VaultStorage, dir string, fn WalkFunc) error {
+	entries, err := s.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if strings.HasPrefix(entry.Name, ".") {
+			continue
+		}
+
+		if err := fn(entry); err != nil {
+			if err == SkipDir && entry.IsDir {
+				continue
+			}
+			return err
+		}
+
+		if entry.IsDir {
+			if err := Walk(ctx, s, entry.Path, fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// cleanPath normalizes a vault-relative path and rejects traversal outside the root
+func cleanPath(p string) (string, error) {
+	p = strings.Trim(strings.ReplaceAll(p, "\\", "/"), "/")
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("invalid path %q: directory traversal not allowed", p)
+		}
+	}
+
+	cleaned := path.Clean(p)
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
+
+// notExist builds an fs.ErrNotExist error for a vault path
+func notExist(op, p string) error {
+	return &fs.PathError{Op: op, Path: p, Err: fs.ErrNotExist}
+}
@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/gitrepo/gitrepotest"
+	"github.com/susamn/obsidian-web/internal/objectstore/objectstoretest"
+)
+
+// storageCases returns a local and an object-backed storage so every test runs against both
+func storageCases(t *testing.T) map[string]VaultStorage {
+	t.Helper()
+
+	srv := objectstoretest.NewServer("vault")
+	t.Cleanup(srv.Close)
+
+	object, err := New(&config.StorageConfig{
+		Type: "s3",
+		S3: &config.S3StorageConfig{
+			Bucket:   "vault",
+			Region:   "us-east-1",
+			Prefix:   "notes",
+			Endpoint: srv.URL,
+		},
+	})
+	if err != nil {
+		t.Fatalf("New(s3) error = %v", err)
+	}
+
+	return map[string]VaultStorage{
+		"local":  NewLocalStorage(t.TempDir()),
+		"object": object,
+	}
+}
+
+func TestVaultStorage_ReadWriteStat(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storageCases(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := s.Write(ctx, "dir/note.md", []byte("# Note")); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			data, err := s.Read(ctx, "dir/note.md")
+			if err != nil || string(data) != "# Note" {
+				t.Fatalf("Read() = %q, %v", data, err)
+			}
+
+			rc, err := s.Open(ctx, "/dir/note.md")
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			streamed, _ := io.ReadAll(rc)
+			rc.Close()
+			if string(streamed) != "# Note" {
+				t.Errorf("Open() content = %q", streamed)
+			}
+
+			info, err := s.Stat(ctx, "dir/note.md")
+			if err != nil {
+				t.Fatalf("Stat(file) error = %v", err)
+			}
+			if info.IsDir || info.Size != 6 || info.Name != "note.md" || info.Path != "dir/note.md" {
+				t.Errorf("Stat(file) = %+v", info)
+			}
+
+			info, err = s.Stat(ctx, "dir")
+			if err != nil || !info.IsDir {
+				t.Errorf("Stat(dir) = %+v, %v", info, err)
+			}
+
+			if _, err := s.Stat(ctx, "missing.md"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Stat(missing) error = %v, want fs.ErrNotExist", err)
+			}
+			if _, err := s.Read(ctx, "missing.md"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Read(missing) error = %v, want fs.ErrNotExist", err)
+			}
+		})
+	}
+}
+
+func TestVaultStorage_ListAndWalk(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storageCases(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, p := range []string{"b.md", "a/one.md", "a/deep/two.md", ".hidden/x.md"} {
+				if err := s.Write(ctx, p, []byte(p)); err != nil {
+					t.Fatalf("Write(%s) error = %v", p, err)
+				}
+			}
+			if err := s.Mkdir(ctx, "empty"); err != nil {
+				t.Fatalf("Mkdir() error = %v", err)
+			}
+
+			entries, err := s.List(ctx, "")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name)
+			}
+			want := []string{".hidden", "a", "b.md", "empty"}
+			if len(names) != len(want) {
+				t.Fatalf("List() names = %v, want %v", names, want)
+			}
+			for i := range want {
+				if names[i] != want[i] {
+					t.Fatalf("List() names = %v, want %v", names, want)
+				}
+			}
+
+			var walked []string
+			err = Walk(ctx, s, "", func(info FileInfo) error {
+				if !info.IsDir {
+					walked = append(walked, info.Path)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Walk() error = %v", err)
+			}
+			wantWalk := []string{"a/deep/two.md", "a/one.md", "b.md"}
+			if len(walked) != len(wantWalk) {
+				t.Fatalf("Walk() files = %v, want %v", walked, wantWalk)
+			}
+			for i := range wantWalk {
+				if walked[i] != wantWalk[i] {
+					t.Fatalf("Walk() files = %v, want %v", walked, wantWalk)
+				}
+			}
+		})
+	}
+}
+
+func TestVaultStorage_RenameAndDelete(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storageCases(t) {
+		t.Run(name, func(t *testing.T) {
+			s.Write(ctx, "old.md", []byte("x"))
+			s.Write(ctx, "folder/inner.md", []byte("y"))
+
+			if err := s.Rename(ctx, "old.md", "moved/new.md"); err != nil {
+				t.Fatalf("Rename(file) error = %v", err)
+			}
+			if _, err := s.Stat(ctx, "old.md"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("old path still exists after rename: %v", err)
+			}
+			if data, err := s.Read(ctx, "moved/new.md"); err != nil || string(data) != "x" {
+				t.Errorf("Read(renamed) = %q, %v", data, err)
+			}
+
+			if err := s.Rename(ctx, "folder", "renamed"); err != nil {
+				t.Fatalf("Rename(dir) error = %v", err)
+			}
+			if data, err := s.Read(ctx, "renamed/inner.md"); err != nil || string(data) != "y" {
+				t.Errorf("Read(renamed dir child) = %q, %v", data, err)
+			}
+
+			if err := s.Delete(ctx, "renamed"); err != nil {
+				t.Fatalf("Delete(dir) error = %v", err)
+			}
+			if _, err := s.Stat(ctx, "renamed/inner.md"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("child still exists after directory delete: %v", err)
+			}
+			if err := s.Delete(ctx, "missing.md"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Delete(missing) error = %v, want fs.ErrNotExist", err)
+			}
+		})
+	}
+}
+
+func TestVaultStorage_RejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storageCases(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := s.Write(ctx, "../escape.md", []byte("x")); err == nil {
+				t.Error("Write() allowed directory traversal")
+			}
+			if _, err := s.Read(ctx, "a/../../etc/passwd"); err == nil {
+				t.Error("Read() allowed directory traversal")
+			}
+		})
+	}
+}
+
+func TestObjectStorage_UsesPrefix(t *testing.T) {
+	srv := objectstoretest.NewServer("vault")
+	defer srv.Close()
+
+	s, err := New(&config.StorageConfig{
+		Type:  "minio",
+		MinIO: &config.MinIOStorageConfig{Endpoint: srv.URL, Bucket: "vault"},
+	})
+	if err != nil {
+		t.Fatalf("New(minio) error = %v", err)
+	}
+	if err := s.Write(context.Background(), "note.md", []byte("hi")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, ok := srv.Object("note.md"); !ok {
+		t.Error("expected object at bucket root for unprefixed MinIO vault")
+	}
+
+	prefixed := NewObjectStorage(nil, "/team/vault/")
+	if prefixed.prefix != "team/vault/" {
+		t.Errorf("prefix = %q, want %q", prefixed.prefix, "team/vault/")
+	}
+}
+
+func TestLocalStorage_WriteCreatesParents(t *testing.T) {
+	root := t.TempDir()
+	s := NewLocalStorage(root)
+
+	if err := s.Write(context.Background(), "a/b/c.md", []byte("deep")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a", "b", "c.md")); err != nil {
+		t.Errorf("file not written to disk: %v", err)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	if _, err := New(nil); err == nil {
+		t.Error("New(nil) should fail")
+	}
+	if _, err := New(&config.StorageConfig{Type: "local", Local: &config.LocalStorageConfig{}}); err == nil {
+		t.Error("New() with empty local path should fail")
+	}
+	if _, err := New(&config.StorageConfig{Type: "s3"}); err == nil {
+		t.Error("New() with missing s3 config should fail")
+	}
+}
+
+func TestGitStorage_CommitsWrites(t *testing.T) {
+	remote := gitrepotest.NewRemote(t, map[string]string{"note.md": "v1"})
+	ctx := context.Background()
+
+	s, err := New(&config.StorageConfig{
+		Type: "git",
+		Git: &config.GitStorageConfig{
+			Path:          filepath.Join(t.TempDir(), "vault"),
+			URL:           remote.URL,
+			CommitMessage: "vault: {{.Action}} {{.Path}}",
+		},
+	})
+	if err != nil {
+		t.Fatalf("New(git) error = %v", err)
+	}
+	g := s.(*GitStorage)
+
+	if data, err := s.Read(ctx, "note.md"); err != nil || string(data) != "v1" {
+		t.Fatalf("Read(cloned) = %q, %v", data, err)
+	}
+
+	if err := s.Write(ctx, "note.md", []byte("v2")); err != nil {
+		t.Fatalf("Write(update) error = %v", err)
+	}
+	if err := s.Write(ctx, "/new.md", []byte("fresh")); err != nil {
+		t.Fatalf("Write(create) error = %v", err)
+	}
+	if err := s.Delete(ctx, "note.md"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	out, err := exec.Command("git", "-C", g.Repo().Dir(), "log", "--format=%s").Output()
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	want := "vault: delete note.md\nvault: create new.md\nvault: update note.md\nInitial commit\n"
+	if string(out) != want {
+		t.Errorf("commit log = %q, want %q", out, want)
+	}
+}
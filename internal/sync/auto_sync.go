@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/ignore"
+	"github.com/susamn/obsidian-web/internal/logger"
+)
+
+// watchProbeTimeout is how long auto mode waits for the watcher to report its probe file
+const watchProbeTimeout = 2 * time.Second
+
+// autoSync watches a local vault with fsnotify, switching to polling when a
+// probe at startup shows that notifications never arrive (network and FUSE mounts)
+type autoSync struct {
+	vaultID string
+	local   *localSync
+	poll    *pollSync
+
+	mu     sync.Mutex
+	active syncBackend // Chosen by Start
+}
+
+// newAutoSync creates both backends; Start decides which one runs
+func newAutoSync(vaultID, rootPath string, pollInterval time.Duration) (*autoSync, error) {
+	local, err := newLocalSync(vaultID, rootPath)
+	if err != nil {
+		return nil, err
+	}
+	poll, err := newPollSync(vaultID, rootPath, pollInterval)
+	if err != nil {
+		_ = local.Stop()
+		return nil, err
+	}
+
+	return &autoSync{
+		vaultID: vaultID,
+		local:   local,
+		poll:    poll,
+	}, nil
+}
+
+// setIgnoreRules passes the rules to both backends
+func (a *autoSync) setIgnoreRules(rules *ignore.Rules, onFileChange func()) {
+	a.local.setIgnoreRules(rules, onFileChange)
+	a.poll.setIgnoreRules(rules, onFileChange)
+}
+
+// Start probes the watcher and runs whichever backend works (blocking)
+func (a *autoSync) Start(ctx context.Context, events chan<- FileChangeEvent) error {
+	var backend syncBackend = a.local
+
+	ok, err := a.local.probe(watchProbeTimeout)
+	switch {
+	case err != nil:
+		// A read-only vault cannot be probed; notifications usually work there
+		logger.WithError(err).WithField("vault_id", a.vaultID).Warn("Could not probe filesystem watcher, using fsnotify (set watch_mode: poll if changes are missed)")
+	case !ok:
+		logger.WithField("vault_id", a.vaultID).Warn("Filesystem watcher reported nothing, switching to polling")
+		_ = a.local.Stop()
+		backend = a.poll
+	}
+
+	a.mu.Lock()
+	a.active = backend
+	a.mu.Unlock()
+
+	return backend.Start(ctx, events)
+}
+
+// Stop stops both backends
+func (a *autoSync) Stop() error {
+	_ = a.poll.Stop()
+	return a.local.Stop()
+}
+
+// ReIndex walks the vault through the running backend
+func (a *autoSync) ReIndex(ctx context.Context, events chan<- FileChangeEvent) error {
+	a.mu.Lock()
+	backend := a.active
+	a.mu.Unlock()
+
+	if backend == nil {
+		// Not started yet; both walk the same directory
+		backend = a.poll
+	}
+	return backend.ReIndex(ctx, events)
+}
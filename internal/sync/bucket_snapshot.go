@@ -0,0 +1,263 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/ignore"
+	"github.com/susamn/obsidian-web/internal/logger"
+	"github.com/susamn/obsidian-web/internal/objectstore"
+)
+
+// objectState is the snapshot entry kept for each tracked object
+type objectState struct {
+	etag         string
+	lastModified time.Time
+}
+
+// bucketSnapshot tracks the objects under a bucket prefix and turns listing
+// differences into FileChangeEvents. Shared by the S3 and MinIO backends.
+type bucketSnapshot struct {
+	vaultID  string
+	rootPath string
+	prefix   string
+	client   *objectstore.Client
+
+	ignore         *ignore.Rules
+	onIgnoreChange func()
+
+	mu         sync.Mutex
+	objects    map[string]objectState // relative path -> state
+	ignoreETag string                 // ETag of the vault's ignore file, "" when absent
+}
+
+// newBucketSnapshot creates an empty snapshot for the given bucket prefix
+func newBucketSnapshot(vaultID, prefix string, client *objectstore.Client) *bucketSnapshot {
+	return &bucketSnapshot{
+		vaultID:  vaultID,
+		rootPath: RemoteVaultRoot(vaultID),
+		prefix:   normalizePrefix(prefix),
+		client:   client,
+		objects:  make(map[string]objectState),
+	}
+}
+
+// setIgnoreRules sets the rules for objects that are not tracked, and the
+// callback for when the ignore file object changes
+func (b *bucketSnapshot) setIgnoreRules(rules *ignore.Rules, onFileChange func()) {
+	b.ignore = rules
+	b.onIgnoreChange = onFileChange
+}
+
+// refresh lists the bucket and replaces the snapshot
+// When emitChanges is set, events are emitted for the diff against the previous snapshot
+func (b *bucketSnapshot) refresh(ctx context.Context, events chan<- FileChangeEvent, emitChanges bool) error {
+	current, ignoreETag, err := b.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	previous := b.objects
+	b.objects = current
+	ignoreChanged := b.ignoreETag != ignoreETag
+	b.ignoreETag = ignoreETag
+	b.mu.Unlock()
+
+	if !emitChanges {
+		return nil
+	}
+
+	for _, change := range diffSnapshots(previous, current) {
+		if !b.emit(ctx, events, change.path, change.eventType) {
+			return ctx.Err()
+		}
+	}
+
+	if ignoreChanged && b.onIgnoreChange != nil {
+		b.onIgnoreChange()
+	}
+	return nil
+}
+
+// reIndex lists the whole prefix and emits FileCreated for every object (blocking)
+func (b *bucketSnapshot) reIndex(ctx context.Context, events chan<- FileChangeEvent) error {
+	current, ignoreETag, err := b.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.objects = current
+	b.ignoreETag = ignoreETag
+	b.mu.Unlock()
+
+	for relPath := range current {
+		if !b.emit(ctx, events, relPath, FileCreated) {
+			return ctx.Err()
+		}
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"vault_id": b.vaultID,
+		"objects":  len(current),
+	}).Info("Bucket re-index listing completed")
+	return nil
+}
+
+// applyNotification records a single object event in the snapshot and emits the matching event
+// An ObjectCreated for a key already in the snapshot is reported as a modification
+func (b *bucketSnapshot) applyNotification(ctx context.Context, events chan<- FileChangeEvent, n objectstore.NotificationEvent) bool {
+	if n.Key == b.prefix+ignore.FileName {
+		b.mu.Lock()
+		b.ignoreETag = ""
+		if n.IsCreated() {
+			b.ignoreETag = n.ETag
+		}
+		b.mu.Unlock()
+
+		if b.onIgnoreChange != nil {
+			b.onIgnoreChange()
+		}
+		return true
+	}
+
+	relPath, ok := keyToRelPath(b.prefix, n.Key)
+	if !ok || b.ignore.Ignored(relPath, false) {
+		return true
+	}
+
+	b.mu.Lock()
+	_, existed := b.objects[relPath]
+	var eventType FileEventType
+	switch {
+	case n.IsCreated():
+		b.objects[relPath] = objectState{etag: n.ETag, lastModified: n.EventTime}
+		eventType = FileCreated
+		if existed {
+			eventType = FileModified
+		}
+	case n.IsRemoved():
+		delete(b.objects, relPath)
+		eventType = FileDeleted
+	default:
+		b.mu.Unlock()
+		return true
+	}
+	b.mu.Unlock()
+
+	return b.emit(ctx, events, relPath, eventType)
+}
+
+// size returns the number of tracked objects
+func (b *bucketSnapshot) size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.objects)
+}
+
+// list fetches the current object listing keyed by vault-relative path,
+// leaving out ignored objects, along with the ignore file's ETag
+func (b *bucketSnapshot) list(ctx context.Context) (map[string]objectState, string, error) {
+	listCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	objects, err := b.client.ListObjects(listCtx, b.prefix)
+	if err != nil {
+		return nil, "", fmt.Errorf("list objects: %w", err)
+	}
+
+	current := make(map[string]objectState, len(objects))
+	ignoreETag := ""
+	for _, obj := range objects {
+		if obj.Key == b.prefix+ignore.FileName {
+			ignoreETag = obj.ETag
+			continue
+		}
+		relPath, ok := keyToRelPath(b.prefix, obj.Key)
+		if !ok || b.ignore.Ignored(relPath, false) {
+			continue
+		}
+		current[relPath] = objectState{etag: obj.ETag, lastModified: obj.LastModified}
+	}
+	return current, ignoreETag, nil
+}
+
+// emit sends an event for relPath (blocking), returning false if ctx was cancelled
+func (b *bucketSnapshot) emit(ctx context.Context, events chan<- FileChangeEvent, relPath string, eventType FileEventType) bool {
+	event := FileChangeEvent{
+		VaultID:   b.vaultID,
+		Path:      filepath.Join(b.rootPath, filepath.FromSlash(relPath)),
+		EventType: eventType,
+		Timestamp: time.Now(),
+	}
+
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// snapshotChange is a single difference between two listings
+type snapshotChange struct {
+	path      string
+	eventType FileEventType
+}
+
+// diffSnapshots compares two listings and returns the changes between them
+func diffSnapshots(previous, current map[string]objectState) []snapshotChange {
+	var changes []snapshotChange
+
+	for relPath, state := range current {
+		old, existed := previous[relPath]
+		switch {
+		case !existed:
+			changes = append(changes, snapshotChange{relPath, FileCreated})
+		case old.etag != state.etag || !old.lastModified.Equal(state.lastModified):
+			changes = append(changes, snapshotChange{relPath, FileModified})
+		}
+	}
+
+	for relPath := range previous {
+		if _, ok := current[relPath]; !ok {
+			changes = append(changes, snapshotChange{relPath, FileDeleted})
+		}
+	}
+
+	return changes
+}
+
+// normalizePrefix strips leading slashes and ensures a non-empty prefix ends with "/"
+func normalizePrefix(prefix string) string {
+	prefix = strings.TrimLeft(prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// keyToRelPath converts an object key to a vault-relative slash path
+// Returns false for directory markers and hidden files or directories
+func keyToRelPath(prefix, key string) (string, bool) {
+	if !strings.HasPrefix(key, prefix) {
+		return "", false
+	}
+	relPath := strings.TrimPrefix(key, prefix)
+	if relPath == "" || strings.HasSuffix(relPath, "/") {
+		return "", false
+	}
+
+	for _, part := range strings.Split(relPath, "/") {
+		if strings.HasPrefix(part, ".") {
+			return "", false
+		}
+	}
+
+	return relPath, true
+}
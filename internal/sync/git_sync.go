@@ -0,0 +1,231 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/gitrepo"
+	"github.com/susamn/obsidian-web/internal/ignore"
+	"github.com/susamn/obsidian-web/internal/logger"
+)
+
+// defaultGitPullInterval is used when the config does not set pull_interval
+const defaultGitPullInterval = time.Minute
+
+// gitSync monitors a git working copy: local edits are picked up by the
+// fsnotify watcher and remote commits are pulled on an interval and
+// turned into events from the pulled diff
+type gitSync struct {
+	vaultID    string
+	interval   time.Duration
+	repo       *gitrepo.Repo
+	local      *localSync
+	onConflict func(*SyncConflict)
+	ignore     *ignore.Rules
+}
+
+// newGitSync creates a new git sync service, cloning the repository if needed
+func newGitSync(ctx context.Context, vaultID string, cfg *config.GitStorageConfig) (*gitSync, error) {
+	repo, err := gitrepo.Open(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := newLocalSync(vaultID, repo.Dir())
+	if err != nil {
+		return nil, err
+	}
+
+	interval := cfg.PullInterval
+	if interval <= 0 {
+		interval = defaultGitPullInterval
+	}
+
+	return &gitSync{
+		vaultID:  vaultID,
+		interval: interval,
+		repo:     repo,
+		local:    local,
+	}, nil
+}
+
+// setConflictHandler registers the callback for merge conflicts
+func (g *gitSync) setConflictHandler(fn func(*SyncConflict)) {
+	g.onConflict = fn
+}
+
+// setIgnoreRules applies rules to the watcher and to pulled changes.
+// The watcher also sees a pulled ignore file being written, so it reports the change.
+func (g *gitSync) setIgnoreRules(rules *ignore.Rules, onFileChange func()) {
+	g.ignore = rules
+	g.local.setIgnoreRules(rules, onFileChange)
+}
+
+// Start watches the working tree and pulls until the context is cancelled (blocking)
+func (g *gitSync) Start(ctx context.Context, events chan<- FileChangeEvent) error {
+	logger.WithFields(map[string]interface{}{
+		"vault_id": g.vaultID,
+		"path":     g.repo.Dir(),
+		"branch":   g.repo.Branch(),
+		"interval": g.interval,
+	}).Info("Starting git sync")
+
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- g.local.Start(ctx, events)
+	}()
+
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			err := <-watchErr
+			logger.WithField("vault_id", g.vaultID).Info("Git sync stopped")
+			return err
+		case err := <-watchErr:
+			return err
+		case <-ticker.C:
+			g.pull(ctx, events)
+		}
+	}
+}
+
+// pull merges remote commits and emits events for the files they touched
+func (g *gitSync) pull(ctx context.Context, events chan<- FileChangeEvent) {
+	from, to, err := g.repo.Pull(ctx)
+
+	var conflict *gitrepo.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		logger.WithFields(map[string]interface{}{
+			"vault_id": g.vaultID,
+			"paths":    conflict.Paths,
+		}).Warn("Git pull hit merge conflicts, merge aborted")
+
+		if g.onConflict != nil {
+			paths := make([]string, len(conflict.Paths))
+			for i, p := range conflict.Paths {
+				paths[i] = filepath.Join(g.repo.Dir(), filepath.FromSlash(p))
+			}
+			g.onConflict(&SyncConflict{
+				VaultID:   g.vaultID,
+				Paths:     paths,
+				Message:   conflict.Error(),
+				Timestamp: time.Now(),
+			})
+		}
+		return
+
+	case err != nil:
+		if ctx.Err() == nil {
+			logger.WithError(err).WithField("vault_id", g.vaultID).Warn("Git pull failed")
+		}
+		return
+	}
+
+	// A clean pull resolves any earlier conflict
+	if g.onConflict != nil {
+		g.onConflict(nil)
+	}
+
+	if from == to {
+		return
+	}
+
+	changes, err := g.repo.Changes(ctx, from, to)
+	if err != nil {
+		logger.WithError(err).WithField("vault_id", g.vaultID).Warn("Failed to diff pulled commits")
+		return
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"vault_id": g.vaultID,
+		"from":     from,
+		"to":       to,
+		"changes":  len(changes),
+	}).Info("Pulled remote changes")
+
+	for _, change := range changes {
+		event, ok := g.changeEvent(change)
+		if !ok {
+			continue
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// changeEvent converts a pulled change into a sync event.
+// Hidden and ignored paths are dropped, so a rename into or out of one becomes a delete or create.
+func (g *gitSync) changeEvent(change gitrepo.Change) (FileChangeEvent, bool) {
+	event := FileChangeEvent{
+		VaultID:   g.vaultID,
+		Path:      filepath.Join(g.repo.Dir(), filepath.FromSlash(change.Path)),
+		EventType: FileModified,
+		Timestamp: time.Now(),
+	}
+
+	switch change.Type {
+	case gitrepo.ChangeAdded:
+		event.EventType = FileCreated
+	case gitrepo.ChangeDeleted:
+		event.EventType = FileDeleted
+	case gitrepo.ChangeRenamed:
+		switch {
+		case g.skipRelPath(change.OldPath):
+			event.EventType = FileCreated
+		case g.skipRelPath(change.Path):
+			event.Path = filepath.Join(g.repo.Dir(), filepath.FromSlash(change.OldPath))
+			event.EventType = FileDeleted
+			return event, true
+		default:
+			event.OldPath = filepath.Join(g.repo.Dir(), filepath.FromSlash(change.OldPath))
+			event.EventType = FileMoved
+		}
+	}
+
+	if g.skipRelPath(change.Path) {
+		return FileChangeEvent{}, false
+	}
+	return event, true
+}
+
+// skipRelPath reports whether a pulled file is hidden or ignored
+func (g *gitSync) skipRelPath(p string) bool {
+	return isHiddenRelPath(p) || g.ignore.Ignored(p, false)
+}
+
+// isHiddenRelPath reports whether any segment of a slash path is hidden,
+// matching the watcher which never descends into hidden directories
+func isHiddenRelPath(p string) bool {
+	for _, part := range strings.Split(p, "/") {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop stops the working tree watcher
+func (g *gitSync) Stop() error {
+	return g.local.Stop()
+}
+
+// ReIndex walks the working tree and emits FileCreated events for all files
+func (g *gitSync) ReIndex(ctx context.Context, events chan<- FileChangeEvent) error {
+	if err := g.local.ReIndex(ctx, events); err != nil {
+		return fmt.Errorf("git re-index failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/gitrepo/gitrepotest"
+)
+
+func newTestGitSync(t *testing.T, remote *gitrepotest.Remote) *gitSync {
+	t.Helper()
+	g, err := newGitSync(context.Background(), "git-vault", &config.GitStorageConfig{
+		Path:         filepath.Join(t.TempDir(), "vault"),
+		URL:          remote.URL,
+		PullInterval: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("newGitSync() error = %v", err)
+	}
+	return g
+}
+
+func TestGitSync_PullEmitsChanges(t *testing.T) {
+	remote := gitrepotest.NewRemote(t, map[string]string{"keep.md": "a", "gone.md": "b"})
+	g := newTestGitSync(t, remote)
+	dir := g.repo.Dir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan FileChangeEvent, 100)
+	done := make(chan error, 1)
+	go func() { done <- g.Start(ctx, events) }()
+
+	remote.Push("Remote edits", map[string]string{
+		"keep.md":          "changed",
+		"gone.md":          "",
+		"notes/new.md":     "new",
+		".obsidian/x.json": "{}",
+	})
+
+	// Pulled changes and watcher events interleave, so collect until every expected event arrives
+	want := map[FileChangeEvent]bool{
+		{Path: filepath.Join(dir, "notes", "new.md"), EventType: FileCreated}: false,
+		{Path: filepath.Join(dir, "keep.md"), EventType: FileModified}:        false,
+		{Path: filepath.Join(dir, "gone.md"), EventType: FileDeleted}:         false,
+	}
+	timeout := time.After(3 * time.Second)
+	for remaining := len(want); remaining > 0; {
+		select {
+		case ev := <-events:
+			if filepath.Base(filepath.Dir(ev.Path)) == ".obsidian" {
+				t.Errorf("hidden path emitted: %s", ev.Path)
+			}
+			key := FileChangeEvent{Path: ev.Path, EventType: ev.EventType}
+			if seen, ok := want[key]; ok && !seen {
+				want[key] = true
+				remaining--
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for pulled events, got %v", want)
+		}
+	}
+
+	cancel()
+	g.Stop()
+	if err := <-done; err != nil {
+		t.Errorf("Start() returned error = %v", err)
+	}
+}
+
+func TestGitSync_ConflictReported(t *testing.T) {
+	remote := gitrepotest.NewRemote(t, map[string]string{"shared.md": "base\n"})
+	g := newTestGitSync(t, remote)
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(g.repo.Dir(), "shared.md"), []byte("local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.repo.Commit(ctx, "local edit"); err != nil {
+		t.Fatal(err)
+	}
+	remote.Push("remote edit", map[string]string{"shared.md": "remote\n"})
+
+	var reported []*SyncConflict
+	g.setConflictHandler(func(c *SyncConflict) { reported = append(reported, c) })
+
+	events := make(chan FileChangeEvent, 10)
+	g.pull(ctx, events)
+
+	if len(reported) != 1 || reported[0] == nil {
+		t.Fatalf("conflict handler calls = %v, want one conflict", reported)
+	}
+	want := filepath.Join(g.repo.Dir(), "shared.md")
+	if len(reported[0].Paths) != 1 || reported[0].Paths[0] != want {
+		t.Errorf("conflict paths = %v, want [%s]", reported[0].Paths, want)
+	}
+	if len(events) != 0 {
+		t.Errorf("conflicting pull emitted %d events", len(events))
+	}
+}
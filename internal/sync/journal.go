@@ -0,0 +1,402 @@
+package sync
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// journalCompactThreshold is how many obsolete records the journal holds
+// before it is rewritten with only the pending events
+const journalCompactThreshold = 1000
+
+// Journal record operations
+const (
+	journalOpAdd = "add" // Event recorded
+	journalOpAck = "ack" // Event processed, no longer needed
+	journalOpDLQ = "dlq" // Event failed and waits in the DLQ
+)
+
+// JournalEntry is a recorded event that has not been acknowledged yet
+type JournalEntry struct {
+	Event   FileChangeEvent // Event.Seq is the entry's sequence number
+	InDLQ   bool            // Failed and waiting in the reconciliation DLQ
+	Retries int             // Times the event failed and was sent to the DLQ
+}
+
+// JournalStats describes the journal file
+type JournalStats struct {
+	Path           string
+	SizeBytes      int64
+	Pending        int       // Events not acknowledged yet
+	Compactions    int64     // Rewrites since the journal was opened
+	LastCompaction time.Time // Zero until the first rewrite
+}
+
+// journalRecord is one line of the journal file
+type journalRecord struct {
+	Op      string    `json:"op"`
+	Seq     uint64    `json:"seq"`
+	VaultID string    `json:"vault_id,omitempty"`
+	Type    string    `json:"type,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	OldPath string    `json:"old_path,omitempty"`
+	Time    time.Time `json:"time,omitzero"`
+	DLQ     bool      `json:"dlq,omitempty"`
+	Retries int       `json:"retries,omitempty"`
+}
+
+// Journal is an append-only file of sync events that have not been fully
+// processed yet, so they survive a crash or restart. Each event is recorded
+// before it is queued and acknowledged once a worker has applied it.
+//
+// Appends are written but not synced; callers batch them and call Sync once
+// before acting on the events, so an event that was accepted is never lost,
+// even to a power failure. MarkDLQ syncs its record before returning. Acks
+// are not synced: losing one only replays an event that was already applied,
+// which the workers treat like any repeated change.
+// Safe for concurrent use.
+type Journal struct {
+	path string
+
+	mu             sync.Mutex
+	file           *os.File
+	size           int64
+	records        int // Lines in the file
+	nextSeq        uint64
+	pending        map[uint64]*JournalEntry
+	compactions    int64
+	lastCompaction time.Time
+}
+
+// OpenJournal opens or creates the journal at path and loads its pending events.
+// A record cut short by a crash is skipped.
+func OpenJournal(path string) (*Journal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	j := &Journal{
+		path:    path,
+		nextSeq: 1,
+		pending: make(map[uint64]*JournalEntry),
+	}
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+
+	// Start from a file holding only what is still pending
+	if err := j.compactLocked(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// load replays the journal file into memory
+func (j *Journal) load() error {
+	f, err := os.Open(j.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if rec.Seq >= j.nextSeq {
+			j.nextSeq = rec.Seq + 1
+		}
+
+		switch rec.Op {
+		case journalOpAdd:
+			eventType, ok := parseFileEventType(rec.Type)
+			if !ok {
+				continue
+			}
+			j.pending[rec.Seq] = &JournalEntry{
+				Event: FileChangeEvent{
+					VaultID:   rec.VaultID,
+					Path:      rec.Path,
+					OldPath:   rec.OldPath,
+					EventType: eventType,
+					Timestamp: rec.Time,
+					Seq:       rec.Seq,
+				},
+				InDLQ:   rec.DLQ,
+				Retries: rec.Retries,
+			}
+		case journalOpAck:
+			delete(j.pending, rec.Seq)
+		case journalOpDLQ:
+			if entry, ok := j.pending[rec.Seq]; ok {
+				entry.InDLQ = true
+				entry.Retries = rec.Retries
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+	return nil
+}
+
+// Append records an event and returns its sequence number.
+// The record is only durable once Sync returns.
+func (j *Journal) Append(event FileChangeEvent) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	seq := j.nextSeq
+	event.Seq = seq
+	if err := j.writeLocked(addRecord(&JournalEntry{Event: event}), false); err != nil {
+		return 0, err
+	}
+	j.nextSeq++
+	j.pending[seq] = &JournalEntry{Event: event}
+	return seq, nil
+}
+
+// Sync flushes the records written so far to disk
+func (j *Journal) Sync() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return fmt.Errorf("journal is closed")
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	return nil
+}
+
+// Ack marks an event as processed. Unknown sequence numbers are ignored.
+func (j *Journal) Ack(seq uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, ok := j.pending[seq]; !ok {
+		return nil
+	}
+	if err := j.writeLocked(journalRecord{Op: journalOpAck, Seq: seq}, false); err != nil {
+		return err
+	}
+	delete(j.pending, seq)
+	return j.maybeCompactLocked()
+}
+
+// MarkDLQ records that an event failed and went to the DLQ, returning how
+// many times it has failed so far
+func (j *Journal) MarkDLQ(seq uint64) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry, ok := j.pending[seq]
+	if !ok {
+		return 0, fmt.Errorf("journal entry %d not found", seq)
+	}
+	retries := entry.Retries + 1
+	if err := j.writeLocked(journalRecord{Op: journalOpDLQ, Seq: seq, Retries: retries}, true); err != nil {
+		return 0, err
+	}
+	entry.InDLQ = true
+	entry.Retries = retries
+	return retries, j.maybeCompactLocked()
+}
+
+// Pending returns the events not acknowledged yet, oldest first
+func (j *Journal) Pending() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := make([]JournalEntry, 0, len(j.pending))
+	for _, entry := range j.pending {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Event.Seq < entries[b].Event.Seq
+	})
+	return entries
+}
+
+// Stats returns the journal's size and compaction counters
+func (j *Journal) Stats() JournalStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return JournalStats{
+		Path:           j.path,
+		SizeBytes:      j.size,
+		Pending:        len(j.pending),
+		Compactions:    j.compactions,
+		LastCompaction: j.lastCompaction,
+	}
+}
+
+// Close flushes and closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Sync()
+	if closeErr := j.file.Close(); err == nil {
+		err = closeErr
+	}
+	j.file = nil
+	return err
+}
+
+// writeLocked appends one record to the file, syncing it to disk when durable is set
+func (j *Journal) writeLocked(rec journalRecord, durable bool) error {
+	if j.file == nil {
+		return fmt.Errorf("journal is closed")
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal record: %w", err)
+	}
+	line = append(line, '\n')
+	n, err := j.file.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	j.records++
+	if durable {
+		if err := j.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// maybeCompactLocked rewrites the file once most of it is obsolete
+func (j *Journal) maybeCompactLocked() error {
+	obsolete := j.records - len(j.pending)
+	if obsolete < journalCompactThreshold || obsolete < j.records/2 {
+		return nil
+	}
+	if err := j.compactLocked(); err != nil {
+		return err
+	}
+	j.compactions++
+	j.lastCompaction = time.Now()
+	return nil
+}
+
+// compactLocked replaces the file with one record per pending event
+func (j *Journal) compactLocked() error {
+	tmpPath := j.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create journal: %w", err)
+	}
+
+	w := bufio.NewWriter(tmp)
+	var size int64
+	entries := make([]*JournalEntry, 0, len(j.pending))
+	for _, entry := range j.pending {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].Event.Seq < entries[b].Event.Seq
+	})
+	for _, entry := range entries {
+		line, err := json.Marshal(addRecord(entry))
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to encode journal record: %w", err)
+		}
+		n, _ := w.Write(append(line, '\n'))
+		size += int64(n)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close journal: %w", err)
+	}
+
+	if j.file != nil {
+		_ = j.file.Close()
+		j.file = nil
+	}
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fmt.Errorf("failed to replace journal: %w", err)
+	}
+	// The rename itself is only durable once the directory is synced
+	if err := syncDir(filepath.Dir(j.path)); err != nil {
+		return fmt.Errorf("failed to sync journal directory: %w", err)
+	}
+	file, err := os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+
+	j.file = file
+	j.size = size
+	j.records = len(entries)
+	return nil
+}
+
+// syncDir flushes a directory's entries to disk. Windows cannot open a
+// directory for syncing, so it is skipped there.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// addRecord encodes a pending entry as a single record
+func addRecord(entry *JournalEntry) journalRecord {
+	return journalRecord{
+		Op:      journalOpAdd,
+		Seq:     entry.Event.Seq,
+		VaultID: entry.Event.VaultID,
+		Type:    entry.Event.EventType.String(),
+		Path:    entry.Event.Path,
+		OldPath: entry.Event.OldPath,
+		Time:    entry.Event.Timestamp,
+		DLQ:     entry.InDLQ,
+		Retries: entry.Retries,
+	}
+}
+
+// parseFileEventType is the inverse of FileEventType.String
+func parseFileEventType(s string) (FileEventType, bool) {
+	for _, t := range []FileEventType{FileCreated, FileModified, FileDeleted, FileMoved} {
+		if t.String() == s {
+			return t, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,116 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournal_ReplaysUnacknowledgedEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.journal")
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	var seqs []uint64
+	for _, event := range []FileChangeEvent{
+		{VaultID: "v", Path: "/vault/a.md", EventType: FileCreated, Timestamp: now},
+		{VaultID: "v", Path: "/vault/b.md", EventType: FileModified, Timestamp: now},
+		{VaultID: "v", Path: "/vault/d.md", OldPath: "/vault/c.md", EventType: FileMoved, Timestamp: now},
+	} {
+		seq, err := j.Append(event)
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		seqs = append(seqs, seq)
+	}
+	if err := j.Sync(); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if err := j.Ack(seqs[0]); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+	for want := 1; want <= 2; want++ {
+		if retries, err := j.MarkDLQ(seqs[1]); err != nil || retries != want {
+			t.Fatalf("MarkDLQ() = %d, %v, want %d", retries, err, want)
+		}
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// A write cut short by a crash must not lose the rest
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"op":"add","seq":9,"pa`)
+	f.Close()
+
+	j, err = OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal() reopen error = %v", err)
+	}
+	defer j.Close()
+
+	pending := j.Pending()
+	if len(pending) != 2 {
+		t.Fatalf("Pending() = %+v, want 2 entries", pending)
+	}
+	if got := pending[0]; got.Event.Seq != seqs[1] || got.Event.Path != "/vault/b.md" || got.Event.EventType != FileModified || !got.InDLQ || got.Retries != 2 {
+		t.Errorf("Pending()[0] = %+v, want b.md in the DLQ with 2 retries", got)
+	}
+	if got := pending[1]; got.Event.EventType != FileMoved || got.Event.OldPath != "/vault/c.md" || !got.Event.Timestamp.Equal(now) || got.InDLQ {
+		t.Errorf("Pending()[1] = %+v, want the c.md -> d.md move", got)
+	}
+
+	// Sequence numbers keep increasing across restarts
+	seq, err := j.Append(FileChangeEvent{Path: "/vault/e.md", EventType: FileCreated})
+	if err != nil || seq <= seqs[2] {
+		t.Errorf("Append() after reopen = %d, %v, want > %d", seq, err, seqs[2])
+	}
+}
+
+func TestJournal_Compacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.journal")
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	defer j.Close()
+
+	kept, err := j.Append(FileChangeEvent{Path: "/vault/kept.md", EventType: FileCreated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < journalCompactThreshold; i++ {
+		seq, err := j.Append(FileChangeEvent{Path: "/vault/note.md", EventType: FileModified})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := j.Ack(seq); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := j.Stats()
+	if stats.Compactions == 0 || stats.LastCompaction.IsZero() {
+		t.Fatalf("Stats() = %+v, want a compaction", stats)
+	}
+	if stats.Pending != 1 {
+		t.Errorf("Stats().Pending = %d, want 1", stats.Pending)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != stats.SizeBytes {
+		t.Errorf("Stats().SizeBytes = %d, file is %d bytes", stats.SizeBytes, info.Size())
+	}
+	if pending := j.Pending(); len(pending) != 1 || pending[0].Event.Seq != kept {
+		t.Errorf("Pending() = %+v, want only the unacknowledged event", pending)
+	}
+}
@@ -9,14 +9,46 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/susamn/obsidian-web/internal/ignore"
 	"github.com/susamn/obsidian-web/internal/logger"
 )
 
+// renamePairWindow is how long a renamed-away path waits for the create
+// that completes the move before it is reported as deleted
+const renamePairWindow = 100 * time.Millisecond
+
+// ignoreReloadDelay lets a burst of writes to the ignore file settle before rules are re-applied
+const ignoreReloadDelay = 500 * time.Millisecond
+
+// pendingRename is a path that disappeared through a rename and may
+// reappear elsewhere in the vault
+type pendingRename struct {
+	path     string
+	deadline time.Time
+}
+
+// heldMove is a file renamed within its folder. Editors that keep backups
+// save by renaming the note to a backup name and writing it again, so the
+// move is only reported once no create at the old path follows in time.
+type heldMove struct {
+	oldPath  string
+	newPath  string
+	deadline time.Time
+}
+
 // localSync monitors local filesystem for changes using fsnotify
 type localSync struct {
 	vaultID  string
 	rootPath string
 	watcher  *fsnotify.Watcher
+
+	// Rename pairing state, only touched by watchLoop
+	pending []pendingRename
+	held    []heldMove
+	moved   map[string]time.Time // Old paths of recent moves, to drop duplicate renames
+
+	ignore         *ignore.Rules
+	onIgnoreChange func()
 }
 
 // newLocalSync creates a new local filesystem sync service
@@ -36,9 +68,16 @@ func newLocalSync(vaultID, rootPath string) (*localSync, error) {
 		vaultID:  vaultID,
 		rootPath: rootPath,
 		watcher:  watcher,
+		moved:    make(map[string]time.Time),
 	}, nil
 }
 
+// setIgnoreRules sets the rules for paths that are neither watched nor reported
+func (l *localSync) setIgnoreRules(rules *ignore.Rules, onFileChange func()) {
+	l.ignore = rules
+	l.onIgnoreChange = onFileChange
+}
+
 // Start begins monitoring the filesystem in a non-blocking manner
 func (l *localSync) Start(ctx context.Context, events chan<- FileChangeEvent) error {
 	logger.WithFields(map[string]interface{}{
@@ -67,32 +106,84 @@ func (l *localSync) Stop() error {
 }
 
 // ReIndex walks the entire vault and emits FileCreated events for all files
-func (l *localSync) ReIndex(events chan<- FileChangeEvent) error {
+func (l *localSync) ReIndex(ctx context.Context, events chan<- FileChangeEvent) error {
 	logger.WithField("vault_id", l.vaultID).Info("Starting local re-index")
 
-	// Run in a separate goroutine to avoid blocking
-	go func() {
-		// Context for re-indexing (can be cancelled if needed, but here we use Background)
-		// In a real implementation, we might want to pass a context from SyncService
-		ctx := context.Background()
+	// Reuse emitEventsForDirectory to walk the root and emit events
+	l.emitEventsForDirectory(ctx, l.rootPath, events, FileCreated)
 
-		// Reuse emitEventsForDirectory to walk the root and emit events
-		l.emitEventsForDirectory(ctx, l.rootPath, events, FileCreated)
+	logger.WithField("vault_id", l.vaultID).Info("Local re-index walk completed")
+	return nil
+}
 
-		logger.WithField("vault_id", l.vaultID).Info("Local re-index walk completed")
-	}()
+// probe checks that the watcher reports changes by creating a hidden file in
+// the vault root. Must be called before Start. Other events that arrive while
+// probing are dropped; nothing is being processed yet at that point.
+func (l *localSync) probe(timeout time.Duration) (bool, error) {
+	if err := l.watcher.Add(l.rootPath); err != nil {
+		return false, fmt.Errorf("failed to watch vault root: %w", err)
+	}
 
-	return nil
+	f, err := os.CreateTemp(l.rootPath, ".obsidian-web-probe-*")
+	if err != nil {
+		return false, fmt.Errorf("failed to create probe file: %w", err)
+	}
+	name := f.Name()
+	f.Close()
+	defer os.Remove(name)
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		select {
+		case event, ok := <-l.watcher.Events:
+			if !ok {
+				return false, nil
+			}
+			if event.Name == name {
+				return true, nil
+			}
+		case <-l.watcher.Errors:
+		case <-deadline.C:
+			return false, nil
+		}
+	}
 }
 
 // watchLoop processes filesystem events (blocking)
 func (l *localSync) watchLoop(ctx context.Context, events chan<- FileChangeEvent) {
+	// Fires when the oldest pending rename runs out of time to be paired,
+	// or the oldest held move can be reported
+	pairTimer := time.NewTimer(renamePairWindow)
+	pairTimer.Stop()
+	defer pairTimer.Stop()
+	armPairTimer := func() {
+		if deadline, ok := l.nextPairDeadline(); ok {
+			pairTimer.Reset(time.Until(deadline))
+		}
+	}
+
+	// Fires once edits to the ignore file have settled
+	ignoreTimer := time.NewTimer(ignoreReloadDelay)
+	ignoreTimer.Stop()
+	defer ignoreTimer.Stop()
+	ignoreFile := filepath.Join(l.rootPath, ignore.FileName)
+
 	for {
 		select {
 		case <-ctx.Done():
 			// Context cancelled, stop watching
 			return
 
+		case now := <-pairTimer.C:
+			if !l.expirePendingRenames(ctx, events, now) {
+				return
+			}
+			armPairTimer()
+
+		case <-ignoreTimer.C:
+			l.ignoreFileChanged()
+
 		case event, ok := <-l.watcher.Events:
 			if !ok {
 				// Watcher closed
@@ -103,6 +194,89 @@ func (l *localSync) watchLoop(ctx context.Context, events chan<- FileChangeEvent
 			info, statErr := os.Stat(event.Name)
 			isDir := statErr == nil && info.IsDir()
 
+			// The ignore file is hidden, but changing it re-applies the rules
+			if event.Name == ignoreFile {
+				if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) != 0 {
+					ignoreTimer.Reset(ignoreReloadDelay)
+				}
+				continue
+			}
+
+			// Hidden directories (like .git, .obsidian) and ignored ones are never watched or walked
+			if isDir && (l.isHiddenDir(event.Name) || l.isIgnored(event.Name, true)) {
+				continue
+			}
+
+			// A create where a file was just renamed away within its folder is
+			// a backup save: the note was modified and the backup is new
+			if i := l.heldMoveFrom(event.Name); i >= 0 && event.Op&fsnotify.Create != 0 && !isDir {
+				h := l.held[i]
+				l.held = append(l.held[:i], l.held[i+1:]...)
+				delete(l.moved, h.oldPath)
+				for _, ev := range []FileChangeEvent{
+					{VaultID: l.vaultID, Path: h.oldPath, EventType: FileModified, Timestamp: time.Now()},
+					{VaultID: l.vaultID, Path: h.newPath, EventType: FileCreated, Timestamp: time.Now()},
+				} {
+					if !l.send(ctx, events, ev) {
+						return
+					}
+				}
+				continue
+			}
+
+			// Anything else touching a held move's paths comes after the move
+			if !l.releaseHeldMoves(ctx, events, event.Name) {
+				return
+			}
+
+			// A path renamed away is held back: a create within the pairing
+			// window turns it into a move, otherwise it becomes a delete.
+			// Whether it was a directory is unknown now, so either kind of rule applies.
+			// The path may already exist again when a backup save writes it back.
+			if event.Op&fsnotify.Rename != 0 {
+				skip := l.isHiddenFile(event.Name) || l.isIgnored(event.Name, false) || l.isIgnored(event.Name, true)
+				if !skip && l.addPendingRename(event.Name) {
+					armPairTimer()
+				}
+				continue
+			}
+
+			if event.Op&fsnotify.Create != 0 && !l.isHiddenFile(event.Name) && !l.isIgnored(event.Name, isDir) {
+				oldPath, ok := l.takePendingRename(event.Name)
+				if ok && oldPath == event.Name && !isDir {
+					// Renamed away and written again, as backup-saving editors do
+					if !l.send(ctx, events, FileChangeEvent{
+						VaultID:   l.vaultID,
+						Path:      event.Name,
+						EventType: FileModified,
+						Timestamp: time.Now(),
+					}) {
+						return
+					}
+					continue
+				}
+				if ok && !isDir && filepath.Dir(oldPath) == filepath.Dir(event.Name) {
+					l.held = append(l.held, heldMove{
+						oldPath:  oldPath,
+						newPath:  event.Name,
+						deadline: time.Now().Add(renamePairWindow),
+					})
+					armPairTimer()
+					continue
+				}
+				if ok && oldPath != event.Name {
+					if isDir {
+						// Watches below the old path still report old names
+						l.removeWatches(oldPath)
+						_ = l.addRecursive(event.Name)
+					}
+					if !l.sendMove(ctx, events, oldPath, event.Name) {
+						return
+					}
+					continue
+				}
+			}
+
 			// Handle directory creation (need to watch new directories and emit events for all contents)
 			if event.Op&fsnotify.Create != 0 && isDir {
 				// New directory created, add it to watcher recursively
@@ -113,8 +287,8 @@ func (l *localSync) watchLoop(ctx context.Context, events chan<- FileChangeEvent
 				continue
 			}
 
-			// Skip hidden files (but allow all other file types)
-			if l.isHiddenFile(event.Name) {
+			// Skip hidden and ignored files (but allow all other file types)
+			if l.isHiddenFile(event.Name) || l.isIgnored(event.Name, false) {
 				continue
 			}
 
@@ -125,15 +299,8 @@ func (l *localSync) watchLoop(ctx context.Context, events chan<- FileChangeEvent
 
 			// Convert fsnotify event to FileChangeEvent
 			fileEvent := l.convertEvent(event)
-			if fileEvent != nil {
-				// Send event BLOCKING - let backpressure propagate to fsnotify
-				// This prevents event loss during bulk operations
-				select {
-				case events <- *fileEvent:
-					// Event sent successfully
-				case <-ctx.Done():
-					return
-				}
+			if fileEvent != nil && !l.send(ctx, events, *fileEvent) {
+				return
 			}
 
 		case err, ok := <-l.watcher.Errors:
@@ -147,6 +314,160 @@ func (l *localSync) watchLoop(ctx context.Context, events chan<- FileChangeEvent
 	}
 }
 
+// send delivers an event BLOCKING - backpressure propagates to fsnotify so
+// no events are lost during bulk operations. Returns false once ctx is done.
+func (l *localSync) send(ctx context.Context, events chan<- FileChangeEvent, event FileChangeEvent) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// sendMove delivers a FileMoved event. Returns false once ctx is done.
+func (l *localSync) sendMove(ctx context.Context, events chan<- FileChangeEvent, oldPath, newPath string) bool {
+	return l.send(ctx, events, FileChangeEvent{
+		VaultID:   l.vaultID,
+		Path:      newPath,
+		OldPath:   oldPath,
+		EventType: FileMoved,
+		Timestamp: time.Now(),
+	})
+}
+
+// addPendingRename queues a renamed-away path for pairing.
+// Returns false for duplicates: a moved directory also reports a rename of itself.
+func (l *localSync) addPendingRename(path string) bool {
+	if deadline, ok := l.moved[path]; ok {
+		delete(l.moved, path)
+		if time.Now().Before(deadline) {
+			return false
+		}
+	}
+	for _, p := range l.pending {
+		if p.path == path {
+			return false
+		}
+	}
+
+	l.pending = append(l.pending, pendingRename{
+		path:     path,
+		deadline: time.Now().Add(renamePairWindow),
+	})
+	return true
+}
+
+// takePendingRename finds the rename that a create at newPath completes.
+// A move keeps the name and a rename keeps the directory; creates matching
+// neither are left alone rather than guessed at.
+func (l *localSync) takePendingRename(newPath string) (string, bool) {
+	match := -1
+	for i, p := range l.pending {
+		if filepath.Base(p.path) == filepath.Base(newPath) {
+			match = i
+			break
+		}
+		if match < 0 && filepath.Dir(p.path) == filepath.Dir(newPath) {
+			match = i
+		}
+	}
+	if match < 0 {
+		return "", false
+	}
+
+	oldPath := l.pending[match].path
+	l.pending = append(l.pending[:match], l.pending[match+1:]...)
+	l.moved[oldPath] = time.Now().Add(renamePairWindow)
+	return oldPath, true
+}
+
+// heldMoveFrom returns the index of the held move away from path, or -1
+func (l *localSync) heldMoveFrom(path string) int {
+	for i, h := range l.held {
+		if h.oldPath == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// releaseHeldMoves reports the held moves from or to path, so events that
+// follow on either path are applied after the move. Returns false once ctx is done.
+func (l *localSync) releaseHeldMoves(ctx context.Context, events chan<- FileChangeEvent, path string) bool {
+	kept := l.held[:0]
+	for _, h := range l.held {
+		if h.oldPath != path && h.newPath != path {
+			kept = append(kept, h)
+			continue
+		}
+		if !l.sendMove(ctx, events, h.oldPath, h.newPath) {
+			return false
+		}
+	}
+	l.held = kept
+	return true
+}
+
+// nextPairDeadline returns when the oldest pending rename or held move is due
+func (l *localSync) nextPairDeadline() (time.Time, bool) {
+	var next time.Time
+	if len(l.pending) > 0 {
+		next = l.pending[0].deadline
+	}
+	if len(l.held) > 0 && (next.IsZero() || l.held[0].deadline.Before(next)) {
+		next = l.held[0].deadline
+	}
+	return next, !next.IsZero()
+}
+
+// expirePendingRenames reports held moves that were not followed by a backup
+// save and emits FileDeleted for renames that were never paired (the path
+// left the vault). Returns false once ctx is done.
+func (l *localSync) expirePendingRenames(ctx context.Context, events chan<- FileChangeEvent, now time.Time) bool {
+	for path, deadline := range l.moved {
+		if now.After(deadline) {
+			delete(l.moved, path)
+		}
+	}
+
+	for len(l.held) > 0 && !now.Before(l.held[0].deadline) {
+		h := l.held[0]
+		l.held = l.held[1:]
+		if !l.sendMove(ctx, events, h.oldPath, h.newPath) {
+			return false
+		}
+	}
+
+	for len(l.pending) > 0 && !now.Before(l.pending[0].deadline) {
+		p := l.pending[0]
+		l.pending = l.pending[1:]
+
+		// A directory moved out of the vault leaves watches on its subdirectories
+		l.removeWatches(p.path)
+
+		if !l.send(ctx, events, FileChangeEvent{
+			VaultID:   l.vaultID,
+			Path:      p.path,
+			EventType: FileDeleted,
+			Timestamp: now,
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// removeWatches drops the watches on a directory that no longer exists and on everything below it
+func (l *localSync) removeWatches(dir string) {
+	prefix := dir + string(filepath.Separator)
+	for _, watched := range l.watcher.WatchList() {
+		if watched == dir || strings.HasPrefix(watched, prefix) {
+			_ = l.watcher.Remove(watched)
+		}
+	}
+}
+
 // addRecursive adds a directory and all subdirectories to the watcher
 func (l *localSync) addRecursive(path string) error {
 	dirCount := 0
@@ -157,8 +478,8 @@ func (l *localSync) addRecursive(path string) error {
 
 		// Only watch directories
 		if info.IsDir() {
-			// Skip hidden directories (like .git, .obsidian)
-			if l.isHiddenDir(walkPath) && walkPath != l.rootPath {
+			// Skip hidden directories (like .git, .obsidian) and ignored ones
+			if walkPath != l.rootPath && (l.isHiddenDir(walkPath) || l.isIgnored(walkPath, true)) {
 				return filepath.SkipDir
 			}
 
@@ -189,8 +510,8 @@ func (l *localSync) emitEventsForDirectory(ctx context.Context, dirPath string,
 			return err
 		}
 
-		// Skip hidden files and directories
-		if l.isHiddenDir(walkPath) && walkPath != dirPath {
+		// Skip hidden and ignored files and directories
+		if walkPath != dirPath && (l.isHiddenDir(walkPath) || l.isIgnored(walkPath, info.IsDir())) {
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
@@ -230,6 +551,37 @@ func (l *localSync) emitEventsForDirectory(ctx context.Context, dirPath string,
 	}
 }
 
+// isIgnored checks an absolute path under the vault root against the ignore rules
+func (l *localSync) isIgnored(path string, isDir bool) bool {
+	if l.ignore == nil {
+		return false
+	}
+	relPath, err := filepath.Rel(l.rootPath, path)
+	if err != nil {
+		return false
+	}
+	return l.ignore.Ignored(relPath, isDir)
+}
+
+// ignoreFileChanged lets the owner reload the rules, then drops watches on
+// directories that are now ignored and adds any that no longer are
+func (l *localSync) ignoreFileChanged() {
+	logger.WithField("vault_id", l.vaultID).Info("Ignore file changed, re-applying rules")
+
+	if l.onIgnoreChange != nil {
+		l.onIgnoreChange()
+	}
+
+	for _, watched := range l.watcher.WatchList() {
+		if watched != l.rootPath && l.isIgnored(watched, true) {
+			l.removeWatches(watched)
+		}
+	}
+	if err := l.addRecursive(l.rootPath); err != nil {
+		logger.WithError(err).WithField("vault_id", l.vaultID).Warn("Failed to re-add watches after ignore change")
+	}
+}
+
 // isMarkdownFile checks if the file is a markdown file (kept for potential future use)
 func (l *localSync) isMarkdownFile(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
@@ -260,7 +612,8 @@ func (l *localSync) convertEvent(event fsnotify.Event) *FileChangeEvent {
 	case event.Op&fsnotify.Remove != 0:
 		eventType = FileDeleted
 	case event.Op&fsnotify.Rename != 0:
-		// Treat rename as delete (the old path is gone)
+		// Renames of paths that are gone are paired in watchLoop; reaching
+		// here means the path was recreated and a create event will follow
 		eventType = FileDeleted
 	default:
 		// Ignore other events (chmod, etc.)
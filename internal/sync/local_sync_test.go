@@ -0,0 +1,252 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/ignore"
+	"github.com/susamn/obsidian-web/internal/storage"
+)
+
+// startLocalSync runs a watcher on dir until the test ends
+func startLocalSync(t *testing.T, dir string, setup ...func(*localSync)) <-chan FileChangeEvent {
+	t.Helper()
+	l, err := newLocalSync("test-vault", dir)
+	if err != nil {
+		t.Fatalf("newLocalSync() error = %v", err)
+	}
+	for _, fn := range setup {
+		fn(l)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan FileChangeEvent, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = l.Start(ctx, events)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		l.Stop()
+		<-done
+	})
+
+	// Give the watcher time to initialize
+	time.Sleep(100 * time.Millisecond)
+	return events
+}
+
+// nextEvent returns the next event of the given type, skipping others
+func nextEvent(t *testing.T, events <-chan FileChangeEvent, eventType FileEventType) FileChangeEvent {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.EventType == eventType {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s event", eventType)
+			return FileChangeEvent{}
+		}
+	}
+}
+
+func TestLocalSync_RenameEmitsMove(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "archive"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	oldPath := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(oldPath, []byte("# Note"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	events := startLocalSync(t, dir)
+
+	newPath := filepath.Join(dir, "archive", "note.md")
+	if err := os.Rename(oldPath, newPath); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := nextEvent(t, events, FileMoved)
+	if ev.OldPath != oldPath || ev.Path != newPath {
+		t.Errorf("FileMoved = %s -> %s, want %s -> %s", ev.OldPath, ev.Path, oldPath, newPath)
+	}
+
+	// Nothing should be reported as deleted once the pairing window passes
+	time.Sleep(2 * renamePairWindow)
+	for len(events) > 0 {
+		if ev := <-events; ev.EventType == FileDeleted {
+			t.Errorf("unexpected delete for %s", ev.Path)
+		}
+	}
+}
+
+func TestLocalSync_RenameInPlace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(path, []byte("# Note"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	events := startLocalSync(t, dir)
+
+	// A rename within the folder is reported once the backup-save window passes
+	renamed := filepath.Join(dir, "renamed.md")
+	if err := os.Rename(path, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextEvent(t, events, FileMoved); ev.OldPath != path || ev.Path != renamed {
+		t.Errorf("FileMoved = %s -> %s, want %s -> %s", ev.OldPath, ev.Path, path, renamed)
+	}
+
+	time.Sleep(2 * renamePairWindow)
+	for len(events) > 0 {
+		if ev := <-events; ev.EventType == FileDeleted || ev.EventType == FileCreated {
+			t.Errorf("unexpected %s for %s", ev.EventType, ev.Path)
+		}
+	}
+}
+
+func TestLocalSync_BackupSaveKeepsNote(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(path, []byte("# Note"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	events := startLocalSync(t, dir)
+
+	// Editors that keep backups move the note aside and write it again
+	backup := path + "~"
+	if err := os.Rename(path, backup); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("# Note v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The note keeps its entry and the backup is a new file
+	if ev := nextEvent(t, events, FileModified); ev.Path != path {
+		t.Errorf("FileModified path = %s, want %s", ev.Path, path)
+	}
+	if ev := nextEvent(t, events, FileCreated); ev.Path != backup {
+		t.Errorf("FileCreated path = %s, want %s", ev.Path, backup)
+	}
+	time.Sleep(2 * renamePairWindow)
+	for len(events) > 0 {
+		ev := <-events
+		if ev.EventType == FileMoved || ev.EventType == FileDeleted {
+			t.Errorf("unexpected %s for %s", ev.EventType, ev.Path)
+		}
+	}
+}
+
+func TestLocalSync_DirectoryMoveRewatches(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "proj", "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	events := startLocalSync(t, dir)
+
+	newDir := filepath.Join(dir, "work")
+	if err := os.Rename(filepath.Join(dir, "proj"), newDir); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := nextEvent(t, events, FileMoved)
+	if ev.OldPath != filepath.Join(dir, "proj") || ev.Path != newDir {
+		t.Errorf("FileMoved = %s -> %s", ev.OldPath, ev.Path)
+	}
+
+	// Files in moved subdirectories must be reported under the new name
+	file := filepath.Join(newDir, "sub", "later.md")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextEvent(t, events, FileCreated); ev.Path != file {
+		t.Errorf("FileCreated path = %s, want %s", ev.Path, file)
+	}
+
+	// The directory's own rename notification must not turn into a delete
+	time.Sleep(2 * renamePairWindow)
+	for len(events) > 0 {
+		if ev := <-events; ev.EventType == FileDeleted {
+			t.Errorf("unexpected delete for %s", ev.Path)
+		}
+	}
+}
+
+func TestLocalSync_RenameOutOfVaultIsDelete(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	path := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(path, []byte("# Note"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	events := startLocalSync(t, dir)
+
+	if err := os.Rename(path, filepath.Join(outside, "note.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	if ev := nextEvent(t, events, FileDeleted); ev.Path != path {
+		t.Errorf("FileDeleted path = %s, want %s", ev.Path, path)
+	}
+}
+
+func TestLocalSync_IgnoreRules(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "drafts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ignoreFile := filepath.Join(dir, ignore.FileName)
+	if err := os.WriteFile(ignoreFile, []byte("drafts/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := ignore.NewRules([]string{"*.tmp"})
+	store := storage.NewLocalStorage(dir)
+	if err := rules.Reload(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := make(chan struct{}, 1)
+	events := startLocalSync(t, dir, func(l *localSync) {
+		l.setIgnoreRules(rules, func() {
+			_ = rules.Reload(context.Background(), store)
+			reloaded <- struct{}{}
+		})
+	})
+
+	for _, name := range []string{filepath.Join("drafts", "idea.md"), "scratch.tmp", "note.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ev := nextEvent(t, events, FileCreated); ev.Path != filepath.Join(dir, "note.md") {
+		t.Errorf("FileCreated path = %s, want only note.md to be reported", ev.Path)
+	}
+
+	// Dropping the directory rule takes effect without a restart
+	if err := os.WriteFile(ignoreFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloaded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ignore file change was not reported")
+	}
+	// Watches are re-evaluated right after the callback returns
+	time.Sleep(50 * time.Millisecond)
+
+	later := filepath.Join(dir, "drafts", "later.md")
+	if err := os.WriteFile(later, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextEvent(t, events, FileCreated); ev.Path != later {
+		t.Errorf("FileCreated path = %s, want %s", ev.Path, later)
+	}
+}
@@ -2,54 +2,155 @@ package sync
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
 
 	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/ignore"
 	"github.com/susamn/obsidian-web/internal/logger"
+	"github.com/susamn/obsidian-web/internal/objectstore"
 )
 
-// minioSync monitors MinIO bucket for changes
-// TODO: Implement MinIO event notifications or polling
+// Reconnect backoff bounds for the MinIO notification stream
+const (
+	minioMinBackoff = 1 * time.Second
+	minioMaxBackoff = 1 * time.Minute
+)
+
+// minioSync monitors a MinIO bucket using ListenBucketNotification
+// When the notification stream drops, a listing diff catches up on anything
+// missed while disconnected before the stream is re-established
 type minioSync struct {
-	vaultID string
-	config  *config.MinIOStorageConfig
+	vaultID    string
+	config     *config.MinIOStorageConfig
+	client     *objectstore.Client
+	bucket     *bucketSnapshot
+	minBackoff time.Duration
+	maxBackoff time.Duration
 }
 
 // newMinIOSync creates a new MinIO sync service
-func newMinIOSync(vaultID string, config *config.MinIOStorageConfig) *minioSync {
-	return &minioSync{
-		vaultID: vaultID,
-		config:  config,
+func newMinIOSync(vaultID string, cfg *config.MinIOStorageConfig) (*minioSync, error) {
+	client, err := objectstore.NewMinIOClient(cfg)
+	if err != nil {
+		return nil, err
 	}
+
+	return &minioSync{
+		vaultID:    vaultID,
+		config:     cfg,
+		client:     client,
+		bucket:     newBucketSnapshot(vaultID, "", client),
+		minBackoff: minioMinBackoff,
+		maxBackoff: minioMaxBackoff,
+	}, nil
+}
+
+// setIgnoreRules sets the rules for objects that are not tracked
+func (m *minioSync) setIgnoreRules(rules *ignore.Rules, onFileChange func()) {
+	m.bucket.setIgnoreRules(rules, onFileChange)
 }
 
-// Start begins monitoring the MinIO bucket (placeholder implementation)
+// Start listens for bucket notifications until the context is cancelled (blocking)
 func (m *minioSync) Start(ctx context.Context, events chan<- FileChangeEvent) error {
 	logger.WithFields(map[string]interface{}{
 		"vault_id": m.vaultID,
 		"bucket":   m.config.Bucket,
 		"endpoint": m.config.Endpoint,
-	}).Warn("MinIO sync not yet implemented - placeholder running")
+	}).Info("Starting MinIO sync")
 
-	// TODO: Implement MinIO monitoring
-	// Options:
-	// 1. MinIO Bucket Notifications (webhook, AMQP, NATS, etc.)
-	// 2. Polling with ListObjects
-	// 3. MinIO Event API
+	// Baseline listing so later diffs know what already existed
+	if err := m.bucket.refresh(ctx, events, false); err != nil {
+		logger.WithError(err).WithField("vault_id", m.vaultID).Warn("Initial MinIO listing failed")
+	}
 
-	// For now, just wait for context cancellation
-	<-ctx.Done()
-	logger.WithField("vault_id", m.vaultID).Info("MinIO sync stopped")
-	return nil
+	backoff := m.minBackoff
+	reconnecting := false
+
+	for {
+		if reconnecting {
+			// Fall back to a listing diff for anything missed while disconnected
+			if err := m.bucket.refresh(ctx, events, true); err != nil {
+				logger.WithError(err).WithField("vault_id", m.vaultID).Warn("MinIO listing diff failed")
+			}
+		}
+		reconnecting = true
+
+		connected, err := m.listen(ctx, events)
+		if ctx.Err() != nil {
+			logger.WithField("vault_id", m.vaultID).Info("MinIO sync stopped")
+			return nil
+		}
+		if connected {
+			backoff = m.minBackoff
+		}
+
+		logger.WithError(err).WithFields(map[string]interface{}{
+			"vault_id": m.vaultID,
+			"backoff":  backoff,
+		}).Warn("MinIO notification stream dropped, reconnecting")
+
+		select {
+		case <-ctx.Done():
+			logger.WithField("vault_id", m.vaultID).Info("MinIO sync stopped")
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > m.maxBackoff {
+			backoff = m.maxBackoff
+		}
+	}
+}
+
+// listen consumes one notification stream until it ends
+// Returns whether the stream was established, and the error that ended it
+func (m *minioSync) listen(ctx context.Context, events chan<- FileChangeEvent) (bool, error) {
+	stream, err := m.client.ListenBucketNotification(ctx, "",
+		[]string{objectstore.EventObjectCreatedAll, objectstore.EventObjectRemovedAll})
+	if err != nil {
+		return false, err
+	}
+
+	// Close the stream when ctx is cancelled so Next unblocks
+	stop := context.AfterFunc(ctx, func() { stream.Close() })
+	defer func() {
+		stop()
+		stream.Close()
+	}()
+
+	logger.WithField("vault_id", m.vaultID).Debug("MinIO notification stream connected")
+
+	for {
+		notification, err := stream.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("stream closed by server")
+			}
+			return true, err
+		}
+
+		if !m.bucket.applyNotification(ctx, events, notification) {
+			return true, ctx.Err()
+		}
+	}
 }
 
 // Stop stops the MinIO sync service
+// The notification stream is closed when the context passed to Start is cancelled
 func (m *minioSync) Stop() error {
-	// TODO: Cleanup MinIO resources if needed
 	return nil
 }
 
-// ReIndex triggers a full re-index (placeholder)
-func (m *minioSync) ReIndex(events chan<- FileChangeEvent) error {
-	logger.WithField("vault_id", m.vaultID).Warn("MinIO ReIndex not implemented")
+// ReIndex lists the whole bucket and emits FileCreated events for every object
+func (m *minioSync) ReIndex(ctx context.Context, events chan<- FileChangeEvent) error {
+	logger.WithField("vault_id", m.vaultID).Info("Starting MinIO re-index")
+
+	if err := m.bucket.reIndex(ctx, events); err != nil {
+		return fmt.Errorf("minio re-index failed: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,118 @@
+package sync
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/objectstore/objectstoretest"
+)
+
+func newTestMinIOSync(t *testing.T, srv *objectstoretest.Server) *minioSync {
+	t.Helper()
+	m, err := newMinIOSync("minio-vault", &config.MinIOStorageConfig{
+		Endpoint: strings.TrimPrefix(srv.URL, "http://"),
+		Bucket:   "vault",
+	})
+	if err != nil {
+		t.Fatalf("newMinIOSync() error = %v", err)
+	}
+	m.minBackoff = 20 * time.Millisecond
+	m.maxBackoff = 100 * time.Millisecond
+	return m
+}
+
+func waitForListeners(t *testing.T, srv *objectstoretest.Server, want int) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for srv.ListenerCount() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d notification listeners, got %d", want, srv.ListenerCount())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestMinIOSync_NotificationsAndFallback(t *testing.T) {
+	srv := objectstoretest.NewServer("vault")
+	defer srv.Close()
+
+	srv.PutObject("existing.md", []byte("old"))
+
+	m := newTestMinIOSync(t, srv)
+	root := RemoteVaultRoot("minio-vault")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan FileChangeEvent, 100)
+
+	done := make(chan error, 1)
+	go func() { done <- m.Start(ctx, events) }()
+
+	waitForListeners(t, srv, 1)
+
+	srv.PutObject("notes/new note.md", []byte("hello"))
+	waitForEvent(t, events, filepath.Join(root, "notes", "new note.md"), FileCreated)
+
+	srv.PutObject("existing.md", []byte("changed"))
+	waitForEvent(t, events, filepath.Join(root, "existing.md"), FileModified)
+
+	srv.DeleteObject("notes/new note.md")
+	waitForEvent(t, events, filepath.Join(root, "notes", "new note.md"), FileDeleted)
+
+	// Drop the stream and change the bucket while disconnected
+	srv.SetNotifications(false)
+	srv.PutObject("missed.md", []byte("while offline"))
+	waitForEvent(t, events, filepath.Join(root, "missed.md"), FileCreated)
+
+	// Stream comes back after backoff
+	srv.SetNotifications(true)
+	waitForListeners(t, srv, 1)
+
+	srv.PutObject("after.md", []byte("back online"))
+	waitForEvent(t, events, filepath.Join(root, "after.md"), FileCreated)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start() did not return after cancel")
+	}
+}
+
+func TestMinIOSync_ReIndex(t *testing.T) {
+	srv := objectstoretest.NewServer("vault")
+	defer srv.Close()
+
+	srv.PutObject("a.md", []byte("a"))
+	srv.PutObject("img/b.png", []byte("b"))
+
+	m := newTestMinIOSync(t, srv)
+	events := make(chan FileChangeEvent, 10)
+	if err := m.ReIndex(context.Background(), events); err != nil {
+		t.Fatalf("ReIndex() error = %v", err)
+	}
+
+	root := RemoteVaultRoot("minio-vault")
+	seen := make(map[string]bool)
+	timeout := time.After(2 * time.Second)
+	for len(seen) < 2 {
+		select {
+		case ev := <-events:
+			if ev.EventType == FileCreated {
+				seen[ev.Path] = true
+			}
+		case <-timeout:
+			t.Fatalf("timed out, got %v", seen)
+		}
+	}
+	if !seen[filepath.Join(root, "a.md")] || !seen[filepath.Join(root, "img", "b.png")] {
+		t.Errorf("unexpected re-index events %v", seen)
+	}
+}
@@ -0,0 +1,373 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/ignore"
+	"github.com/susamn/obsidian-web/internal/logger"
+)
+
+// defaultPollInterval is used when the config does not set poll_interval
+const defaultPollInterval = 10 * time.Second
+
+// fileState is the snapshot entry kept for each path in poll mode
+type fileState struct {
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+// pollSync detects changes by scanning the vault directory on an interval.
+// Used where filesystem notifications never fire (NFS, SMB, sshfs and other FUSE mounts).
+type pollSync struct {
+	vaultID  string
+	rootPath string
+	interval time.Duration
+
+	ignore         *ignore.Rules
+	onIgnoreChange func()
+
+	// Only touched by Start
+	snapshot    map[string]fileState // Vault-relative slash path -> state
+	ignoreState fileState            // The vault's ignore file, zero when absent
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// newPollSync creates a scanner for a local vault directory
+func newPollSync(vaultID, rootPath string, interval time.Duration) (*pollSync, error) {
+	if _, err := os.Stat(rootPath); err != nil {
+		return nil, fmt.Errorf("vault path does not exist: %w", err)
+	}
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	return &pollSync{
+		vaultID:  vaultID,
+		rootPath: rootPath,
+		interval: interval,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// setIgnoreRules sets the rules for paths that are not scanned, and the
+// callback for when the ignore file changes
+func (p *pollSync) setIgnoreRules(rules *ignore.Rules, onFileChange func()) {
+	p.ignore = rules
+	p.onIgnoreChange = onFileChange
+}
+
+// Start takes an initial snapshot and then scans on every interval (blocking)
+func (p *pollSync) Start(ctx context.Context, events chan<- FileChangeEvent) error {
+	logger.WithFields(map[string]interface{}{
+		"vault_id": p.vaultID,
+		"path":     p.rootPath,
+		"interval": p.interval,
+	}).Info("Starting polling sync")
+
+	snapshot, ignoreState, err := p.scan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to scan vault: %w", err)
+	}
+	p.snapshot = snapshot
+	p.ignoreState = ignoreState
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.WithField("vault_id", p.vaultID).Info("Polling sync stopped")
+			return nil
+		case <-p.done:
+			logger.WithField("vault_id", p.vaultID).Info("Polling sync stopped")
+			return nil
+		case <-ticker.C:
+			if !p.poll(ctx, events) {
+				return nil
+			}
+		}
+	}
+}
+
+// Stop ends the scan loop
+func (p *pollSync) Stop() error {
+	p.stopOnce.Do(func() { close(p.done) })
+	return nil
+}
+
+// ReIndex scans the vault and emits FileCreated events for all files
+func (p *pollSync) ReIndex(ctx context.Context, events chan<- FileChangeEvent) error {
+	logger.WithField("vault_id", p.vaultID).Info("Starting polling re-index")
+
+	snapshot, _, err := p.scan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to scan vault: %w", err)
+	}
+	for relPath, state := range snapshot {
+		if state.isDir {
+			continue
+		}
+		if !p.send(ctx, events, pollChange{path: relPath, eventType: FileCreated}) {
+			return ctx.Err()
+		}
+	}
+
+	logger.WithField("vault_id", p.vaultID).Info("Polling re-index scan completed")
+	return nil
+}
+
+// poll scans once and emits events for the differences. Returns false once ctx is done.
+func (p *pollSync) poll(ctx context.Context, events chan<- FileChangeEvent) bool {
+	current, ignoreState, err := p.scan(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		logger.WithError(err).WithField("vault_id", p.vaultID).Warn("Vault scan failed, retrying next interval")
+		return true
+	}
+
+	for _, change := range diffFileSnapshots(p.snapshot, current) {
+		if !p.send(ctx, events, change) {
+			return false
+		}
+	}
+	p.snapshot = current
+
+	if ignoreState != p.ignoreState {
+		p.ignoreState = ignoreState
+		logger.WithField("vault_id", p.vaultID).Info("Ignore file changed, re-applying rules")
+		if p.onIgnoreChange != nil {
+			p.onIgnoreChange()
+		}
+		// Paths the new rules include or exclude are the owner's to re-process;
+		// they must not show up as changes on the next scan
+		if snapshot, _, err := p.scan(ctx); err == nil {
+			p.snapshot = snapshot
+		}
+	}
+	return true
+}
+
+// scan walks the vault, leaving out hidden and ignored paths, and returns
+// its state along with the state of the ignore file
+func (p *pollSync) scan(ctx context.Context) (map[string]fileState, fileState, error) {
+	var ignoreState fileState
+	if info, err := os.Stat(filepath.Join(p.rootPath, ignore.FileName)); err == nil {
+		ignoreState = fileState{size: info.Size(), modTime: info.ModTime()}
+	}
+
+	snapshot := make(map[string]fileState)
+	err := filepath.WalkDir(p.rootPath, func(walkPath string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if walkPath == p.rootPath {
+				return err
+			}
+			// Removed while scanning; the next scan reports it
+			return nil
+		}
+		if walkPath == p.rootPath {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(p.rootPath, walkPath)
+		if err != nil {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		// Skip hidden and ignored files and directories
+		if strings.HasPrefix(d.Name(), ".") || p.ignore.Ignored(relPath, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		state := fileState{isDir: d.IsDir()}
+		if !state.isDir {
+			state.size = info.Size()
+			state.modTime = info.ModTime()
+		}
+		snapshot[relPath] = state
+		return nil
+	})
+	if err != nil {
+		return nil, fileState{}, err
+	}
+	return snapshot, ignoreState, nil
+}
+
+// send delivers a change as an event (blocking), returning false if ctx was cancelled
+func (p *pollSync) send(ctx context.Context, events chan<- FileChangeEvent, change pollChange) bool {
+	event := FileChangeEvent{
+		VaultID:   p.vaultID,
+		Path:      filepath.Join(p.rootPath, filepath.FromSlash(change.path)),
+		EventType: change.eventType,
+		Timestamp: time.Now(),
+	}
+	if change.oldPath != "" {
+		event.OldPath = filepath.Join(p.rootPath, filepath.FromSlash(change.oldPath))
+	}
+
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// pollChange is a single difference between two scans
+type pollChange struct {
+	path      string
+	oldPath   string // Set only for FileMoved
+	eventType FileEventType
+}
+
+// diffFileSnapshots compares two scans and returns the changes between them,
+// in the order they must be applied.
+//
+// Changes are reported like the fsnotify watcher reports them: created and
+// modified files (directories are implied by their contents), deleted files
+// and directories, and moves. A directory that vanished while one with the
+// same name appeared is a move, as is a file that vanished while a file with
+// the same size and modification time appeared under the same name or in the
+// same directory.
+func diffFileSnapshots(previous, current map[string]fileState) []pollChange {
+	var created, deleted, modified []string
+	for p, state := range current {
+		old, existed := previous[p]
+		switch {
+		case !existed:
+			created = append(created, p)
+		case !state.isDir && (old.isDir || old.size != state.size || !old.modTime.Equal(state.modTime)):
+			modified = append(modified, p)
+		}
+	}
+	for p := range previous {
+		if _, ok := current[p]; !ok {
+			deleted = append(deleted, p)
+		}
+	}
+	// Parents sort before their contents
+	sort.Strings(created)
+	sort.Strings(deleted)
+	sort.Strings(modified)
+
+	isCreated := make(map[string]bool, len(created))
+	for _, p := range created {
+		isCreated[p] = true
+	}
+	isDeleted := make(map[string]bool, len(deleted))
+	for _, p := range deleted {
+		isDeleted[p] = true
+	}
+
+	var moves, removedAfterMove []pollChange
+	for _, oldDir := range deleted {
+		if !isDeleted[oldDir] || !previous[oldDir].isDir {
+			continue
+		}
+		newDir := ""
+		for _, c := range created {
+			if isCreated[c] && current[c].isDir && path.Base(c) == path.Base(oldDir) {
+				newDir = c
+				break
+			}
+		}
+		if newDir == "" {
+			continue
+		}
+
+		moves = append(moves, pollChange{path: newDir, oldPath: oldDir, eventType: FileMoved})
+		delete(isDeleted, oldDir)
+		delete(isCreated, newDir)
+
+		// Contents move along; whatever did not arrive is gone from the new location
+		for _, p := range deleted {
+			if !isDeleted[p] || !strings.HasPrefix(p, oldDir+"/") {
+				continue
+			}
+			movedPath := newDir + strings.TrimPrefix(p, oldDir)
+			delete(isDeleted, p)
+
+			cur, ok := current[movedPath]
+			if !ok || !isCreated[movedPath] || cur.isDir != previous[p].isDir {
+				removedAfterMove = append(removedAfterMove, pollChange{path: movedPath, eventType: FileDeleted})
+				continue
+			}
+			delete(isCreated, movedPath)
+			if old := previous[p]; !cur.isDir && (old.size != cur.size || !old.modTime.Equal(cur.modTime)) {
+				modified = append(modified, movedPath)
+			}
+		}
+	}
+
+	for _, oldPath := range deleted {
+		old := previous[oldPath]
+		if !isDeleted[oldPath] || old.isDir {
+			continue
+		}
+		match := ""
+		for _, c := range created {
+			cur := current[c]
+			if !isCreated[c] || cur.isDir || cur.size != old.size || !cur.modTime.Equal(old.modTime) {
+				continue
+			}
+			if path.Base(c) == path.Base(oldPath) {
+				match = c
+				break
+			}
+			if match == "" && path.Dir(c) == path.Dir(oldPath) {
+				match = c
+			}
+		}
+		if match == "" {
+			continue
+		}
+		moves = append(moves, pollChange{path: match, oldPath: oldPath, eventType: FileMoved})
+		delete(isDeleted, oldPath)
+		delete(isCreated, match)
+	}
+
+	changes := moves
+	for i := len(removedAfterMove) - 1; i >= 0; i-- {
+		changes = append(changes, removedAfterMove[i])
+	}
+	for _, p := range created {
+		if isCreated[p] && !current[p].isDir {
+			changes = append(changes, pollChange{path: p, eventType: FileCreated})
+		}
+	}
+	for _, p := range modified {
+		changes = append(changes, pollChange{path: p, eventType: FileModified})
+	}
+	// Contents are deleted before the directory holding them
+	for i := len(deleted) - 1; i >= 0; i-- {
+		if isDeleted[deleted[i]] {
+			changes = append(changes, pollChange{path: deleted[i], eventType: FileDeleted})
+		}
+	}
+	return changes
+}
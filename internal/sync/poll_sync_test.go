@@ -0,0 +1,180 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffFileSnapshots(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	t1 := t0.Add(time.Minute)
+	file := func(size int64, mod time.Time) fileState { return fileState{size: size, modTime: mod} }
+	dir := fileState{isDir: true}
+
+	tests := []struct {
+		name     string
+		previous map[string]fileState
+		current  map[string]fileState
+		want     []pollChange
+	}{
+		{
+			name:     "create, modify and delete",
+			previous: map[string]fileState{"a.md": file(1, t0), "b.md": file(2, t0), "old": dir, "old/c.md": file(3, t0)},
+			current:  map[string]fileState{"a.md": file(5, t1), "b.md": file(2, t0), "new": dir, "new/d.md": file(4, t1)},
+			want: []pollChange{
+				{path: "new/d.md", eventType: FileCreated},
+				{path: "a.md", eventType: FileModified},
+				{path: "old/c.md", eventType: FileDeleted},
+				{path: "old", eventType: FileDeleted},
+			},
+		},
+		{
+			name:     "file rename and move",
+			previous: map[string]fileState{"a.md": file(1, t0), "b.md": file(2, t0), "archive": dir},
+			current:  map[string]fileState{"renamed.md": file(1, t0), "archive": dir, "archive/b.md": file(2, t0)},
+			want: []pollChange{
+				{path: "renamed.md", oldPath: "a.md", eventType: FileMoved},
+				{path: "archive/b.md", oldPath: "b.md", eventType: FileMoved},
+			},
+		},
+		{
+			name:     "same size elsewhere is not a move",
+			previous: map[string]fileState{"x/a.md": file(1, t0)},
+			current:  map[string]fileState{"y/b.md": file(1, t0)},
+			want: []pollChange{
+				{path: "y/b.md", eventType: FileCreated},
+				{path: "x/a.md", eventType: FileDeleted},
+			},
+		},
+		{
+			name: "directory move carries its contents",
+			previous: map[string]fileState{
+				"proj": dir, "proj/a.md": file(1, t0), "proj/sub": dir, "proj/sub/b.md": file(2, t0), "proj/gone.md": file(3, t0),
+			},
+			current: map[string]fileState{
+				"work": dir, "work/proj": dir, "work/proj/a.md": file(9, t1), "work/proj/sub": dir, "work/proj/sub/b.md": file(2, t0),
+			},
+			want: []pollChange{
+				{path: "work/proj", oldPath: "proj", eventType: FileMoved},
+				{path: "work/proj/gone.md", eventType: FileDeleted},
+				{path: "work/proj/a.md", eventType: FileModified},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffFileSnapshots(tt.previous, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffFileSnapshots() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPollSync_EmitsChanges(t *testing.T) {
+	dir := t.TempDir()
+	note := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(note, []byte("# Note"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := newPollSync("test-vault", dir, 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("newPollSync() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan FileChangeEvent, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = p.Start(ctx, events)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	created := filepath.Join(dir, "sub", "new.md")
+	if err := os.MkdirAll(filepath.Dir(created), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(created, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextEvent(t, events, FileCreated); ev.Path != created {
+		t.Errorf("FileCreated path = %s, want %s", ev.Path, created)
+	}
+
+	moved := filepath.Join(dir, "renamed.md")
+	if err := os.Rename(note, moved); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextEvent(t, events, FileMoved); ev.OldPath != note || ev.Path != moved {
+		t.Errorf("FileMoved = %s -> %s, want %s -> %s", ev.OldPath, ev.Path, note, moved)
+	}
+
+	if err := os.Remove(created); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextEvent(t, events, FileDeleted); ev.Path != created {
+		t.Errorf("FileDeleted path = %s, want %s", ev.Path, created)
+	}
+
+	// Stop ends the loop even while ctx is live
+	p.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+}
+
+func TestAutoSync_KeepsWorkingWatcher(t *testing.T) {
+	dir := t.TempDir()
+	a, err := newAutoSync("test-vault", dir, time.Hour)
+	if err != nil {
+		t.Fatalf("newAutoSync() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan FileChangeEvent, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = a.Start(ctx, events)
+	}()
+	defer func() {
+		cancel()
+		a.Stop()
+		<-done
+	}()
+
+	// With an hour-long poll interval only the watcher can report this in time
+	time.Sleep(200 * time.Millisecond)
+	path := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextEvent(t, events, FileCreated); ev.Path != path {
+		t.Errorf("FileCreated path = %s, want %s", ev.Path, path)
+	}
+
+	a.mu.Lock()
+	_, usesWatcher := a.active.(*localSync)
+	a.mu.Unlock()
+	if !usesWatcher {
+		t.Error("auto mode should keep fsnotify when it reports changes")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if e.Name() != "note.md" {
+			t.Errorf("probe file %s left behind", e.Name())
+		}
+	}
+}
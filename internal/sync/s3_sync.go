@@ -2,54 +2,95 @@ package sync
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/ignore"
 	"github.com/susamn/obsidian-web/internal/logger"
+	"github.com/susamn/obsidian-web/internal/objectstore"
 )
 
-// s3Sync monitors S3 bucket for changes
-// TODO: Implement S3 event notifications or polling
+// defaultS3PollInterval is used when the config does not set poll_interval
+const defaultS3PollInterval = 30 * time.Second
+
+// s3Sync monitors an S3 bucket for changes by polling ListObjectsV2
+// Each poll is diffed against the previous snapshot using ETag and LastModified
 type s3Sync struct {
-	vaultID string
-	config  *config.S3StorageConfig
+	vaultID  string
+	interval time.Duration
+	config   *config.S3StorageConfig
+	bucket   *bucketSnapshot
 }
 
 // newS3Sync creates a new S3 sync service
-func newS3Sync(vaultID string, config *config.S3StorageConfig) *s3Sync {
-	return &s3Sync{
-		vaultID: vaultID,
-		config:  config,
+func newS3Sync(vaultID string, cfg *config.S3StorageConfig) (*s3Sync, error) {
+	client, err := objectstore.NewS3Client(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := cfg.PollInterval
+	if interval <= 0 {
+		interval = defaultS3PollInterval
 	}
+
+	return &s3Sync{
+		vaultID:  vaultID,
+		interval: interval,
+		config:   cfg,
+		bucket:   newBucketSnapshot(vaultID, cfg.Prefix, client),
+	}, nil
+}
+
+// setIgnoreRules sets the rules for objects that are not tracked
+func (s *s3Sync) setIgnoreRules(rules *ignore.Rules, onFileChange func()) {
+	s.bucket.setIgnoreRules(rules, onFileChange)
 }
 
-// Start begins monitoring the S3 bucket (placeholder implementation)
+// Start polls the bucket until the context is cancelled (blocking)
+// The first listing only establishes the baseline, mirroring fsnotify which
+// does not report files that already exist when watching starts
 func (s *s3Sync) Start(ctx context.Context, events chan<- FileChangeEvent) error {
 	logger.WithFields(map[string]interface{}{
 		"vault_id": s.vaultID,
 		"bucket":   s.config.Bucket,
-		"region":   s.config.Region,
-	}).Warn("S3 sync not yet implemented - placeholder running")
-
-	// TODO: Implement S3 monitoring
-	// Options:
-	// 1. S3 Event Notifications (SNS/SQS)
-	// 2. Polling with ListObjectsV2
-	// 3. CloudWatch Events
-
-	// For now, just wait for context cancellation
-	<-ctx.Done()
-	logger.WithField("vault_id", s.vaultID).Info("S3 sync stopped")
-	return nil
+		"prefix":   s.bucket.prefix,
+		"interval": s.interval,
+	}).Info("Starting S3 sync")
+
+	if err := s.bucket.refresh(ctx, events, false); err != nil {
+		logger.WithError(err).WithField("vault_id", s.vaultID).Warn("Initial S3 listing failed, will retry")
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.WithField("vault_id", s.vaultID).Info("S3 sync stopped")
+			return nil
+		case <-ticker.C:
+			if err := s.bucket.refresh(ctx, events, true); err != nil {
+				logger.WithError(err).WithField("vault_id", s.vaultID).Warn("S3 poll failed")
+			}
+		}
+	}
 }
 
 // Stop stops the S3 sync service
+// Polling stops when the context passed to Start is cancelled
 func (s *s3Sync) Stop() error {
-	// TODO: Cleanup S3 resources if needed
 	return nil
 }
 
-// ReIndex triggers a full re-index (placeholder)
-func (s *s3Sync) ReIndex(events chan<- FileChangeEvent) error {
-	logger.WithField("vault_id", s.vaultID).Warn("S3 ReIndex not implemented")
+// ReIndex lists the whole bucket prefix and emits FileCreated events for every object
+func (s *s3Sync) ReIndex(ctx context.Context, events chan<- FileChangeEvent) error {
+	logger.WithField("vault_id", s.vaultID).Info("Starting S3 re-index")
+
+	if err := s.bucket.reIndex(ctx, events); err != nil {
+		return fmt.Errorf("s3 re-index failed: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,196 @@
+package sync
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/objectstore/objectstoretest"
+)
+
+func newTestS3Sync(t *testing.T, srv *objectstoretest.Server, prefix string) *s3Sync {
+	t.Helper()
+	s, err := newS3Sync("s3-vault", &config.S3StorageConfig{
+		Bucket:       "vault",
+		Region:       "us-east-1",
+		Prefix:       prefix,
+		Endpoint:     srv.URL,
+		PollInterval: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("newS3Sync() error = %v", err)
+	}
+	return s
+}
+
+// waitForEvent reads events until one matches path and type, or times out
+func waitForEvent(t *testing.T, events <-chan FileChangeEvent, path string, eventType FileEventType) {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Path == path && ev.EventType == eventType {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s event on %s", eventType, path)
+		}
+	}
+}
+
+func TestS3Sync_PollEmitsChanges(t *testing.T) {
+	srv := objectstoretest.NewServer("vault")
+	defer srv.Close()
+
+	srv.PutObject("vault/existing.md", []byte("old"))
+	srv.PutObject("vault/gone.md", []byte("bye"))
+	srv.PutObject("outside.md", []byte("not in prefix"))
+
+	s := newTestS3Sync(t, srv, "vault")
+	root := RemoteVaultRoot("s3-vault")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan FileChangeEvent, 100)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Start(ctx, events) }()
+
+	// Wait for the baseline listing
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		n := s.bucket.size()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("baseline snapshot not established, have %d entries", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case ev := <-events:
+		t.Fatalf("baseline listing should not emit events, got %+v", ev)
+	default:
+	}
+
+	srv.PutObject("vault/notes/new.md", []byte("new"))
+	waitForEvent(t, events, filepath.Join(root, "notes", "new.md"), FileCreated)
+
+	srv.PutObject("vault/existing.md", []byte("changed"))
+	waitForEvent(t, events, filepath.Join(root, "existing.md"), FileModified)
+
+	srv.DeleteObject("vault/gone.md")
+	waitForEvent(t, events, filepath.Join(root, "gone.md"), FileDeleted)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start() did not return after cancel")
+	}
+}
+
+func TestS3Sync_ReIndexEmitsFullListing(t *testing.T) {
+	srv := objectstoretest.NewServer("vault")
+	defer srv.Close()
+
+	srv.PutObject("a.md", []byte("a"))
+	srv.PutObject("dir/b.md", []byte("b"))
+	srv.PutObject("dir/", nil)
+	srv.PutObject(".obsidian/app.json", []byte("{}"))
+
+	s := newTestS3Sync(t, srv, "")
+	events := make(chan FileChangeEvent, 10)
+
+	if err := s.ReIndex(context.Background(), events); err != nil {
+		t.Fatalf("ReIndex() error = %v", err)
+	}
+
+	root := RemoteVaultRoot("s3-vault")
+	seen := make(map[string]FileEventType)
+	timeout := time.After(2 * time.Second)
+	for len(seen) < 2 {
+		select {
+		case ev := <-events:
+			seen[ev.Path] = ev.EventType
+		case <-timeout:
+			t.Fatalf("timed out, got %v", seen)
+		}
+	}
+
+	for _, p := range []string{filepath.Join(root, "a.md"), filepath.Join(root, "dir", "b.md")} {
+		if seen[p] != FileCreated {
+			t.Errorf("expected FileCreated for %s, got %v", p, seen)
+		}
+	}
+
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected extra event %+v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	now := time.Now()
+	previous := map[string]objectState{
+		"same.md":    {etag: "1", lastModified: now},
+		"etag.md":    {etag: "1", lastModified: now},
+		"touched.md": {etag: "1", lastModified: now},
+		"removed.md": {etag: "1", lastModified: now},
+	}
+	current := map[string]objectState{
+		"same.md":    {etag: "1", lastModified: now},
+		"etag.md":    {etag: "2", lastModified: now},
+		"touched.md": {etag: "1", lastModified: now.Add(time.Second)},
+		"added.md":   {etag: "1", lastModified: now},
+	}
+
+	got := make(map[string]FileEventType)
+	for _, c := range diffSnapshots(previous, current) {
+		got[c.path] = c.eventType
+	}
+
+	want := map[string]FileEventType{
+		"etag.md":    FileModified,
+		"touched.md": FileModified,
+		"added.md":   FileCreated,
+		"removed.md": FileDeleted,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("diffSnapshots() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("diffSnapshots()[%s] = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestKeyToRelPath(t *testing.T) {
+	tests := []struct {
+		prefix, key string
+		want        string
+		ok          bool
+	}{
+		{"vault/", "vault/notes/a.md", "notes/a.md", true},
+		{"", "a.md", "a.md", true},
+		{"vault/", "vault/dir/", "", false},
+		{"", ".obsidian/workspace.json", "", false},
+		{"", "notes/.hidden.md", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := keyToRelPath(tt.prefix, tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("keyToRelPath(%q, %q) = (%q, %v), want (%q, %v)", tt.prefix, tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -3,10 +3,14 @@ package sync
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/ignore"
+	"github.com/susamn/obsidian-web/internal/logger"
 )
 
 // FileEventType represents the type of file system event
@@ -16,6 +20,7 @@ const (
 	FileCreated FileEventType = iota
 	FileModified
 	FileDeleted
+	FileMoved
 )
 
 // String returns the string representation of FileEventType
@@ -27,6 +32,8 @@ func (f FileEventType) String() string {
 		return "modified"
 	case FileDeleted:
 		return "deleted"
+	case FileMoved:
+		return "moved"
 	default:
 		return "unknown"
 	}
@@ -36,29 +43,64 @@ func (f FileEventType) String() string {
 type FileChangeEvent struct {
 	VaultID   string
 	Path      string
+	OldPath   string // Previous path, set only for FileMoved
 	EventType FileEventType
 	Timestamp time.Time
+	Seq       uint64 // Journal sequence number, 0 when the event is not journaled
+	Force     bool   // Process the event even when the file's content hash is unchanged
+}
+
+// RemoteVaultRoot returns the root path that remote storage backends (S3, MinIO)
+// use for the paths in their events, so downstream services can treat
+// them like local vaults and compute vault-relative paths
+func RemoteVaultRoot(vaultID string) string {
+	return filepath.Join(os.TempDir(), "vault-cache", vaultID)
+}
+
+// SyncConflict describes remote changes that could not be merged into the vault
+type SyncConflict struct {
+	VaultID   string
+	Paths     []string // Absolute paths of the conflicting files
+	Message   string
+	Timestamp time.Time
 }
 
 // syncBackend is internal interface for different sync implementations
 type syncBackend interface {
 	Start(ctx context.Context, events chan<- FileChangeEvent) error
 	Stop() error
-	ReIndex(events chan<- FileChangeEvent) error
+	// ReIndex emits FileCreated events for every file; blocks until done or ctx is cancelled
+	ReIndex(ctx context.Context, events chan<- FileChangeEvent) error
+}
+
+// conflictReporter is implemented by backends that merge remote changes and can conflict
+type conflictReporter interface {
+	setConflictHandler(fn func(*SyncConflict))
+}
+
+// ignoreFilter is implemented by backends that can skip ignored paths and
+// notice when the vault's ignore file changes
+type ignoreFilter interface {
+	setIgnoreRules(rules *ignore.Rules, onFileChange func())
 }
 
 // SyncService monitors storage backend for file changes
 // All operations are non-blocking and run in goroutines
 type SyncService struct {
-	ctx      context.Context
-	cancel   context.CancelFunc
-	vaultID  string
-	storage  *config.StorageConfig
-	events   chan FileChangeEvent
-	backend  syncBackend
-	wg       sync.WaitGroup
-	startErr error
-	mu       sync.RWMutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+	vaultID   string
+	storage   *config.StorageConfig
+	events    chan FileChangeEvent
+	in        chan FileChangeEvent // Where the backend sends; events itself unless journaled
+	journal   *Journal
+	backend   syncBackend
+	wg        sync.WaitGroup
+	reindexWg sync.WaitGroup // In-flight re-index walks that send on events
+	startErr  error
+	draining  bool // No new re-index walks may start; guarded by mu
+	closed    bool // Events channel is closed; guarded by mu
+	mu        sync.RWMutex
 }
 
 // NewSyncService creates a new sync service for a vault
@@ -87,7 +129,14 @@ func NewSyncService(ctx context.Context, vaultID string, storage *config.Storage
 			cancel()
 			return nil, fmt.Errorf("local storage config is nil")
 		}
-		backend, err = newLocalSync(vaultID, localCfg.Path)
+		switch localCfg.WatchMode {
+		case config.WatchModeFsnotify:
+			backend, err = newLocalSync(vaultID, localCfg.Path)
+		case config.WatchModePoll:
+			backend, err = newPollSync(vaultID, localCfg.Path, localCfg.PollInterval)
+		default:
+			backend, err = newAutoSync(vaultID, localCfg.Path, localCfg.PollInterval)
+		}
 		if err != nil {
 			cancel()
 			return nil, fmt.Errorf("failed to create local sync: %w", err)
@@ -99,7 +148,11 @@ func NewSyncService(ctx context.Context, vaultID string, storage *config.Storage
 			cancel()
 			return nil, fmt.Errorf("s3 storage config is nil")
 		}
-		backend = newS3Sync(vaultID, s3Cfg)
+		backend, err = newS3Sync(vaultID, s3Cfg)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create s3 sync: %w", err)
+		}
 
 	case config.MinIOStorage:
 		minioCfg := storage.GetMinIOConfig()
@@ -107,7 +160,23 @@ func NewSyncService(ctx context.Context, vaultID string, storage *config.Storage
 			cancel()
 			return nil, fmt.Errorf("minio storage config is nil")
 		}
-		backend = newMinIOSync(vaultID, minioCfg)
+		backend, err = newMinIOSync(vaultID, minioCfg)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create minio sync: %w", err)
+		}
+
+	case config.GitStorage:
+		gitCfg := storage.GetGitConfig()
+		if gitCfg == nil {
+			cancel()
+			return nil, fmt.Errorf("git storage config is nil")
+		}
+		backend, err = newGitSync(svcCtx, vaultID, gitCfg)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create git sync: %w", err)
+		}
 
 	default:
 		cancel()
@@ -120,6 +189,7 @@ func NewSyncService(ctx context.Context, vaultID string, storage *config.Storage
 		vaultID: vaultID,
 		storage: storage,
 		events:  events,
+		in:      events,
 		backend: backend,
 	}
 
@@ -128,23 +198,121 @@ func NewSyncService(ctx context.Context, vaultID string, storage *config.Storage
 
 // Start begins monitoring the storage backend in a non-blocking goroutine
 func (s *SyncService) Start() error {
+	var forwarded chan struct{}
+	if s.journal != nil {
+		forwarded = make(chan struct{})
+		go s.forward(forwarded)
+	}
+
 	s.wg.Add(1)
 	go func() {
 		defer s.wg.Done()
-		defer close(s.events)
 
 		// Start the backend (non-blocking)
-		err := s.backend.Start(s.ctx, s.events)
+		err := s.backend.Start(s.ctx, s.in)
+
+		s.mu.Lock()
 		if err != nil {
-			s.mu.Lock()
 			s.startErr = err
-			s.mu.Unlock()
 		}
+		s.draining = true
+		s.mu.Unlock()
+
+		// Let running re-index walks finish before closing the channel they send on
+		s.reindexWg.Wait()
+
+		if forwarded != nil {
+			close(s.in)
+			<-forwarded
+		}
+
+		s.mu.Lock()
+		s.closed = true
+		close(s.events)
+		s.mu.Unlock()
 	}()
 
 	return nil
 }
 
+// forward records backend events in the journal before queuing them for workers.
+// Events that arrive together are synced to disk once, before any is queued.
+func (s *SyncService) forward(done chan<- struct{}) {
+	defer close(done)
+
+	batch := make([]FileChangeEvent, 0, cap(s.in))
+	for event := range s.in {
+		batch = append(batch[:0], s.record(event))
+	drain:
+		for len(batch) < cap(batch) {
+			select {
+			case event, ok := <-s.in:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, s.record(event))
+			default:
+				break drain
+			}
+		}
+
+		if err := s.journal.Sync(); err != nil {
+			logger.WithError(err).WithField("vault_id", s.vaultID).Warn("Failed to sync journal")
+		}
+
+		for _, event := range batch {
+			select {
+			case s.events <- event:
+			case <-s.ctx.Done():
+				// Already journaled; replayed on the next start
+			}
+		}
+	}
+}
+
+// record appends an event to the journal, setting its sequence number.
+// Events that are already journaled, or that fail to write, pass through unchanged.
+func (s *SyncService) record(event FileChangeEvent) FileChangeEvent {
+	if s.journal == nil || event.Seq != 0 {
+		return event
+	}
+	seq, err := s.journal.Append(event)
+	if err != nil {
+		logger.WithError(err).WithFields(map[string]interface{}{
+			"vault_id": s.vaultID,
+			"path":     event.Path,
+		}).Warn("Failed to journal event")
+		return event
+	}
+	event.Seq = seq
+	return event
+}
+
+// SetJournal records every event in j before it is queued, so events the
+// workers have not acknowledged survive a restart. Must be called before Start.
+func (s *SyncService) SetJournal(j *Journal) {
+	s.journal = j
+	s.in = make(chan FileChangeEvent, cap(s.events))
+}
+
+// SetConflictHandler registers a callback for merge conflicts reported by the backend
+// It is called with nil once the backend is back in sync. Must be called before Start.
+// Backends that cannot conflict ignore it.
+func (s *SyncService) SetConflictHandler(fn func(*SyncConflict)) {
+	if reporter, ok := s.backend.(conflictReporter); ok {
+		reporter.setConflictHandler(fn)
+	}
+}
+
+// SetIgnoreRules makes the backend skip paths matched by rules in events and re-index walks.
+// onFileChange is called when the vault's ignore file changes; the backend
+// re-applies rules once it returns. Must be called before Start.
+func (s *SyncService) SetIgnoreRules(rules *ignore.Rules, onFileChange func()) {
+	if filter, ok := s.backend.(ignoreFilter); ok {
+		filter.setIgnoreRules(rules, onFileChange)
+	}
+}
+
 // Events returns the read-only channel for file change events
 func (s *SyncService) Events() <-chan FileChangeEvent {
 	return s.events
@@ -182,6 +350,9 @@ func (s *SyncService) VaultID() string {
 // PendingEventsCount returns the number of pending events in the channel
 // This is a non-blocking operation that returns the current buffer length
 func (s *SyncService) PendingEventsCount() int {
+	if s.in != s.events {
+		return len(s.events) + len(s.in)
+	}
 	return len(s.events)
 }
 
@@ -189,17 +360,42 @@ func (s *SyncService) PendingEventsCount() int {
 // Returns true if event was injected, false if channel is full
 // Non-blocking operation
 func (s *SyncService) InjectEvent(event FileChangeEvent) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return false
+	}
+
+	recorded := s.record(event)
 	select {
-	case s.events <- event:
+	case s.events <- recorded:
 		return true
 	default:
+		// Not queued, so the caller still owns the event
+		if recorded.Seq != event.Seq {
+			_ = s.journal.Ack(recorded.Seq)
+		}
 		return false
 	}
 }
 
 // ReIndex triggers a full re-index of the vault
-// It walks the entire filesystem and emits FileCreated events for all files
-// This operation runs asynchronously on the backend but this method blocks until the walk is started
+// It walks the entire storage and emits FileCreated events for all files
+// The walk runs asynchronously and is cancelled when the service stops
 func (s *SyncService) ReIndex() error {
-	return s.backend.ReIndex(s.events)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.draining || s.ctx.Err() != nil {
+		return fmt.Errorf("sync service stopped")
+	}
+
+	s.reindexWg.Add(1)
+	go func() {
+		defer s.reindexWg.Done()
+		if err := s.backend.ReIndex(s.ctx, s.in); err != nil {
+			logger.WithError(err).WithField("vault_id", s.vaultID).Error("Re-index failed")
+		}
+	}()
+
+	return nil
 }
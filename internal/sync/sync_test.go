@@ -753,3 +753,65 @@ func TestSyncService_NoDeadlock_ContextCancelDuringFileCreation(t *testing.T) {
 		t.Fatal("Deadlock detected: Stop() after context cancel did not complete")
 	}
 }
+
+func TestSyncService_JournalsEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "note.md"), []byte("# Note"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	storage := &config.StorageConfig{
+		Type: "local",
+		Local: &config.LocalStorageConfig{
+			Path:      tmpDir,
+			WatchMode: config.WatchModeFsnotify,
+		},
+	}
+	svc, err := NewSyncService(context.Background(), "test-vault", storage)
+	if err != nil {
+		t.Fatalf("Failed to create sync service: %v", err)
+	}
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "vault.journal"))
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	defer journal.Close()
+	svc.SetJournal(journal)
+
+	if err := svc.Start(); err != nil {
+		t.Fatalf("Failed to start sync service: %v", err)
+	}
+	defer svc.Stop()
+
+	if err := svc.ReIndex(); err != nil {
+		t.Fatalf("ReIndex() error = %v", err)
+	}
+
+	var event FileChangeEvent
+	select {
+	case event = <-svc.Events():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for re-index event")
+	}
+	if event.Seq == 0 {
+		t.Fatal("Expected the event to carry its journal sequence number")
+	}
+	if pending := journal.Pending(); len(pending) != 1 || pending[0].Event.Path != event.Path {
+		t.Fatalf("Pending() = %+v, want the queued event", pending)
+	}
+
+	// Retried events are not journaled twice
+	if !svc.InjectEvent(event) {
+		t.Fatal("InjectEvent() = false")
+	}
+	if retried := <-svc.Events(); retried.Seq != event.Seq || len(journal.Pending()) != 1 {
+		t.Errorf("Retried event seq = %d with %d pending, want seq %d with 1 pending", retried.Seq, len(journal.Pending()), event.Seq)
+	}
+
+	if err := journal.Ack(event.Seq); err != nil {
+		t.Fatal(err)
+	}
+	if pending := journal.Pending(); len(pending) != 0 {
+		t.Errorf("Pending() after Ack = %+v, want none", pending)
+	}
+}
@@ -0,0 +1,299 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/logger"
+	"github.com/susamn/obsidian-web/internal/storage"
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+)
+
+// TrashDir is the vault folder deleted files are moved to, as in Obsidian
+const TrashDir = ".trash"
+
+// trashPurgeInterval is how often the trash is checked for expired items
+const trashPurgeInterval = time.Hour
+
+var (
+	// ErrTrashItemNotFound is returned for a trash item ID that is not in the trash
+	ErrTrashItemNotFound = errors.New("trash item not found")
+	// ErrRestoreConflict is returned when a restored item's original path is taken
+	ErrRestoreConflict = errors.New("original path already exists")
+)
+
+// MoveToTrash moves a file or folder into the vault trash and marks its
+// entries deleted. The workers then drop it from the index like any delete.
+func (v *Vault) MoveToTrash(ctx context.Context, entry *db.FileEntry) (*db.TrashItem, error) {
+	store, dbService, err := v.trashServices()
+	if err != nil {
+		return nil, err
+	}
+
+	originalPath := filepath.ToSlash(entry.Path)
+	trashPath, err := freePath(ctx, store, path.Join(TrashDir, path.Base(originalPath)), entry.IsDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Rename(ctx, originalPath, trashPath); err != nil {
+		return nil, fmt.Errorf("move to trash: %w", err)
+	}
+
+	entries, err := dbService.DeleteFileEntryTree(entry.Path)
+	if err != nil {
+		return nil, v.undoMoveToTrash(ctx, store, trashPath, originalPath, nil, err)
+	}
+	item := &db.TrashItem{
+		FileID:       entry.ID,
+		OriginalPath: originalPath,
+		TrashPath:    trashPath,
+		IsDir:        entry.IsDir,
+	}
+	for _, e := range entries {
+		item.Size += e.Size
+	}
+	if err := dbService.AddTrashItem(item); err != nil {
+		return nil, v.undoMoveToTrash(ctx, store, trashPath, originalPath, entries, err)
+	}
+
+	// The watcher reports these too; queue them without waiting so a full
+	// sync channel cannot hold up the caller
+	for _, e := range entries {
+		v.queueEvent(syncpkg.FileChangeEvent{
+			VaultID:   v.config.ID,
+			Path:      filepath.Join(v.vaultPath, e.Path),
+			EventType: syncpkg.FileDeleted,
+			Timestamp: time.Now(),
+		})
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"vault_id":   v.config.ID,
+		"path":       originalPath,
+		"trash_path": trashPath,
+		"entries":    len(entries),
+	}).Info("Moved to trash")
+	return item, nil
+}
+
+// undoMoveToTrash moves a file back out of the trash after a failed database
+// step and queues the entries already marked deleted so they are reactivated
+func (v *Vault) undoMoveToTrash(ctx context.Context, store storage.VaultStorage, trashPath, originalPath string, entries []db.FileEntry, cause error) error {
+	if err := store.Rename(ctx, trashPath, originalPath); err != nil {
+		logger.WithError(err).WithFields(map[string]interface{}{
+			"vault_id":   v.config.ID,
+			"path":       originalPath,
+			"trash_path": trashPath,
+		}).Error("Failed to move back out of trash")
+		return fmt.Errorf("%w (left in trash at %s: %v)", cause, trashPath, err)
+	}
+	for _, e := range entries {
+		v.queueEvent(syncpkg.FileChangeEvent{
+			VaultID:   v.config.ID,
+			Path:      filepath.Join(v.vaultPath, e.Path),
+			EventType: syncpkg.FileCreated,
+			Timestamp: time.Now(),
+		})
+	}
+	return cause
+}
+
+// RestoreFromTrash moves a trash item back to its original path and returns the
+// path it was restored to. If that path is taken, the item is restored next to
+// it under a numbered name when rename is set, or ErrRestoreConflict is returned.
+// Entries still marked deleted at the path are reactivated with their IDs.
+func (v *Vault) RestoreFromTrash(ctx context.Context, id int64, rename bool) (*db.TrashItem, string, error) {
+	store, dbService, err := v.trashServices()
+	if err != nil {
+		return nil, "", err
+	}
+	item, err := dbService.GetTrashItem(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if item == nil {
+		return nil, "", ErrTrashItemNotFound
+	}
+
+	target := item.OriginalPath
+	if _, err := store.Stat(ctx, target); err == nil {
+		if !rename {
+			return item, "", ErrRestoreConflict
+		}
+		if target, err = freePath(ctx, store, target, item.IsDir); err != nil {
+			return item, "", err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return item, "", err
+	}
+
+	if err := store.Rename(ctx, item.TrashPath, target); err != nil {
+		return item, "", fmt.Errorf("restore from trash: %w", err)
+	}
+	if err := dbService.RemoveTrashItem(item.ID); err != nil {
+		return item, "", err
+	}
+
+	// Queue the restored files, since storage without a watcher reports nothing
+	paths := []string{target}
+	if item.IsDir {
+		err := storage.Walk(ctx, store, target, func(info storage.FileInfo) error {
+			paths = append(paths, info.Path)
+			return nil
+		})
+		if err != nil {
+			logger.WithError(err).WithField("path", target).Warn("Failed to list restored folder")
+		}
+	}
+	for _, p := range paths {
+		v.injectEvent(syncpkg.FileChangeEvent{
+			VaultID:   v.config.ID,
+			Path:      filepath.Join(v.vaultPath, filepath.FromSlash(p)),
+			EventType: syncpkg.FileCreated,
+			Timestamp: time.Now(),
+		})
+	}
+
+	logger.WithFields(map[string]interface{}{
+		"vault_id": v.config.ID,
+		"path":     target,
+		"trash_id": item.ID,
+	}).Info("Restored from trash")
+	return item, target, nil
+}
+
+// PurgeTrashItem permanently deletes a trash item, along with the deleted
+// entries, revisions and metadata kept for it
+func (v *Vault) PurgeTrashItem(ctx context.Context, id int64) error {
+	_, dbService, err := v.trashServices()
+	if err != nil {
+		return err
+	}
+	item, err := dbService.GetTrashItem(id)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return ErrTrashItemNotFound
+	}
+	_, err = v.purgeTrash(ctx, func(other db.TrashItem) bool { return other.ID == item.ID })
+	return err
+}
+
+// EmptyTrash permanently deletes every trash item and returns how many were purged
+func (v *Vault) EmptyTrash(ctx context.Context) (int, error) {
+	return v.purgeTrash(ctx, func(db.TrashItem) bool { return true })
+}
+
+// PurgeExpiredTrash permanently deletes the items deleted before cutoff and
+// returns how many were purged
+func (v *Vault) PurgeExpiredTrash(ctx context.Context, cutoff time.Time) (int, error) {
+	return v.purgeTrash(ctx, func(item db.TrashItem) bool { return item.Deleted.Before(cutoff) })
+}
+
+// purgeTrash permanently deletes the trash items matched by purge
+func (v *Vault) purgeTrash(ctx context.Context, purge func(db.TrashItem) bool) (int, error) {
+	store, dbService, err := v.trashServices()
+	if err != nil {
+		return 0, err
+	}
+	items, err := dbService.GetTrashItems()
+	if err != nil {
+		return 0, err
+	}
+
+	// An original path deleted more than once keeps its entries until its last item goes
+	remaining := make(map[string]int)
+	for _, item := range items {
+		remaining[item.OriginalPath]++
+	}
+
+	purged := 0
+	for _, item := range items {
+		if !purge(item) {
+			continue
+		}
+		if err := store.Delete(ctx, item.TrashPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return purged, fmt.Errorf("delete %s: %w", item.TrashPath, err)
+		}
+		if err := dbService.RemoveTrashItem(item.ID); err != nil {
+			return purged, err
+		}
+		purged++
+
+		remaining[item.OriginalPath]--
+		if remaining[item.OriginalPath] == 0 {
+			if err := dbService.PurgeDeletedFileEntries(filepath.FromSlash(item.OriginalPath)); err != nil {
+				return purged, err
+			}
+		}
+	}
+	return purged, nil
+}
+
+// runTrashPurge purges trash items older than the vault's purge age until the vault stops
+func (v *Vault) runTrashPurge(maxAge time.Duration) {
+	defer v.eventRouter.Done()
+
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		n, err := v.PurgeExpiredTrash(v.ctx, time.Now().Add(-maxAge))
+		if err != nil {
+			logger.WithError(err).WithField("vault_id", v.config.ID).Warn("Failed to purge expired trash")
+		} else if n > 0 {
+			logger.WithFields(map[string]interface{}{
+				"vault_id": v.config.ID,
+				"count":    n,
+			}).Info("Purged expired trash")
+		}
+
+		select {
+		case <-v.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// trashServices returns the storage and database the trash works on
+func (v *Vault) trashServices() (storage.VaultStorage, *db.DBService, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.storage == nil {
+		return nil, nil, fmt.Errorf("vault storage not available")
+	}
+	if v.dbService == nil {
+		return nil, nil, fmt.Errorf("db service not available")
+	}
+	return v.storage, v.dbService, nil
+}
+
+// freePath returns p, or the first of "name 1", "name 2"... next to it that does not exist
+func freePath(ctx context.Context, store storage.VaultStorage, p string, isDir bool) (string, error) {
+	dir, name := path.Split(p)
+	ext := ""
+	if !isDir {
+		ext = path.Ext(name)
+	}
+	stem := strings.TrimSuffix(name, ext)
+
+	candidate := p
+	for n := 1; ; n++ {
+		_, err := store.Stat(ctx, candidate)
+		if errors.Is(err, fs.ErrNotExist) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s%s %d%s", dir, stem, n, ext)
+	}
+}
@@ -3,6 +3,8 @@ package vault
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,11 +12,13 @@ import (
 	"github.com/susamn/obsidian-web/internal/config"
 	"github.com/susamn/obsidian-web/internal/db"
 	"github.com/susamn/obsidian-web/internal/explorer"
+	"github.com/susamn/obsidian-web/internal/ignore"
 	"github.com/susamn/obsidian-web/internal/indexing"
 	"github.com/susamn/obsidian-web/internal/logger"
 	"github.com/susamn/obsidian-web/internal/recon"
 	"github.com/susamn/obsidian-web/internal/search"
 	"github.com/susamn/obsidian-web/internal/sse"
+	"github.com/susamn/obsidian-web/internal/storage"
 	syncpkg "github.com/susamn/obsidian-web/internal/sync"
 )
 
@@ -49,7 +53,7 @@ func (s VaultStatus) String() string {
 // FileOperation represents a file indexing operation
 type FileOperation struct {
 	Path      string
-	Operation string // "create", "modify", "delete"
+	Operation string // "create", "modify", "delete", "move"
 	Timestamp time.Time
 }
 
@@ -61,18 +65,22 @@ type Vault struct {
 
 	config    *config.VaultConfig
 	vaultPath string
+	storage   storage.VaultStorage
+	ignore    *ignore.Rules // Config patterns plus the vault's ignore file
 
 	// Services
 	syncService     *syncpkg.SyncService
+	journal         *syncpkg.Journal // Events not processed yet, replayed on start
 	indexService    *indexing.IndexService
 	searchService   *search.SearchService
 	explorerService *explorer.ExplorerService
 	dbService       *db.DBService
 
 	// Event processing
-	reconService *recon.ReconciliationService
-	workers      []*Worker
-	sseManager   *sse.Manager
+	reconService  *recon.ReconciliationService
+	driftInterval time.Duration // How often drift between disk, DB and index is checked
+	workers       []*Worker
+	sseManager    *sse.Manager
 
 	// State
 	status       VaultStatus
@@ -81,6 +89,7 @@ type Vault struct {
 	eventRouter  *sync.WaitGroup
 	recentOps    []FileOperation // Last 10 operations
 	maxRecentOps int
+	syncConflict *syncpkg.SyncConflict // Unresolved merge conflict from the sync backend
 }
 
 // VaultMetrics provides vault status and metrics
@@ -91,6 +100,9 @@ type VaultMetrics struct {
 	Uptime           time.Duration
 	IndexedFiles     uint64
 	RecentOperations []FileOperation
+	SyncConflict     *syncpkg.SyncConflict // Set while remote changes cannot be merged
+	Reconciliation   recon.ReconciliationMetrics
+	Journal          syncpkg.JournalStats // Zero when the journal could not be opened
 }
 
 // NewVault creates a new vault instance with all services
@@ -109,6 +121,12 @@ func NewVault(ctx context.Context, cfg *config.VaultConfig) (*Vault, error) {
 		return nil, fmt.Errorf("failed to determine vault path: %w", err)
 	}
 
+	// Create the storage all file reads and writes go through
+	store, err := storage.New(&cfg.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault storage: %w", err)
+	}
+
 	// Create cancellable context
 	vaultCtx, cancel := context.WithCancel(ctx)
 
@@ -117,6 +135,7 @@ func NewVault(ctx context.Context, cfg *config.VaultConfig) (*Vault, error) {
 		cancel:       cancel,
 		config:       cfg,
 		vaultPath:    vaultPath,
+		storage:      store,
 		status:       VaultStatusInitializing,
 		stopChan:     make(chan struct{}),
 		eventRouter:  &sync.WaitGroup{},
@@ -139,17 +158,29 @@ func NewVault(ctx context.Context, cfg *config.VaultConfig) (*Vault, error) {
 	return vault, nil
 }
 
+// DBFilePath returns the path of a vault's SQLite database
+func DBFilePath(cfg *config.VaultConfig) string {
+	return fmt.Sprintf("%s/vault_%s.db", cfg.DBPath, cfg.ID)
+}
+
 // initializeServices creates all vault services
 func (v *Vault) initializeServices() error {
 	var err error
 
-	dbPath := fmt.Sprintf("%s/vault_%s.db", v.config.DBPath, v.config.ID)
+	dbPath := DBFilePath(v.config)
 	// Create and start db service
 	v.dbService, err = db.NewDBService(v.ctx, &dbPath)
 	if err != nil {
 		return fmt.Errorf("failed to create db service: %w", err)
 	}
 
+	// Load ignore rules shared by sync, indexing and the explorer
+	v.ignore = ignore.NewRules(v.config.Ignore)
+	v.ignore.SetFileTypes(v.config.FileTypes)
+	if err := v.ignore.Reload(v.ctx, v.storage); err != nil {
+		logger.WithError(err).WithField("vault_id", v.config.ID).Warn("Failed to load ignore file, using configured patterns only")
+	}
+
 	// Create sync service
 	v.syncService, err = syncpkg.NewSyncService(v.ctx, v.config.ID, &v.config.Storage)
 	if err != nil {
@@ -157,22 +188,31 @@ func (v *Vault) initializeServices() error {
 	}
 
 	// Create index service
-	v.indexService, err = indexing.NewIndexService(v.ctx, v.config, v.vaultPath)
+	v.indexService, err = indexing.NewIndexServiceWithStorage(v.ctx, v.config, v.vaultPath, v.storage)
 	if err != nil {
 		return fmt.Errorf("failed to create index service: %w", err)
 	}
+	v.indexService.SetIgnoreRules(v.ignore)
+	v.indexService.SetFileEntries(v.dbService)
 
 	// Create search service (it will be started after index is ready)
 	v.searchService = search.NewSearchService(v.ctx, v.config.ID, v.indexService.GetIndex())
 
+	// The sqlite_fts engine keeps notes in the vault database instead of a bleve index
+	if v.config.Search.Engine == config.SearchEngineSQLiteFTS {
+		v.indexService.SetNoteStore(v.dbService)
+		v.searchService.SetNoteSearcher(v.dbService)
+	}
+
 	// Register search service to receive index update notifications
 	v.indexService.RegisterIndexNotifier(v.searchService)
 
 	// Create explorer service
-	v.explorerService, err = explorer.NewExplorerService(v.ctx, v.config.ID, v.vaultPath, v.dbService)
+	v.explorerService, err = explorer.NewExplorerServiceWithStorage(v.ctx, v.config.ID, v.vaultPath, v.storage, v.dbService)
 	if err != nil {
 		return fmt.Errorf("failed to create explorer service: %w", err)
 	}
+	v.explorerService.SetIgnoreRules(v.ignore)
 
 	// Create workers - reconciliation service will be created after sync service
 	const numWorkers = 2
@@ -195,6 +235,13 @@ func (v *Vault) Start() error {
 		return fmt.Errorf("failed to start db service: %w", err)
 	}
 
+	if v.config.Search.Engine == config.SearchEngineSQLiteFTS {
+		if err := v.dbService.EnableNoteSearch(); err != nil {
+			v.setStatus(VaultStatusError)
+			return fmt.Errorf("failed to enable sqlite_fts search: %w", err)
+		}
+	}
+
 	// Start index service
 	if err := v.indexService.Start(); err != nil {
 		v.setStatus(VaultStatusError)
@@ -210,6 +257,24 @@ func (v *Vault) Start() error {
 		return fmt.Errorf("failed to start explorer service: %w", err)
 	}
 
+	// Route merge conflicts (git storage) to vault status and the DLQ
+	v.syncService.SetConflictHandler(v.handleSyncConflict)
+
+	// Skip ignored paths and rebuild when the ignore file changes
+	v.syncService.SetIgnoreRules(v.ignore, v.handleIgnoreFileChange)
+
+	// Journal events until a worker has processed them, so nothing is lost on a crash
+	journalPath := fmt.Sprintf("%s/vault_%s.journal", v.config.DBPath, v.config.ID)
+	journal, err := syncpkg.OpenJournal(journalPath)
+	if err != nil {
+		logger.WithError(err).WithField("vault_id", v.config.ID).Warn("Failed to open event journal, events will not survive a restart")
+	} else {
+		v.mu.Lock()
+		v.journal = journal
+		v.mu.Unlock()
+		v.syncService.SetJournal(journal)
+	}
+
 	// Start sync service
 	if err := v.syncService.Start(); err != nil {
 		v.setStatus(VaultStatusError)
@@ -239,6 +304,12 @@ func (v *Vault) Start() error {
 
 	// Set sync service reference for retrying events
 	v.reconService.SetSyncService(v.syncService)
+	v.reconService.SetJournal(journal)
+
+	// Periodically re-process only the files that drifted out of sync
+	v.reconService.SetStorage(v.storage, v.vaultPath)
+	v.reconService.SetIgnoreRules(v.ignore)
+	v.reconService.SetDriftInterval(v.driftInterval)
 
 	// Start reconciliation service
 	v.reconService.Start()
@@ -257,12 +328,152 @@ func (v *Vault) Start() error {
 			v.explorerService,
 			v.reconService,
 		)
+		v.workers[i].SetStorage(v.storage)
+		v.workers[i].SetJournal(journal)
+		v.workers[i].SetRevisionRetention(db.RevisionRetention{
+			Keep:   v.config.Revisions.Keep,
+			MaxAge: v.config.Revisions.MaxAge,
+		})
 		v.workers[i].Start(syncEvents)
 	}
 
+	// Re-queue whatever was left unprocessed by the last run
+	if journal != nil {
+		v.eventRouter.Add(1)
+		go v.replayJournal(journal)
+	}
+
+	// Re-read existing notes when the DB gained tables filled from their contents
+	if v.dbService.NeedsBackfill() {
+		v.eventRouter.Add(1)
+		go v.backfill()
+	}
+
+	if days := v.config.Trash.PurgeAfterDays; days > 0 {
+		v.eventRouter.Add(1)
+		go v.runTrashPurge(time.Duration(days) * 24 * time.Hour)
+	}
+
 	return nil
 }
 
+// replayJournal re-queues journaled events that were not processed before the
+// last shutdown. Each event is checked against the vault first, since files
+// may have changed while the server was down; failed events go back to the DLQ.
+func (v *Vault) replayJournal(journal *syncpkg.Journal) {
+	defer v.eventRouter.Done()
+
+	entries := journal.Pending()
+	if len(entries) == 0 {
+		return
+	}
+	logger.WithFields(map[string]interface{}{
+		"vault_id": v.config.ID,
+		"count":    len(entries),
+	}).Info("Replaying journaled events")
+
+	for _, entry := range entries {
+		event := v.currentEvent(entry.Event)
+		// The DB may have been updated before the server stopped, leaving the hash current
+		event.Force = true
+		if entry.InDLQ && v.reconService.RestoreDLQ(event) {
+			continue
+		}
+		if !v.injectEvent(event) {
+			return
+		}
+	}
+}
+
+// backfill queues a FileModified event for every tracked markdown file so the
+// workers fill DB tables that were added since the files were last processed
+func (v *Vault) backfill() {
+	defer v.eventRouter.Done()
+
+	entries, err := v.dbService.GetActiveFileEntries()
+	if err != nil {
+		logger.WithError(err).WithField("vault_id", v.config.ID).Warn("Failed to list files to backfill")
+		return
+	}
+	logger.WithFields(map[string]interface{}{
+		"vault_id": v.config.ID,
+		"count":    len(entries),
+	}).Info("Backfilling file metadata")
+
+	for _, entry := range entries {
+		if entry.IsDir || !indexing.IsIndexable(entry.Path) {
+			continue
+		}
+		event := syncpkg.FileChangeEvent{
+			VaultID:   v.config.ID,
+			Path:      filepath.Join(v.vaultPath, filepath.FromSlash(entry.Path)),
+			EventType: syncpkg.FileModified,
+			Timestamp: time.Now(),
+			Force:     true,
+		}
+		if !v.injectEvent(event) {
+			return
+		}
+	}
+}
+
+// injectEvent queues an event for the workers, waiting while the sync channel is full.
+// Returns false if the vault stopped first.
+func (v *Vault) injectEvent(event syncpkg.FileChangeEvent) bool {
+	for !v.syncService.InjectEvent(event) {
+		select {
+		case <-v.ctx.Done():
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return true
+}
+
+// queueEvent queues an event for the workers without waiting, logging it if
+// the sync channel is full
+func (v *Vault) queueEvent(event syncpkg.FileChangeEvent) {
+	if !v.syncService.InjectEvent(event) {
+		logger.WithFields(map[string]interface{}{
+			"vault_id":   v.config.ID,
+			"path":       event.Path,
+			"event_type": event.EventType.String(),
+		}).Warn("Sync channel full, dropped event")
+	}
+}
+
+// currentEvent adjusts a journaled event to the vault as it is now: a deleted
+// file that is back is modified, and a changed file that is gone is deleted
+func (v *Vault) currentEvent(event syncpkg.FileChangeEvent) syncpkg.FileChangeEvent {
+	exists := func(absPath string) bool {
+		relPath, err := filepath.Rel(v.vaultPath, absPath)
+		if err != nil {
+			return true
+		}
+		_, err = v.storage.Stat(v.ctx, filepath.ToSlash(relPath))
+		return err == nil
+	}
+
+	switch {
+	case event.EventType == syncpkg.FileDeleted:
+		if exists(event.Path) {
+			event.EventType = syncpkg.FileModified
+		}
+	case exists(event.Path):
+	case event.EventType == syncpkg.FileMoved:
+		// The move was undone or the file is gone; either way the old path is what matters
+		event.Path = event.OldPath
+		event.OldPath = ""
+		event.EventType = syncpkg.FileDeleted
+		if exists(event.Path) {
+			event.EventType = syncpkg.FileModified
+		}
+	default:
+		event.EventType = syncpkg.FileDeleted
+	}
+	return event
+}
+
 // Resume resumes a stopped vault
 func (v *Vault) Resume() error {
 	v.mu.RLock()
@@ -398,6 +609,16 @@ func (v *Vault) Stop() error {
 		v.dbService.Stop()
 	}
 
+	// Workers are done; whatever is still journaled is replayed on the next start
+	v.mu.Lock()
+	if v.journal != nil {
+		if err := v.journal.Close(); err != nil {
+			logger.WithError(err).WithField("vault_id", v.config.ID).Warn("Failed to close event journal")
+		}
+		v.journal = nil
+	}
+	v.mu.Unlock()
+
 	v.setStatus(VaultStatusStopped)
 	return nil
 }
@@ -418,18 +639,22 @@ func (v *Vault) GetMetrics() VaultMetrics {
 	}
 
 	if v.indexService != nil {
-		index := v.indexService.GetIndex()
-		if index != nil {
-			count, err := index.DocCount()
-			if err == nil {
-				metrics.IndexedFiles = count
-			}
+		count, err := v.indexService.DocCount()
+		if err == nil {
+			metrics.IndexedFiles = count
 		}
 	}
 
 	ops := make([]FileOperation, len(v.recentOps))
 	copy(ops, v.recentOps)
 	metrics.RecentOperations = ops
+	metrics.SyncConflict = v.syncConflict
+	if v.reconService != nil {
+		metrics.Reconciliation = v.reconService.GetMetrics()
+	}
+	if v.journal != nil {
+		metrics.Journal = v.journal.Stats()
+	}
 
 	return metrics
 }
@@ -462,6 +687,13 @@ func (v *Vault) GetExplorerService() *explorer.ExplorerService {
 	return v.explorerService
 }
 
+// GetStorage returns the storage the vault's files are read from and written to
+func (v *Vault) GetStorage() storage.VaultStorage {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.storage
+}
+
 // GetDBService returns the database service
 func (v *Vault) GetDBService() *db.DBService {
 	v.mu.RLock()
@@ -487,6 +719,8 @@ func (v *Vault) trackFileOperation(event syncpkg.FileChangeEvent) {
 		op = "modify"
 	case syncpkg.FileDeleted:
 		op = "delete"
+	case syncpkg.FileMoved:
+		op = "move"
 	}
 
 	v.mu.Lock()
@@ -511,7 +745,56 @@ func (v *Vault) updateDatabase(event syncpkg.FileChangeEvent) (string, error) {
 		return "", fmt.Errorf("db service not available")
 	}
 
-	return v.dbService.PerformDatabaseUpdate(v.vaultPath, event)
+	return v.dbService.PerformDatabaseUpdate(v.storage, v.vaultPath, event)
+}
+
+// handleIgnoreFileChange reloads the ignore rules after the vault's ignore file changed
+// and reindexes so newly ignored files disappear and newly included files show up.
+func (v *Vault) handleIgnoreFileChange() {
+	if err := v.ignore.Reload(v.ctx, v.storage); err != nil {
+		logger.WithError(err).WithField("vault_id", v.config.ID).Warn("Failed to reload ignore file")
+		return
+	}
+
+	logger.WithField("vault_id", v.config.ID).Info("Ignore file changed, reindexing vault")
+	v.TriggerReindex()
+}
+
+// handleSyncConflict records a merge conflict reported by the sync backend and
+// sends the conflicting files to the DLQ so they are reprocessed from the working tree.
+// A nil conflict means the backend is back in sync.
+func (v *Vault) handleSyncConflict(conflict *syncpkg.SyncConflict) {
+	v.mu.Lock()
+	previous := v.syncConflict
+	v.syncConflict = conflict
+	reconService := v.reconService
+	v.mu.Unlock()
+
+	if conflict == nil || reconService == nil {
+		return
+	}
+
+	// The backend reports the same conflict on every pull until it is resolved
+	if previous != nil && strings.Join(previous.Paths, "\n") == strings.Join(conflict.Paths, "\n") {
+		return
+	}
+
+	for _, path := range conflict.Paths {
+		reconService.SendToDLQ(syncpkg.FileChangeEvent{
+			VaultID:   v.config.ID,
+			Path:      path,
+			EventType: syncpkg.FileModified,
+			Timestamp: conflict.Timestamp,
+		})
+	}
+}
+
+// SetDriftCheckInterval sets how often the vault is compared with its database and
+// index to pick up missed changes. Zero disables the check. Must be called before Start.
+func (v *Vault) SetDriftCheckInterval(interval time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.driftInterval = interval
 }
 
 // TriggerReindex triggers a full reindex of the vault via the reconciliation service
@@ -591,7 +874,7 @@ func (v *Vault) setStatus(status VaultStatus) {
 	v.mu.Unlock()
 }
 
-// getVaultPath determines the local path for the vault based on storage type
+// getVaultPath determines the root path event paths are reported under for the vault
 func getVaultPath(cfg *config.VaultConfig) (string, error) {
 	switch cfg.Storage.GetType() {
 	case config.LocalStorage:
@@ -601,14 +884,17 @@ func getVaultPath(cfg *config.VaultConfig) (string, error) {
 		}
 		return localCfg.Path, nil
 
-	case config.S3Storage:
-		// For S3, sync service will download to local cache
-		// For now, return a placeholder - sync service handles this
-		return fmt.Sprintf("/tmp/vault-cache/%s", cfg.ID), fmt.Errorf("S3 storage not yet implemented")
+	case config.GitStorage:
+		gitCfg := cfg.Storage.GetGitConfig()
+		if gitCfg == nil || gitCfg.Path == "" {
+			return "", fmt.Errorf("git storage config missing or path empty")
+		}
+		// Events are reported under the absolute working tree path
+		return filepath.Abs(gitCfg.Path)
 
-	case config.MinIOStorage:
-		// For MinIO, sync service will download to local cache
-		return fmt.Sprintf("/tmp/vault-cache/%s", cfg.ID), fmt.Errorf("MinIO storage not yet implemented")
+	case config.S3Storage, config.MinIOStorage:
+		// Remote vaults are read through VaultStorage; the path only roots sync event paths
+		return syncpkg.RemoteVaultRoot(cfg.ID), nil
 
 	default:
 		return "", fmt.Errorf("unknown storage type: %s", cfg.Storage.GetType())
@@ -2,10 +2,14 @@ package vault
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/gitrepo/gitrepotest"
+	"github.com/susamn/obsidian-web/internal/storage"
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
 )
 
 // TestNewVault_ValidConfig tests creating a vault with valid configuration
@@ -127,22 +131,20 @@ func TestNewVault_InvalidStorage(t *testing.T) {
 			},
 		},
 		{
-			name: "S3NotImplemented",
+			name: "S3MissingBucket",
 			storage: config.StorageConfig{
 				Type: "s3",
 				S3: &config.S3StorageConfig{
-					Bucket: "test-bucket",
 					Region: "us-east-1",
 				},
 			},
 		},
 		{
-			name: "MinIONotImplemented",
+			name: "MinIOMissingEndpoint",
 			storage: config.StorageConfig{
 				Type: "minio",
 				MinIO: &config.MinIOStorageConfig{
-					Endpoint: "localhost:9000",
-					Bucket:   "test-bucket",
+					Bucket: "test-bucket",
 				},
 			},
 		},
@@ -530,7 +532,7 @@ func TestGetVaultPath(t *testing.T) {
 			expectError: true,
 		},
 		{
-			name: "S3Storage_NotImplemented",
+			name: "S3Storage",
 			cfg: &config.VaultConfig{
 				ID: "s3-vault",
 				Storage: config.StorageConfig{
@@ -540,11 +542,11 @@ func TestGetVaultPath(t *testing.T) {
 					},
 				},
 			},
-			expectError:  true,
+			expectError:  false,
 			expectedPath: "/tmp/vault-cache/s3-vault",
 		},
 		{
-			name: "MinIOStorage_NotImplemented",
+			name: "MinIOStorage",
 			cfg: &config.VaultConfig{
 				ID: "minio-vault",
 				Storage: config.StorageConfig{
@@ -554,9 +556,23 @@ func TestGetVaultPath(t *testing.T) {
 					},
 				},
 			},
-			expectError:  true,
+			expectError:  false,
 			expectedPath: "/tmp/vault-cache/minio-vault",
 		},
+		{
+			name: "GitStorage",
+			cfg: &config.VaultConfig{
+				ID: "git-vault",
+				Storage: config.StorageConfig{
+					Type: "git",
+					Git: &config.GitStorageConfig{
+						Path: "/vault/repo",
+					},
+				},
+			},
+			expectError:  false,
+			expectedPath: "/vault/repo",
+		},
 	}
 
 	for _, tt := range tests {
@@ -684,3 +700,45 @@ func TestVault_ConcurrentAccess(t *testing.T) {
 		t.Fatalf("Failed to stop vault: %v", err)
 	}
 }
+
+// TestVault_GitStorage tests a git-backed vault clones its repository and reports sync conflicts
+func TestVault_GitStorage(t *testing.T) {
+	remote := gitrepotest.NewRemote(t, map[string]string{"note.md": "# Note\n"})
+	repoDir := filepath.Join(t.TempDir(), "repo")
+
+	cfg := &config.VaultConfig{
+		ID:        "git-vault",
+		Name:      "Git Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir(),
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type: "git",
+			Git:  &config.GitStorageConfig{Path: repoDir, URL: remote.URL},
+		},
+	}
+
+	v, err := NewVault(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	defer v.Stop()
+
+	if _, ok := v.GetStorage().(*storage.GitStorage); !ok {
+		t.Errorf("Expected git storage, got %T", v.GetStorage())
+	}
+	if data, err := v.GetStorage().Read(context.Background(), "note.md"); err != nil || string(data) != "# Note\n" {
+		t.Errorf("Expected cloned note, got %q, %v", data, err)
+	}
+
+	conflict := &syncpkg.SyncConflict{VaultID: "git-vault", Paths: []string{filepath.Join(repoDir, "note.md")}}
+	v.handleSyncConflict(conflict)
+	if got := v.GetMetrics().SyncConflict; got != conflict {
+		t.Errorf("Expected conflict in metrics, got %+v", got)
+	}
+
+	v.handleSyncConflict(nil)
+	if got := v.GetMetrics().SyncConflict; got != nil {
+		t.Errorf("Expected conflict to clear, got %+v", got)
+	}
+}
@@ -14,7 +14,9 @@ import (
 	"github.com/susamn/obsidian-web/internal/logger"
 	"github.com/susamn/obsidian-web/internal/recon"
 	"github.com/susamn/obsidian-web/internal/sse"
+	"github.com/susamn/obsidian-web/internal/storage"
 	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+	"gopkg.in/yaml.v3"
 )
 
 // Worker processes file events directly from sync channel with DB-first approach
@@ -27,15 +29,19 @@ type Worker struct {
 	wg        *sync.WaitGroup
 
 	// Services
+	storage         storage.VaultStorage
 	dbService       *db.DBService
 	indexService    *indexing.IndexService
 	explorerService *explorer.ExplorerService
 	reconService    *recon.ReconciliationService
 	sseManager      *sse.Manager
+	journal         *syncpkg.Journal
+	revisions       db.RevisionRetention
 
 	// Metrics
 	processedCount int64
 	failedCount    int64
+	unchangedCount int64
 
 	// Configuration
 	maxRetries int
@@ -60,6 +66,7 @@ func NewWorker(
 		vaultPath:       vaultPath,
 		ctx:             ctx,
 		wg:              wg,
+		storage:         storage.NewLocalStorage(vaultPath),
 		dbService:       dbService,
 		indexService:    indexService,
 		explorerService: explorerService,
@@ -69,6 +76,21 @@ func NewWorker(
 	}
 }
 
+// SetStorage sets the storage file details are read through (defaults to the local vault directory)
+func (w *Worker) SetStorage(store storage.VaultStorage) {
+	w.storage = store
+}
+
+// SetJournal acknowledges processed events in j so they are not replayed
+func (w *Worker) SetJournal(j *syncpkg.Journal) {
+	w.journal = j
+}
+
+// SetRevisionRetention sets how many note revisions are kept, and for how long
+func (w *Worker) SetRevisionRetention(retention db.RevisionRetention) {
+	w.revisions = retention
+}
+
 // Start starts the worker processing loop consuming from shared sync channel
 func (w *Worker) Start(syncEvents <-chan syncpkg.FileChangeEvent) {
 	w.wg.Add(1)
@@ -109,7 +131,7 @@ func (w *Worker) run(syncEvents <-chan syncpkg.FileChangeEvent) {
 // processEvent handles a single file event
 func (w *Worker) processEvent(event syncpkg.FileChangeEvent) {
 	// Step 1: Update DB with retry logic and get file ID
-	fileID, err := w.updateDBWithRetry(event)
+	result, err := w.updateDBWithRetry(event)
 	if err != nil {
 		// DB update failed after retries, send to DLQ
 		logger.WithFields(map[string]interface{}{
@@ -125,11 +147,24 @@ func (w *Worker) processEvent(event syncpkg.FileChangeEvent) {
 		w.reconService.SendToDLQ(event)
 		return
 	}
+	fileID := result.FileID
+
+	// Same bytes as last time (e.g. a save without edits): the note data, explorer,
+	// index and clients are already current, so skip straight to the journal
+	if result.Unchanged {
+		atomic.AddInt64(&w.unchangedCount, 1)
+		w.ackEvent(event)
+		atomic.AddInt64(&w.processedCount, 1)
+		return
+	}
+
+	// Step 2: Keep the file's tags, links and properties in the DB in step with its content (best effort)
+	w.updateNoteData(event, fileID)
 
-	// Step 2: Update Explorer cache (synchronous)
+	// Step 3: Update Explorer cache (synchronous)
 	w.explorerService.InvalidateCacheSync(event)
 
-	// Step 3: Update Index (best effort, synchronous) with file ID
+	// Step 4: Update Index (best effort, synchronous) with file ID
 	// Check if index service is ready first (avoids race condition during startup)
 	indexStatus := w.indexService.GetStatus()
 	if indexStatus != indexing.StatusReady {
@@ -141,6 +176,10 @@ func (w *Worker) processEvent(event syncpkg.FileChangeEvent) {
 	} else {
 		switch event.EventType {
 		case syncpkg.FileCreated, syncpkg.FileModified:
+			// Only markdown goes to the index; attachments stop at the DB and explorer
+			if !indexing.IsIndexable(event.Path) {
+				break
+			}
 			if err := w.indexService.ReIndexSync(event.Path, fileID); err != nil {
 				logger.WithError(err).WithFields(map[string]interface{}{
 					"worker_id": w.id,
@@ -149,6 +188,9 @@ func (w *Worker) processEvent(event syncpkg.FileChangeEvent) {
 				}).Warn("Failed to update index")
 			}
 		case syncpkg.FileDeleted:
+			if !indexing.IsIndexable(event.Path) {
+				break
+			}
 			if err := w.indexService.DeleteFromIndexSync(event.Path, fileID); err != nil {
 				logger.WithError(err).WithFields(map[string]interface{}{
 					"worker_id": w.id,
@@ -156,33 +198,199 @@ func (w *Worker) processEvent(event syncpkg.FileChangeEvent) {
 					"file_id":   fileID,
 				}).Warn("Failed to delete from index")
 			}
+		case syncpkg.FileMoved:
+			w.reIndexMoved(event, fileID)
 		}
 	}
 
-	// Step 4: Queue for SSE batching
+	// Step 5: Queue for SSE batching
 	w.queueSSEEvent(event)
 
+	// Step 6: Done with the event; drop it from the journal
+	w.ackEvent(event)
+
 	atomic.AddInt64(&w.processedCount, 1)
 }
 
+// ackEvent drops a processed event from the journal
+func (w *Worker) ackEvent(event syncpkg.FileChangeEvent) {
+	if w.journal != nil && event.Seq != 0 {
+		if err := w.journal.Ack(event.Seq); err != nil {
+			logger.WithError(err).WithField("path", event.Path).Warn("Failed to acknowledge journaled event")
+		}
+	}
+}
+
+// updateNoteData keeps the tags, links, properties and revisions stored for a
+// file in step with its content. Moves keep the file ID, so its note data carries over.
+func (w *Worker) updateNoteData(event syncpkg.FileChangeEvent, fileID string) {
+	if fileID == "" {
+		return
+	}
+
+	var err error
+	switch event.EventType {
+	case syncpkg.FileCreated, syncpkg.FileModified:
+		if indexing.IsIndexable(event.Path) {
+			err = w.storeNoteData(event.Path, fileID)
+		}
+		// Links written before this file existed, or naming an alias it gained, now resolve to it
+		if err == nil {
+			err = w.dbService.ResolveLinksTo(fileID)
+		}
+	case syncpkg.FileMoved:
+		err = w.dbService.ResolveLinksTo(fileID)
+	case syncpkg.FileDeleted:
+		if err = w.dbService.DeleteFileTags(fileID); err == nil {
+			err = w.dbService.DeleteFileLinks(fileID)
+		}
+		if err == nil {
+			err = w.dbService.DeleteFileProperties(fileID)
+		}
+	}
+
+	if err != nil {
+		logger.WithError(err).WithFields(map[string]interface{}{
+			"worker_id": w.id,
+			"path":      event.Path,
+			"file_id":   fileID,
+		}).Warn("Failed to update file tags, links and properties")
+	}
+}
+
+// storeNoteData saves a markdown file as a revision, then parses it and saves its tags, links and frontmatter properties
+func (w *Worker) storeNoteData(path, fileID string) error {
+	relPath, err := filepath.Rel(w.vaultPath, path)
+	if err != nil {
+		return err
+	}
+	content, err := w.storage.Read(w.ctx, filepath.ToSlash(relPath))
+	if err != nil {
+		return err
+	}
+	// Kept first, so a note that fails to parse still has its history
+	if _, err := w.dbService.AddRevision(fileID, content, w.revisions); err != nil {
+		return err
+	}
+	doc, err := indexing.ParseMarkdown(content, relPath)
+	if err != nil {
+		return err
+	}
+	if err := w.dbService.SetFileTags(fileID, doc.Tags); err != nil {
+		return err
+	}
+
+	parsed := indexing.ExtractLinks(doc.Content)
+	links := make([]db.Link, 0, len(parsed))
+	for _, l := range parsed {
+		links = append(links, db.Link{
+			RawTarget: l.Raw,
+			Target:    l.Target,
+			Line:      l.Line,
+			Alias:     l.Alias,
+			Heading:   l.Heading,
+			IsEmbed:   l.Embed,
+			Context:   l.Context,
+		})
+	}
+	if err := w.dbService.SetFileLinks(fileID, links); err != nil {
+		return err
+	}
+
+	// Invalid frontmatter leaves the note without properties
+	var frontmatter map[string]any
+	parseErr := yaml.Unmarshal([]byte(doc.Metadata), &frontmatter)
+	if parseErr != nil {
+		frontmatter = nil
+	}
+	if err := w.dbService.SetFileProperties(fileID, frontmatter); err != nil {
+		return err
+	}
+	if parseErr != nil {
+		return fmt.Errorf("parse frontmatter: %w", parseErr)
+	}
+	return nil
+}
+
+// reIndexMoved points the index at a moved file, or at every file below a moved directory.
+// Documents keep their file IDs; anything indexed under the old relative path is dropped.
+func (w *Worker) reIndexMoved(event syncpkg.FileChangeEvent, fileID string) {
+	relPath, err := filepath.Rel(w.vaultPath, event.Path)
+	if err != nil {
+		return
+	}
+
+	info, err := w.storage.Stat(w.ctx, filepath.ToSlash(relPath))
+	if err != nil {
+		logger.WithError(err).WithFields(map[string]interface{}{
+			"worker_id": w.id,
+			"path":      event.Path,
+		}).Warn("Moved file is gone, skipping index update")
+		return
+	}
+
+	if !info.IsDir {
+		w.reIndexMovedFile(event.OldPath, event.Path, fileID)
+		return
+	}
+
+	_ = storage.Walk(w.ctx, w.storage, info.Path, func(child storage.FileInfo) error {
+		if child.IsDir {
+			return nil
+		}
+
+		newPath := filepath.Join(w.vaultPath, filepath.FromSlash(child.Path))
+		childRel, err := filepath.Rel(event.Path, newPath)
+		if err != nil {
+			return nil
+		}
+
+		var childID string
+		if w.dbService != nil {
+			if entry, err := w.dbService.GetFileEntryByPath(filepath.FromSlash(child.Path)); err == nil && entry != nil {
+				childID = entry.ID
+			}
+		}
+		w.reIndexMovedFile(filepath.Join(event.OldPath, childRel), newPath, childID)
+		return nil
+	})
+}
+
+// reIndexMovedFile re-indexes a single file at its new path
+func (w *Worker) reIndexMovedFile(oldPath, newPath, fileID string) {
+	if err := w.indexService.DeleteFromIndexSync(oldPath, ""); err != nil {
+		logger.WithError(err).WithFields(map[string]interface{}{
+			"worker_id": w.id,
+			"path":      oldPath,
+		}).Debug("No path-keyed document to drop for moved file")
+	}
+	if err := w.indexService.ReIndexSync(newPath, fileID); err != nil {
+		logger.WithError(err).WithFields(map[string]interface{}{
+			"worker_id": w.id,
+			"path":      newPath,
+			"file_id":   fileID,
+		}).Warn("Failed to update index for moved file")
+	}
+}
+
 // updateDBWithRetry attempts to update the database with retry logic
-// Returns the file ID and error
-func (w *Worker) updateDBWithRetry(event syncpkg.FileChangeEvent) (string, error) {
+// Returns the update result and error
+func (w *Worker) updateDBWithRetry(event syncpkg.FileChangeEvent) (db.UpdateResult, error) {
 	var lastErr error
 
 	for attempt := 0; attempt <= w.maxRetries; attempt++ {
 		// Perform the DB update based on event type
-		fileID, err := w.updateDatabase(event)
+		result, err := w.updateDatabase(event)
 		if err == nil {
 			if attempt > 0 {
 				logger.WithFields(map[string]interface{}{
 					"worker_id": w.id,
 					"path":      event.Path,
-					"file_id":   fileID,
+					"file_id":   result.FileID,
 					"attempt":   attempt + 1,
 				}).Info("DB update succeeded after retry")
 			}
-			return fileID, nil
+			return result, nil
 		}
 
 		lastErr = err
@@ -201,22 +409,22 @@ func (w *Worker) updateDBWithRetry(event syncpkg.FileChangeEvent) (string, error
 			case <-time.After(w.retryDelay):
 				// Continue to next retry
 			case <-w.ctx.Done():
-				return "", fmt.Errorf("context cancelled during retry: %w", err)
+				return db.UpdateResult{}, fmt.Errorf("context cancelled during retry: %w", err)
 			}
 		}
 	}
 
-	return "", fmt.Errorf("DB update failed after %d attempts: %w", w.maxRetries+1, lastErr)
+	return db.UpdateResult{}, fmt.Errorf("DB update failed after %d attempts: %w", w.maxRetries+1, lastErr)
 }
 
 // updateDatabase performs the actual database update
-// Returns the file ID and error
-func (w *Worker) updateDatabase(event syncpkg.FileChangeEvent) (string, error) {
+// Returns the update result and error
+func (w *Worker) updateDatabase(event syncpkg.FileChangeEvent) (db.UpdateResult, error) {
 	if w.dbService == nil {
-		return "", fmt.Errorf("db service not initialized")
+		return db.UpdateResult{}, fmt.Errorf("db service not initialized")
 	}
 
-	return w.dbService.PerformDatabaseUpdate(w.vaultPath, event)
+	return w.dbService.ApplyFileEvent(w.storage, w.vaultPath, event)
 }
 
 // queueSSEEvent queues an SSE event
@@ -234,6 +442,8 @@ func (w *Worker) queueSSEEvent(event syncpkg.FileChangeEvent) {
 		action = sse.ActionCreate // Modified is treated as create for frontend
 	case syncpkg.FileDeleted:
 		action = sse.ActionDelete
+	case syncpkg.FileMoved:
+		action = sse.ActionMove
 	default:
 		return
 	}
@@ -256,6 +466,15 @@ func (w *Worker) queueSSEEvent(event syncpkg.FileChangeEvent) {
 		}
 	}
 
+	if action == sse.ActionMove {
+		oldRelPath, err := filepath.Rel(w.vaultPath, event.OldPath)
+		if err != nil {
+			return
+		}
+		w.sseManager.QueueFileMove(w.vaultID, fileID, oldRelPath, relPath)
+		return
+	}
+
 	// Queue the file change in SSE manager
 	w.sseManager.QueueFileChange(w.vaultID, fileID, relPath, action)
 }
@@ -266,6 +485,7 @@ func (w *Worker) GetMetrics() WorkerMetrics {
 		WorkerID:       w.id,
 		ProcessedCount: atomic.LoadInt64(&w.processedCount),
 		FailedCount:    atomic.LoadInt64(&w.failedCount),
+		UnchangedCount: atomic.LoadInt64(&w.unchangedCount),
 	}
 }
 
@@ -274,4 +494,5 @@ type WorkerMetrics struct {
 	WorkerID       int
 	ProcessedCount int64
 	FailedCount    int64
+	UnchangedCount int64 // Events skipped after the DB update because the content hash matched
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/susamn/obsidian-web/internal/config"
 	"github.com/susamn/obsidian-web/internal/db"
 	"github.com/susamn/obsidian-web/internal/explorer"
+	"github.com/susamn/obsidian-web/internal/search"
 	"github.com/susamn/obsidian-web/internal/vault"
 )
 
@@ -112,9 +113,7 @@ func TestFileStatusIntegration(t *testing.T) {
 	time.Sleep(3 * time.Second)
 
 	// Force reindex to ensure everything is processed
-	if err := v.ForceReindex(); err != nil {
-		t.Fatalf("Failed to force reindex: %v", err)
-	}
+	v.TriggerReindex()
 	time.Sleep(2 * time.Second)
 
 	// ==========================================
@@ -281,7 +280,7 @@ func TestFileStatusIntegration(t *testing.T) {
 	time.Sleep(1 * time.Second)
 
 	// Search for a term that appears in multiple files
-	results, err := searchService.SearchByText("notes")
+	results, err := searchService.SearchByText("notes", search.SearchOptions{})
 	if err != nil {
 		t.Errorf("Search failed: %v", err)
 	} else {
@@ -438,7 +437,7 @@ func TestFileStatusIntegration(t *testing.T) {
 	}
 
 	for _, term := range deletedTerms {
-		results, err := searchService.SearchByText(term)
+		results, err := searchService.SearchByText(term, search.SearchOptions{})
 		if err != nil {
 			t.Errorf("Search failed for term '%s': %v", term, err)
 			continue
@@ -2,22 +2,29 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"mime"
 	"net/http"
-	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/susamn/obsidian-web/internal/db"
 	"github.com/susamn/obsidian-web/internal/logger"
+	"github.com/susamn/obsidian-web/internal/storage"
+	"github.com/susamn/obsidian-web/internal/vault"
 )
 
 // FileResponse represents a file's content
 type FileResponse struct {
-	Path    string `json:"path"`
-	Content string `json:"content"`
-	Size    int64  `json:"size"`
+	Path        string `json:"path"`
+	Content     string `json:"content"`
+	Size        int64  `json:"size"`
+	ContentHash string `json:"content_hash,omitempty"` // SHA-256 of the content, also sent as the ETag
 }
 
 // COMMENTED OUT - UNUSED: Path-based file access (replaced by ID-based handleGetFileByID)
@@ -118,22 +125,22 @@ func (s *Server) handleGetRaw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build full file path
-	fullPath := s.buildVaultFilePath(v, filePath)
-
-	// Serve raw file
-	http.ServeFile(w, r, fullPath)
+	// Serve raw file from vault storage
+	s.serveVaultFile(w, r, v, filePath)
 }
 */
 
 // handleGetFileByID godoc
 // @Summary Get a file from a vault by node ID
 // @Description Get the content of a file from a vault using its node ID. Returns file content along with metadata including relative path (read-only).
+// @Description The SHA-256 of the content is returned as content_hash. The ETag covers the content, id, path and name; a matching If-None-Match gets a 304.
 // @Tags files
 // @Produce json
 // @Param vault path string true "Vault ID"
 // @Param id path string true "Node ID"
-// @Success 200 {object} object{content=string,path=string,id=string,name=string}
+// @Param If-None-Match header string false "ETag from an earlier response"
+// @Success 200 {object} object{content=string,path=string,id=string,name=string,content_hash=string}
+// @Success 304 "Content unchanged"
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 405 {object} ErrorResponse
@@ -170,15 +177,42 @@ func (s *Server) handleGetFileByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Hash what is served rather than trusting the stored hash, which lags behind the watcher
+	contentHash := db.ContentHash(content)
+	etag := fileETag(fileEntry, contentHash)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	// Return file content with metadata (path is read-only, never used as input)
 	writeSuccess(w, map[string]interface{}{
-		"content": string(content),
-		"path":    fileEntry.Path, // Relative path for UI navigation only
-		"id":      fileEntry.ID,
-		"name":    fileEntry.Name,
+		"content":      string(content),
+		"path":         fileEntry.Path, // Relative path for UI navigation only
+		"id":           fileEntry.ID,
+		"name":         fileEntry.Name,
+		"content_hash": contentHash,
 	})
 }
 
+// fileETag identifies a by-ID response: the content hash alone would let a
+// renamed or moved file answer 304 with its old path and name
+func fileETag(entry *db.FileEntry, contentHash string) string {
+	return `"` + db.ContentHash([]byte(strings.Join([]string{contentHash, entry.ID, entry.Path, entry.Name}, "\x00"))) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, ignoring weak prefixes
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // parseVaultPath extracts vault ID and file path from URL
 func (s *Server) parseVaultPath(urlPath, prefix string) (vaultID, filePath string, ok bool) {
 	// Remove prefix
@@ -204,65 +238,94 @@ func (s *Server) parseVaultPath(urlPath, prefix string) (vaultID, filePath strin
 	return vaultID, filePath, true
 }
 
-// buildVaultFilePath constructs the full filesystem path for a file
-func (s *Server) buildVaultFilePath(v interface{ VaultID() string }, filePath string) string {
-	// Get vault path from config
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	vaultID := v.VaultID()
-	for _, vaultCfg := range s.config.Vaults {
-		if vaultCfg.ID == vaultID {
-			localCfg := vaultCfg.Storage.GetLocalConfig()
-			if localCfg != nil {
-				return filepath.Join(localCfg.Path, filePath)
-			}
-		}
+// vaultStorage returns the storage a vault's files are read from and written to
+func (s *Server) vaultStorage(v *vault.Vault) (storage.VaultStorage, error) {
+	store := v.GetStorage()
+	if store == nil {
+		return nil, fmt.Errorf("vault storage not available")
 	}
-
-	return ""
+	return store, nil
 }
 
 // readVaultFile reads a file from vault and returns content and size
-func (s *Server) readVaultFile(v interface{ VaultID() string }, filePath string) (content string, size int64, err error) {
-	fullPath := s.buildVaultFilePath(v, filePath)
-	if fullPath == "" {
-		return "", 0, fmt.Errorf("vault path not found")
+func (s *Server) readVaultFile(v *vault.Vault, filePath string) (content string, size int64, err error) {
+	data, size, err := s.readVaultFileInBinary(v, filePath)
+	if err != nil {
+		return "", 0, err
 	}
 
-	// Check file exists
-	info, err := os.Stat(fullPath)
+	return string(data), size, nil
+}
+
+// readVaultFileInBinary reads a file from vault and returns its bytes and size
+func (s *Server) readVaultFileInBinary(v *vault.Vault, filePath string) ([]byte, int64, error) {
+	store, err := s.vaultStorage(v)
 	if err != nil {
-		return "", 0, err
+		return nil, 0, err
 	}
 
-	// Read file content
-	data, err := os.ReadFile(fullPath)
+	data, err := store.Read(s.ctx, filePath)
 	if err != nil {
-		return "", 0, err
+		return nil, 0, err
 	}
 
-	return string(data), info.Size(), nil
+	return data, int64(len(data)), nil
 }
 
-// readVaultFile reads a file from vault and returns content and size
-func (s *Server) readVaultFileInBinary(v interface{ VaultID() string }, filePath string) ([]byte, int64, error) {
-	fullPath := s.buildVaultFilePath(v, filePath)
-	if fullPath == "" {
-		return nil, 0, fmt.Errorf("vault path not found")
+// serveVaultFile writes a file from vault storage to the response, honouring range and conditional requests
+func (s *Server) serveVaultFile(w http.ResponseWriter, r *http.Request, v *vault.Vault, filePath string) {
+	store, err := s.vaultStorage(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	info, err := os.Stat(fullPath)
+	info, err := store.Stat(r.Context(), filePath)
+	if err == nil && info.IsDir {
+		err = fs.ErrNotExist
+	}
 	if err != nil {
-		return nil, 0, err
+		writeVaultFileError(w, err)
+		return
 	}
 
-	data, err := os.ReadFile(fullPath)
+	body, err := store.Open(r.Context(), filePath)
 	if err != nil {
-		return nil, 0, err
+		writeVaultFileError(w, err)
+		return
 	}
+	defer body.Close()
 
-	return data, info.Size(), nil
+	// Seekable bodies (local files) get range and conditional handling;
+	// object store bodies are streamed through as they arrive
+	if rs, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, info.Name, info.ModTime, rs)
+		return
+	}
+
+	if ctype := mime.TypeByExtension(filepath.Ext(info.Name)); ctype != "" {
+		w.Header().Set("Content-Type", ctype)
+	}
+	if !info.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		logger.WithError(err).WithField("path", filePath).Warn("Failed to stream file")
+	}
+}
+
+// writeVaultFileError maps a vault storage error to 404 for missing files and 500 otherwise
+func writeVaultFileError(w http.ResponseWriter, err error) {
+	if errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusNotFound, "File not found")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read file: %v", err))
 }
 
 // handleGetTree godoc
@@ -759,13 +822,6 @@ func (s *Server) handleGetAsset(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Build full file path
-	fullPath := s.buildVaultFilePath(v, fileEntry.Path)
-	if fullPath == "" {
-		writeError(w, http.StatusNotFound, "Asset path not found")
-		return
-	}
-
 	// Set Content-Type header if we have a MIME type
 	if mimeType != "" {
 		w.Header().Set("Content-Type", mimeType)
@@ -774,8 +830,8 @@ func (s *Server) handleGetAsset(w http.ResponseWriter, r *http.Request) {
 	// Set Cache-Control header for better performance
 	w.Header().Set("Cache-Control", "public, max-age=3600")
 
-	// Serve the file
-	http.ServeFile(w, r, fullPath)
+	// Serve the file from vault storage
+	s.serveVaultFile(w, r, v, fileEntry.Path)
 }
 
 // getMimeType returns the MIME type for a given FileType
@@ -899,15 +955,14 @@ func (s *Server) handleCreateFile(w http.ResponseWriter, r *http.Request) {
 		req.Name += ".md"
 	}
 
-	// Build full filesystem path
-	fullPath := s.buildVaultFilePath(v, targetPath)
-	if fullPath == "" {
-		writeError(w, http.StatusInternalServerError, "Failed to build file path")
+	store, err := s.vaultStorage(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	// Check if file/folder already exists
-	if _, err := os.Stat(fullPath); err == nil {
+	if _, err := store.Stat(r.Context(), targetPath); err == nil {
 		writeError(w, http.StatusConflict, fmt.Sprintf("%s already exists", map[bool]string{true: "Folder", false: "File"}[req.IsFolder]))
 		return
 	}
@@ -915,24 +970,17 @@ func (s *Server) handleCreateFile(w http.ResponseWriter, r *http.Request) {
 	// Create the file or folder
 	if req.IsFolder {
 		// Create directory
-		if err := os.MkdirAll(fullPath, 0755); err != nil {
+		if err := store.Mkdir(r.Context(), targetPath); err != nil {
 			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create folder: %v", err))
 			return
 		}
 	} else {
-		// Ensure parent directory exists
-		parentDir := filepath.Dir(fullPath)
-		if err := os.MkdirAll(parentDir, 0755); err != nil {
-			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create parent directory: %v", err))
-			return
-		}
-
-		// Create file with content
+		// Create file with content (storage creates parent directories)
 		content := req.Content
 		if content == "" {
 			content = "# " + strings.TrimSuffix(req.Name, ".md") + "\n\n"
 		}
-		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		if err := store.Write(r.Context(), targetPath, []byte(content)); err != nil {
 			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create file: %v", err))
 			return
 		}
@@ -969,3 +1017,70 @@ func (s *Server) handleCreateFile(w http.ResponseWriter, r *http.Request) {
 		"is_folder": req.IsFolder,
 	})
 }
+
+// DeleteFileRequest represents a request to delete a file or folder
+type DeleteFileRequest struct {
+	VaultID string `json:"vault_id"`
+	ID      string `json:"id"` // File or folder ID
+}
+
+// handleDeleteFile godoc
+// @Summary Move a file or folder to the trash
+// @Description Move a file or folder to the vault's .trash folder and mark its entries deleted. It can be restored or purged through the trash endpoints.
+// @Tags files
+// @Accept json
+// @Produce json
+// @Param request body DeleteFileRequest true "Delete file request"
+// @Success 200 {object} object{message=string,id=string,path=string,trash_id=int}
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 405 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/file/delete [post]
+func (s *Server) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req DeleteFileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if req.VaultID == "" {
+		writeError(w, http.StatusBadRequest, "vault_id is required")
+		return
+	}
+	if req.ID == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	v, dbService, ok := s.validateAndGetVaultWithDB(w, req.VaultID)
+	if !ok {
+		return
+	}
+	entry, ok := s.getFileEntryByID(w, dbService, req.VaultID, req.ID)
+	if !ok {
+		return
+	}
+	if entry.Path == "" || entry.Path == "." {
+		writeError(w, http.StatusBadRequest, "Cannot delete the vault root")
+		return
+	}
+
+	item, err := v.MoveToTrash(r.Context(), entry)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to move to trash: %v", err))
+		return
+	}
+
+	writeSuccess(w, map[string]interface{}{
+		"message":  fmt.Sprintf("%s moved to trash", map[bool]string{true: "Folder", false: "File"}[entry.IsDir]),
+		"id":       entry.ID,
+		"path":     item.OriginalPath,
+		"trash_id": item.ID,
+	})
+}
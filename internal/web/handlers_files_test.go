@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/db"
 	"github.com/susamn/obsidian-web/internal/vault"
 )
 
@@ -345,9 +346,7 @@ func TestHandleGetFileByID(t *testing.T) {
 	}
 
 	// Force reindex to populate database
-	if err := v.ForceReindex(); err != nil {
-		t.Fatalf("Failed to reindex vault: %v", err)
-	}
+	v.TriggerReindex()
 
 	cfg := &config.Config{
 		Server: config.ServerConfig{
@@ -464,6 +463,27 @@ func TestHandleGetFileByID(t *testing.T) {
 	})
 }
 
+func TestFileETag(t *testing.T) {
+	entry := &db.FileEntry{ID: "id-1", Name: "note.md", Path: "folder/note.md"}
+	etag := fileETag(entry, "hash")
+	if fileETag(entry, "hash") != etag {
+		t.Error("ETag not stable")
+	}
+
+	for name, changed := range map[string]db.FileEntry{
+		"renamed": {ID: "id-1", Name: "other.md", Path: "folder/other.md"},
+		"moved":   {ID: "id-1", Name: "note.md", Path: "archive/note.md"},
+		"other":   {ID: "id-2", Name: "note.md", Path: "folder/note.md"},
+	} {
+		if fileETag(&changed, "hash") == etag {
+			t.Errorf("%s: ETag unchanged", name)
+		}
+	}
+	if fileETag(entry, "other-hash") == etag {
+		t.Error("edited: ETag unchanged")
+	}
+}
+
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && containsHelper(s, substr))
@@ -817,3 +837,78 @@ func TestReadVaultFile(t *testing.T) {
 		t.Error("Expected error for missing file")
 	}
 }
+
+func TestServeVaultFile(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	indexDir := t.TempDir()
+
+	testContent := "0123456789"
+	if err := os.WriteFile(filepath.Join(tempDir, "image.png"), []byte(testContent), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, "folder"), 0755); err != nil {
+		t.Fatalf("Failed to create test folder: %v", err)
+	}
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: indexDir + "/test.bleve",
+		Storage: config.StorageConfig{
+			Type: "local",
+			Local: &config.LocalStorageConfig{
+				Path: tempDir,
+			},
+		},
+	}
+
+	v, _ := vault.NewVault(ctx, vaultCfg)
+	cfg := &config.Config{
+		Vaults: []config.VaultConfig{*vaultCfg},
+	}
+	server := NewServer(ctx, cfg, map[string]*vault.Vault{"test-vault": v})
+
+	// Full file
+	req := httptest.NewRequest("GET", "/api/v1/assets/test-vault/id", nil)
+	w := httptest.NewRecorder()
+	server.serveVaultFile(w, req, v, "image.png")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if w.Body.String() != testContent {
+		t.Errorf("Expected body %q, got %q", testContent, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Expected Content-Type image/png, got %q", ct)
+	}
+
+	// Range requests are served from the open file
+	req = httptest.NewRequest("GET", "/api/v1/assets/test-vault/id", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	w = httptest.NewRecorder()
+	server.serveVaultFile(w, req, v, "image.png")
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("Expected status 206, got %d", w.Code)
+	}
+	if w.Body.String() != "234" {
+		t.Errorf("Expected body %q, got %q", "234", w.Body.String())
+	}
+
+	// Missing files and directories are not found
+	for _, p := range []string{"missing.png", "folder"} {
+		w = httptest.NewRecorder()
+		server.serveVaultFile(w, httptest.NewRequest("GET", "/", nil), v, p)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d", p, w.Code)
+		}
+	}
+
+	// Other storage errors are server errors
+	w = httptest.NewRecorder()
+	server.serveVaultFile(w, httptest.NewRequest("GET", "/", nil), v, "../outside.png")
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 for an invalid path, got %d", w.Code)
+	}
+}
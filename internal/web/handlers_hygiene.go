@@ -0,0 +1,272 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/render"
+	"github.com/susamn/obsidian-web/internal/utils"
+	"github.com/susamn/obsidian-web/internal/vault"
+)
+
+// Sections of the hygiene report, for the section query parameter
+const (
+	hygieneUnresolved = "unresolved"
+	hygieneAnchors    = "anchors"
+	hygieneOrphans    = "orphans"
+	hygieneDeadEnds   = "dead_ends"
+)
+
+// HygieneLink is a wikilink or embed that does not lead anywhere
+type HygieneLink struct {
+	SourceID   string `json:"source_id"`
+	SourcePath string `json:"source_path"`
+	Line       int    `json:"line"`
+	Target     string `json:"target"` // Link text without the alias
+	Heading    string `json:"heading,omitempty"`
+	IsEmbed    bool   `json:"is_embed"`
+	Context    string `json:"context"`
+}
+
+// HygieneNote is a note missing inbound or outbound links
+type HygieneNote struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+}
+
+// HygieneLinks is one page of problem links
+type HygieneLinks struct {
+	Total int           `json:"total"`
+	Items []HygieneLink `json:"items"`
+}
+
+// HygieneNotes is one page of problem notes
+type HygieneNotes struct {
+	Total int           `json:"total"`
+	Items []HygieneNote `json:"items"`
+}
+
+// HygieneResponse reports the broken links, orphans and dead ends of a vault.
+// Offset and limit apply to each section on its own.
+type HygieneResponse struct {
+	VaultID         string        `json:"vault_id"`
+	Folder          string        `json:"folder,omitempty"`
+	Offset          int           `json:"offset"`
+	Limit           int           `json:"limit"`
+	UnresolvedLinks *HygieneLinks `json:"unresolved_links,omitempty"`
+	BrokenAnchors   *HygieneLinks `json:"broken_anchors,omitempty"`
+	Orphans         *HygieneNotes `json:"orphans,omitempty"`
+	DeadEnds        *HygieneNotes `json:"dead_ends,omitempty"`
+}
+
+// handleHygiene godoc
+// @Summary Report broken links, orphans and dead ends
+// @Description Lists wikilinks and embeds whose target does not exist, [[note#heading]] links whose heading is missing
+// @Description in the target, notes no other note links to (orphans) and notes that link nowhere (dead ends).
+// @Description Each section is sorted by path and paginated with the same offset and limit.
+// @Tags hygiene
+// @Produce json
+// @Param vault path string true "Vault ID"
+// @Param folder query string false "Only report on notes in this folder"
+// @Param section query string false "Only return one section: unresolved, anchors, orphans or dead_ends"
+// @Param offset query int false "Items to skip in each section"
+// @Param limit query int false "Items per section (default 100)"
+// @Success 200 {object} HygieneResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 405 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/hygiene/{vault} [get]
+func (s *Server) handleHygiene(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	vaultID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/hygiene/"), "/")
+	if vaultID == "" {
+		writeError(w, http.StatusBadRequest, "Vault ID required")
+		return
+	}
+
+	query := r.URL.Query()
+	folder := strings.Trim(filepath.ToSlash(query.Get("folder")), "/")
+	if strings.Contains(folder, "..") {
+		writeError(w, http.StatusBadRequest, "Invalid folder")
+		return
+	}
+	section := query.Get("section")
+	switch section {
+	case "", hygieneUnresolved, hygieneAnchors, hygieneOrphans, hygieneDeadEnds:
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown section: %s", section))
+		return
+	}
+	offset, err := queryInt(query.Get("offset"), 0)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, "Invalid offset")
+		return
+	}
+	limit, err := queryInt(query.Get("limit"), 100)
+	if err != nil || limit <= 0 {
+		writeError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+
+	v, dbService, ok := s.validateAndGetVaultWithDB(w, vaultID)
+	if !ok {
+		return
+	}
+
+	resp := HygieneResponse{VaultID: vaultID, Folder: folder, Offset: offset, Limit: limit}
+	want := func(name string) bool { return section == "" || section == name }
+
+	if want(hygieneUnresolved) {
+		links, err := dbService.GetUnresolvedLinks()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list unresolved links: %v", err))
+			return
+		}
+		resp.UnresolvedLinks = pageHygieneLinks(filterLinksByFolder(links, folder), offset, limit)
+	}
+
+	if want(hygieneAnchors) {
+		links, err := dbService.GetHeadingLinks()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list heading links: %v", err))
+			return
+		}
+		resp.BrokenAnchors = pageHygieneLinks(s.brokenAnchors(v, dbService, filterLinksByFolder(links, folder)), offset, limit)
+	}
+
+	if want(hygieneOrphans) || want(hygieneDeadEnds) {
+		stats, err := dbService.GetLinkStats()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to count links: %v", err))
+			return
+		}
+		orphans, deadEnds := []HygieneNote{}, []HygieneNote{}
+		for _, st := range stats {
+			path := filepath.ToSlash(st.Path)
+			if !utils.IsMarkdownFile(path) || !inFolder(path, folder) {
+				continue
+			}
+			if st.Inbound == 0 {
+				orphans = append(orphans, HygieneNote{ID: st.ID, Path: path})
+			}
+			if st.Outbound == 0 {
+				deadEnds = append(deadEnds, HygieneNote{ID: st.ID, Path: path})
+			}
+		}
+		if want(hygieneOrphans) {
+			resp.Orphans = &HygieneNotes{Total: len(orphans), Items: paginate(orphans, offset, limit)}
+		}
+		if want(hygieneDeadEnds) {
+			resp.DeadEnds = &HygieneNotes{Total: len(deadEnds), Items: paginate(deadEnds, offset, limit)}
+		}
+	}
+
+	writeSuccess(w, resp)
+}
+
+// brokenAnchors returns the heading links whose heading is not in the target note.
+// Each target is read once; links to targets that cannot be read are skipped.
+func (s *Server) brokenAnchors(v *vault.Vault, dbService *db.DBService, links []db.SourcedLink) []db.SourcedLink {
+	renderer := render.NewStructuredRenderer(nil)
+	headings := make(map[string][]render.Heading)
+	readable := make(map[string]bool)
+
+	broken := []db.SourcedLink{}
+	for _, link := range links {
+		if _, seen := readable[link.TargetID]; !seen {
+			readable[link.TargetID] = false
+			entry, err := dbService.GetFileEntryByID(link.TargetID)
+			if err == nil && entry != nil && utils.IsMarkdownFile(entry.Name) {
+				if content, _, err := s.readVaultFile(v, entry.Path); err == nil {
+					headings[link.TargetID] = renderer.ExtractHeadings(content)
+					readable[link.TargetID] = true
+				}
+			}
+		}
+		if readable[link.TargetID] && !hasHeading(headings[link.TargetID], link.Heading) {
+			broken = append(broken, link)
+		}
+	}
+	return broken
+}
+
+// hasHeading reports whether an anchor names one of the headings, by text or
+// slug. For nested anchors (#Chapter#Section) the last heading is checked.
+func hasHeading(headings []render.Heading, anchor string) bool {
+	if i := strings.LastIndex(anchor, "#"); i >= 0 {
+		anchor = anchor[i+1:]
+	}
+	anchor = strings.TrimSpace(anchor)
+	for _, h := range headings {
+		if strings.EqualFold(h.Text, anchor) || strings.EqualFold(h.ID, anchor) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLinksByFolder keeps the links whose source note is in folder
+func filterLinksByFolder(links []db.SourcedLink, folder string) []db.SourcedLink {
+	if folder == "" {
+		return links
+	}
+	kept := links[:0]
+	for _, link := range links {
+		if inFolder(filepath.ToSlash(link.SourcePath), folder) {
+			kept = append(kept, link)
+		}
+	}
+	return kept
+}
+
+// inFolder reports whether a slash-separated vault path is inside folder; every path is in ""
+func inFolder(path, folder string) bool {
+	return folder == "" || strings.HasPrefix(path, folder+"/")
+}
+
+// pageHygieneLinks converts one page of links for the response
+func pageHygieneLinks(links []db.SourcedLink, offset, limit int) *HygieneLinks {
+	items := []HygieneLink{}
+	for _, link := range paginate(links, offset, limit) {
+		items = append(items, HygieneLink{
+			SourceID:   link.SourceID,
+			SourcePath: filepath.ToSlash(link.SourcePath),
+			Line:       link.Line,
+			Target:     link.RawTarget,
+			Heading:    link.Heading,
+			IsEmbed:    link.IsEmbed,
+			Context:    link.Context,
+		})
+	}
+	return &HygieneLinks{Total: len(links), Items: items}
+}
+
+// paginate returns items[offset:offset+limit], clamped to the slice
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
+
+// queryInt parses an integer query parameter, returning def when it is empty
+func queryInt(value string, def int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,147 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/vault"
+)
+
+func TestHandleHygiene(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir() + "/test.bleve",
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type:  "local",
+			Local: &config.LocalStorageConfig{Path: tempDir},
+		},
+	}
+
+	v, err := vault.NewVault(ctx, vaultCfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	if err := v.Start(); err != nil {
+		t.Fatalf("Failed to start vault: %v", err)
+	}
+	defer v.Stop()
+	if err := v.WaitForReady(5 * time.Second); err != nil {
+		t.Fatalf("Vault not ready: %v", err)
+	}
+
+	// The watcher reports the notes and the workers store their links
+	for name, content := range map[string]string{
+		"a.md":       "# A\nSee [[b#Intro]], [[b#missing]] and [[ghost]]\n",
+		"b.md":       "# B\n## Intro\n",
+		"notes/c.md": "# C\n![[missing.png]]\n",
+	} {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Wait for the links of all three notes
+	dbService := v.GetDBService()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		unresolved, _ := dbService.GetUnresolvedLinks()
+		headings, _ := dbService.GetHeadingLinks()
+		if len(unresolved) == 2 && len(headings) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Links not indexed: unresolved %+v, headings %+v", unresolved, headings)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: []config.VaultConfig{*vaultCfg}}, map[string]*vault.Vault{"test-vault": v})
+
+	get := func(url string) (*httptest.ResponseRecorder, HygieneResponse) {
+		w := httptest.NewRecorder()
+		server.handleHygiene(w, httptest.NewRequest(http.MethodGet, url, nil))
+		var resp struct{ Data HygieneResponse }
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w, resp.Data
+	}
+	paths := func(notes *HygieneNotes) []string {
+		var out []string
+		for _, n := range notes.Items {
+			out = append(out, n.Path)
+		}
+		return out
+	}
+
+	t.Run("full report", func(t *testing.T) {
+		w, report := get("/api/v1/hygiene/test-vault")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if u := report.UnresolvedLinks; u.Total != 2 || u.Items[0].Target != "ghost" || u.Items[0].Line != 2 ||
+			u.Items[1].SourcePath != "notes/c.md" || !u.Items[1].IsEmbed {
+			t.Errorf("Unexpected unresolved links: %+v", u)
+		}
+		if b := report.BrokenAnchors; b.Total != 1 || b.Items[0].Heading != "missing" {
+			t.Errorf("Unexpected broken anchors: %+v", b)
+		}
+		if got := paths(report.Orphans); len(got) != 2 || got[0] != "a.md" || got[1] != "notes/c.md" {
+			t.Errorf("Unexpected orphans: %v", got)
+		}
+		if got := paths(report.DeadEnds); len(got) != 1 || got[0] != "b.md" {
+			t.Errorf("Unexpected dead ends: %v", got)
+		}
+	})
+
+	t.Run("folder", func(t *testing.T) {
+		_, report := get("/api/v1/hygiene/test-vault?folder=notes")
+		if report.UnresolvedLinks.Total != 1 || report.BrokenAnchors.Total != 0 ||
+			report.Orphans.Total != 1 || report.DeadEnds.Total != 0 {
+			t.Errorf("Unexpected report for notes/: %+v", report)
+		}
+	})
+
+	t.Run("section and page", func(t *testing.T) {
+		_, report := get("/api/v1/hygiene/test-vault?section=unresolved&offset=1&limit=1")
+		if report.Orphans != nil || report.BrokenAnchors != nil || report.DeadEnds != nil {
+			t.Errorf("Expected only unresolved links, got %+v", report)
+		}
+		if u := report.UnresolvedLinks; u.Total != 2 || len(u.Items) != 1 || u.Items[0].Target != "missing.png" {
+			t.Errorf("Unexpected page: %+v", u)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for url, want := range map[string]int{
+			"/api/v1/hygiene/nonexistent":             http.StatusNotFound,
+			"/api/v1/hygiene/":                        http.StatusBadRequest,
+			"/api/v1/hygiene/test-vault?section=all":  http.StatusBadRequest,
+			"/api/v1/hygiene/test-vault?limit=0":      http.StatusBadRequest,
+			"/api/v1/hygiene/test-vault?folder=../up": http.StatusBadRequest,
+		} {
+			if w, _ := get(url); w.Code != want {
+				t.Errorf("%s: expected status %d, got %d", url, want, w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/susamn/obsidian-web/internal/db"
+)
+
+// PropertyQueryRequest selects notes by their frontmatter properties
+type PropertyQueryRequest struct {
+	Filter string   `json:"filter,omitempty"` // e.g. status = done AND due < 2026-11-01
+	Sort   []string `json:"sort,omitempty"`   // property keys, prefixed with - for descending
+	Fields []string `json:"fields,omitempty"` // properties to return; all when empty
+	Offset int      `json:"offset,omitempty"`
+	Limit  int      `json:"limit,omitempty"` // default 100
+}
+
+// PropertyQueryResponse is one page of the notes matching a property query
+type PropertyQueryResponse struct {
+	VaultID string             `json:"vault_id"`
+	Total   int                `json:"total"`
+	Offset  int                `json:"offset"`
+	Limit   int                `json:"limit"`
+	Results []db.PropertyMatch `json:"results"`
+}
+
+// handlePropertyQuery godoc
+// @Summary Query notes by frontmatter properties
+// @Description Lists the notes whose frontmatter properties match a filter such as `status = done AND due < 2026-11-01`.
+// @Description Comparisons (= != < <= > >=) are joined with AND and OR and grouped with parentheses; quote keys and values
+// @Description containing spaces. Numbers, true/false and YYYY-MM-DD dates compare with properties of that type, other
+// @Description values compare as case-insensitive text. = on a list property matches any item.
+// @Description Results are sorted by the sort keys, then by path.
+// @Tags properties
+// @Accept json
+// @Produce json
+// @Param vault path string true "Vault ID"
+// @Param query body PropertyQueryRequest true "Property query"
+// @Success 200 {object} PropertyQueryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 405 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/properties/{vault}/query [post]
+func (s *Server) handlePropertyQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/properties/"), "/")
+	vaultID, action, _ := strings.Cut(path, "/")
+	if vaultID == "" {
+		writeError(w, http.StatusBadRequest, "Vault ID required")
+		return
+	}
+	if action != "query" {
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	_, dbService, ok := s.validateAndGetVaultWithDB(w, vaultID)
+	if !ok {
+		return
+	}
+
+	var req PropertyQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	if req.Offset < 0 || req.Limit < 0 {
+		writeError(w, http.StatusBadRequest, "Offset and limit must not be negative")
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 100
+	}
+
+	results, total, err := dbService.QueryProperties(db.PropertyQuery{
+		Filter: req.Filter,
+		Sort:   req.Sort,
+		Fields: req.Fields,
+		Offset: req.Offset,
+		Limit:  req.Limit,
+	})
+	if errors.Is(err, db.ErrInvalidPropertyQuery) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Property query failed: %v", err))
+		return
+	}
+
+	writeSuccess(w, PropertyQueryResponse{
+		VaultID: vaultID,
+		Total:   total,
+		Offset:  req.Offset,
+		Limit:   req.Limit,
+		Results: results,
+	})
+}
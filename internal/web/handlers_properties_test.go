@@ -0,0 +1,123 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+	"github.com/susamn/obsidian-web/internal/vault"
+)
+
+func TestHandlePropertyQuery(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir() + "/test.bleve",
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type:  "local",
+			Local: &config.LocalStorageConfig{Path: tempDir},
+		},
+	}
+
+	v, err := vault.NewVault(ctx, vaultCfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	if err := v.Start(); err != nil {
+		t.Fatalf("Failed to start vault: %v", err)
+	}
+	defer v.Stop()
+	if err := v.WaitForReady(5 * time.Second); err != nil {
+		t.Fatalf("Vault not ready: %v", err)
+	}
+
+	// Track the notes directly rather than waiting for the watcher; the
+	// workers store the same properties when the watcher reports them
+	dbService := v.GetDBService()
+	for name, frontmatter := range map[string]map[string]any{
+		"a.md": {"status": "done", "due": "2026-10-01"},
+		"b.md": {"status": "done", "due": "2026-12-01"},
+		"c.md": {"status": "open", "due": "2026-10-15"},
+	} {
+		content := "---\nstatus: " + frontmatter["status"].(string) + "\ndue: " + frontmatter["due"].(string) + "\n---\n# " + name + "\n"
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fileID, err := dbService.PerformDatabaseUpdate(v.GetStorage(), tempDir, syncpkg.FileChangeEvent{
+			Path:      path,
+			EventType: syncpkg.FileCreated,
+			Timestamp: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to track %s: %v", name, err)
+		}
+		if err := dbService.SetFileProperties(fileID, frontmatter); err != nil {
+			t.Fatalf("Failed to store properties of %s: %v", name, err)
+		}
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: []config.VaultConfig{*vaultCfg}}, map[string]*vault.Vault{"test-vault": v})
+
+	post := func(url string, body any) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		server.handlePropertyQuery(w, httptest.NewRequest(http.MethodPost, url, bytes.NewReader(data)))
+		return w
+	}
+
+	t.Run("filter, sort and projection", func(t *testing.T) {
+		w := post("/api/v1/properties/test-vault/query", PropertyQueryRequest{
+			Filter: "status = done AND due < 2026-11-01 OR status = open",
+			Sort:   []string{"-due"},
+			Fields: []string{"due"},
+		})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct{ Data PropertyQueryResponse }
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		got := resp.Data
+		if got.Total != 2 || got.Limit != 100 || got.Results[0].Path != "c.md" || got.Results[1].Path != "a.md" {
+			t.Fatalf("Unexpected results: %+v", got)
+		}
+		if props := got.Results[0].Properties; len(props) != 1 || props["due"] != "2026-10-15" {
+			t.Errorf("Expected only the due property, got %v", props)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if w := post("/api/v1/properties/test-vault/query", PropertyQueryRequest{Filter: "status ="}); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a bad filter, got %d", w.Code)
+		}
+		if w := post("/api/v1/properties/test-vault/query", PropertyQueryRequest{Limit: -1}); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a negative limit, got %d", w.Code)
+		}
+		if w := post("/api/v1/properties/nonexistent/query", PropertyQueryRequest{}); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+		if w := post("/api/v1/properties/test-vault", PropertyQueryRequest{}); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 without /query, got %d", w.Code)
+		}
+		w := httptest.NewRecorder()
+		server.handlePropertyQuery(w, httptest.NewRequest(http.MethodGet, "/api/v1/properties/test-vault/query", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/diff"
+	"github.com/susamn/obsidian-web/internal/logger"
+)
+
+// currentRevision names the file as it is now in a diff request
+const currentRevision = "current"
+
+// RevisionsResponse lists the stored revisions of a note
+type RevisionsResponse struct {
+	VaultID   string        `json:"vault_id"`
+	FileID    string        `json:"file_id"`
+	Path      string        `json:"path"`
+	Revisions []db.Revision `json:"revisions"` // Newest first
+}
+
+// RevisionResponse is a stored revision with its content
+type RevisionResponse struct {
+	db.Revision
+	Content string `json:"content"`
+}
+
+// RevisionDiffResponse is the unified diff between two versions of a note
+type RevisionDiffResponse struct {
+	FileID string `json:"file_id"`
+	From   string `json:"from"` // Revision ID or "current"
+	To     string `json:"to"`
+	Diff   string `json:"diff"` // Empty when the versions are the same
+}
+
+// handleRevisions godoc
+// @Summary List, show, diff and restore note revisions
+// @Description A revision is stored each time a note's content changes, up to the vault's retention.
+// @Description GET /{vault}/{id} lists the revisions of a note, newest first. GET /{vault}/{id}/{rev} returns a revision's content.
+// @Description GET /{vault}/{id}/diff?from=&to= returns the unified diff between two revisions; either side may be "current"
+// @Description (the default for to) for the file as it is now. POST /{vault}/{id}/{rev}/restore writes the revision back to the
+// @Description file, which records it as a new revision once the change is synced.
+// @Tags revisions
+// @Produce json
+// @Param vault path string true "Vault ID"
+// @Param id path string true "File ID"
+// @Param rev path int false "Revision ID"
+// @Param from query string false "Revision to diff from, or current"
+// @Param to query string false "Revision to diff to, or current (default)"
+// @Success 200 {object} RevisionsResponse "RevisionResponse for a revision, RevisionDiffResponse for a diff"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 405 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/revisions/{vault}/{id} [get]
+func (s *Server) handleRevisions(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/revisions/"), "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" || len(parts) > 4 {
+		writeError(w, http.StatusBadRequest, "Invalid path format")
+		return
+	}
+	vaultID, fileID := parts[0], parts[1]
+
+	// Work out the action before touching the vault, so bad requests fail fast
+	var action, rev string
+	switch {
+	case len(parts) == 2:
+		action = "list"
+	case len(parts) == 3 && parts[2] == "diff":
+		action = "diff"
+	case len(parts) == 3:
+		action, rev = "show", parts[2]
+	case parts[3] == "restore":
+		action, rev = "restore", parts[2]
+	default:
+		writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	method := http.MethodGet
+	if action == "restore" {
+		method = http.MethodPost
+	}
+	if r.Method != method {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	v, dbService, ok := s.validateAndGetVaultWithDB(w, vaultID)
+	if !ok {
+		return
+	}
+	entry, ok := s.getFileEntryByID(w, dbService, vaultID, fileID)
+	if !ok {
+		return
+	}
+
+	switch action {
+	case "list":
+		revisions, err := dbService.GetRevisions(entry.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list revisions: %v", err))
+			return
+		}
+		writeSuccess(w, RevisionsResponse{
+			VaultID:   vaultID,
+			FileID:    entry.ID,
+			Path:      filepath.ToSlash(entry.Path),
+			Revisions: revisions,
+		})
+
+	case "show":
+		revision, content, ok := s.getRevision(w, dbService, entry.ID, rev)
+		if !ok {
+			return
+		}
+		writeSuccess(w, RevisionResponse{Revision: *revision, Content: string(content)})
+
+	case "diff":
+		from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+		if from == "" {
+			writeError(w, http.StatusBadRequest, "from is required")
+			return
+		}
+		if to == "" {
+			to = currentRevision
+		}
+		versions := make([]string, 2)
+		for i, name := range []string{from, to} {
+			if name == currentRevision {
+				content, _, err := s.readVaultFileInBinary(v, entry.Path)
+				if err != nil {
+					writeError(w, http.StatusNotFound, fmt.Sprintf("File not found: %v", err))
+					return
+				}
+				versions[i] = string(content)
+				continue
+			}
+			_, content, ok := s.getRevision(w, dbService, entry.ID, name)
+			if !ok {
+				return
+			}
+			versions[i] = string(content)
+		}
+		path := filepath.ToSlash(entry.Path)
+		writeSuccess(w, RevisionDiffResponse{
+			FileID: entry.ID,
+			From:   from,
+			To:     to,
+			Diff:   diff.Unified(revisionLabel(path, from), revisionLabel(path, to), versions[0], versions[1]),
+		})
+
+	case "restore":
+		revision, content, ok := s.getRevision(w, dbService, entry.ID, rev)
+		if !ok {
+			return
+		}
+		store, err := s.vaultStorage(v)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		// The watcher picks the write up like any other edit
+		if err := store.Write(r.Context(), filepath.ToSlash(entry.Path), content); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to restore revision: %v", err))
+			return
+		}
+
+		logger.WithFields(map[string]interface{}{
+			"vault_id": vaultID,
+			"file_id":  entry.ID,
+			"path":     entry.Path,
+			"revision": revision.ID,
+		}).Info("Restored revision")
+
+		writeSuccess(w, map[string]interface{}{
+			"message":  "Revision restored",
+			"id":       entry.ID,
+			"path":     filepath.ToSlash(entry.Path),
+			"revision": revision.ID,
+		})
+	}
+}
+
+// getRevision fetches a revision of a file by the ID in the URL.
+// Writes an error and returns false if it is invalid or not found.
+func (s *Server) getRevision(w http.ResponseWriter, dbService *db.DBService, fileID, rev string) (*db.Revision, []byte, bool) {
+	id, err := strconv.ParseInt(rev, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid revision: %s", rev))
+		return nil, nil, false
+	}
+	revision, content, err := dbService.GetRevision(fileID, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get revision: %v", err))
+		return nil, nil, false
+	}
+	if revision == nil {
+		writeError(w, http.StatusNotFound, "Revision not found")
+		return nil, nil, false
+	}
+	return revision, content, true
+}
+
+// revisionLabel names one side of a revision diff
+func revisionLabel(path, rev string) string {
+	if rev == currentRevision {
+		return path + " (current)"
+	}
+	return path + " (revision " + rev + ")"
+}
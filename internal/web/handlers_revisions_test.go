@@ -0,0 +1,160 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/db"
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+	"github.com/susamn/obsidian-web/internal/vault"
+)
+
+func TestHandleRevisions(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir() + "/test.bleve",
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type:  "local",
+			Local: &config.LocalStorageConfig{Path: tempDir},
+		},
+	}
+
+	v, err := vault.NewVault(ctx, vaultCfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	if err := v.Start(); err != nil {
+		t.Fatalf("Failed to start vault: %v", err)
+	}
+	defer v.Stop()
+	if err := v.WaitForReady(5 * time.Second); err != nil {
+		t.Fatalf("Vault not ready: %v", err)
+	}
+
+	// Track the note and store its earlier version directly; the workers
+	// record the current version when the watcher reports it
+	dbService := v.GetDBService()
+	path := filepath.Join(tempDir, "note.md")
+	if err := os.WriteFile(path, []byte("# Note\n\nfirst draft\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileID, err := dbService.PerformDatabaseUpdate(v.GetStorage(), tempDir, syncpkg.FileChangeEvent{
+		Path:      path,
+		EventType: syncpkg.FileCreated,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to track note: %v", err)
+	}
+	if _, err := dbService.AddRevision(fileID, []byte("# Note\n\nfirst draft\n"), db.RevisionRetention{}); err != nil {
+		t.Fatal(err)
+	}
+	revisions, err := dbService.GetRevisions(fileID)
+	if err != nil || len(revisions) == 0 {
+		t.Fatalf("GetRevisions() = %v, %v", revisions, err)
+	}
+	first := revisions[0].ID
+	if err := os.WriteFile(path, []byte("# Note\n\noverwritten\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: []config.VaultConfig{*vaultCfg}}, map[string]*vault.Vault{"test-vault": v})
+
+	do := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.handleRevisions(w, httptest.NewRequest(method, url, nil))
+		return w
+	}
+	base := "/api/v1/revisions/test-vault/" + fileID
+
+	t.Run("list", func(t *testing.T) {
+		w := do(http.MethodGet, base)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data RevisionsResponse `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Data.Path != "note.md" || len(resp.Data.Revisions) == 0 {
+			t.Errorf("Unexpected response: %+v", resp.Data)
+		}
+	})
+
+	t.Run("show", func(t *testing.T) {
+		w := do(http.MethodGet, fmt.Sprintf("%s/%d", base, first))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data RevisionResponse `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Data.ID != first || resp.Data.Content != "# Note\n\nfirst draft\n" {
+			t.Errorf("Unexpected revision: %+v", resp.Data)
+		}
+	})
+
+	t.Run("diff against current", func(t *testing.T) {
+		w := do(http.MethodGet, fmt.Sprintf("%s/diff?from=%d", base, first))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data RevisionDiffResponse `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Data.To != "current" || !strings.Contains(resp.Data.Diff, "-first draft\n+overwritten\n") {
+			t.Errorf("Unexpected diff: %+v", resp.Data)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			method, url string
+			code        int
+		}{
+			{http.MethodGet, base + "/999999", http.StatusNotFound},
+			{http.MethodGet, base + "/abc", http.StatusBadRequest},
+			{http.MethodGet, base + "/diff", http.StatusBadRequest},
+			{http.MethodGet, "/api/v1/revisions/test-vault/missing", http.StatusNotFound},
+			{http.MethodGet, fmt.Sprintf("%s/%d/restore", base, first), http.StatusMethodNotAllowed},
+		} {
+			if w := do(tc.method, tc.url); w.Code != tc.code {
+				t.Errorf("%s %s: expected %d, got %d", tc.method, tc.url, tc.code, w.Code)
+			}
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		w := do(http.MethodPost, fmt.Sprintf("%s/%d/restore", base, first))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != "# Note\n\nfirst draft\n" {
+			t.Errorf("File after restore = %q, %v", data, err)
+		}
+	})
+}
@@ -2,21 +2,44 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	bsearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/susamn/obsidian-web/internal/search"
 )
 
 // SearchRequest represents a search request
 type SearchRequest struct {
 	Query     string   `json:"query"`
-	Type      string   `json:"type,omitempty"`       // text, tag, wikilink, fuzzy, phrase, prefix
+	Type      string   `json:"type,omitempty"`       // text, tag, wikilink, fuzzy, phrase, prefix, query
 	Tags      []string `json:"tags,omitempty"`       // for tag search
 	Wikilinks []string `json:"wikilinks,omitempty"`  // for wikilink search
 	Limit     int      `json:"limit,omitempty"`      // max results
 	TitleOnly bool     `json:"title_only,omitempty"` // search title only
+
+	Offset      int      `json:"offset,omitempty"`       // results to skip
+	SearchAfter []string `json:"search_after,omitempty"` // "sort" of the last result on the previous page
+	Sort        []string `json:"sort,omitempty"`         // score, title, path, created, modified; "-" reverses
+	Fields      []string `json:"fields,omitempty"`       // title, path, tags, wikilinks, created, modified, date
+
+	Facets         []string            `json:"facets,omitempty"`          // tags, folders, modified
+	FacetSize      int                 `json:"facet_size,omitempty"`      // tags and folders counted, default 10
+	FacetSelection map[string][]string `json:"facet_selection,omitempty"` // facet values results must match
+
+	// Date ranges, as YYYY-MM-DD or RFC 3339; after is inclusive, before exclusive
+	CreatedAfter   string `json:"created_after,omitempty"`
+	CreatedBefore  string `json:"created_before,omitempty"`
+	ModifiedAfter  string `json:"modified_after,omitempty"`
+	ModifiedBefore string `json:"modified_before,omitempty"`
+	DateAfter      string `json:"date_after,omitempty"` // frontmatter date
+	DateBefore     string `json:"date_before,omitempty"`
 }
 
 // SearchResult represents a single search result
@@ -25,6 +48,13 @@ type SearchResult struct {
 	Score     float64                `json:"score"`
 	Fields    map[string]interface{} `json:"fields,omitempty"`
 	Fragments map[string][]string    `json:"fragments,omitempty"`
+	Sort      []string               `json:"sort,omitempty"` // Pass as search_after to continue after this result
+}
+
+// QueryErrorResponse is the error for a query that does not parse
+type QueryErrorResponse struct {
+	ErrorResponse
+	Position int `json:"position"` // Byte offset of the error in the query
 }
 
 // SearchResponse represents search results
@@ -32,18 +62,48 @@ type SearchResponse struct {
 	Total   uint64         `json:"total"`
 	Results []SearchResult `json:"results"`
 	Took    string         `json:"took"`
+
+	Facets map[string]FacetResult `json:"facets,omitempty"`
+}
+
+// FacetResult counts the matching notes per value of a facet
+type FacetResult struct {
+	Total   int           `json:"total"`   // Values counted, a note counts once per tag
+	Missing int           `json:"missing"` // Notes without a value
+	Other   int           `json:"other"`   // Values counted but not listed
+	Buckets []FacetBucket `json:"buckets"`
+}
+
+// FacetBucket is one facet value and its count. Modified buckets are months
+// labelled YYYY-MM, newest first, then "older", with the dates they cover.
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
 }
 
 // handleSearch godoc
 // @Summary Search a vault
-// @Description Search for notes in a vault
+// @Description Search for notes in a vault. Results are pages of limit (default 50), best score first
+// @Description unless sort is set. Page with offset, or with search_after set to the last result's sort
+// @Description values, which stays consistent while notes change. fields selects the fields returned.
+// @Description Type query takes Obsidian search syntax, e.g. `tag:#project path:work/ "exact phrase" -draft (foo OR bar) line:(todo urgent)`;
+// @Description a query that does not parse fails with 400 and the position of the error.
+// @Description facets counts all matching notes by tags, top-level folders (folders, "/" for the vault root)
+// @Description and modified month; facet_selection narrows results to the given values, e.g.
+// @Description `{"folders": ["work"], "modified": ["2024-05", "older"]}`. Values of one facet match any of
+// @Description them, and a selected facet is counted without its own selection. Not supported by sqlite_fts.
+// @Description created_after/created_before, modified_after/modified_before and date_after/date_before (frontmatter
+// @Description date) narrow any search type to a date range, given as YYYY-MM-DD or RFC 3339; after is inclusive,
+// @Description before exclusive, and notes without the date are left out.
 // @Tags search
 // @Accept json
 // @Produce json
 // @Param vault path string true "Vault ID"
 // @Param query body SearchRequest true "Search query"
 // @Success 200 {object} SearchResponse
-// @Failure 400 {object} ErrorResponse
+// @Failure 400 {object} QueryErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Failure 405 {object} ErrorResponse
 // @Failure 503 {object} ErrorResponse
@@ -91,6 +151,14 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	if req.Limit == 0 {
 		req.Limit = 50
 	}
+	opts, err := req.options()
+	if err == nil {
+		err = opts.Validate()
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
 
 	// Get search service
 	searchSvc := v.GetSearchService()
@@ -101,6 +169,21 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 
 	// Execute search
 	results, err := s.executeSearch(searchSvc, &req)
+	var parseErr *search.ParseError
+	if errors.As(err, &parseErr) {
+		writeJSON(w, http.StatusBadRequest, QueryErrorResponse{
+			ErrorResponse: ErrorResponse{
+				Error:   http.StatusText(http.StatusBadRequest),
+				Message: fmt.Sprintf("Invalid query: %v", parseErr),
+			},
+			Position: parseErr.Pos,
+		})
+		return
+	}
+	if errors.Is(err, search.ErrUnsupportedQuery) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid query: %v", err))
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Search failed: %v", err))
 		return
@@ -112,68 +195,130 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 
 // searchService interface for methods we need
 type searchService interface {
-	SearchByText(query string) (*bleve.SearchResult, error)
-	SearchByTag(tag string) (*bleve.SearchResult, error)
-	SearchByMultipleTags(tags []string) (*bleve.SearchResult, error)
-	SearchByWikilink(wikilink string) (*bleve.SearchResult, error)
-	SearchByMultipleWikilinks(wikilinks []string) (*bleve.SearchResult, error)
-	SearchByTitleOnly(query string) (*bleve.SearchResult, error)
-	FuzzySearch(query string, fuzziness int) (*bleve.SearchResult, error)
-	PhraseSearch(phrase string) (*bleve.SearchResult, error)
-	PrefixSearch(prefix string) (*bleve.SearchResult, error)
-}
-
-// executeSearch performs the actual search based on request type
+	SearchByText(query string, opts search.SearchOptions) (*bleve.SearchResult, error)
+	SearchByTag(tag string, opts search.SearchOptions) (*bleve.SearchResult, error)
+	SearchByMultipleTags(tags []string, opts search.SearchOptions) (*bleve.SearchResult, error)
+	SearchByWikilink(wikilink string, opts search.SearchOptions) (*bleve.SearchResult, error)
+	SearchByMultipleWikilinks(wikilinks []string, opts search.SearchOptions) (*bleve.SearchResult, error)
+	SearchByTitleOnly(query string, opts search.SearchOptions) (*bleve.SearchResult, error)
+	FuzzySearch(query string, fuzziness int, opts search.SearchOptions) (*bleve.SearchResult, error)
+	PhraseSearch(phrase string, opts search.SearchOptions) (*bleve.SearchResult, error)
+	PrefixSearch(prefix string, opts search.SearchOptions) (*bleve.SearchResult, error)
+	SearchByQuery(query string, opts search.SearchOptions) (*bleve.SearchResult, error)
+}
+
+// executeSearch performs the actual search and converts the results
 func (s *Server) executeSearch(searchSvc searchService, req *SearchRequest) (*SearchResponse, error) {
+	result, err := runSearch(searchSvc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to response format
+	return s.convertSearchResult(result), nil
+}
+
+// runSearch performs a search based on request type
+func runSearch(searchSvc searchService, req *SearchRequest) (*bleve.SearchResult, error) {
 	var result *bleve.SearchResult
-	var err error
+	opts, err := req.options()
+	if err != nil {
+		return nil, err
+	}
 
 	// Execute search based on type
 	switch req.Type {
 	case "tag":
 		if len(req.Tags) == 1 {
-			result, err = searchSvc.SearchByTag(req.Tags[0])
+			result, err = searchSvc.SearchByTag(req.Tags[0], opts)
 		} else if len(req.Tags) > 1 {
-			result, err = searchSvc.SearchByMultipleTags(req.Tags)
+			result, err = searchSvc.SearchByMultipleTags(req.Tags, opts)
 		} else {
 			return nil, fmt.Errorf("tags required for tag search")
 		}
 
 	case "wikilink":
 		if len(req.Wikilinks) == 1 {
-			result, err = searchSvc.SearchByWikilink(req.Wikilinks[0])
+			result, err = searchSvc.SearchByWikilink(req.Wikilinks[0], opts)
 		} else if len(req.Wikilinks) > 1 {
-			result, err = searchSvc.SearchByMultipleWikilinks(req.Wikilinks)
+			result, err = searchSvc.SearchByMultipleWikilinks(req.Wikilinks, opts)
 		} else {
 			return nil, fmt.Errorf("wikilinks required for wikilink search")
 		}
 
 	case "fuzzy":
-		result, err = searchSvc.FuzzySearch(req.Query, 2)
+		result, err = searchSvc.FuzzySearch(req.Query, 2, opts)
 
 	case "phrase":
-		result, err = searchSvc.PhraseSearch(req.Query)
+		result, err = searchSvc.PhraseSearch(req.Query, opts)
 
 	case "prefix":
-		result, err = searchSvc.PrefixSearch(req.Query)
+		result, err = searchSvc.PrefixSearch(req.Query, opts)
 
 	case "title":
-		result, err = searchSvc.SearchByTitleOnly(req.Query)
+		result, err = searchSvc.SearchByTitleOnly(req.Query, opts)
+
+	case "query":
+		result, err = searchSvc.SearchByQuery(req.Query, opts)
 
 	default: // "text" or empty
 		if req.TitleOnly {
-			result, err = searchSvc.SearchByTitleOnly(req.Query)
+			result, err = searchSvc.SearchByTitleOnly(req.Query, opts)
 		} else {
-			result, err = searchSvc.SearchByText(req.Query)
+			result, err = searchSvc.SearchByText(req.Query, opts)
 		}
 	}
 
-	if err != nil {
-		return nil, err
+	return result, err
+}
+
+// options returns the paging, sorting, field selection, facets and date
+// ranges of a request
+func (req *SearchRequest) options() (search.SearchOptions, error) {
+	opts := search.SearchOptions{
+		Limit:       req.Limit,
+		Offset:      req.Offset,
+		SearchAfter: req.SearchAfter,
+		Sort:        req.Sort,
+		Fields:      req.Fields,
+		Facets:      req.Facets,
+		FacetSize:   req.FacetSize,
+		Selection:   req.FacetSelection,
+	}
+	for _, r := range []struct {
+		name          string
+		after, before string
+		dates         *search.DateRange
+	}{
+		{"created", req.CreatedAfter, req.CreatedBefore, &opts.Created},
+		{"modified", req.ModifiedAfter, req.ModifiedBefore, &opts.Modified},
+		{"date", req.DateAfter, req.DateBefore, &opts.Date},
+	} {
+		var err error
+		if r.dates.After, err = parseDateParam(r.name+"_after", r.after); err != nil {
+			return opts, err
+		}
+		if r.dates.Before, err = parseDateParam(r.name+"_before", r.before); err != nil {
+			return opts, err
+		}
 	}
+	return opts, nil
+}
 
-	// Convert to response format
-	return s.convertSearchResult(result), nil
+// parseDateParam parses a YYYY-MM-DD (UTC midnight) or RFC 3339 date, or
+// returns the zero time when value is empty
+func parseDateParam(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q (use YYYY-MM-DD or RFC 3339)", name, value)
+	}
+	return t, nil
 }
 
 // convertSearchResult converts bleve search results to API response
@@ -203,6 +348,10 @@ func (s *Server) convertSearchResult(result *bleve.SearchResult) *SearchResponse
 			sr.Fragments = hit.Fragments
 		}
 
+		if len(hit.DecodedSort) > 0 {
+			sr.Sort = hit.DecodedSort
+		}
+
 		results = append(results, sr)
 	}
 
@@ -210,7 +359,271 @@ func (s *Server) convertSearchResult(result *bleve.SearchResult) *SearchResponse
 		Total:   result.Total,
 		Results: results,
 		Took:    result.Took.String(),
+		Facets:  convertFacets(result.Facets),
+	}
+}
+
+// convertFacets converts bleve facet results to API response
+func convertFacets(facets bsearch.FacetResults) map[string]FacetResult {
+	if len(facets) == 0 {
+		return nil
+	}
+	converted := make(map[string]FacetResult, len(facets))
+	for name, facet := range facets {
+		fr := FacetResult{
+			Total:   facet.Total,
+			Missing: facet.Missing,
+			Other:   facet.Other,
+			Buckets: []FacetBucket{},
+		}
+		for _, term := range facet.Terms.Terms() {
+			fr.Buckets = append(fr.Buckets, FacetBucket{Value: term.Term, Count: term.Count})
+		}
+		for _, r := range facet.DateRanges {
+			bucket := FacetBucket{Value: r.Name, Count: r.Count}
+			if r.Start != nil {
+				bucket.Start = *r.Start
+			}
+			if r.End != nil {
+				bucket.End = *r.End
+			}
+			fr.Buckets = append(fr.Buckets, bucket)
+		}
+		converted[name] = fr
+	}
+	return converted
+}
+
+// defaultVaultSearchTimeout bounds each vault of a federated search when
+// search.vault_timeout is not set
+const defaultVaultSearchTimeout = 5 * time.Second
+
+// FederatedSearchRequest is a search run in several vaults at once
+type FederatedSearchRequest struct {
+	SearchRequest
+	Vaults []string `json:"vaults,omitempty"` // Vault IDs to search; every active vault when empty
+}
+
+// FederatedSearchResult is a search result with the vault it came from
+type FederatedSearchResult struct {
+	SearchResult
+	VaultID         string  `json:"vault_id"`
+	NormalizedScore float64 `json:"normalized_score"` // Score divided by the vault's best score, so vaults compare; 0 when unranked
+}
+
+// VaultSearchStatus reports how the search went in one vault
+type VaultSearchStatus struct {
+	VaultID string `json:"vault_id"`
+	Total   uint64 `json:"total"`
+	Took    string `json:"took,omitempty"`
+	Error   string `json:"error,omitempty"` // Set when the vault could not be searched; the others still are
+}
+
+// FederatedSearchResponse holds the merged results of a federated search
+type FederatedSearchResponse struct {
+	Total   uint64                  `json:"total"` // Matches across the vaults searched
+	Results []FederatedSearchResult `json:"results"`
+	Vaults  []VaultSearchStatus     `json:"vaults"`
+	Took    string                  `json:"took"`
+}
+
+// handleFederatedSearch godoc
+// @Summary Search several vaults
+// @Description Run a search in every active vault, or the vaults listed in vaults, in parallel. Takes the
+// @Description same fields as a vault search except offset, search_after, sort and facets. Each vault's
+// @Description scores are divided by its best score, and the best limit results across vaults are returned
+// @Description with their vault_id. A vault that fails or takes longer than search.vault_timeout is
+// @Description reported in vaults with an error instead of failing the request.
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param query body FederatedSearchRequest true "Search query and vaults"
+// @Success 200 {object} FederatedSearchResponse
+// @Failure 400 {object} QueryErrorResponse
+// @Failure 405 {object} ErrorResponse
+// @Router /api/v1/search [post]
+func (s *Server) handleFederatedSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req FederatedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+
+	if req.Query == "" && len(req.Tags) == 0 && len(req.Wikilinks) == 0 {
+		writeError(w, http.StatusBadRequest, "Query, tags, or wikilinks required")
+		return
+	}
+	if req.Offset > 0 || len(req.SearchAfter) > 0 || len(req.Sort) > 0 || len(req.Facets) > 0 {
+		writeError(w, http.StatusBadRequest, "Invalid request: offset, search_after, sort and facets are not supported across vaults")
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 50
+	}
+	opts, err := req.options()
+	if err == nil {
+		err = opts.Validate()
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+
+	// A query that does not parse would fail the same way in every vault
+	if req.Type == "query" {
+		var parseErr *search.ParseError
+		if _, err := search.ParseQuery(req.Query); errors.As(err, &parseErr) {
+			writeJSON(w, http.StatusBadRequest, QueryErrorResponse{
+				ErrorResponse: ErrorResponse{
+					Error:   http.StatusText(http.StatusBadRequest),
+					Message: fmt.Sprintf("Invalid query: %v", parseErr),
+				},
+				Position: parseErr.Pos,
+			})
+			return
+		}
+	}
+
+	writeSuccess(w, s.executeFederatedSearch(&req))
+}
+
+// vaultSearch is the outcome of a search in one vault
+type vaultSearch struct {
+	status VaultSearchStatus
+	result *bleve.SearchResult
+}
+
+// executeFederatedSearch searches the requested vaults in parallel and merges
+// the results by normalized score
+func (s *Server) executeFederatedSearch(req *FederatedSearchRequest) *FederatedSearchResponse {
+	start := time.Now()
+	timeout := s.config.Search.VaultTimeout
+	if timeout <= 0 {
+		timeout = defaultVaultSearchTimeout
+	}
+
+	// Listed vaults that cannot be searched are reported; unlisted ones that
+	// are not active are skipped
+	var ids []string
+	if len(req.Vaults) > 0 {
+		seen := make(map[string]bool, len(req.Vaults))
+		for _, id := range req.Vaults {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	} else {
+		for _, v := range s.listVaults() {
+			if v.IsActive() {
+				ids = append(ids, v.VaultID())
+			}
+		}
+		sort.Strings(ids)
+	}
+
+	searches := make([]vaultSearch, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			searches[i] = s.searchVault(id, &req.SearchRequest, timeout)
+		}(i, id)
+	}
+	wg.Wait()
+
+	resp := &FederatedSearchResponse{
+		Results: []FederatedSearchResult{},
+		Vaults:  make([]VaultSearchStatus, 0, len(searches)),
+	}
+	for _, vs := range searches {
+		resp.Vaults = append(resp.Vaults, vs.status)
+		if vs.result == nil {
+			continue
+		}
+		resp.Total += vs.result.Total
+		converted := s.convertSearchResult(vs.result)
+		for _, sr := range converted.Results {
+			// Unranked hits, as from a search without text, score 0
+			normalized := 0.0
+			if vs.result.MaxScore > 0 {
+				normalized = sr.Score / vs.result.MaxScore
+			}
+			resp.Results = append(resp.Results, FederatedSearchResult{
+				SearchResult:    sr,
+				VaultID:         vs.status.VaultID,
+				NormalizedScore: normalized,
+			})
+		}
+	}
+
+	// Vaults are in ID order and each vault's results best first, so a stable
+	// sort breaks ties by vault and then by the vault's own order
+	sort.SliceStable(resp.Results, func(i, j int) bool {
+		return resp.Results[i].NormalizedScore > resp.Results[j].NormalizedScore
+	})
+	if len(resp.Results) > req.Limit {
+		resp.Results = resp.Results[:req.Limit]
+	}
+	resp.Took = time.Since(start).String()
+	return resp
+}
+
+// searchVault runs a search in one vault, giving up after timeout
+func (s *Server) searchVault(vaultID string, req *SearchRequest, timeout time.Duration) vaultSearch {
+	vs := vaultSearch{status: VaultSearchStatus{VaultID: vaultID}}
+
+	v, ok := s.getVault(vaultID)
+	if !ok {
+		vs.status.Error = "Vault not found"
+		return vs
+	}
+	if !v.IsActive() {
+		vs.status.Error = "Vault not active"
+		return vs
+	}
+	searchSvc := v.GetSearchService()
+	if searchSvc == nil {
+		vs.status.Error = "Search service not available"
+		return vs
+	}
+
+	// The search cannot be cancelled, so one that times out finishes in the
+	// background and its result is dropped
+	type outcome struct {
+		result *bleve.SearchResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := runSearch(searchSvc, req)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil {
+			vs.status.Error = fmt.Sprintf("Search failed: %v", o.err)
+			return vs
+		}
+		if o.result == nil {
+			o.result = &bleve.SearchResult{}
+		}
+		vs.result = o.result
+		vs.status.Total = o.result.Total
+		vs.status.Took = o.result.Took.String()
+	case <-time.After(timeout):
+		vs.status.Error = fmt.Sprintf("Search timed out after %s", timeout)
+	case <-s.ctx.Done():
+		vs.status.Error = "Server shutting down"
 	}
+	return vs
 }
 
 // extractVaultID extracts vault ID from URL path
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/susamn/obsidian-web/internal/config"
 	"github.com/susamn/obsidian-web/internal/vault"
@@ -130,3 +134,679 @@ func TestConvertSearchResult(t *testing.T) {
 		t.Errorf("Expected 0 results, got %d", len(resp.Results))
 	}
 }
+
+func TestHandleSearchPaging(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	for name, title := range map[string]string{"1.md": "Cherry", "2.md": "apple", "3.md": "Banana"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("# "+title+"\n\nFruit salad.\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir() + "/test.bleve",
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type:  "local",
+			Local: &config.LocalStorageConfig{Path: tempDir},
+		},
+	}
+
+	v, err := vault.NewVault(ctx, vaultCfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	if err := v.Start(); err != nil {
+		t.Fatalf("Failed to start vault: %v", err)
+	}
+	defer v.Stop()
+	if err := v.WaitForReady(5 * time.Second); err != nil {
+		t.Fatalf("Vault not ready: %v", err)
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: []config.VaultConfig{*vaultCfg}}, map[string]*vault.Vault{"test-vault": v})
+	post := func(req SearchRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		server.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search/test-vault", bytes.NewReader(body)))
+		return w
+	}
+
+	// Titles sort ignoring case, one result per page
+	req := SearchRequest{Query: "fruit", Sort: []string{"title"}, Fields: []string{"title", "modified"}, Limit: 1}
+	var titles []string
+	for len(titles) <= 3 {
+		w := post(req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data SearchResponse `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Data.Total != 3 {
+			t.Errorf("Total = %d on page %d, want 3", resp.Data.Total, len(titles)+1)
+		}
+		if len(resp.Data.Results) == 0 {
+			break
+		}
+		result := resp.Data.Results[0]
+		if _, ok := result.Fields["modified"].(string); !ok || len(result.Fields) != 2 {
+			t.Errorf("Fields = %v, want title and modified", result.Fields)
+		}
+		titles = append(titles, result.Fields["title"].(string))
+		req.SearchAfter = result.Sort
+	}
+	if got := strings.Join(titles, " "); got != "apple Banana Cherry" {
+		t.Errorf("Pages = %q, want \"apple Banana Cherry\"", got)
+	}
+
+	for _, bad := range []SearchRequest{
+		{Query: "fruit", Sort: []string{"size"}},
+		{Query: "fruit", Offset: 1, SearchAfter: []string{"1", "x"}},
+		{Query: "fruit", Fields: []string{"content"}},
+	} {
+		if w := post(bad); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %+v, got %d", bad, w.Code)
+		}
+	}
+}
+
+func TestHandleSearchQuery(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	notes := map[string]string{
+		"work/Meeting.md": "---\ntags: [project/alpha]\n---\n# Meeting\n\ntodo urgent call\nfoo\n",
+		"work/draft.md":   "# Draft\n\ntodo later\nurgent\nfoo #project\n",
+		"home/list.md":    "# List\n\nbar and foo\n",
+	}
+	for name, content := range notes {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir() + "/test.bleve",
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type:  "local",
+			Local: &config.LocalStorageConfig{Path: tempDir},
+		},
+	}
+
+	v, err := vault.NewVault(ctx, vaultCfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	if err := v.Start(); err != nil {
+		t.Fatalf("Failed to start vault: %v", err)
+	}
+	defer v.Stop()
+	if err := v.WaitForReady(5 * time.Second); err != nil {
+		t.Fatalf("Vault not ready: %v", err)
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: []config.VaultConfig{*vaultCfg}}, map[string]*vault.Vault{"test-vault": v})
+	post := func(query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(SearchRequest{Query: query, Type: "query"})
+		w := httptest.NewRecorder()
+		server.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search/test-vault", bytes.NewReader(body)))
+		return w
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{"foo -bar", "work/Meeting.md work/draft.md"},
+		{"tag:#project", "work/Meeting.md work/draft.md"},
+		{"path:WORK/ file:meeting", "work/Meeting.md"},
+		{"line:(todo urgent)", "work/Meeting.md"},
+		{"bar OR (tag:#project -line:(todo urgent))", "home/list.md work/draft.md"},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			w := post(tc.query)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Data SearchResponse `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for _, r := range resp.Data.Results {
+				paths = append(paths, filepath.ToSlash(r.Fields["path"].(string)))
+			}
+			sort.Strings(paths)
+			if got := strings.Join(paths, " "); got != tc.want {
+				t.Errorf("Results = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	w := post("foo (bar")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an unclosed group, got %d: %s", w.Code, w.Body.String())
+	}
+	var qerr QueryErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&qerr); err != nil {
+		t.Fatal(err)
+	}
+	if qerr.Position != 4 || !strings.Contains(qerr.Message, "missing )") {
+		t.Errorf("Error = %+v, want missing ) at position 4", qerr)
+	}
+}
+
+func TestHandleSearchFacets(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	notes := map[string]string{
+		"work/Meeting.md": "---\ntags: [project]\n---\n# Meeting\n\nfoo\n",
+		"work/draft.md":   "# Draft\n\nfoo #project #later\n",
+		"home/list.md":    "# List\n\nfoo #later\n",
+		"inbox.md":        "# Inbox\n\nfoo\n",
+	}
+	for name, content := range notes {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir() + "/test.bleve",
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type:  "local",
+			Local: &config.LocalStorageConfig{Path: tempDir},
+		},
+	}
+
+	v, err := vault.NewVault(ctx, vaultCfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	if err := v.Start(); err != nil {
+		t.Fatalf("Failed to start vault: %v", err)
+	}
+	defer v.Stop()
+	if err := v.WaitForReady(5 * time.Second); err != nil {
+		t.Fatalf("Vault not ready: %v", err)
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: []config.VaultConfig{*vaultCfg}}, map[string]*vault.Vault{"test-vault": v})
+	post := func(req SearchRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		server.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search/test-vault", bytes.NewReader(body)))
+		return w
+	}
+	buckets := func(fr FacetResult) string {
+		var values []string
+		for _, b := range fr.Buckets {
+			values = append(values, fmt.Sprintf("%s:%d", b.Value, b.Count))
+		}
+		return strings.Join(values, " ")
+	}
+	thisMonth := time.Now().UTC().Format("2006-01")
+
+	w := post(SearchRequest{
+		Query:          "foo",
+		Facets:         []string{"tags", "folders", "modified"},
+		FacetSelection: map[string][]string{"tags": {"later"}},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data SearchResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Total != 2 {
+		t.Errorf("Total = %d, want 2 notes tagged later", resp.Data.Total)
+	}
+	// The tags facet ignores its own selection, the others count the 2 results
+	if got, want := buckets(resp.Data.Facets["tags"]), "later:2 project:2"; got != want {
+		t.Errorf("tags = %q, want %q", got, want)
+	}
+	if got, want := buckets(resp.Data.Facets["folders"]), "home:1 work:1"; got != want {
+		t.Errorf("folders = %q, want %q", got, want)
+	}
+	modified := resp.Data.Facets["modified"]
+	if got, want := buckets(modified), thisMonth+":2"; got != want || modified.Buckets[0].Start == "" {
+		t.Errorf("modified = %q (%+v), want %q with a start", got, modified.Buckets, want)
+	}
+
+	w = post(SearchRequest{Query: "foo", FacetSelection: map[string][]string{"folders": {"/"}}})
+	resp.Data = SearchResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Results) != 1 || filepath.ToSlash(resp.Data.Results[0].Fields["path"].(string)) != "inbox.md" {
+		t.Errorf("Results = %+v, want inbox.md at the vault root", resp.Data.Results)
+	}
+	if resp.Data.Facets != nil {
+		t.Errorf("Facets = %+v, want none requested", resp.Data.Facets)
+	}
+
+	w = post(SearchRequest{Query: "foo", FacetSelection: map[string][]string{"modified": {"May"}}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid modified value, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleSearchDateRanges(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	notes := map[string]string{
+		"standup.md":  "---\ndate: 2024-05-06\n---\n# Standup\n\nnotes #meeting\n",
+		"retro.md":    "---\ndate: 2024-04-26\n---\n# Retro\n\nnotes #meeting\n",
+		"shopping.md": "# Shopping\n\nnotes\n",
+	}
+	for name, content := range notes {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir() + "/test.bleve",
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type:  "local",
+			Local: &config.LocalStorageConfig{Path: tempDir},
+		},
+	}
+
+	v, err := vault.NewVault(ctx, vaultCfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	if err := v.Start(); err != nil {
+		t.Fatalf("Failed to start vault: %v", err)
+	}
+	defer v.Stop()
+	if err := v.WaitForReady(5 * time.Second); err != nil {
+		t.Fatalf("Vault not ready: %v", err)
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: []config.VaultConfig{*vaultCfg}}, map[string]*vault.Vault{"test-vault": v})
+	post := func(req SearchRequest) *httptest.ResponseRecorder {
+		req.Fields = []string{"path"}
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		server.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search/test-vault", bytes.NewReader(body)))
+		return w
+	}
+
+	// The notes were just written, so they were all modified today
+	today := time.Now().UTC().Format("2006-01-02")
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	for _, tc := range []struct {
+		name string
+		req  SearchRequest
+		want string
+	}{
+		{"modified today", SearchRequest{Query: "notes", ModifiedAfter: today}, "retro.md shopping.md standup.md"},
+		{"modified before today", SearchRequest{Query: "notes", ModifiedBefore: today}, ""},
+		{"tagged and modified this week", SearchRequest{Type: "tag", Tags: []string{"meeting"},
+			ModifiedAfter: time.Now().AddDate(0, 0, -7).Format(time.RFC3339), ModifiedBefore: tomorrow}, "retro.md standup.md"},
+		{"frontmatter date", SearchRequest{Query: "notes", DateAfter: "2024-05-01", DateBefore: "2024-05-08"}, "standup.md"},
+		{"created and date", SearchRequest{Type: "query", Query: "tag:#meeting", CreatedAfter: today, DateBefore: "2024-05-01"}, "retro.md"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := post(tc.req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Data SearchResponse `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for _, r := range resp.Data.Results {
+				paths = append(paths, r.Fields["path"].(string))
+			}
+			sort.Strings(paths)
+			if got := strings.Join(paths, " "); got != tc.want {
+				t.Errorf("Results = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	for _, req := range []SearchRequest{
+		{Query: "notes", ModifiedAfter: "last week"},
+		{Query: "notes", DateAfter: "2024-05-08", DateBefore: "2024-05-01"},
+	} {
+		if w := post(req); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %+v, got %d: %s", req, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHandleSearchSQLiteFTS(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	notes := map[string]string{
+		"garden.md":  "---\ntags: [outdoors]\n---\n# Garden\n\nTomatoes need plenty of sunlight.\n",
+		"kitchen.md": "# Kitchen\n\nA sauce of tomatoes and basil, see [[garden]].\n",
+	}
+	for name, content := range notes {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir() + "/test.bleve",
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type:  "local",
+			Local: &config.LocalStorageConfig{Path: tempDir},
+		},
+		Search: config.VaultSearch{Engine: config.SearchEngineSQLiteFTS},
+	}
+
+	v, err := vault.NewVault(ctx, vaultCfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	if err := v.Start(); err != nil {
+		if strings.Contains(err.Error(), "sqlite_fts5") {
+			t.Skip("SQLite built without FTS5, run with -tags sqlite_fts5")
+		}
+		t.Fatalf("Failed to start vault: %v", err)
+	}
+	defer v.Stop()
+	if err := v.WaitForReady(5 * time.Second); err != nil {
+		t.Fatalf("Vault not ready: %v", err)
+	}
+	if _, err := os.Stat(vaultCfg.IndexPath); !os.IsNotExist(err) {
+		t.Errorf("bleve index created at %s with the sqlite_fts engine", vaultCfg.IndexPath)
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: []config.VaultConfig{*vaultCfg}}, map[string]*vault.Vault{"test-vault": v})
+	search := func(req SearchRequest) SearchResponse {
+		t.Helper()
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		server.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search/test-vault", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data SearchResponse `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data
+	}
+	paths := func(resp SearchResponse) string {
+		var paths []string
+		for _, r := range resp.Results {
+			paths = append(paths, r.Fields["path"].(string))
+		}
+		sort.Strings(paths)
+		return strings.Join(paths, " ")
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  SearchRequest
+		want string
+	}{
+		{"text", SearchRequest{Query: "tomatoes"}, "garden.md kitchen.md"},
+		{"phrase", SearchRequest{Query: "sauce of tomatoes", Type: "phrase"}, "kitchen.md"},
+		{"prefix", SearchRequest{Query: "sun", Type: "prefix"}, "garden.md"},
+		{"tag", SearchRequest{Type: "tag", Tags: []string{"outdoors"}}, "garden.md"},
+		{"wikilink", SearchRequest{Type: "wikilink", Wikilinks: []string{"garden"}}, "kitchen.md"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := paths(search(tc.req)); got != tc.want {
+				t.Errorf("Results = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("snippets", func(t *testing.T) {
+		resp := search(SearchRequest{Query: "basil"})
+		if len(resp.Results) != 1 || len(resp.Results[0].Fragments["content"]) != 1 ||
+			!strings.Contains(resp.Results[0].Fragments["content"][0], "<mark>basil</mark>") {
+			t.Errorf("Results = %+v, want a highlighted content snippet", resp.Results)
+		}
+	})
+
+	t.Run("sorted pages", func(t *testing.T) {
+		req := SearchRequest{Query: "tomatoes", Sort: []string{"-path"}, Limit: 1}
+		first := search(req)
+		req.SearchAfter = first.Results[0].Sort
+		second := search(req)
+		if first.Total != 2 || second.Total != 2 || paths(first) != "kitchen.md" || paths(second) != "garden.md" {
+			t.Errorf("Pages = %q of %d, %q of %d", paths(first), first.Total, paths(second), second.Total)
+		}
+	})
+
+	t.Run("normalized across vaults", func(t *testing.T) {
+		federated := func(req SearchRequest) FederatedSearchResponse {
+			t.Helper()
+			body, _ := json.Marshal(FederatedSearchRequest{SearchRequest: req})
+			w := httptest.NewRecorder()
+			server.handleFederatedSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search", bytes.NewReader(body)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Data FederatedSearchResponse `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			return resp.Data
+		}
+
+		resp := federated(SearchRequest{Query: "sunlight OR basil"})
+		if len(resp.Results) != 2 || resp.Results[0].NormalizedScore != 1 || resp.Results[1].NormalizedScore >= 1 {
+			t.Errorf("Results = %+v, want the best normalized to 1 and the other below", resp.Results)
+		}
+
+		// Hits found without text are not ranked
+		resp = federated(SearchRequest{Type: "tag", Tags: []string{"outdoors"}})
+		if len(resp.Results) != 1 || resp.Results[0].NormalizedScore != 0 {
+			t.Errorf("Results = %+v, want one unranked hit", resp.Results)
+		}
+	})
+
+	t.Run("unsupported queries", func(t *testing.T) {
+		for _, q := range []string{"tomatoes -basil", "file:garden", "line:(tomatoes sunlight)"} {
+			body, _ := json.Marshal(SearchRequest{Query: q, Type: "query"})
+			w := httptest.NewRecorder()
+			server.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search/test-vault", bytes.NewReader(body)))
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "not supported by the sqlite_fts engine") {
+				t.Errorf("%q: expected 400 naming the engine, got %d: %s", q, w.Code, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("kept in sync", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(tempDir, "pantry.md"), []byte("# Pantry\n\nDried tomatoes.\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Remove(filepath.Join(tempDir, "kitchen.md")); err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			got := paths(search(SearchRequest{Query: "tomatoes"}))
+			if got == "garden.md pantry.md" {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Results = %q after changes, want \"garden.md pantry.md\"", got)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	})
+}
+
+func TestHandleFederatedSearch(t *testing.T) {
+	ctx := context.Background()
+
+	vaultNotes := map[string]map[string]string{
+		"personal": {
+			"garden.md": "# Garden\n\ntomatoes tomatoes tomatoes\n",
+			"recipes.md": "# Recipes\n\ntomatoes and basil, with a long list of other ingredients " +
+				"like garlic, onions, olive oil, salt, pepper and bread\n",
+		},
+		"team": {
+			"roadmap.md": "# Roadmap\n\nship the tomatoes tracker\n",
+		},
+	}
+	var vaultCfgs []config.VaultConfig
+	vaults := make(map[string]*vault.Vault)
+	for id, notes := range vaultNotes {
+		tempDir := t.TempDir()
+		for name, content := range notes {
+			if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		vaultCfg := config.VaultConfig{
+			ID:        id,
+			Name:      id,
+			Enabled:   true,
+			IndexPath: t.TempDir() + "/test.bleve",
+			DBPath:    t.TempDir(),
+			Storage: config.StorageConfig{
+				Type:  "local",
+				Local: &config.LocalStorageConfig{Path: tempDir},
+			},
+		}
+		v, err := vault.NewVault(ctx, &vaultCfg)
+		if err != nil {
+			t.Fatalf("Failed to create vault: %v", err)
+		}
+		if err := v.Start(); err != nil {
+			t.Fatalf("Failed to start vault: %v", err)
+		}
+		defer v.Stop()
+		if err := v.WaitForReady(5 * time.Second); err != nil {
+			t.Fatalf("Vault not ready: %v", err)
+		}
+		vaultCfgs = append(vaultCfgs, vaultCfg)
+		vaults[id] = v
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: vaultCfgs}, vaults)
+	post := func(req FederatedSearchRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/search", bytes.NewReader(body)))
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) FederatedSearchResponse {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data FederatedSearchResponse `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data
+	}
+
+	t.Run("all vaults", func(t *testing.T) {
+		resp := decode(post(FederatedSearchRequest{SearchRequest: SearchRequest{Query: "tomatoes"}}))
+		if resp.Total != 3 || len(resp.Results) != 3 {
+			t.Fatalf("Total = %d with %d results, want 3", resp.Total, len(resp.Results))
+		}
+		if len(resp.Vaults) != 2 || resp.Vaults[0].VaultID != "personal" || resp.Vaults[1].VaultID != "team" {
+			t.Errorf("Vaults = %+v, want personal and team", resp.Vaults)
+		}
+		// Each vault's best match normalizes to 1, ties are in vault order
+		var got []string
+		for _, r := range resp.Results {
+			got = append(got, r.VaultID+":"+r.Fields["path"].(string))
+		}
+		if want := "personal:garden.md team:roadmap.md personal:recipes.md"; strings.Join(got, " ") != want {
+			t.Errorf("Results = %v, want %s", got, want)
+		}
+		if resp.Results[0].NormalizedScore != 1 || resp.Results[1].NormalizedScore != 1 || resp.Results[2].NormalizedScore >= 1 {
+			t.Errorf("Normalized scores = %v, %v, %v", resp.Results[0].NormalizedScore, resp.Results[1].NormalizedScore, resp.Results[2].NormalizedScore)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		resp := decode(post(FederatedSearchRequest{SearchRequest: SearchRequest{Query: "tomatoes", Limit: 1}}))
+		if resp.Total != 3 || len(resp.Results) != 1 || resp.Results[0].VaultID != "personal" {
+			t.Errorf("Total = %d, Results = %+v, want the best of 3", resp.Total, resp.Results)
+		}
+	})
+
+	t.Run("subset with a missing vault", func(t *testing.T) {
+		resp := decode(post(FederatedSearchRequest{
+			SearchRequest: SearchRequest{Query: "tomatoes"},
+			Vaults:        []string{"team", "archive"},
+		}))
+		if len(resp.Results) != 1 || resp.Results[0].VaultID != "team" {
+			t.Errorf("Results = %+v, want the team vault's note", resp.Results)
+		}
+		if len(resp.Vaults) != 2 || resp.Vaults[0].Error != "" || resp.Vaults[1].Error != "Vault not found" {
+			t.Errorf("Vaults = %+v, want team searched and archive not found", resp.Vaults)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, req := range []SearchRequest{
+			{},
+			{Query: "tomatoes", Offset: 10},
+			{Query: "tomatoes", Sort: []string{"title"}},
+			{Query: "tomatoes", Facets: []string{"tags"}},
+			{Query: "tomatoes (basil", Type: "query"},
+		} {
+			if w := post(FederatedSearchRequest{SearchRequest: req}); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected 400 for %+v, got %d: %s", req, w.Code, w.Body.String())
+			}
+		}
+	})
+}
@@ -3,7 +3,7 @@ package web
 import (
 	"fmt"
 	"net/http"
-	"os"
+	"strings"
 
 	"github.com/susamn/obsidian-web/internal/db"
 	"github.com/susamn/obsidian-web/internal/logger"
@@ -79,8 +79,12 @@ func (s *Server) handleStructuredRenderByID(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Get file metadata for timestamps
-	fullPath := s.buildVaultFilePath(v, filePath)
-	fileInfo, err := os.Stat(fullPath)
+	store, err := s.vaultStorage(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	fileInfo, err := store.Stat(r.Context(), filePath)
 	if err != nil {
 		logger.WithError(err).WithFields(map[string]interface{}{
 			"vault_id":  vaultID,
@@ -95,7 +99,8 @@ func (s *Server) handleStructuredRenderByID(w http.ResponseWriter, r *http.Reque
 
 	// Create file resolver using the database service
 	resolver := &DBFileResolver{
-		dbService: dbService,
+		dbService:  dbService,
+		sourcePath: filePath,
 	}
 
 	// Create structured renderer
@@ -106,8 +111,8 @@ func (s *Server) handleStructuredRenderByID(w http.ResponseWriter, r *http.Reque
 		content,
 		vaultID,
 		nodeID,
-		fileInfo.ModTime(), // Use ModTime for both created and modified for now
-		fileInfo.ModTime(),
+		fileInfo.ModTime, // Use ModTime for both created and modified for now
+		fileInfo.ModTime,
 	)
 	if err != nil {
 		logger.WithError(err).WithFields(map[string]interface{}{
@@ -125,14 +130,23 @@ func (s *Server) handleStructuredRenderByID(w http.ResponseWriter, r *http.Reque
 // DBFileResolver implements render.FileResolver using the database service
 type DBFileResolver struct {
 	dbService interface {
-		GetFileEntryByName(name string) (*db.FileEntry, error)
+		ResolveLink(target, sourcePath string) (*db.FileEntry, error)
+		GetTagCount(tag string) (int, error)
+		GetBacklinks(fileID string) ([]db.SourcedLink, error)
 	}
+	sourcePath string // Note being rendered; links resolve from its folder first
 }
 
-// ResolveWikiLink resolves a wikilink to file metadata
+// ResolveWikiLink resolves a wikilink to file metadata the way Obsidian does
 func (r *DBFileResolver) ResolveWikiLink(vaultID, linkTarget string) (exists bool, fileID, path string) {
-	// Try to find the file by name
-	entry, err := r.dbService.GetFileEntryByName(linkTarget)
+	// A heading or block reference does not change which file the link names
+	if i := strings.Index(linkTarget, "#"); i >= 0 {
+		linkTarget = linkTarget[:i]
+	}
+	if linkTarget == "" {
+		return false, "", ""
+	}
+	entry, err := r.dbService.ResolveLink(linkTarget, r.sourcePath)
 	if err != nil || entry == nil {
 		return false, "", ""
 	}
@@ -140,18 +154,38 @@ func (r *DBFileResolver) ResolveWikiLink(vaultID, linkTarget string) (exists boo
 	return true, entry.ID, entry.Path
 }
 
-// GetBacklinks finds all files linking to the given file
-// TODO: Implement this properly with a backlinks table or index
+// GetBacklinks finds all links to the given file, with the line each one is on as context
 func (r *DBFileResolver) GetBacklinks(vaultID, fileID string) []render.Backlink {
-	// For now, return empty slice
-	// This would require scanning all files or maintaining a backlinks index
-	return []render.Backlink{}
+	links, err := r.dbService.GetBacklinks(fileID)
+	if err != nil {
+		logger.WithError(err).WithFields(map[string]interface{}{
+			"vault_id": vaultID,
+			"file_id":  fileID,
+		}).Warn("Failed to get backlinks")
+		return []render.Backlink{}
+	}
+
+	backlinks := make([]render.Backlink, 0, len(links))
+	for _, link := range links {
+		backlinks = append(backlinks, render.Backlink{
+			FileID:   link.SourceID,
+			FileName: link.SourceName,
+			FilePath: link.SourcePath,
+			Context:  link.Context,
+		})
+	}
+	return backlinks
 }
 
 // GetTagCount returns the number of files with a given tag
-// TODO: Implement this properly with a tags table or index
 func (r *DBFileResolver) GetTagCount(vaultID, tag string) int {
-	// For now, return 0
-	// This would require maintaining a tags index
-	return 0
+	count, err := r.dbService.GetTagCount(tag)
+	if err != nil {
+		logger.WithError(err).WithFields(map[string]interface{}{
+			"vault_id": vaultID,
+			"tag":      tag,
+		}).Warn("Failed to count tag")
+		return 0
+	}
+	return count
 }
@@ -0,0 +1,96 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/susamn/obsidian-web/internal/db"
+)
+
+// TagsResponse lists the tags in a vault
+type TagsResponse struct {
+	VaultID string        `json:"vault_id"`
+	Tags    []db.TagCount `json:"tags"`
+	Count   int           `json:"count"`
+}
+
+// TaggedFile is a file carrying a tag
+type TaggedFile struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// TagFilesResponse lists the files carrying a tag
+type TagFilesResponse struct {
+	VaultID string       `json:"vault_id"`
+	Tag     string       `json:"tag"`
+	Files   []TaggedFile `json:"files"`
+	Count   int          `json:"count"`
+}
+
+// handleTags godoc
+// @Summary List tags or the files carrying a tag
+// @Description Without a tag, list every tag in the vault with the number of files carrying it, most used first.
+// @Description With a tag (nested tags keep their slashes, the leading # is optional), list the files carrying it.
+// @Tags tags
+// @Produce json
+// @Param vault path string true "Vault ID"
+// @Param tag path string false "Tag"
+// @Success 200 {object} TagsResponse "Without a tag; TagFilesResponse with one"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 405 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/tags/{vault}/{tag} [get]
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// Split into vault and optional tag
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/tags/"), "/")
+	vaultID, tag, _ := strings.Cut(path, "/")
+	if vaultID == "" {
+		writeError(w, http.StatusBadRequest, "Vault ID required")
+		return
+	}
+
+	_, dbService, ok := s.validateAndGetVaultWithDB(w, vaultID)
+	if !ok {
+		return
+	}
+
+	if tag == "" {
+		tags, err := dbService.GetTagCounts()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list tags: %v", err))
+			return
+		}
+		writeSuccess(w, TagsResponse{
+			VaultID: vaultID,
+			Tags:    tags,
+			Count:   len(tags),
+		})
+		return
+	}
+
+	entries, err := dbService.GetFilesByTag(tag)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list files for tag: %v", err))
+		return
+	}
+	files := make([]TaggedFile, 0, len(entries))
+	for _, entry := range entries {
+		files = append(files, TaggedFile{ID: entry.ID, Name: entry.Name, Path: entry.Path})
+	}
+	writeSuccess(w, TagFilesResponse{
+		VaultID: vaultID,
+		Tag:     db.NormalizeTag(tag),
+		Files:   files,
+		Count:   len(files),
+	})
+}
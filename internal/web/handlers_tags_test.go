@@ -0,0 +1,137 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+	"github.com/susamn/obsidian-web/internal/vault"
+)
+
+func TestHandleTags(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir() + "/test.bleve",
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type:  "local",
+			Local: &config.LocalStorageConfig{Path: tempDir},
+		},
+	}
+
+	v, err := vault.NewVault(ctx, vaultCfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	if err := v.Start(); err != nil {
+		t.Fatalf("Failed to start vault: %v", err)
+	}
+	defer v.Stop()
+	if err := v.WaitForReady(5 * time.Second); err != nil {
+		t.Fatalf("Vault not ready: %v", err)
+	}
+
+	// Track two notes directly rather than waiting for the watcher; the
+	// workers store the same tags when the watcher reports them
+	dbService := v.GetDBService()
+	for name, tags := range map[string][]string{
+		"a.md": {"project", "project/alpha"},
+		"b.md": {"project"},
+	} {
+		content := "# " + name + "\n"
+		for _, tag := range tags {
+			content += " #" + tag
+		}
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fileID, err := dbService.PerformDatabaseUpdate(v.GetStorage(), tempDir, syncpkg.FileChangeEvent{
+			Path:      path,
+			EventType: syncpkg.FileCreated,
+			Timestamp: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("Failed to track %s: %v", name, err)
+		}
+		if err := dbService.SetFileTags(fileID, tags); err != nil {
+			t.Fatalf("Failed to tag %s: %v", name, err)
+		}
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: []config.VaultConfig{*vaultCfg}}, map[string]*vault.Vault{"test-vault": v})
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.handleTags(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	t.Run("list tags", func(t *testing.T) {
+		w := get("/api/v1/tags/test-vault")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct{ Data TagsResponse }
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Data.Count != 2 || resp.Data.Tags[0].Name != "project" || resp.Data.Tags[0].Count != 2 {
+			t.Errorf("Unexpected tags: %+v", resp.Data)
+		}
+	})
+
+	t.Run("files for nested tag", func(t *testing.T) {
+		w := get("/api/v1/tags/test-vault/%23project/alpha")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct{ Data TagFilesResponse }
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Data.Tag != "project/alpha" || resp.Data.Count != 1 || resp.Data.Files[0].Path != "a.md" {
+			t.Errorf("Unexpected files: %+v", resp.Data)
+		}
+	})
+
+	t.Run("unknown tag", func(t *testing.T) {
+		w := get("/api/v1/tags/test-vault/missing")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var resp struct{ Data TagFilesResponse }
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Data.Files == nil || resp.Data.Count != 0 {
+			t.Errorf("Expected an empty file list, got %+v", resp.Data)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if w := get("/api/v1/tags/nonexistent"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+		if w := get("/api/v1/tags/"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+		w := httptest.NewRecorder()
+		server.handleTags(w, httptest.NewRequest(http.MethodPost, "/api/v1/tags/test-vault", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/susamn/obsidian-web/internal/db"
+	"github.com/susamn/obsidian-web/internal/vault"
+)
+
+// TrashResponse lists the items in a vault's trash
+type TrashResponse struct {
+	VaultID string         `json:"vault_id"`
+	Items   []db.TrashItem `json:"items"` // Most recently deleted first
+}
+
+// handleTrash godoc
+// @Summary List, restore and purge trash items
+// @Description Files deleted through the API are moved to the vault's .trash folder. GET /{vault} lists the trash and
+// @Description DELETE /{vault} empties it. DELETE /{vault}/{item} purges one item. POST /{vault}/{item}/restore moves an
+// @Description item back to its original path; when that path is taken it fails with 409, or with conflict=rename the
+// @Description item is restored next to it under a numbered name.
+// @Tags trash
+// @Produce json
+// @Param vault path string true "Vault ID"
+// @Param item path int false "Trash item ID"
+// @Param conflict query string false "fail (default) or rename"
+// @Success 200 {object} TrashResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 405 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/trash/{vault} [get]
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/trash/"), "/"), "/")
+	if parts[0] == "" || len(parts) > 3 || (len(parts) == 3 && parts[2] != "restore") {
+		writeError(w, http.StatusBadRequest, "Invalid path format")
+		return
+	}
+	vaultID := parts[0]
+
+	var itemID int64
+	if len(parts) > 1 {
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid trash item: %s", parts[1]))
+			return
+		}
+		itemID = id
+	}
+
+	var action string
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		action = "list"
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		action = "empty"
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		action = "purge"
+	case len(parts) == 3 && r.Method == http.MethodPost:
+		action = "restore"
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	conflict := r.URL.Query().Get("conflict")
+	if conflict != "" && conflict != "fail" && conflict != "rename" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid conflict: %s (expected fail or rename)", conflict))
+		return
+	}
+
+	v, dbService, ok := s.validateAndGetVaultWithDB(w, vaultID)
+	if !ok {
+		return
+	}
+
+	switch action {
+	case "list":
+		items, err := dbService.GetTrashItems()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list trash: %v", err))
+			return
+		}
+		writeSuccess(w, TrashResponse{VaultID: vaultID, Items: items})
+
+	case "empty":
+		purged, err := v.EmptyTrash(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to empty trash: %v", err))
+			return
+		}
+		writeSuccess(w, map[string]interface{}{
+			"message": "Trash emptied",
+			"purged":  purged,
+		})
+
+	case "purge":
+		if err := v.PurgeTrashItem(r.Context(), itemID); err != nil {
+			writeTrashError(w, "Failed to purge trash item", err)
+			return
+		}
+		writeSuccess(w, map[string]interface{}{
+			"message": "Trash item purged",
+			"id":      itemID,
+		})
+
+	case "restore":
+		item, restoredPath, err := v.RestoreFromTrash(r.Context(), itemID, conflict == "rename")
+		if err != nil {
+			writeTrashError(w, "Failed to restore trash item", err)
+			return
+		}
+		writeSuccess(w, map[string]interface{}{
+			"message":       "Trash item restored",
+			"id":            item.ID,
+			"file_id":       item.FileID,
+			"original_path": item.OriginalPath,
+			"path":          restoredPath,
+		})
+	}
+}
+
+// writeTrashError writes the status matching an error from a trash operation
+func writeTrashError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, vault.ErrTrashItemNotFound):
+		writeError(w, http.StatusNotFound, "Trash item not found")
+	case errors.Is(err, vault.ErrRestoreConflict):
+		writeError(w, http.StatusConflict, "A file already exists at the original path")
+	default:
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("%s: %v", message, err))
+	}
+}
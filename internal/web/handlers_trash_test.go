@@ -0,0 +1,201 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/susamn/obsidian-web/internal/config"
+	"github.com/susamn/obsidian-web/internal/db"
+	syncpkg "github.com/susamn/obsidian-web/internal/sync"
+	"github.com/susamn/obsidian-web/internal/vault"
+)
+
+func TestHandleTrash(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+
+	vaultCfg := &config.VaultConfig{
+		ID:        "test-vault",
+		Name:      "Test Vault",
+		Enabled:   true,
+		IndexPath: t.TempDir() + "/test.bleve",
+		DBPath:    t.TempDir(),
+		Storage: config.StorageConfig{
+			Type:  "local",
+			Local: &config.LocalStorageConfig{Path: tempDir},
+		},
+	}
+
+	v, err := vault.NewVault(ctx, vaultCfg)
+	if err != nil {
+		t.Fatalf("Failed to create vault: %v", err)
+	}
+	if err := v.Start(); err != nil {
+		t.Fatalf("Failed to start vault: %v", err)
+	}
+	defer v.Stop()
+	if err := v.WaitForReady(5 * time.Second); err != nil {
+		t.Fatalf("Vault not ready: %v", err)
+	}
+
+	dbService := v.GetDBService()
+	path := filepath.Join(tempDir, "note.md")
+	if err := os.WriteFile(path, []byte("# Note\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileID, err := dbService.PerformDatabaseUpdate(v.GetStorage(), tempDir, syncpkg.FileChangeEvent{
+		Path:      path,
+		EventType: syncpkg.FileCreated,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to track note: %v", err)
+	}
+
+	server := NewServer(ctx, &config.Config{Vaults: []config.VaultConfig{*vaultCfg}}, map[string]*vault.Vault{"test-vault": v})
+
+	do := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.handleTrash(w, httptest.NewRequest(method, url, nil))
+		return w
+	}
+	trash := func() []db.TrashItem {
+		w := do(http.MethodGet, "/api/v1/trash/test-vault")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data TrashResponse `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data.Items
+	}
+
+	t.Run("delete moves to trash", func(t *testing.T) {
+		body, _ := json.Marshal(DeleteFileRequest{VaultID: "test-vault", ID: fileID})
+		w := httptest.NewRecorder()
+		server.handleDeleteFile(w, httptest.NewRequest(http.MethodPost, "/api/v1/file/delete", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("note.md still exists: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, ".trash", "note.md")); err != nil {
+			t.Errorf("note.md not in trash: %v", err)
+		}
+		if entry, _ := dbService.GetFileEntryByIDWithStatus(fileID, db.FileStatusDeleted); entry == nil {
+			t.Error("Entry not marked deleted")
+		}
+
+		items := trash()
+		if len(items) != 1 || items[0].FileID != fileID || items[0].OriginalPath != "note.md" || items[0].TrashPath != ".trash/note.md" {
+			t.Fatalf("Unexpected trash: %+v", items)
+		}
+	})
+
+	t.Run("delete root", func(t *testing.T) {
+		// Tracking a nested note creates the root entry
+		nested := filepath.Join(tempDir, "folder", "nested.md")
+		if err := os.MkdirAll(filepath.Dir(nested), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(nested, []byte("# Nested\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dbService.PerformDatabaseUpdate(v.GetStorage(), tempDir, syncpkg.FileChangeEvent{
+			Path:      nested,
+			EventType: syncpkg.FileCreated,
+			Timestamp: time.Now(),
+		}); err != nil {
+			t.Fatalf("Failed to track nested note: %v", err)
+		}
+		root, err := dbService.GetFileEntryByPath("")
+		if err != nil || root == nil {
+			t.Fatalf("Root entry not found: %v", err)
+		}
+
+		body, _ := json.Marshal(DeleteFileRequest{VaultID: "test-vault", ID: root.ID})
+		w := httptest.NewRecorder()
+		server.handleDeleteFile(w, httptest.NewRequest(http.MethodPost, "/api/v1/file/delete", bytes.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected 400, got %d: %s", w.Code, w.Body.String())
+		}
+		if _, err := os.Stat(nested); err != nil {
+			t.Errorf("Nested note gone after deleting root: %v", err)
+		}
+	})
+
+	t.Run("restore conflict", func(t *testing.T) {
+		if err := os.WriteFile(path, []byte("# Replacement\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(path)
+
+		item := trash()[0]
+		if w := do(http.MethodPost, fmt.Sprintf("/api/v1/trash/test-vault/%d/restore", item.ID)); w.Code != http.StatusConflict {
+			t.Fatalf("Expected 409, got %d: %s", w.Code, w.Body.String())
+		}
+
+		w := do(http.MethodPost, fmt.Sprintf("/api/v1/trash/test-vault/%d/restore?conflict=rename", item.ID))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		data, err := os.ReadFile(filepath.Join(tempDir, "note 1.md"))
+		if err != nil || string(data) != "# Note\n" {
+			t.Errorf("Restored file = %q, %v", data, err)
+		}
+		if items := trash(); len(items) != 0 {
+			t.Errorf("Trash after restore = %+v, want empty", items)
+		}
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		store := v.GetStorage()
+		if err := store.Write(ctx, ".trash/gone.md", []byte("gone")); err != nil {
+			t.Fatal(err)
+		}
+		item := &db.TrashItem{OriginalPath: "gone.md", TrashPath: ".trash/gone.md"}
+		if err := dbService.AddTrashItem(item); err != nil {
+			t.Fatal(err)
+		}
+
+		if w := do(http.MethodDelete, fmt.Sprintf("/api/v1/trash/test-vault/%d", item.ID)); w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, ".trash", "gone.md")); !os.IsNotExist(err) {
+			t.Errorf("Purged file still exists: %v", err)
+		}
+		if w := do(http.MethodDelete, fmt.Sprintf("/api/v1/trash/test-vault/%d", item.ID)); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 purging twice, got %d", w.Code)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			method, url string
+			code        int
+		}{
+			{http.MethodGet, "/api/v1/trash/", http.StatusBadRequest},
+			{http.MethodGet, "/api/v1/trash/test-vault/abc", http.StatusBadRequest},
+			{http.MethodGet, "/api/v1/trash/test-vault/1", http.StatusMethodNotAllowed},
+			{http.MethodPost, "/api/v1/trash/test-vault/1/restore?conflict=overwrite", http.StatusBadRequest},
+			{http.MethodPost, "/api/v1/trash/test-vault/999/restore", http.StatusNotFound},
+		} {
+			if w := do(tc.method, tc.url); w.Code != tc.code {
+				t.Errorf("%s %s: expected %d, got %d", tc.method, tc.url, tc.code, w.Code)
+			}
+		}
+	})
+}
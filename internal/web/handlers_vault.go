@@ -110,6 +110,7 @@ func (s *Server) handleGetVaultInfo(w http.ResponseWriter, r *http.Request, vaul
 		"uptime":            metrics.Uptime.String(),
 		"indexed_files":     metrics.IndexedFiles,
 		"recent_operations": metrics.RecentOperations,
+		"sync_conflict":     metrics.SyncConflict,
 	}
 
 	writeSuccess(w, info)
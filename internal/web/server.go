@@ -64,11 +64,18 @@ func (s *Server) setupRoutes(mux *http.ServeMux) {
 	// ACTIVE ROUTES - Used by frontend
 	mux.HandleFunc("/api/v1/assets/", s.handleGetAsset)                     // Images/assets in markdown
 	mux.HandleFunc("/api/v1/file/create", s.handleCreateFile)               // CreateNoteDialog
+	mux.HandleFunc("/api/v1/file/delete", s.handleDeleteFile)               // Move to the vault trash
 	mux.HandleFunc("/api/v1/files/sr/by-id/", s.handleStructuredRenderByID) // StructuredRenderer
 	mux.HandleFunc("/api/v1/files/by-id/", s.handleGetFileByID)             // fileService.getFileContent
 	mux.HandleFunc("/api/v1/files/tree/", s.handleGetTree)                  // fileService.getTree
 	mux.HandleFunc("/api/v1/files/meta/", s.handleGetMetadata)              // fileService.getMetadata
 	mux.HandleFunc("/api/v1/search/", s.handleSearch)                       // SearchPanel
+	mux.HandleFunc("/api/v1/search", s.handleFederatedSearch)               // Search across vaults
+	mux.HandleFunc("/api/v1/tags/", s.handleTags)                           // Tag list and files per tag
+	mux.HandleFunc("/api/v1/hygiene/", s.handleHygiene)                     // Broken links, orphans and dead ends
+	mux.HandleFunc("/api/v1/properties/", s.handlePropertyQuery)            // Query notes by frontmatter properties
+	mux.HandleFunc("/api/v1/revisions/", s.handleRevisions)                 // Note history, diffs and restores
+	mux.HandleFunc("/api/v1/trash/", s.handleTrash)                         // Trash listing, restores and purges
 	mux.HandleFunc("/api/v1/vaults", s.handleVaults)                        // HomeView
 	mux.HandleFunc("/api/v1/health", s.handleHealth)                        // Health check
 	mux.HandleFunc("/api/v1/sse/", s.handleSSE)                             // useSSE composable